@@ -0,0 +1,272 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const merchantId = "mock-merchant"
+const hangTimeout = 5 * time.Minute // дольше любого таймаута клиента
+const paymentInformationId = "mock-payment-information"
+
+// статусы транзакции, как в transport/bank
+const statusPaid = 1
+const statusDeclined = 2
+
+// payment ссылка на оплату. Сценарий запоминается при создании, чтобы его смена не влияла на выданные ссылки
+type payment struct {
+	id            string
+	transactionId string
+	amount        float64
+	cardType      string
+	scenario      string
+	paidAt        time.Time
+}
+
+type mockBank struct {
+	mu       sync.Mutex
+	baseUrl  string
+	scenario string
+	delay    time.Duration
+	latency  time.Duration
+	payments map[string]*payment // по id ссылки и по id транзакции
+}
+
+func newMockBank(baseUrl, scenario string, delay, latency time.Duration) *mockBank {
+	return &mockBank{
+		baseUrl:  strings.TrimRight(baseUrl, "/"),
+		scenario: scenario,
+		delay:    delay,
+		latency:  latency,
+		payments: make(map[string]*payment),
+	}
+}
+
+func (b *mockBank) routes() http.Handler {
+	mux := http.NewServeMux()
+	// пути совпадают с боевыми из configs/bank.json
+	mux.HandleFunc("/core/merchants/", b.withScenario(b.attributes))
+	mux.HandleFunc("/payments/check-merchant/", b.withScenario(b.checkMerchant))
+	mux.HandleFunc("/payments/payments/", b.withScenario(b.createPayment))
+	mux.HandleFunc("/payments/transactions/", b.withScenario(b.transaction))
+	mux.HandleFunc("/pay/", b.withScenario(b.paymentPage))
+	mux.HandleFunc("/mock/scenario", b.setScenario)
+	return mux
+}
+
+func (b *mockBank) currentScenario() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.scenario
+}
+
+// withScenario отвечает ошибкой для сценариев, в которых банк недоступен целиком
+func (b *mockBank) withScenario(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("%s %s", r.Method, r.URL.Path)
+
+		switch b.currentScenario() {
+		case ScenarioTimeout:
+			select {
+			case <-r.Context().Done():
+			case <-time.After(hangTimeout):
+			}
+		case ScenarioSlow:
+			b.mu.Lock()
+			latency := b.latency
+			b.mu.Unlock()
+			select {
+			case <-r.Context().Done():
+			case <-time.After(latency):
+				next(w, r)
+			}
+		case ScenarioServerError:
+			http.Error(w, "internal error", http.StatusInternalServerError)
+		case ScenarioRateLimited:
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+		case ScenarioMalformed:
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"merchant": {"id": `)
+		default:
+			next(w, r)
+		}
+	}
+}
+
+func (b *mockBank) attributes(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]any{
+		"merchant":           map[string]string{"id": merchantId},
+		"paymentInformation": []map[string]string{{"id": paymentInformationId}},
+	})
+}
+
+func (b *mockBank) checkMerchant(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		Merchant string `json:"merchant"`
+		Payment  string `json:"paymentInformation"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Merchant != merchantId || req.Payment != paymentInformationId {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+	if b.currentScenario() == ScenarioRejected {
+		http.Error(w, "merchant is not allowed", http.StatusUnprocessableEntity)
+		return
+	}
+
+	writeJSON(w, map[string]any{"checkMerchantId": randomId()})
+}
+
+func (b *mockBank) createPayment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req struct {
+		CheckMerchant string `json:"checkMerchant"`
+		Amount        string `json:"amount"`
+		CardType      string `json:"cardType"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.CheckMerchant == "" {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	amount, err := strconv.ParseFloat(req.Amount, 64)
+	if err != nil {
+		http.Error(w, "invalid amount", http.StatusBadRequest)
+		return
+	}
+
+	b.mu.Lock()
+	p := &payment{
+		id:            randomId(),
+		transactionId: randomId(),
+		amount:        amount,
+		cardType:      req.CardType,
+		scenario:      b.scenario,
+		paidAt:        time.Now(),
+	}
+	if p.scenario == ScenarioDelayed {
+		p.paidAt = p.paidAt.Add(b.delay)
+	}
+	b.payments[p.id] = p
+	b.payments[p.transactionId] = p
+	b.mu.Unlock()
+
+	writeJSON(w, map[string]any{"paymentUrl": b.baseUrl + "/pay/" + p.id})
+}
+
+// paymentPage страница оплаты: после оплаты банк редиректит на страницу с id транзакции
+func (b *mockBank) paymentPage(w http.ResponseWriter, r *http.Request) {
+	p := b.find(strings.TrimPrefix(r.URL.Path, "/pay/"))
+	if p == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch {
+	case time.Now().Before(p.paidAt):
+		fmt.Fprint(w, "waiting for payment")
+	case p.scenario == ScenarioNoTransaction:
+		http.Redirect(w, r, b.baseUrl+"/payments/result/", http.StatusFound)
+	default:
+		http.Redirect(w, r, b.baseUrl+"/payments/result/"+p.transactionId, http.StatusFound)
+	}
+}
+
+func (b *mockBank) transaction(w http.ResponseWriter, r *http.Request) {
+	p := b.find(strings.TrimPrefix(r.URL.Path, "/payments/transactions/"))
+	if p == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	status := statusPaid
+	if p.scenario == ScenarioDeclined {
+		status = statusDeclined
+	}
+	writeJSON(w, map[string]any{
+		"id":       p.transactionId,
+		"amount":   p.amount,
+		"cardType": p.cardType,
+		"merchant": map[string]string{"id": merchantId},
+		"status":   status,
+	})
+}
+
+// setScenario меняет сценарий для новых ссылок: {"scenario": "delayed", "delay": "10s", "latency": "2s"}
+func (b *mockBank) setScenario(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		writeJSON(w, map[string]any{"scenario": b.scenario, "delay": b.delay.String(), "latency": b.latency.String()})
+		return
+	}
+
+	var req struct {
+		Scenario string `json:"scenario"`
+		Delay    string `json:"delay"`
+		Latency  string `json:"latency"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !isScenarioValid(req.Scenario) {
+		http.Error(w, "available scenarios: "+scenarioList(), http.StatusBadRequest)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if req.Delay != "" {
+		delay, err := time.ParseDuration(req.Delay)
+		if err != nil {
+			http.Error(w, "invalid delay", http.StatusBadRequest)
+			return
+		}
+		b.delay = delay
+	}
+	if req.Latency != "" {
+		latency, err := time.ParseDuration(req.Latency)
+		if err != nil {
+			http.Error(w, "invalid latency", http.StatusBadRequest)
+			return
+		}
+		b.latency = latency
+	}
+	b.scenario = req.Scenario
+	log.Printf("scenario changed to %s", req.Scenario)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (b *mockBank) find(id string) *payment {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.payments[id]
+}
+
+func writeJSON(w http.ResponseWriter, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		log.Println(err)
+	}
+}
+
+func randomId() string {
+	buf := make([]byte, 12)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,388 @@
+//go:build integration
+
+// Интеграционные тесты жизненного цикла заказа против эмулятора банка.
+// Нужны MySQL из dev.env и proxy.json в configs/, через прокси должен быть доступен 127.0.0.1:
+//
+//	go test -tags integration ./cmd/mockbank
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"payment-go/internal/config"
+	"payment-go/internal/database"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories"
+	"payment-go/internal/services"
+	"payment-go/internal/transport/webhook/order"
+)
+
+// таймауты запросов к банку 10-20 секунд, ожидание с запасом
+const createWait = 90 * time.Second
+const checkWait = 60 * time.Second
+const webhookWait = 10 * time.Second
+
+const orderAmount = 10
+
+var bank *mockBank
+var hooks = &webhookRecorder{received: make(map[string]chan receivedWebhook)}
+var hooksUrl string
+var setupErr error
+
+type receivedWebhook struct {
+	path string
+	body order.WebhookOrderCompletedDto
+}
+
+// webhookRecorder принимает вебхуки магазина и раздаёт их тестам по номеру заказа
+type webhookRecorder struct {
+	mu       sync.Mutex
+	received map[string]chan receivedWebhook
+}
+
+func (r *webhookRecorder) channel(orderNumber string) chan receivedWebhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ch, ok := r.received[orderNumber]
+	if !ok {
+		ch = make(chan receivedWebhook, 4)
+		r.received[orderNumber] = ch
+	}
+	return ch
+}
+
+func (r *webhookRecorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var body order.WebhookOrderCompletedDto
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.channel(body.OrderNumber) <- receivedWebhook{path: req.URL.Path, body: body}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func TestMain(m *testing.M) {
+	bank = newMockBank("", ScenarioSuccess, 0, 500*time.Millisecond)
+	bankSrv := httptest.NewServer(bank.routes())
+	bank.baseUrl = bankSrv.URL
+	hooksSrv := httptest.NewServer(hooks)
+	hooksUrl = hooksSrv.URL
+
+	dir, err := os.MkdirTemp("", "mockbank")
+	if err == nil {
+		setupErr = setup(dir, bankSrv.URL)
+	} else {
+		setupErr = err
+	}
+
+	code := m.Run()
+	bankSrv.Close()
+	hooksSrv.Close()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// setup кладёт в рабочую директорию конфиги с эмулятором и короткими задержками,
+// остальные конфиги берутся из configs/ репозитория
+func setup(dir, url string) error {
+	repoConfigs, err := filepath.Abs("../../configs")
+	if err != nil {
+		return err
+	}
+	files := map[string]string{
+		"bank.json": fmt.Sprintf(`{"host": "%[1]s/", "links": {
+			"get_atts_url": "%[1]s/core/merchants/M10%%20Wallet/attributes/",
+			"check_merchant_url": "%[1]s/payments/check-merchant/",
+			"payment_url": "%[1]s/payments/payments/",
+			"check_payment_url": "%[1]s/payments/check/",
+			"transaction_info": "%[1]s/payments/transactions/"}}`, url),
+		"link_check.json":  `{"backoff": ["200ms"], "timeout": "15s", "max_attempts": 100}`,
+		"link_create.json": `{"retry_delay": "200ms", "max_retry_delay": "1s", "max_attempts": 3, "card_fallbacks": 0}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			return err
+		}
+	}
+	if err := os.Symlink(repoConfigs, filepath.Join(dir, "configs")); err != nil {
+		return err
+	}
+	if err := os.Chdir(dir); err != nil {
+		return err
+	}
+
+	if err := config.Load(); err != nil {
+		return err
+	}
+	if _, err := database.NewConnection(config.GetConfig()); err != nil {
+		return err
+	}
+	return database.MakeMigrations(models.GetModels())
+}
+
+func setScenario(scenario string) {
+	bank.mu.Lock()
+	defer bank.mu.Unlock()
+	bank.scenario = scenario
+}
+
+func setDelay(delay time.Duration) {
+	bank.mu.Lock()
+	defer bank.mu.Unlock()
+	bank.delay = delay
+}
+
+// newOrder создаёт заказ нового магазина на новой включённой карте. Вебхуки магазина приходят в hooks
+func newOrder(t *testing.T) *models.Order {
+	t.Helper()
+	if setupErr != nil {
+		t.Skip("integration environment is not available:", setupErr)
+	}
+
+	suffix := fmt.Sprintf("%07d", rand.Intn(10_000_000))
+	shop, err := models.NewShop("mockbank-"+suffix, "https://example.com", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// хост проверяется только при создании магазина, локальный адрес им не пройти
+	shop.Host = hooksUrl
+	onSuccess, onFailure := "/success", "/failure"
+	shop.Webhooks.OnSuccess = &onSuccess
+	shop.Webhooks.OnFailure = &onFailure
+	if err = repositories.ShopRepository().Save(shop); err != nil {
+		t.Fatal(err)
+	}
+
+	card := models.NewCardWithPhone("99455", suffix)
+	card.Status = models.CardStatusEnabled
+	if err = repositories.CardRepository().Save(card); err != nil {
+		t.Fatal(err)
+	}
+
+	ord := &models.Order{
+		ShopID:        shop.ID,
+		Shop:          *shop,
+		PaymentMethod: config.PaymentMethodKapitalBank,
+		CardID:        card.ID,
+		Card:          *card,
+		Amount:        orderAmount,
+	}
+	if err = repositories.OrderRepository().Save(ord); err != nil {
+		t.Fatal(err)
+	}
+	return ord
+}
+
+func createLink(t *testing.T, ord *models.Order) *models.PaymentLink {
+	t.Helper()
+	done := make(chan *models.PaymentLink, 1)
+	services.BankApiService().TaskCreateLink(ord, func(link *models.PaymentLink) {
+		done <- link
+	})
+	select {
+	case link := <-done:
+		return link
+	case <-time.After(createWait):
+		t.Fatal("link was not created in time")
+	}
+	return nil
+}
+
+func checkLink(t *testing.T, link *models.PaymentLink) *models.PaymentLink {
+	t.Helper()
+	done := make(chan *models.PaymentLink, 1)
+	services.BankApiService().TaskCheckLink(context.Background(), link, func(link *models.PaymentLink) {
+		done <- link
+	})
+	select {
+	case link := <-done:
+		return link
+	case <-time.After(checkWait):
+		t.Fatal("link check did not finish in time")
+	}
+	return nil
+}
+
+func assertLink(t *testing.T, link *models.PaymentLink, want string) {
+	t.Helper()
+	if link.Status != want {
+		t.Fatalf("link status %s, want %s (last bank response: %q)", link.Status, want, link.LastBankResponse)
+	}
+}
+
+// assertOrderPaid проверяет завершённый заказ: статус, начисления на карту и магазин и вебхук об успехе
+func assertOrderPaid(t *testing.T, ord *models.Order) {
+	t.Helper()
+	assertOrder(t, ord, models.StatusCompleted, "/success")
+
+	fee := math.Round(orderAmount*config.SettlementOrderFeePercent) / 100
+	assertBalances(t, ord, orderAmount, orderAmount-fee)
+}
+
+// assertOrderFailed проверяет неоплаченный заказ: статус, нулевые балансы и вебхук об отказе
+func assertOrderFailed(t *testing.T, ord *models.Order) {
+	t.Helper()
+	assertOrder(t, ord, models.StatusFailed, "/failure")
+	assertBalances(t, ord, 0, 0)
+}
+
+func assertOrder(t *testing.T, ord *models.Order, status, webhookPath string) {
+	t.Helper()
+	saved, err := repositories.OrderRepository().FindById(ord.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Status != status {
+		t.Fatalf("order status %s, want %s", saved.Status, status)
+	}
+	if saved.DatePaid == nil {
+		t.Fatal("finished order has no date")
+	}
+
+	select {
+	case hook := <-hooks.channel(saved.Number.String()):
+		if hook.path != webhookPath || hook.body.Success != (status == models.StatusCompleted) || hook.body.Amount != orderAmount {
+			t.Fatalf("unexpected webhook %s %+v", hook.path, hook.body)
+		}
+	case <-time.After(webhookWait):
+		t.Fatal("order webhook was not delivered")
+	}
+}
+
+// assertBalances ждёт начисления: его делает подписчик события outbox асинхронно
+func assertBalances(t *testing.T, ord *models.Order, card, shop float64) {
+	t.Helper()
+	deadline := time.Now().Add(webhookWait)
+	for {
+		cardBalance := 0.0
+		if info, err := repositories.CardRepository().GetCardInfo(ord.CardID); err == nil {
+			cardBalance = info.Balance
+		}
+		shopBalance, err := services.SettlementService().GetBalance(ord.ShopID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cardBalance == card && math.Abs(shopBalance-shop) < 0.001 {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("card balance %.2f, want %.2f; shop balance %.2f, want %.2f", cardBalance, card, shopBalance, shop)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func TestLinkSuccess(t *testing.T) {
+	setScenario(ScenarioSuccess)
+	ord := newOrder(t)
+	link := createLink(t, ord)
+	assertLink(t, link, models.StatusPending)
+	if len(link.URL) == 0 {
+		t.Fatal("link has no payment url")
+	}
+
+	assertLink(t, checkLink(t, link), models.StatusCompleted)
+	assertOrderPaid(t, ord)
+}
+
+// оплата приходит не сразу: проверки повторяются, пока банк не отдаст транзакцию
+func TestLinkDelayedPayment(t *testing.T) {
+	setScenario(ScenarioDelayed)
+	setDelay(2 * time.Second)
+	ord := newOrder(t)
+	link := createLink(t, ord)
+	assertLink(t, link, models.StatusPending)
+
+	link = checkLink(t, link)
+	assertLink(t, link, models.StatusCompleted)
+	if link.CheckAttempts < 2 {
+		t.Fatalf("link was checked %d times, want a retry before the payment", link.CheckAttempts)
+	}
+	assertOrderPaid(t, ord)
+}
+
+func TestLinkDeclined(t *testing.T) {
+	setScenario(ScenarioDeclined)
+	ord := newOrder(t)
+	link := createLink(t, ord)
+	assertLink(t, link, models.StatusPending)
+
+	assertLink(t, checkLink(t, link), models.StatusFailed)
+	assertOrderFailed(t, ord)
+}
+
+// банк редиректит без id транзакции: ссылка проверяется до link_check.timeout и завершается отказом
+func TestLinkWithoutTransaction(t *testing.T) {
+	setScenario(ScenarioNoTransaction)
+	ord := newOrder(t)
+	link := createLink(t, ord)
+	assertLink(t, link, models.StatusPending)
+
+	link = checkLink(t, link)
+	assertLink(t, link, models.StatusFailed)
+	if len(link.TransactionId) != 0 {
+		t.Fatalf("link got transaction id %q", link.TransactionId)
+	}
+	assertOrderFailed(t, ord)
+}
+
+func TestLinkRejected(t *testing.T) {
+	setScenario(ScenarioRejected)
+	ord := newOrder(t)
+	assertLink(t, createLink(t, ord), models.StatusFailed)
+	assertOrderFailed(t, ord)
+}
+
+func TestLinkServerError(t *testing.T) {
+	setScenario(ScenarioServerError)
+	ord := newOrder(t)
+	assertLink(t, createLink(t, ord), models.StatusFailed)
+	assertOrderFailed(t, ord)
+}
+
+func TestLinkMalformedResponse(t *testing.T) {
+	setScenario(ScenarioMalformed)
+	ord := newOrder(t)
+	assertLink(t, createLink(t, ord), models.StatusFailed)
+	assertOrderFailed(t, ord)
+}
+
+func TestLinkCreateTimeout(t *testing.T) {
+	setScenario(ScenarioTimeout)
+	ord := newOrder(t)
+	assertLink(t, createLink(t, ord), models.StatusFailed)
+	assertOrderFailed(t, ord)
+}
+
+// банк перестал отвечать после выдачи ссылки: проверка завершает её по link_check.timeout
+func TestLinkCheckTimeout(t *testing.T) {
+	setScenario(ScenarioSuccess)
+	ord := newOrder(t)
+	link := createLink(t, ord)
+	assertLink(t, link, models.StatusPending)
+
+	setScenario(ScenarioTimeout)
+	assertLink(t, checkLink(t, link), models.StatusFailed)
+	assertOrderFailed(t, ord)
+}
+
+func TestLinkSlowResponses(t *testing.T) {
+	setScenario(ScenarioSlow)
+	ord := newOrder(t)
+	link := createLink(t, ord)
+	assertLink(t, link, models.StatusPending)
+
+	assertLink(t, checkLink(t, link), models.StatusCompleted)
+	assertOrderPaid(t, ord)
+}
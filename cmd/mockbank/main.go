@@ -0,0 +1,30 @@
+// mockbank эмулирует API банка для локальной разработки.
+// Сценарий задаётся флагом -scenario и меняется на лету через POST /mock/scenario
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8090", "listen address")
+	baseUrl := flag.String("base-url", "", "public url of the server, by default http://<addr>")
+	scenario := flag.String("scenario", ScenarioSuccess, "scenario: "+scenarioList())
+	delay := flag.Duration("delay", 30*time.Second, "payment delay for the delayed scenario")
+	latency := flag.Duration("latency", 3*time.Second, "response delay for the slow scenario")
+	flag.Parse()
+
+	if !isScenarioValid(*scenario) {
+		log.Fatalf("unknown scenario %q, available: %s", *scenario, scenarioList())
+	}
+	if *baseUrl == "" {
+		*baseUrl = "http://" + *addr
+	}
+
+	b := newMockBank(*baseUrl, *scenario, *delay, *latency)
+	log.Printf("mock bank listening on %s, scenario %s", *addr, *scenario)
+	log.Fatal(http.ListenAndServe(*addr, b.routes()))
+}
@@ -0,0 +1,42 @@
+package main
+
+import "strings"
+
+const ScenarioSuccess = "success"              // ссылка сразу оплачена
+const ScenarioDelayed = "delayed"              // оплата проходит через -delay после создания ссылки
+const ScenarioDeclined = "declined"            // банк отклонил оплату
+const ScenarioNoTransaction = "no_transaction" // редирект без id транзакции
+const ScenarioRejected = "rejected"            // банк отказывает в check-merchant
+const ScenarioServerError = "server_error"     // все запросы возвращают 500
+const ScenarioRateLimited = "rate_limited"     // все запросы возвращают 429
+const ScenarioMalformed = "malformed"          // все ответы содержат некорректный JSON
+const ScenarioTimeout = "timeout"              // запросы остаются без ответа
+const ScenarioSlow = "slow"                    // каждый ответ задерживается на -latency
+
+func scenarios() []string {
+	return []string{
+		ScenarioSuccess,
+		ScenarioDelayed,
+		ScenarioDeclined,
+		ScenarioNoTransaction,
+		ScenarioRejected,
+		ScenarioServerError,
+		ScenarioRateLimited,
+		ScenarioMalformed,
+		ScenarioTimeout,
+		ScenarioSlow,
+	}
+}
+
+func isScenarioValid(scenario string) bool {
+	for _, val := range scenarios() {
+		if scenario == val {
+			return true
+		}
+	}
+	return false
+}
+
+func scenarioList() string {
+	return strings.Join(scenarios(), ", ")
+}
@@ -1,6 +1,8 @@
 package app
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/gofiber/adaptor/v2"
 	"github.com/gofiber/fiber/v2"
@@ -8,10 +10,14 @@ import (
 	_ "github.com/gofiber/fiber/v2/middleware/cors"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
 	"payment-go/internal/config"
 	"payment-go/internal/controllers"
 	"payment-go/internal/controllers/analytics"
+	"payment-go/internal/controllers/auth"
 	"payment-go/internal/controllers/crud"
+	"payment-go/internal/controllers/merchant"
 	"payment-go/internal/controllers/webhook"
 	"payment-go/internal/database"
 	"payment-go/internal/models"
@@ -20,17 +26,21 @@ import (
 	"payment-go/internal/utils/proxy"
 	"strconv"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 )
 
 type IApp interface {
 	Prepare() error
 	Launch() error
+	Shutdown(ctx context.Context) error
 }
 type app struct {
 	config    *config.Config
 	fiber     *fiber.App
 	taskQueue proxy.ITaskQueue
+	draining  atomic.Bool
 }
 
 var appOnce = sync.Once{}
@@ -83,7 +93,7 @@ func (a *app) makeRoutes() {
 	func() {
 
 		// create order and get wait-link
-		api.Post("/order/create", controllers.OrderController().Create)
+		api.Post("/order/create", a.rejectWhenDraining, controllers.OrderController().Create)
 
 		// get payment info
 		api.Get("/order/payment-info", controllers.OrderController().GetPaymentInfo)
@@ -100,9 +110,19 @@ func (a *app) makeRoutes() {
 		)
 		// wait-link page
 		api.Get("/order/:order_number/process_to_payment", controllers.IndexController().Payment)
+		// order status stream: SSE, WebSocket, long-poll
+		api.Get("/order/:order_number/stream", controllers.OrderController().Stream)
+		api.Get("/order/:order_number/ws", controllers.OrderController().StreamWebSocket)
+		api.Get("/order/:order_number/poll", controllers.OrderController().Poll)
+		// return to shop after payment
+		api.Get("/order/:order_number/return", controllers.OrderController().Return)
 
 		// withdraw
 		api.Post("/withdraw/create", controllers.WithdrawController().Create)
+		api.Post("/withdraw/batch", controllers.WithdrawBatchController().Create)
+
+		// settlements
+		api.Get("/settlement/download", controllers.SettlementController().Download)
 	}()
 
 	// API Webhooks (public)
@@ -114,70 +134,177 @@ func (a *app) makeRoutes() {
 
 	}()
 
-	// CRUD routes
+	// Admin auth routes
+	func() {
+		group := a.fiber.Group("/auth")
+
+		group.Post("/login", auth.AuthController().Login)
+		group.Post("/logout", auth.AuthController().Logout)
+		group.Get("/me", auth.AuthController().Me)
+	}()
+
+	// CRUD routes, доступны только вошедшим пользователям с нужным правом
 	func() {
 		group := a.fiber.Group("/crud")
+		view := auth.Require(models.PermissionView)
+		operate := auth.Require(models.PermissionOperate)
+		finance := auth.Require(models.PermissionFinance)
+		manage := auth.Require(models.PermissionManage)
+
 		cruds := map[string]crud.ICrudController{
-			"order":        crud.OrderCrudController(),
-			"payment_link": crud.PaymentLinkCrudController(),
-			"card":         crud.CardCrudController(),
-			"shop":         crud.ShopCrudController(),
-			"bank_message": crud.BankMessageCrudController(),
-			"withdraw":     crud.WithdrawCrudController(),
+			"order":          crud.OrderCrudController(),
+			"payment_link":   crud.PaymentLinkCrudController(),
+			"card":           crud.CardCrudController(),
+			"shop":           crud.ShopCrudController(),
+			"bank_message":   crud.BankMessageCrudController(),
+			"withdraw":       crud.WithdrawCrudController(),
+			"dispute":        crud.DisputeCrudController(),
+			"withdraw_batch": crud.WithdrawBatchCrudController(),
+			"settlement":     crud.SettlementCrudController(),
+			"admin_user":     crud.AdminUserCrudController(),
+			"merchant":       crud.MerchantCrudController(),
 		}
-		for prefix, crud := range cruds {
-			rules := crud.GetActions()
+		for prefix, ctrl := range cruds {
+			rules := ctrl.GetActions()
+			perms := crud.PermissionsOf(ctrl)
 			if rules.Create {
-				group.Post(fmt.Sprintf("/%s/create", prefix), crud.Create)
+				group.Post(fmt.Sprintf("/%s/create", prefix), auth.Require(perms.Create), crud.Audit(prefix+".create", prefix, "", crud.ResponseKeyOf(ctrl, prefix)), ctrl.Create)
 			}
 			if rules.Read {
-				group.Get(fmt.Sprintf("/%s/read", prefix), crud.Read)
+				group.Get(fmt.Sprintf("/%s/read", prefix), auth.Require(perms.Read), ctrl.Read)
 			}
 			if rules.Update {
-				group.Post(fmt.Sprintf("/%s/update", prefix), crud.Update)
+				group.Post(fmt.Sprintf("/%s/update", prefix), auth.Require(perms.Update), crud.Audit(prefix+".update", prefix, "id", ""), ctrl.Update)
 			}
 			if rules.Delete {
-				group.Post(fmt.Sprintf("/%s/delete", prefix), crud.Delete)
+				group.Post(fmt.Sprintf("/%s/delete", prefix), auth.Require(perms.Delete), crud.Audit(prefix+".delete", prefix, "id", ""), ctrl.Delete)
 			}
 			if rules.List {
-				group.Get(fmt.Sprintf("/%s/list", prefix), crud.List)
+				group.Get(fmt.Sprintf("/%s/list", prefix), auth.Require(perms.List), ctrl.List)
 			}
 		}
 
 		// card
-		group.Get("/card/check-activity", crud.CardCrudController().CheckActivity)
-		group.Post("/card/change-balance", crud.CardCrudController().ChangeBalance)
+		group.Get("/card/check-activity", view, crud.CardCrudController().CheckActivity)
+		group.Post("/card/change-balance", finance, crud.Audit("card.change_balance", "card", "card_id", ""), crud.CardCrudController().ChangeBalance)
 
 		// shop
-		group.Post("/shop/regenerate-private-key", crud.ShopCrudController().RegeneratePrivateKey)
-		group.Post("/shop/validate-host", crud.ShopCrudController().ValidateShopHost)
+		group.Post("/shop/regenerate-private-key", manage, crud.Audit("shop.regenerate_private_key", "shop", "shop_id", ""), crud.ShopCrudController().RegeneratePrivateKey)
+		group.Post("/shop/validate-host", operate, crud.Audit("shop.validate_host", "shop", "shop_id", ""), crud.ShopCrudController().ValidateShopHost)
 
 		// bank message
-		group.Post("/bank_message/approve", crud.BankMessageCrudController().Approve)
-		group.Post("/bank_message/decline", crud.BankMessageCrudController().Decline)
+		group.Post("/bank_message/approve", operate, crud.Audit("bank_message.approve", "bank_message", "message_id", ""), crud.BankMessageCrudController().Approve)
+		group.Post("/bank_message/decline", operate, crud.Audit("bank_message.decline", "bank_message", "message_id", ""), crud.BankMessageCrudController().Decline)
 
 		// withdraw
-		group.Post("/withdraw/approve", crud.WithdrawCrudController().Approve)
-		group.Post("/withdraw/decline", crud.WithdrawCrudController().Decline)
-		group.Post("/withdraw/process", crud.WithdrawCrudController().Process)
+		group.Post("/withdraw/approve", finance, crud.Audit("withdraw.approve", "withdraw", "withdraw_id", ""), crud.WithdrawRiskCrudController().RequireApproval, crud.WithdrawCrudController().Approve)
+		group.Post("/withdraw/decline", finance, crud.Audit("withdraw.decline", "withdraw", "withdraw_id", ""), crud.WithdrawCrudController().Decline)
+		group.Post("/withdraw/process", finance, crud.Audit("withdraw.process", "withdraw", "withdraw_id", ""), crud.WithdrawRiskCrudController().RequireApproval, crud.WithdrawCrudController().Process)
+		group.Post("/withdraw/payout", finance, crud.Audit("withdraw.payout", "withdraw", "withdraw_id", ""), crud.WithdrawRiskCrudController().RequireApproval, crud.PayoutCrudController().Execute)
+		group.Post("/withdraw/payout-confirm", finance, crud.Audit("withdraw.payout_confirm", "withdraw", "withdraw_id", ""), crud.PayoutCrudController().Confirm)
+		group.Get("/withdraw/payout-read", view, crud.PayoutCrudController().Read)
+		group.Get("/withdraw/risk-read", view, crud.WithdrawRiskCrudController().Read)
+		group.Post("/withdraw/risk-approve", finance, crud.Audit("withdraw.risk_approve", "withdraw", "withdraw_id", ""), crud.WithdrawRiskCrudController().Approve)
+
+		// withdraw batch
+		group.Post("/withdraw_batch/upload", finance, crud.Audit("withdraw_batch.upload", "withdraw_batch", "", "batch"), crud.WithdrawBatchCrudController().Upload)
+		group.Post("/withdraw_batch/approve", finance, crud.Audit("withdraw_batch.approve", "withdraw_batch", "batch_id", ""), crud.WithdrawBatchCrudController().Approve)
+		group.Post("/withdraw_batch/decline", finance, crud.Audit("withdraw_batch.decline", "withdraw_batch", "batch_id", ""), crud.WithdrawBatchCrudController().Decline)
+
+		// settlement
+		group.Post("/settlement/close", finance, crud.Audit("settlement.close", "settlement", "", "settlement"), crud.SettlementCrudController().Close)
+		group.Get("/settlement/export", finance, crud.SettlementCrudController().Export)
+
+		// reconciliation
+		group.Post("/reconciliation/import", finance, crud.Audit("reconciliation.import", "bank_statement", "", "statement"), crud.ReconciliationCrudController().Import)
+		group.Post("/reconciliation/link", finance, crud.Audit("reconciliation.link", "order", "order_id", ""), crud.ReconciliationCrudController().Link)
+		group.Post("/reconciliation/ignore", finance, crud.Audit("reconciliation.ignore", "bank_statement_line", "line_id", ""), crud.ReconciliationCrudController().Ignore)
+		group.Get("/reconciliation/messages", view, crud.ReconciliationCrudController().Messages)
+
+		// scheduler
+		group.Get("/scheduler/jobs", view, crud.SchedulerCrudController().Jobs)
+		group.Post("/scheduler/trigger", manage, crud.Audit("scheduler.trigger", "scheduled_job", "id", ""), crud.SchedulerCrudController().Trigger)
+
+		// proxy
+		group.Get("/proxy/health", view, crud.ProxyCrudController().Health)
+
+		// dispute
+		group.Post("/dispute/add-evidence", operate, crud.Audit("dispute.add_evidence", "dispute", "dispute_id", ""), crud.DisputeCrudController().AddEvidence)
+		group.Post("/dispute/win", operate, crud.Audit("dispute.win", "dispute", "dispute_id", ""), crud.DisputeCrudController().Win)
+		group.Post("/dispute/lose", operate, crud.Audit("dispute.lose", "dispute", "dispute_id", ""), crud.DisputeCrudController().Lose)
 
 		// filters
-		group.Post("/order/find", crud.OrderCrudController().Find)
-		group.Post("/shop/find", crud.ShopCrudController().Find)
-		group.Post("/payment_link/find", crud.PaymentLinkCrudController().Find)
-		group.Post("/bank_message/find", crud.BankMessageCrudController().Find)
-		group.Post("/withdraw/find", crud.WithdrawCrudController().Find)
+		group.Post("/order/find", view, crud.OrderCrudController().Find)
+		group.Post("/shop/find", view, crud.ShopCrudController().Find)
+		group.Post("/payment_link/find", view, crud.PaymentLinkCrudController().Find)
+		group.Post("/bank_message/find", view, crud.BankMessageCrudController().Find)
+		group.Post("/withdraw/find", view, crud.WithdrawRiskCrudController().Find)
+		group.Post("/dispute/find", view, crud.DisputeCrudController().Find)
+		group.Post("/withdraw_batch/find", view, crud.WithdrawBatchCrudController().Find)
+		group.Post("/settlement/find", view, crud.SettlementCrudController().Find)
+		group.Post("/reconciliation/find", view, crud.ReconciliationCrudController().Find)
+		group.Post("/scheduler/runs", view, crud.SchedulerCrudController().Runs)
+		group.Post("/audit/find", manage, crud.AuditCrudController().Find)
+
+		// merchant
+		group.Get("/merchant/members", view, crud.MerchantCrudController().Members)
+		group.Post("/merchant/invite", manage, crud.Audit("merchant.invite", "merchant", "merchant_id", ""), crud.MerchantCrudController().Invite)
+
+		// owner tokens for merchant API
+		group.Post("/owner_token/issue", manage, crud.Audit("owner_token.issue", "owner_token", "", "owner_token"), crud.OwnerTokenCrudController().Issue)
+		group.Post("/owner_token/revoke", manage, crud.Audit("owner_token.revoke", "owner_token", "id", ""), crud.OwnerTokenCrudController().Revoke)
+		group.Get("/owner_token/list", manage, crud.OwnerTokenCrudController().List)
+	}()
+
+	// Merchant account routes (public), регистрируются до группы /merchant с авторизацией
+	func() {
+		group := a.fiber.Group("/merchant/auth")
+
+		group.Post("/login", merchant.AccountController().Login)
+		group.Post("/logout", merchant.AccountController().Logout)
+		group.Post("/invite/accept", merchant.AccountController().AcceptInvite)
+	}()
+
+	// Merchant API routes, авторизация ключами магазина, токеном API или сессией кабинета
+	func() {
+		group := a.fiber.Group("/merchant", merchant.MerchantController().Authenticate)
+		orders := merchant.Require(models.MerchantPermissionOrders)
+		finance := merchant.Require(models.MerchantPermissionFinance)
+		integration := merchant.Require(models.MerchantPermissionIntegration)
+		team := merchant.Require(models.MerchantPermissionTeam)
+
+		group.Get("/me", merchant.AccountController().Me)
+		group.Get("/shops", merchant.MerchantController().Shops)
+		group.Get("/balance", finance, merchant.MerchantController().Balance)
+		group.Post("/order/find", orders, merchant.MerchantController().FindOrders)
+		group.Post("/withdraw/find", finance, merchant.MerchantController().FindWithdraws)
+		group.Post("/shop/webhooks", integration, merchant.AuditShop("merchant.shop_webhooks"), merchant.MerchantController().UpdateWebhooks)
+		group.Post("/shop/regenerate-private-key", integration, merchant.AuditShop("merchant.shop_regenerate_private_key"), merchant.MerchantController().RegeneratePrivateKey)
+		group.Get("/shop/host-validation", integration, merchant.MerchantController().HostValidation)
+		group.Post("/shop/validate-host", integration, merchant.AuditShop("merchant.shop_validate_host"), merchant.MerchantController().ValidateHost)
+
+		// team
+		group.Get("/team", team, merchant.TeamController().Members)
+		group.Post("/team/invite", team, merchant.Audit("merchant.team_invite", "merchant_user", "", "invitation.member"), merchant.TeamController().Invite)
+		group.Post("/team/update", team, merchant.Audit("merchant.team_update", "merchant_user", "id", ""), merchant.TeamController().UpdateMember)
+		group.Post("/team/remove", team, merchant.Audit("merchant.team_remove", "merchant_user", "id", ""), merchant.TeamController().RemoveMember)
+
+		// personal API tokens
+		group.Get("/tokens", merchant.RequireMember, merchant.TeamController().Tokens)
+		group.Post("/tokens/create", merchant.RequireMember, merchant.Audit("merchant.token_create", "owner_token", "", "owner_token"), merchant.TeamController().CreateToken)
+		group.Post("/tokens/revoke", merchant.RequireMember, merchant.Audit("merchant.token_revoke", "owner_token", "id", ""), merchant.TeamController().RevokeToken)
 	}()
 
 	// Analytics routes
 	func() {
-		group := a.fiber.Group("/analytics")
+		group := a.fiber.Group("/analytics", auth.Require(models.PermissionView))
 
 		group.Post("/card", analytics.CardAnalyticsController().Totals)
+		group.Post("/dispute-rate", analytics.DisputeAnalyticsController().Rates)
 	}()
 
 	// create order and get wait-link
-	a.fiber.Post("/order/create", controllers.OrderController().Create)
+	a.fiber.Post("/order/create", a.rejectWhenDraining, controllers.OrderController().Create)
 	// check order status
 	a.fiber.Get("/order/:order_id/check-status", controllers.OrderController().CheckStatus)
 }
@@ -187,14 +314,78 @@ func (a *app) init() {
 	services.BankApiService()
 	services.CardService()
 	services.OrderService()
-	errors := services.PaymentLinkService().LoadPendingLinks()
-	if errors != nil {
-		for _, err := range errors {
-			log.Println(err)
-		}
+	services.PayoutService()
+	services.WithdrawRiskService()
+	services.SettlementService()
+	services.DisputeService()
+	services.OutboxService()
+	services.PaymentLinkService()
+	services.SchedulerService().Start()
+
+	if err := services.AdminUserService().Bootstrap(); err != nil {
+		log.Println(err)
+	}
+	if err := services.MerchantAccountService().EnsureMerchants(); err != nil {
+		log.Println(err)
+	}
+}
+
+// rejectWhenDraining не принимает новые заказы во время остановки, балансировщик отправит их на другой узел
+func (a *app) rejectWhenDraining(ctx *fiber.Ctx) error {
+	if a.draining.Load() {
+		return ctx.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"success": false,
+			"error":   "Service is shutting down. Try again later.",
+		})
 	}
+	return ctx.Next()
 }
 
+// Launch запускает сервер и по SIGINT/SIGTERM корректно останавливает приложение
 func (a *app) Launch() error {
-	return a.fiber.Listen(":" + strconv.Itoa(int(a.config.AppPort)))
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- a.fiber.Listen(":" + strconv.Itoa(int(a.config.AppPort)))
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	select {
+	case err := <-listenErr:
+		return err
+	case sig := <-signals:
+		log.Printf("Received %v, shutting down", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+	return a.Shutdown(ctx)
+}
+
+// Shutdown перестаёт принимать заказы, закрывает SSE, останавливает фоновые задачи
+// и дожидается запущенных запросов к банку. Незавершённые ссылки подхватит другой узел или следующий запуск
+func (a *app) Shutdown(ctx context.Context) error {
+	a.draining.Store(true)
+	controllers.OrderController().Shutdown()
+
+	var errs []error
+	if err := services.SchedulerService().Stop(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("scheduler: %w", err))
+	}
+	if err := services.BankApiService().Shutdown(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	// аренды освобождаем после завершения задач, чтобы другой узел не проверял те же ссылки
+	if err := services.JobService().Stop(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("jobs: %w", err))
+	}
+	if err := a.fiber.ShutdownWithContext(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("http: %w", err))
+	}
+	if err := database.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("database: %w", err))
+	}
+	return errors.Join(errs...)
 }
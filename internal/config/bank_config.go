@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"sort"
 	"time"
 )
 
@@ -17,17 +18,75 @@ type Links struct {
 	TransactionInfo  string `json:"transaction_info"`
 }
 
+// BankIntegrationConfig подключение к одному банку-эквайеру
+type BankIntegrationConfig struct {
+	Name            string            `json:"-"`
+	Type            string            `json:"type"` // реализация клиента, см. bank_api
+	Host            string            `json:"host"`
+	Links           Links             `json:"links"`
+	Credentials     map[string]string `json:"credentials"` // BankCredentialToken уходит в заголовке Authorization
+	CardTypeMapping map[string]string `json:"card_type_mapping"`
+}
+
 type BankConfig struct {
-	Host            string   `json:"host"`
-	Links           Links    `json:"links"`
+	// одиночное подключение в старом формате bank.json, используется как банк BankDefault
+	Host  string `json:"host"`
+	Links Links  `json:"links"`
+
+	Banks map[string]*BankIntegrationConfig `json:"banks"`
+	// MethodBanks способ оплаты -> банки в порядке переключения при отказе
+	MethodBanks map[string][]string `json:"payment_methods"`
+
 	CardTypes       []string `json:"-"`
 	PaymentMethods  []string `json:"-"`
 	CardTypeMapping map[string]string
 }
 
+const BankDefault = "default"
+const BankTypeMillion = "million"
+const BankCredentialToken = "token"
+
+// GetBank возвращает подключение по имени, пустое имя - банк по умолчанию
+func (c *BankConfig) GetBank(name string) (*BankIntegrationConfig, bool) {
+	if len(name) == 0 {
+		name = BankDefault
+	}
+	bank, ok := c.Banks[name]
+	return bank, ok
+}
+
+// ProbeHost хост для проверки прокси: банк по умолчанию, иначе первый по имени
+func (c *BankConfig) ProbeHost() string {
+	if def, ok := c.GetBank(BankDefault); ok {
+		return def.Host
+	}
+	names := make([]string, 0, len(c.Banks))
+	for name := range c.Banks {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return c.Banks[names[0]].Host
+}
+
+// GetMethodBanks банки, через которые создаются ссылки для способа оплаты
+func (c *BankConfig) GetMethodBanks(method string) []string {
+	return c.MethodBanks[method]
+}
+
 const PaymentMethodBankTransfer = "bank_transfer"
 const PaymentMethodKapitalBank = "kapital_bank"
 
+// таймауты запросов к банку
+const BankAttributesTimeout = 10 * time.Second
+const BankCheckMerchantTimeout = 15 * time.Second
+const BankGetLinkTimeout = 20 * time.Second
+const BankCheckLinkTimeout = 10 * time.Second
+const BankTransactionIdTimeout = 10 * time.Second
+const BankMaxResponseSize = 1 << 20 // Ответы больше считаются ошибкой
+
 const CardTypeNone = "none"
 const CardTypeVisa = "visa"
 const CardTypeMastercard = "mastercard"
@@ -35,7 +94,13 @@ const CardTypeMastercard = "mastercard"
 const BankSMSWebhookPassword = "K5G9o1xDSMhw8dM8P74AtGxEGhxh7V6v"
 const CardLockingTimeout = 10 * time.Minute
 const CardLockerGCInterval = 30 * time.Second
-const CardDisableAmount = 5000 // При достижении этой отметки карта будет заблокирована
+const CardDisableAmount = 5000    // При достижении этой отметки карта будет заблокирована
+const CardDisableDisputesLost = 3 // После стольких проигранных споров карта будет заблокирована
+
+const DisputeDefaultDeadline = 7 * 24 * time.Hour
+const DisputeExpireInterval = 10 * time.Minute // Как часто проигрывать споры без доказательств после срока
+const DisputeExpireBatchSize = 100
+const DisputeRateModerationThreshold = 0.01 // Доля споров, после которой магазин требует повторной модерации
 
 func buildBankConfig() (*BankConfig, error) {
 	conf := &BankConfig{}
@@ -57,6 +122,36 @@ func buildBankConfig() (*BankConfig, error) {
 		CardTypeMastercard: "Mastercard",
 	}
 
+	// старый формат - единственный банк для оплаты по ссылке
+	if len(conf.Banks) == 0 {
+		conf.Banks = map[string]*BankIntegrationConfig{
+			BankDefault: {
+				Type:  BankTypeMillion,
+				Host:  conf.Host,
+				Links: conf.Links,
+			},
+		}
+	}
+	if conf.MethodBanks == nil {
+		conf.MethodBanks = map[string][]string{
+			PaymentMethodKapitalBank: {BankDefault},
+		}
+	}
+	for name, bank := range conf.Banks {
+		bank.Name = name
+		if len(bank.Type) == 0 {
+			bank.Type = BankTypeMillion
+		}
+		if bank.CardTypeMapping == nil {
+			bank.CardTypeMapping = conf.CardTypeMapping
+		}
+	}
+	// для совместимости Host и Links указывают на банк по умолчанию
+	if def, ok := conf.GetBank(BankDefault); ok {
+		conf.Host = def.Host
+		conf.Links = def.Links
+	}
+
 	if err := validateBankConfig(conf); err != nil {
 		return nil, err
 	}
@@ -64,26 +159,22 @@ func buildBankConfig() (*BankConfig, error) {
 }
 
 func validateBankConfig(conf *BankConfig) error {
-	// host
-	if !validateURL(conf.Host) {
-		return fmt.Errorf("bank.host is not a correct URL")
+	for name, bank := range conf.Banks {
+		if err := validateBankIntegration(bank); err != nil {
+			return fmt.Errorf("bank.banks.%s: %w", name, err)
+		}
 	}
 
-	// links
-	if !validateURL(conf.Links.GetAttsUrl) {
-		return fmt.Errorf("bank.links.get_atts_url is not a correct URL")
-	}
-	if !validateURL(conf.Links.CheckMerchantUrl) {
-		return fmt.Errorf("bank.links.check_merchant_url is not a correct URL")
-	}
-	if !validateURL(conf.Links.PaymentUrl) {
-		return fmt.Errorf("bank.links.payment_url is not a correct URL")
-	}
-	if !validateURL(conf.Links.CheckPaymentUrl) {
-		return fmt.Errorf("bank.links.check_payment_url is not a correct URL")
-	}
-	if !validateURL(conf.Links.TransactionInfo) {
-		return fmt.Errorf("bank.links.transaction_info is not a correct URL")
+	// способы оплаты
+	for method, banks := range conf.MethodBanks {
+		if !contains(conf.PaymentMethods, method) {
+			return fmt.Errorf("bank.payment_methods: unknown payment method %s", method)
+		}
+		for _, name := range banks {
+			if _, ok := conf.Banks[name]; !ok {
+				return fmt.Errorf("bank.payment_methods.%s: unknown bank %s", method, name)
+			}
+		}
 	}
 
 	// card types
@@ -98,3 +189,43 @@ func validateBankConfig(conf *BankConfig) error {
 
 	return nil
 }
+
+func validateBankIntegration(bank *BankIntegrationConfig) error {
+	// host
+	if !validateURL(bank.Host) {
+		return fmt.Errorf("host is not a correct URL")
+	}
+
+	// links
+	if !validateURL(bank.Links.GetAttsUrl) {
+		return fmt.Errorf("links.get_atts_url is not a correct URL")
+	}
+	if !validateURL(bank.Links.CheckMerchantUrl) {
+		return fmt.Errorf("links.check_merchant_url is not a correct URL")
+	}
+	if !validateURL(bank.Links.PaymentUrl) {
+		return fmt.Errorf("links.payment_url is not a correct URL")
+	}
+	if !validateURL(bank.Links.CheckPaymentUrl) {
+		return fmt.Errorf("links.check_payment_url is not a correct URL")
+	}
+	if !validateURL(bank.Links.TransactionInfo) {
+		return fmt.Errorf("links.transaction_info is not a correct URL")
+	}
+
+	for key := range bank.Credentials {
+		if key != BankCredentialToken {
+			return fmt.Errorf("credentials: unknown key %s", key)
+		}
+	}
+	return nil
+}
+
+func contains(list []string, val string) bool {
+	for _, item := range list {
+		if item == val {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/joho/godotenv"
 	"log"
@@ -23,23 +24,42 @@ type Config struct {
 	DbUser        string `env:"DB_USER"`
 	DbPassword    string `env:"DB_PASSWORD"`
 	IsDev         bool   `env:"DEV_MODE" default:"false"`
+	StateBackend  string `env:"STATE_BACKEND" default:"memory"`
+	RedisAddr     string `env:"REDIS_ADDR" default:"127.0.0.1:6379"`
+	RedisPassword string `env:"REDIS_PASSWORD"`
+	RedisDB       int    `env:"REDIS_DB" default:"0"`
+	AdminLogin    string `env:"ADMIN_LOGIN"` // первый администратор, создаётся при пустой таблице пользователей
+	AdminPassword string `env:"ADMIN_PASSWORD"`
 	Proxy         *ProxyConfig
 	Bank          *BankConfig
 	PaymentMethod *PaymentMethodConfig
+	Withdraw      *WithdrawConfig
+	Payout        *PayoutConfig
+	WithdrawRisk  *WithdrawRiskConfig
+	Statement     *StatementConfig
+	LinkCheck     *LinkCheckConfig
+	LinkCreate    *LinkCreateConfig
 }
 
 var config = &Config{}
 var configOnce = sync.Once{}
+var configErr error
 
 var configPaths = []string{"", "./configs/", "../configs/"}
 
+var ErrConfigNotFound = errors.New("config file is not found")
+
 const CreateLinkTimeout = 30 * time.Second
-const CheckLinkInterval = 20 * time.Second
-const CheckLinkTimeout = 10 * time.Minute
-const CheckLinkMaxAttempts = 100
 
-const ModelSubscriptionLifetime = 30 * time.Second
-const SubscriptionGCInterval = 1 * time.Minute
+const ShutdownTimeout = 30 * time.Second
+const SSERetryDelay = 3 * time.Second    // через сколько клиент SSE переподключится при остановке узла
+const StreamHeartbeat = 15 * time.Second // пинг открытых потоков статусов, чтобы прокси не закрывали соединение
+const LongPollTimeout = 25 * time.Second
+
+const AdminSessionTTL = 12 * time.Hour // продлевается при каждом запросе
+const AdminPasswordPlaceholder = "change-me-please"
+const MerchantSessionTTL = 12 * time.Hour
+const MerchantInviteTTL = 72 * time.Hour
 
 const TaskQueueInitialTPI = 300
 const TaskQueueIterationDelay = 100 * time.Millisecond
@@ -51,41 +71,83 @@ const CardSortPaymentSumKoef = 5
 const CardSortPaymentCountKoef = 2
 
 func GetConfig() *Config {
+	if err := Load(); err != nil {
+		log.Fatal(err)
+	}
+	return config
+}
+
+// Load загружает конфигурацию один раз. В отличие от GetConfig возвращает ошибку, а не завершает процесс
+func Load() error {
 	configOnce.Do(func() {
-		envPath := loadEnvFiles()
-		if envPath != "" {
-			fmt.Println("using env file: " + envPath)
-		}
+		config, configErr = buildConfig()
+	})
+	return configErr
+}
 
-		conf, err := buildConfigFromEnv()
-		if err != nil {
-			log.Fatal(err)
-		}
+func buildConfig() (*Config, error) {
+	envPath := loadEnvFiles()
+	if envPath != "" {
+		fmt.Println("using env file: " + envPath)
+	}
 
-		if conf.IsDev {
-			conf.AppHost = "http://127.0.0.1:" + strconv.Itoa(int(conf.AppPort))
-		} else {
-			conf.AppHost = "https://payment-ae.ru/"
-		}
+	conf, err := buildConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
 
-		proxyConf, err := buildProxyConfig()
-		if err != nil {
-			log.Fatal(err)
-		}
-		conf.Proxy = proxyConf
+	if conf.IsDev {
+		conf.AppHost = "http://127.0.0.1:" + strconv.Itoa(int(conf.AppPort))
+	} else {
+		conf.AppHost = "https://payment-ae.ru/"
+	}
 
-		bankConf, err := buildBankConfig()
-		if err != nil {
-			log.Fatal(err)
-		}
-		conf.Bank = bankConf
+	proxyConf, err := buildProxyConfig()
+	if err != nil {
+		return nil, err
+	}
+	conf.Proxy = proxyConf
 
-		conf.PaymentMethod = GetPaymentMethodConfig()
-		conf.Withdraw = BuildWithdrawConfig()
+	bankConf, err := buildBankConfig()
+	if err != nil {
+		return nil, err
+	}
+	conf.Bank = bankConf
 
-		config = conf
-	})
-	return config
+	conf.PaymentMethod = GetPaymentMethodConfig()
+	conf.Withdraw = BuildWithdrawConfig()
+
+	payoutConf, err := buildPayoutConfig()
+	if err != nil {
+		return nil, err
+	}
+	conf.Payout = payoutConf
+
+	riskConf, err := buildWithdrawRiskConfig()
+	if err != nil {
+		return nil, err
+	}
+	conf.WithdrawRisk = riskConf
+
+	statementConf, err := buildStatementConfig()
+	if err != nil {
+		return nil, err
+	}
+	conf.Statement = statementConf
+
+	linkCheckConf, err := buildLinkCheckConfig()
+	if err != nil {
+		return nil, err
+	}
+	conf.LinkCheck = linkCheckConf
+
+	linkCreateConf, err := buildLinkCreateConfig()
+	if err != nil {
+		return nil, err
+	}
+	conf.LinkCreate = linkCreateConf
+
+	return conf, nil
 }
 
 func BankLinks() Links {
@@ -188,7 +250,7 @@ func readJSONConfig(fileName string, conf any) error {
 		return nil
 	}
 
-	return fmt.Errorf("config file is not found - " + fileName)
+	return fmt.Errorf("%w - %s", ErrConfigNotFound, fileName)
 }
 
 func validateURL(str string) bool {
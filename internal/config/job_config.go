@@ -0,0 +1,24 @@
+package config
+
+import "time"
+
+const JobLeaseTTL = 30 * time.Second                // Через сколько задача переходит к другому узлу после падения владельца
+const PendingLinksReloadInterval = 15 * time.Second // Как часто владелец проверки ссылок подхватывает новые ссылки
+const PendingLinksMaxAge = 24 * time.Hour           // Ссылки, не обновлявшиеся дольше, при загрузке не подхватываются
+
+const SchedulerTick = 1 * time.Second // Как часто планировщик проверяет расписание
+
+// аренды
+const JobPendingLinks = "pending_links"
+const JobScheduler = "scheduler"
+
+// задачи планировщика
+const JobCardManagerReset = "card_manager_reset"
+const JobCardLockerGC = "card_locker_gc"
+const JobCardsMaintenance = "cards_maintenance"
+const JobPayouts = "payouts"
+const JobWithdrawRisk = "withdraw_risk"
+const JobSettlements = "settlements"
+const JobDisputes = "disputes"
+const JobOutboxRelay = "outbox_relay"
+const JobProxyProbe = "proxy_probe"
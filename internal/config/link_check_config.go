@@ -0,0 +1,72 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// значения по умолчанию, если link_check.json не найден
+const CheckLinkInterval = 20 * time.Second
+const CheckLinkTimeout = 10 * time.Minute
+const CheckLinkMaxAttempts = 100
+
+type LinkCheckJSONConfig struct {
+	// Backoff задержки перед попытками проверки, например ["5s", "10s", "20s"].
+	// Для попыток сверх списка используется последняя задержка
+	Backoff     []string `json:"backoff"`
+	Timeout     string   `json:"timeout"`
+	MaxAttempts uint     `json:"max_attempts"`
+}
+
+type LinkCheckConfig struct {
+	Backoff     []time.Duration
+	Timeout     time.Duration // отсчитывается от первой проверки ссылки
+	MaxAttempts uint
+}
+
+// Delay задержка перед проверкой с номером attempt (с нуля)
+func (c *LinkCheckConfig) Delay(attempt uint) time.Duration {
+	if attempt >= uint(len(c.Backoff)) {
+		return c.Backoff[len(c.Backoff)-1]
+	}
+	return c.Backoff[attempt]
+}
+
+func buildLinkCheckConfig() (*LinkCheckConfig, error) {
+	conf := &LinkCheckConfig{
+		Backoff:     []time.Duration{CheckLinkInterval},
+		Timeout:     CheckLinkTimeout,
+		MaxAttempts: CheckLinkMaxAttempts,
+	}
+
+	var jsonConf LinkCheckJSONConfig
+	if err := readJSONConfig("link_check.json", &jsonConf); err != nil {
+		if errors.Is(err, ErrConfigNotFound) {
+			return conf, nil
+		}
+		return nil, err
+	}
+
+	if len(jsonConf.Backoff) != 0 {
+		conf.Backoff = make([]time.Duration, 0, len(jsonConf.Backoff))
+		for _, val := range jsonConf.Backoff {
+			d, err := time.ParseDuration(val)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("link_check.backoff: invalid delay %q", val)
+			}
+			conf.Backoff = append(conf.Backoff, d)
+		}
+	}
+	if len(jsonConf.Timeout) != 0 {
+		d, err := time.ParseDuration(jsonConf.Timeout)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("link_check.timeout: invalid duration %q", jsonConf.Timeout)
+		}
+		conf.Timeout = d
+	}
+	if jsonConf.MaxAttempts != 0 {
+		conf.MaxAttempts = jsonConf.MaxAttempts
+	}
+	return conf, nil
+}
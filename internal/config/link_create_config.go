@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// значения по умолчанию, если link_create.json не найден
+const CreateLinkRetryDelay = 2 * time.Second // Задержка перед первым повтором, дальше удваивается
+const CreateLinkMaxRetryDelay = 10 * time.Second
+const CreateLinkMaxAttempts = 5
+const CreateLinkCardFallbacks = 2 // На сколько других карт пробовать переключиться при отказе банка
+
+type LinkCreateJSONConfig struct {
+	RetryDelay    string `json:"retry_delay"`
+	MaxRetryDelay string `json:"max_retry_delay"`
+	MaxAttempts   uint   `json:"max_attempts"`
+	CardFallbacks *uint  `json:"card_fallbacks"`
+}
+
+// LinkCreateConfig повторы временных ошибок банка при создании ссылки. Все повторы укладываются в CreateLinkTimeout
+type LinkCreateConfig struct {
+	RetryDelay    time.Duration
+	MaxRetryDelay time.Duration
+	MaxAttempts   uint
+	CardFallbacks uint
+}
+
+// Delay задержка перед повтором с номером retry (с единицы)
+func (c *LinkCreateConfig) Delay(retry uint) time.Duration {
+	delay := c.RetryDelay
+	for i := uint(1); i < retry && delay < c.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > c.MaxRetryDelay {
+		delay = c.MaxRetryDelay
+	}
+	return delay
+}
+
+func buildLinkCreateConfig() (*LinkCreateConfig, error) {
+	conf := &LinkCreateConfig{
+		RetryDelay:    CreateLinkRetryDelay,
+		MaxRetryDelay: CreateLinkMaxRetryDelay,
+		MaxAttempts:   CreateLinkMaxAttempts,
+		CardFallbacks: CreateLinkCardFallbacks,
+	}
+
+	var jsonConf LinkCreateJSONConfig
+	if err := readJSONConfig("link_create.json", &jsonConf); err != nil {
+		if errors.Is(err, ErrConfigNotFound) {
+			return conf, nil
+		}
+		return nil, err
+	}
+
+	if len(jsonConf.RetryDelay) != 0 {
+		d, err := time.ParseDuration(jsonConf.RetryDelay)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("link_create.retry_delay: invalid duration %q", jsonConf.RetryDelay)
+		}
+		conf.RetryDelay = d
+	}
+	if len(jsonConf.MaxRetryDelay) != 0 {
+		d, err := time.ParseDuration(jsonConf.MaxRetryDelay)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("link_create.max_retry_delay: invalid duration %q", jsonConf.MaxRetryDelay)
+		}
+		conf.MaxRetryDelay = d
+	}
+	if jsonConf.MaxAttempts != 0 {
+		conf.MaxAttempts = jsonConf.MaxAttempts
+	}
+	if jsonConf.CardFallbacks != nil {
+		conf.CardFallbacks = *jsonConf.CardFallbacks
+	}
+	return conf, nil
+}
@@ -0,0 +1,10 @@
+package config
+
+import "time"
+
+const OutboxRelayInterval = 5 * time.Second // Как часто проверять неотправленные события
+const OutboxBatchSize = 100                 // Сколько событий обрабатывать за проход
+const OutboxRetryDelay = 30 * time.Second   // Задержка перед первой повторной попыткой, дальше удваивается
+const OutboxMaxRetryDelay = 1 * time.Hour
+const OutboxMaxAttempts = 30
+const WebhookTimeout = 10 * time.Second
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const PayoutProviderManual = "manual"
+const PayoutProviderHttp = "http"
+const PayoutProviderFake = "fake"
+
+const PayoutCheckInterval = 30 * time.Second
+const PayoutRetryDelay = 1 * time.Minute
+const PayoutMaxAttempts = 10
+
+const WithdrawBatchMaxSize = 1000
+const WithdrawBatchMaxFileSize = 5 * 1024 * 1024
+
+type PayoutHttpConfig struct {
+	PayoutUrl string `json:"payout_url"`
+	StatusUrl string `json:"status_url"`
+	Token     string `json:"token"`
+}
+
+type PayoutConfig struct {
+	// Providers тип вывода -> провайдер выплаты
+	Providers map[string]string `json:"providers"`
+	Http      *PayoutHttpConfig `json:"http,omitempty"`
+}
+
+func buildPayoutConfig() (*PayoutConfig, error) {
+	conf := &PayoutConfig{}
+	if err := readJSONConfig("payout.json", conf); err != nil {
+		// без конфигурации все выплаты производятся вручную
+		if errors.Is(err, ErrConfigNotFound) {
+			return &PayoutConfig{Providers: map[string]string{}}, nil
+		}
+		return nil, err
+	}
+	if conf.Providers == nil {
+		conf.Providers = map[string]string{}
+	}
+
+	if err := validatePayoutConfig(conf); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+func validatePayoutConfig(conf *PayoutConfig) error {
+	for typ, provider := range conf.Providers {
+		switch provider {
+		case PayoutProviderManual, PayoutProviderFake:
+		case PayoutProviderHttp:
+			if conf.Http == nil {
+				return fmt.Errorf("payout.http is required for withdraw type %s", typ)
+			}
+			if !validateURL(conf.Http.PayoutUrl) {
+				return fmt.Errorf("payout.http.payout_url is not a correct URL")
+			}
+			if !validateURL(conf.Http.StatusUrl) {
+				return fmt.Errorf("payout.http.status_url is not a correct URL")
+			}
+		default:
+			return fmt.Errorf("payout.providers.%s: unknown provider %s", typ, provider)
+		}
+	}
+	return nil
+}
+
+// GetPayoutProvider Возвращает провайдера для типа вывода. По умолчанию - ручная выплата.
+func (conf *PayoutConfig) GetPayoutProvider(withdrawType string) string {
+	provider, ok := conf.Providers[withdrawType]
+	if !ok || len(provider) == 0 {
+		return PayoutProviderManual
+	}
+	return provider
+}
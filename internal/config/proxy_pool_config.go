@@ -0,0 +1,9 @@
+package config
+
+import "time"
+
+const ProxyFailureThreshold = 3       // Отказов подряд, после которых прокси исключается из ротации
+const ProxyOpenTime = 1 * time.Minute // Через сколько исключённый прокси проверяется снова
+const ProxyRequestTimeout = 20 * time.Second
+const ProxyProbeInterval = 15 * time.Second
+const ProxyTaskAttempts = 3 // На скольких разных прокси пробовать запрос к банку
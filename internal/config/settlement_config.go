@@ -0,0 +1,11 @@
+package config
+
+import "time"
+
+const SettlementOrderFeePercent = 2.5          // Комиссия сервиса с оплаченного заказа, %
+const SettlementSchedule = "10 * * * *"        // Когда проверять незакрытые дни: каждый час, день закрывается в 01:10
+const SettlementCloseDelay = 1 * time.Hour     // Сколько ждать после полуночи перед закрытием дня
+const SettlementMaxDaysPerRun = 31             // Сколько дней магазина закрывать за проход, остальные закроются следующими
+const SettlementMismatchTolerance = 0.01       // Допустимое расхождение с суммами сообщений банка
+const SettlementSignatureTTL = 5 * time.Minute // Сколько действительна подпись запроса на выгрузку
+const SettlementDateLayout = "2006-01-02"
@@ -0,0 +1,10 @@
+package config
+
+import "time"
+
+const StateBackendMemory = "memory" // один экземпляр приложения
+const StateBackendRedis = "redis"   // несколько экземпляров с общим Redis
+
+const StateKeyPrefix = "payment:"
+const RedisPoolSize = 16
+const RedisTimeout = 5 * time.Second
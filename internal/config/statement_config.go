@@ -0,0 +1,46 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const StatementMatchWindow = 30 * time.Minute // Допустимая разница во времени зачисления и сообщения/заказа
+const StatementAmountTolerance = 0.01         // Допустимая разница в сумме
+const StatementMaxFileSize = 10 * 1024 * 1024 // Максимальный размер файла выписки
+
+// StatementCsvConfig названия колонок csv выписки
+type StatementCsvConfig struct {
+	Account     string `json:"account"`
+	Amount      string `json:"amount"`
+	Date        string `json:"date"`
+	Reference   string `json:"reference"`
+	Description string `json:"description"`
+	DateLayout  string `json:"date_layout"`
+}
+
+type StatementConfig struct {
+	Csv *StatementCsvConfig `json:"csv,omitempty"`
+}
+
+func buildStatementConfig() (*StatementConfig, error) {
+	conf := &StatementConfig{}
+	if err := readJSONConfig("statement.json", conf); err != nil {
+		// без конфигурации доступны только MT940 и CAMT.053
+		if errors.Is(err, ErrConfigNotFound) {
+			return conf, nil
+		}
+		return nil, err
+	}
+
+	if csv := conf.Csv; csv != nil {
+		if len(csv.Account) == 0 || len(csv.Amount) == 0 || len(csv.Date) == 0 {
+			return nil, fmt.Errorf("statement.csv: account, amount and date columns are required")
+		}
+		if len(csv.DateLayout) == 0 {
+			csv.DateLayout = "2006-01-02 15:04:05"
+		}
+	}
+	return conf, nil
+}
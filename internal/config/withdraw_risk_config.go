@@ -0,0 +1,70 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const WithdrawRiskCheckInterval = 1 * time.Minute // Как часто проверять выводы, созданные без риск-проверки
+const WithdrawRiskBatchSize = 100
+
+// WithdrawRiskConfig правила проверки выводов. Нулевое значение отключает правило
+type WithdrawRiskConfig struct {
+	// RecipientDailyCount максимум выводов одному получателю за сутки
+	RecipientDailyCount uint `json:"recipient_daily_count"`
+	// RecipientDailyAmount максимальная сумма выводов одному получателю за сутки
+	RecipientDailyAmount float64 `json:"recipient_daily_amount"`
+	// ShopDailyAmount максимальная сумма выводов магазина за сутки
+	ShopDailyAmount float64  `json:"shop_daily_amount"`
+	BlockedCards    []string `json:"blocked_cards"`
+	BlockedPhones   []string `json:"blocked_phones"`
+	// FlagFirstTimeRecipient помечать выводы получателю, которому магазин ещё не платил. Помеченный вывод подтверждает оператор
+	FlagFirstTimeRecipient bool `json:"flag_first_time_recipient"`
+	// TwoPersonThreshold сумма, начиная с которой вывод подтверждают два разных оператора
+	TwoPersonThreshold float64 `json:"two_person_threshold"`
+}
+
+func buildWithdrawRiskConfig() (*WithdrawRiskConfig, error) {
+	conf := &WithdrawRiskConfig{}
+	if err := readJSONConfig("withdraw_risk.json", conf); err != nil {
+		// без конфигурации проверки отключены
+		if errors.Is(err, ErrConfigNotFound) {
+			return conf, nil
+		}
+		return nil, err
+	}
+
+	if conf.RecipientDailyAmount < 0 || conf.ShopDailyAmount < 0 || conf.TwoPersonThreshold < 0 {
+		return nil, fmt.Errorf("withdraw_risk: limits can not be negative")
+	}
+
+	for i, card := range conf.BlockedCards {
+		conf.BlockedCards[i] = strings.ReplaceAll(card, " ", "")
+	}
+	for i, phone := range conf.BlockedPhones {
+		conf.BlockedPhones[i] = strings.ReplaceAll(phone, " ", "")
+	}
+	return conf, nil
+}
+
+func (conf *WithdrawRiskConfig) IsCardBlocked(card string) bool {
+	card = strings.ReplaceAll(card, " ", "")
+	for _, blocked := range conf.BlockedCards {
+		if blocked == card {
+			return true
+		}
+	}
+	return false
+}
+
+func (conf *WithdrawRiskConfig) IsPhoneBlocked(phone string) bool {
+	phone = strings.ReplaceAll(phone, " ", "")
+	for _, blocked := range conf.BlockedPhones {
+		if blocked == phone {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package analytics
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"payment-go/internal/services"
+	"payment-go/internal/transport/analytics/dispute"
+	"sync"
+)
+
+type IDisputeAnalyticsController interface {
+	Rates(ctx *fiber.Ctx) error
+}
+type disputeAnalyticsController struct {
+}
+
+var disputeIns *disputeAnalyticsController
+var disputeOnce = sync.Once{}
+
+func DisputeAnalyticsController() IDisputeAnalyticsController {
+	disputeOnce.Do(func() {
+		disputeIns = &disputeAnalyticsController{}
+	})
+	return disputeIns
+}
+
+func (con *disputeAnalyticsController) Rates(ctx *fiber.Ctx) error {
+	dto, err := dispute.BuildRatesDto(ctx.Body())
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"success": false,
+			"error":   "invalid json",
+		})
+	}
+
+	rates, err := services.DisputeService().GetRates(dto)
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"rates":   rates,
+	})
+}
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"log"
+	"payment-go/internal/config"
+	"payment-go/internal/models"
+	"payment-go/internal/services"
+	"payment-go/internal/transport/model/admin"
+	"strings"
+	"sync"
+	"time"
+)
+
+const SessionCookie = "admin_session"
+
+const localsAdmin = "admin_user"
+
+type IAuthController interface {
+	Login(ctx *fiber.Ctx) error
+	Logout(ctx *fiber.Ctx) error
+	Me(ctx *fiber.Ctx) error
+}
+type authController struct {
+}
+
+var authIns *authController
+var authOnce = sync.Once{}
+
+func AuthController() IAuthController {
+	authOnce.Do(func() {
+		authIns = &authController{}
+	})
+	return authIns
+}
+
+func (con *authController) Login(ctx *fiber.Ctx) error {
+	var dto *admin.LoginDto
+	if err := json.Unmarshal(ctx.Body(), &dto); err != nil || dto == nil {
+		return ctx.JSON(fiber.Map{
+			"success": false,
+			"error":   "Got Invalid JSON",
+		})
+	}
+
+	res, err := services.AdminUserService().Login(dto)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	ctx.Cookie(&fiber.Cookie{
+		Name:     SessionCookie,
+		Value:    res.Token,
+		Expires:  time.Unix(res.ExpiresAt, 0),
+		Secure:   !config.GetConfig().IsDev,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteStrictMode,
+	})
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"session": res,
+	})
+}
+
+func (con *authController) Logout(ctx *fiber.Ctx) error {
+	if err := services.AdminUserService().Logout(sessionToken(ctx)); err != nil {
+		log.Println(err)
+	}
+	ctx.ClearCookie(SessionCookie)
+	return ctx.JSON(fiber.Map{
+		"success": true,
+	})
+}
+
+func (con *authController) Me(ctx *fiber.Ctx) error {
+	u, err := services.AdminUserService().Authenticate(sessionToken(ctx))
+	if err != nil {
+		return unauthorized(ctx)
+	}
+	return ctx.JSON(fiber.Map{
+		"success": true,
+		"user":    admin.FromAdminUser(u),
+	})
+}
+
+// Require пропускает только пользователей с правом p. Отказы пишутся в лог
+func Require(p models.Permission) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		u, err := services.AdminUserService().Authenticate(sessionToken(ctx))
+		if err != nil {
+			log.Printf("Auth: unauthenticated %s %s from %s", ctx.Method(), ctx.Path(), ctx.IP())
+			return unauthorized(ctx)
+		}
+		if !u.Can(p) {
+			log.Printf("Auth: denied %s %s for '%s' (%s), requires %s", ctx.Method(), ctx.Path(), u.Login, u.Role, p)
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"success": false,
+				"error":   "Permission denied",
+			})
+		}
+
+		ctx.Locals(localsAdmin, u)
+		return ctx.Next()
+	}
+}
+
+// CurrentAdmin пользователь, прошедший Require
+func CurrentAdmin(ctx *fiber.Ctx) *models.AdminUser {
+	u, _ := ctx.Locals(localsAdmin).(*models.AdminUser)
+	return u
+}
+
+// sessionToken токен из заголовка Authorization: Bearer или из cookie
+func sessionToken(ctx *fiber.Ctx) string {
+	header := ctx.Get(fiber.HeaderAuthorization)
+	if token, ok := strings.CutPrefix(header, "Bearer "); ok {
+		return strings.TrimSpace(token)
+	}
+	return ctx.Cookies(SessionCookie)
+}
+
+func unauthorized(ctx *fiber.Ctx) error {
+	return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"success": false,
+		"error":   "Unauthorized",
+	})
+}
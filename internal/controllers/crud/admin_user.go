@@ -0,0 +1,138 @@
+package crud
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"payment-go/internal/controllers/auth"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories"
+	"payment-go/internal/services"
+	"payment-go/internal/transport/model/admin"
+	"strconv"
+	"sync"
+)
+
+type IAdminUserCrudController interface {
+	ICrudController
+}
+type adminUserCrudController struct {
+}
+
+var adminUserIns *adminUserCrudController
+var adminUserOnce = sync.Once{}
+
+func AdminUserCrudController() IAdminUserCrudController {
+	adminUserOnce.Do(func() {
+		adminUserIns = &adminUserCrudController{}
+	})
+	return adminUserIns
+}
+
+func (crud *adminUserCrudController) GetActions() CrudActions {
+	return AllCrudActions()
+}
+
+func (crud *adminUserCrudController) GetPermissions() CrudPermissions {
+	return CrudPermissions{
+		Create: models.PermissionManage,
+		Read:   models.PermissionManage,
+		Update: models.PermissionManage,
+		Delete: models.PermissionManage,
+		List:   models.PermissionManage,
+	}
+}
+
+func (crud *adminUserCrudController) GetResponseKey() string {
+	return "user"
+}
+
+func (crud *adminUserCrudController) Create(ctx *fiber.Ctx) error {
+	dto, err := ParseJSON(admin.CreateAdminUserDto{}, ctx)
+	if err != nil {
+		return InvalidJSON(ctx)
+	}
+
+	u, err := services.AdminUserService().CreateFromDto(dto)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"user": admin.FromAdminUser(u),
+	})
+}
+
+func (crud *adminUserCrudController) Read(ctx *fiber.Ctx) error {
+	strId := ctx.Query("id")
+	id, err := strconv.ParseUint(strId, 10, 32)
+	if err != nil || id == 0 {
+		return ErrorJSON(ctx, "Invalid admin user id passed")
+	}
+
+	u, err := repositories.AdminUserRepository().FindById(uint(id))
+	if err != nil {
+		return ErrorJSON(ctx, "Admin user not found")
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"user": admin.FromAdminUser(u),
+	})
+}
+
+func (crud *adminUserCrudController) Update(ctx *fiber.Ctx) error {
+	dto, err := ParseJSON(admin.UpdateAdminUserDto{}, ctx)
+	if err != nil {
+		return InvalidJSON(ctx)
+	}
+
+	// нельзя лишить доступа самого себя
+	if cur := auth.CurrentAdmin(ctx); cur != nil && cur.ID == dto.ID && (dto.Disabled || dto.Role != cur.Role) {
+		return ErrorJSON(ctx, "Unable to change own role or disable yourself")
+	}
+
+	u, err := services.AdminUserService().UpdateFromDto(dto)
+	if u == nil && err != nil {
+		return ErrorJSON(ctx, err.Error())
+	} else if err != nil {
+		return ErrorJSON(ctx, "Error while saving")
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"user": admin.FromAdminUser(u),
+	})
+}
+
+func (crud *adminUserCrudController) Delete(ctx *fiber.Ctx) error {
+	strId := ctx.Query("id")
+	id, err := strconv.ParseUint(strId, 10, 32)
+	if err != nil || id == 0 {
+		return ErrorJSON(ctx, "Invalid admin user id passed")
+	}
+	if cur := auth.CurrentAdmin(ctx); cur != nil && cur.ID == uint(id) {
+		return ErrorJSON(ctx, "Unable to delete yourself")
+	}
+
+	if err = repositories.AdminUserRepository().Delete(uint(id)); err != nil {
+		return ErrorJSON(ctx, "Error while deleting")
+	}
+
+	return SuccessJSON(ctx, fmt.Sprintf("Admin user #%d was successfully deleted", id))
+}
+
+func (crud *adminUserCrudController) List(ctx *fiber.Ctx) error {
+	p, err := NewPaginator(ctx)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+	page, size, order := p.GetArgs()
+
+	users, err := repositories.AdminUserRepository().GetPaged(page, size, order)
+	if err != nil {
+		return ErrorJSON(ctx, "Unable to get admin users.")
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"total": users.Total,
+		"users": admin.FromAdminUsers(users.Items),
+	})
+}
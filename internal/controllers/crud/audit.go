@@ -0,0 +1,204 @@
+package crud
+
+import (
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"payment-go/internal/controllers/auth"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories"
+	"payment-go/internal/services"
+	"payment-go/internal/transport/model/audit"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type IAuditCrudController interface {
+	Find(ctx *fiber.Ctx) error
+}
+type auditCrudController struct {
+}
+
+var auditIns *auditCrudController
+var auditOnce = sync.Once{}
+
+func AuditCrudController() IAuditCrudController {
+	auditOnce.Do(func() {
+		auditIns = &auditCrudController{}
+	})
+	return auditIns
+}
+
+func (crud *auditCrudController) Find(ctx *fiber.Ctx) error {
+	p, err := NewPaginator(ctx)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+	page, size, _ := p.GetArgs()
+
+	dto, err := audit.BuildFindDto(ctx.Body())
+	if err != nil {
+		return ErrorJSON(ctx, "Invalid request.")
+	}
+
+	logs, err := repositories.AuditLogRepository().Find(dto, page, size)
+	if err != nil {
+		return ErrorJSON(ctx, "Database error.")
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"total": logs.Total,
+		"audit": audit.FromAuditLogs(logs.Items),
+	})
+}
+
+// загрузка сущностей для сравнения версий, ключ - тип сущности, он же префикс crud-маршрутов
+var auditLoaders = map[string]func(id uint) any{
+	"order":          func(id uint) any { return found(repositories.OrderRepository().FindById(id)) },
+	"payment_link":   func(id uint) any { return found(repositories.PaymentLinkRepository().FindById(id)) },
+	"card":           func(id uint) any { return found(repositories.CardRepository().FindById(id)) },
+	"shop":           func(id uint) any { return found(repositories.ShopRepository().FindById(id)) },
+	"bank_message":   func(id uint) any { return found(repositories.BankMessageRepository().FindById(id)) },
+	"withdraw":       func(id uint) any { return found(repositories.WithdrawRepository().FindById(id)) },
+	"dispute":        func(id uint) any { return found(repositories.DisputeRepository().FindById(id)) },
+	"withdraw_batch": func(id uint) any { return found(repositories.WithdrawBatchRepository().FindById(id)) },
+	"settlement":     func(id uint) any { return found(repositories.SettlementRepository().FindById(id)) },
+	"admin_user":     func(id uint) any { return found(repositories.AdminUserRepository().FindById(id)) },
+	"owner_token":    func(id uint) any { return found(repositories.OwnerTokenRepository().FindById(id)) },
+	"merchant":       func(id uint) any { return found(repositories.MerchantRepository().FindById(id)) },
+	"merchant_user":  func(id uint) any { return found(repositories.MerchantUserRepository().FindById(id)) },
+}
+
+// found не даёт nil-указателю превратиться в непустой any
+func found[T any](entity *T, err error) any {
+	if err != nil || entity == nil {
+		return nil
+	}
+	return entity
+}
+
+// AuditActor определяет, кто выполняет запрос: тип участника, id и логин
+type AuditActor func(ctx *fiber.Ctx) (actorType string, id uint, login string)
+
+// AuditTarget id изменяемой сущности из запроса, 0 если сущность создаётся
+type AuditTarget func(ctx *fiber.Ctx) uint
+
+// Audit записывает успешное действие администратора в журнал. Ставится после auth.Require.
+// requestKey - query-параметр или поле тела JSON с id сущности
+func Audit(action, entity, requestKey, responseKey string) fiber.Handler {
+	return AuditAs(adminActor, RequestKey(requestKey), action, entity, responseKey)
+}
+
+func adminActor(ctx *fiber.Ctx) (string, uint, string) {
+	if u := auth.CurrentAdmin(ctx); u != nil {
+		return models.AuditActorAdmin, u.ID, u.Login
+	}
+	return models.AuditActorAdmin, 0, ""
+}
+
+// RequestKey id сущности из query-параметра или поля тела JSON
+func RequestKey(key string) AuditTarget {
+	return func(ctx *fiber.Ctx) uint {
+		return requestEntityId(ctx, key)
+	}
+}
+
+// AuditAs записывает успешное действие в журнал: кто, с какого адреса и что изменилось в сущности.
+// responseKey - путь к созданной сущности в ответе ("shop", "invitation.member"), пусто если id известен из запроса
+func AuditAs(actor AuditActor, target AuditTarget, action, entity, responseKey string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		load := auditLoaders[entity]
+
+		id := target(ctx)
+		var before any
+		if id != 0 && load != nil {
+			before = load(id)
+		}
+
+		if err := ctx.Next(); err != nil {
+			return err
+		}
+		if !responseSucceeded(ctx) {
+			return nil
+		}
+
+		// при создании id известен только из ответа
+		if id == 0 {
+			id = responseEntityId(ctx, responseKey)
+		}
+		var after any
+		if id != 0 && load != nil {
+			after = load(id)
+		}
+
+		entry := &models.AuditLog{
+			Action:     action,
+			EntityType: entity,
+			EntityID:   id,
+			IP:         ctx.IP(),
+			UserAgent:  ctx.Get(fiber.HeaderUserAgent),
+		}
+		entry.ActorType, entry.ActorID, entry.ActorLogin = actor(ctx)
+		// логин мерчанта - email, колонка короче
+		if len(entry.ActorLogin) > 63 {
+			entry.ActorLogin = entry.ActorLogin[:63]
+		}
+		services.AuditService().Record(entry, before, after)
+		return nil
+	}
+}
+
+func requestEntityId(ctx *fiber.Ctx, key string) uint {
+	if key == "" {
+		return 0
+	}
+	if id, err := strconv.ParseUint(ctx.Query(key), 10, 32); err == nil {
+		return uint(id)
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal(ctx.Body(), &body); err != nil {
+		return 0
+	}
+	if id, ok := body[key].(float64); ok && id > 0 {
+		return uint(id)
+	}
+	return 0
+}
+
+// ответы crud всегда 200, успех определяется полем success
+func responseSucceeded(ctx *fiber.Ctx) bool {
+	if ctx.Response().StatusCode() != fiber.StatusOK {
+		return false
+	}
+	var res struct {
+		Success bool `json:"success"`
+	}
+	if err := json.Unmarshal(ctx.Response().Body(), &res); err != nil {
+		return false
+	}
+	return res.Success
+}
+
+// responseEntityId id сущности из ответа вида {"success": true, "shop": {"id": 1, ...}} по пути key
+func responseEntityId(ctx *fiber.Ctx, key string) uint {
+	if key == "" {
+		return 0
+	}
+	raw := json.RawMessage(ctx.Response().Body())
+	for _, part := range strings.Split(key, ".") {
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return 0
+		}
+		raw = obj[part]
+	}
+
+	var entity struct {
+		ID uint `json:"id"`
+	}
+	if err := json.Unmarshal(raw, &entity); err != nil {
+		return 0
+	}
+	return entity.ID
+}
@@ -2,6 +2,7 @@ package crud
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"payment-go/internal/models"
 	"payment-go/internal/repositories"
 	"payment-go/internal/services"
 	"payment-go/internal/transport/model/bank_message"
@@ -38,6 +39,12 @@ func (crud *bankMessageCrudController) GetActions() CrudActions {
 	}
 }
 
+func (crud *bankMessageCrudController) GetPermissions() CrudPermissions {
+	perms := DefaultCrudPermissions()
+	perms.Update = models.PermissionOperate
+	return perms
+}
+
 func (crud *bankMessageCrudController) Create(ctx *fiber.Ctx) error {
 	return ctx.SendStatus(404)
 }
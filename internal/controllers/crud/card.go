@@ -34,6 +34,10 @@ func (crud *cardCrudController) GetActions() CrudActions {
 	return AllCrudActions()
 }
 
+func (crud *cardCrudController) GetPermissions() CrudPermissions {
+	return DefaultCrudPermissions()
+}
+
 func (crud *cardCrudController) Create(ctx *fiber.Ctx) error {
 	dto, err := ParseJSON(card.CreateCardDto{}, ctx)
 	if err != nil {
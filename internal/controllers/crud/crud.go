@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/gofiber/fiber/v2"
+	"payment-go/internal/models"
 	"strconv"
 	"strings"
 )
@@ -35,6 +36,51 @@ func AllCrudActions() CrudActions {
 	}
 }
 
+// CrudPermissions права, необходимые для стандартных действий контроллера
+type CrudPermissions struct {
+	Create models.Permission
+	Read   models.Permission
+	Update models.Permission
+	Delete models.Permission
+	List   models.Permission
+}
+
+// IPermissionedCrud контроллер объявляет права на свои действия, иначе действуют DefaultCrudPermissions
+type IPermissionedCrud interface {
+	GetPermissions() CrudPermissions
+}
+
+// DefaultCrudPermissions просмотр для всех ролей, изменения только для администратора
+func DefaultCrudPermissions() CrudPermissions {
+	return CrudPermissions{
+		Create: models.PermissionManage,
+		Read:   models.PermissionView,
+		Update: models.PermissionManage,
+		Delete: models.PermissionManage,
+		List:   models.PermissionView,
+	}
+}
+
+func PermissionsOf(crud ICrudController) CrudPermissions {
+	if pc, ok := crud.(IPermissionedCrud); ok {
+		return pc.GetPermissions()
+	}
+	return DefaultCrudPermissions()
+}
+
+// IResponseKeyedCrud контроллер отдаёт созданную сущность не под ключом своего префикса
+type IResponseKeyedCrud interface {
+	GetResponseKey() string
+}
+
+// ResponseKeyOf ключ ответа create, под которым лежит созданная сущность
+func ResponseKeyOf(crud ICrudController, prefix string) string {
+	if rc, ok := crud.(IResponseKeyedCrud); ok {
+		return rc.GetResponseKey()
+	}
+	return prefix
+}
+
 func ParseJSON[DTO interface{}](dto DTO, ctx *fiber.Ctx) (*DTO, error) {
 	err := json.Unmarshal(ctx.Body(), &dto)
 	if err != nil {
@@ -0,0 +1,163 @@
+package crud
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories"
+	"payment-go/internal/services"
+	"payment-go/internal/transport/model/dispute"
+	"strconv"
+	"sync"
+)
+
+// сортировка только по этим полям, значение из запроса попадает в ORDER BY
+var disputeSortFields = []string{"id", "created_at", "amount", "status", "deadline_at", "closed_at"}
+
+type IDisputeCrudController interface {
+	ICrudController
+	Find(ctx *fiber.Ctx) error
+	AddEvidence(ctx *fiber.Ctx) error
+	Win(ctx *fiber.Ctx) error
+	Lose(ctx *fiber.Ctx) error
+}
+type disputeCrudController struct {
+}
+
+var disputeIns *disputeCrudController
+var disputeOnce = sync.Once{}
+
+func DisputeCrudController() IDisputeCrudController {
+	disputeOnce.Do(func() {
+		disputeIns = &disputeCrudController{}
+	})
+	return disputeIns
+}
+
+func (crud *disputeCrudController) GetActions() CrudActions {
+	return CrudActions{
+		Create: true,
+		Read:   true,
+		Update: false,
+		Delete: false,
+		List:   false,
+	}
+}
+
+func (crud *disputeCrudController) GetPermissions() CrudPermissions {
+	perms := DefaultCrudPermissions()
+	perms.Create = models.PermissionOperate
+	perms.Update = models.PermissionOperate
+	return perms
+}
+
+func (crud *disputeCrudController) Create(ctx *fiber.Ctx) error {
+	dto, err := dispute.CreateDtoFromJSON(ctx.Body())
+	if err != nil {
+		return InvalidJSON(ctx)
+	}
+
+	d, err := services.DisputeService().Open(dto)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"dispute": dispute.FromDispute(d),
+	})
+}
+
+func (crud *disputeCrudController) Read(ctx *fiber.Ctx) error {
+	strId := ctx.Query("id")
+	id, err := strconv.ParseUint(strId, 10, 32)
+	if err != nil || id == 0 {
+		return ErrorJSON(ctx, "Invalid dispute id passed")
+	}
+
+	d, err := repositories.DisputeRepository().FindById(uint(id))
+	if err != nil {
+		return ErrorJSON(ctx, "Dispute not found")
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"dispute": dispute.FromDispute(d),
+	})
+}
+
+func (crud *disputeCrudController) Update(ctx *fiber.Ctx) error {
+	return ctx.SendStatus(404)
+}
+
+func (crud *disputeCrudController) Delete(ctx *fiber.Ctx) error {
+	return ctx.SendStatus(404)
+}
+
+func (crud *disputeCrudController) List(ctx *fiber.Ctx) error {
+	return ctx.SendStatus(404)
+}
+
+func (crud *disputeCrudController) Find(ctx *fiber.Ctx) error {
+	p, err := NewPaginator(ctx)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+	page, size, _ := p.GetArgs()
+
+	body := ctx.Body()
+	dto, err := dispute.BuildFindDto(body)
+	if err != nil {
+		return ErrorJSON(ctx, "Invalid request.")
+	}
+	if dto.Sort != nil && !dto.Sort.Allowed(disputeSortFields...) {
+		return ErrorJSON(ctx, "Sorting by this field is not supported.")
+	}
+
+	disputes, err := repositories.DisputeRepository().Find(dto, page, size)
+	if err != nil {
+		return ErrorJSON(ctx, "Database error.")
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"total":    disputes.Total,
+		"disputes": dispute.FromDisputes(disputes.Items),
+	})
+}
+
+func (crud *disputeCrudController) AddEvidence(ctx *fiber.Ctx) error {
+	dto, err := dispute.EvidenceDtoFromJSON(ctx.Body())
+	if err != nil {
+		return InvalidJSON(ctx)
+	}
+
+	d, err := services.DisputeService().AddEvidence(dto)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"dispute": dispute.FromDispute(d),
+	})
+}
+
+func (crud *disputeCrudController) Win(ctx *fiber.Ctx) error {
+	return crud.resolve(ctx, true)
+}
+
+func (crud *disputeCrudController) Lose(ctx *fiber.Ctx) error {
+	return crud.resolve(ctx, false)
+}
+
+func (crud *disputeCrudController) resolve(ctx *fiber.Ctx, won bool) error {
+	dto, err := dispute.ResolveDtoFromJSON(ctx.Body())
+	if err != nil {
+		return InvalidJSON(ctx)
+	}
+
+	d, err := services.DisputeService().Resolve(dto, won)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"dispute": dispute.FromDispute(d),
+	})
+}
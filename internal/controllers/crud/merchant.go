@@ -0,0 +1,161 @@
+package crud
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories"
+	"payment-go/internal/services"
+	"payment-go/internal/transport/model/merchant"
+	"strconv"
+	"sync"
+)
+
+type IMerchantCrudController interface {
+	ICrudController
+	Members(ctx *fiber.Ctx) error
+	Invite(ctx *fiber.Ctx) error
+}
+type merchantCrudController struct {
+}
+
+var merchantIns *merchantCrudController
+var merchantOnce = sync.Once{}
+
+func MerchantCrudController() IMerchantCrudController {
+	merchantOnce.Do(func() {
+		merchantIns = &merchantCrudController{}
+	})
+	return merchantIns
+}
+
+func (crud *merchantCrudController) GetActions() CrudActions {
+	return CrudActions{
+		Create: true,
+		Read:   true,
+		Update: true,
+		Delete: false,
+		List:   true,
+	}
+}
+
+func (crud *merchantCrudController) GetPermissions() CrudPermissions {
+	return CrudPermissions{
+		Create: models.PermissionManage,
+		Read:   models.PermissionView,
+		Update: models.PermissionManage,
+		Delete: models.PermissionManage,
+		List:   models.PermissionView,
+	}
+}
+
+// Create вместе с мерчантом создаётся приглашение для владельца
+func (crud *merchantCrudController) Create(ctx *fiber.Ctx) error {
+	dto, err := ParseJSON(merchant.CreateMerchantDto{}, ctx)
+	if err != nil {
+		return InvalidJSON(ctx)
+	}
+
+	m, invite, err := services.MerchantAccountService().CreateFromDto(dto)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"merchant":   merchant.FromMerchant(m),
+		"invitation": invite,
+	})
+}
+
+func (crud *merchantCrudController) Read(ctx *fiber.Ctx) error {
+	strId := ctx.Query("id")
+	id, err := strconv.ParseUint(strId, 10, 32)
+	if err != nil || id == 0 {
+		return ErrorJSON(ctx, "Invalid merchant id passed")
+	}
+
+	m, err := repositories.MerchantRepository().FindById(uint(id))
+	if err != nil {
+		return ErrorJSON(ctx, "Merchant not found")
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"merchant": merchant.FromMerchant(m),
+	})
+}
+
+func (crud *merchantCrudController) Update(ctx *fiber.Ctx) error {
+	dto, err := ParseJSON(merchant.UpdateMerchantDto{}, ctx)
+	if err != nil {
+		return InvalidJSON(ctx)
+	}
+
+	m, err := services.MerchantAccountService().UpdateFromDto(dto)
+	if m == nil && err != nil {
+		return ErrorJSON(ctx, err.Error())
+	} else if err != nil {
+		return ErrorJSON(ctx, "Error while saving")
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"merchant": merchant.FromMerchant(m),
+	})
+}
+
+func (crud *merchantCrudController) Delete(ctx *fiber.Ctx) error {
+	return ctx.SendStatus(404)
+}
+
+func (crud *merchantCrudController) List(ctx *fiber.Ctx) error {
+	p, err := NewPaginator(ctx)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+	page, size, order := p.GetArgs()
+
+	merchants, err := repositories.MerchantRepository().GetPaged(page, size, order)
+	if err != nil {
+		return ErrorJSON(ctx, "Unable to get merchants.")
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"total":     merchants.Total,
+		"merchants": merchant.FromMerchants(merchants.Items),
+	})
+}
+
+func (crud *merchantCrudController) Members(ctx *fiber.Ctx) error {
+	strId := ctx.Query("merchant_id")
+	id, err := strconv.ParseUint(strId, 10, 32)
+	if err != nil || id == 0 {
+		return ErrorJSON(ctx, "merchant_id is required")
+	}
+
+	users, err := repositories.MerchantUserRepository().GetMerchantUsers(uint(id))
+	if err != nil {
+		return ErrorJSON(ctx, "Unable to get members.")
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"members": merchant.FromMerchantUsers(users),
+	})
+}
+
+// Invite приглашение от имени площадки, например когда владелец потерял доступ
+func (crud *merchantCrudController) Invite(ctx *fiber.Ctx) error {
+	dto, err := ParseJSON(merchant.InviteMemberDto{}, ctx)
+	if err != nil {
+		return InvalidJSON(ctx)
+	}
+	if _, err = repositories.MerchantRepository().FindById(dto.MerchantID); err != nil {
+		return ErrorJSON(ctx, "Merchant not found")
+	}
+
+	res, err := services.MerchantAccountService().Invite(dto.MerchantID, 0, &dto.InviteDto)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"invitation": res,
+	})
+}
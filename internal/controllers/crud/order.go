@@ -3,6 +3,7 @@ package crud
 import (
 	"fmt"
 	"github.com/gofiber/fiber/v2"
+	"payment-go/internal/models"
 	"payment-go/internal/repositories"
 	"payment-go/internal/services"
 	"payment-go/internal/transport/model/order"
@@ -148,3 +149,9 @@ func (crud *orderCrudController) Find(ctx *fiber.Ctx) error {
 func (crud *orderCrudController) GetActions() CrudActions {
 	return AllCrudActions()
 }
+
+func (crud *orderCrudController) GetPermissions() CrudPermissions {
+	perms := DefaultCrudPermissions()
+	perms.Update = models.PermissionOperate
+	return perms
+}
@@ -0,0 +1,82 @@
+package crud
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"payment-go/internal/repositories"
+	"payment-go/internal/services"
+	"payment-go/internal/transport/model/merchant"
+	"strconv"
+	"sync"
+)
+
+type IOwnerTokenCrudController interface {
+	Issue(ctx *fiber.Ctx) error
+	Revoke(ctx *fiber.Ctx) error
+	List(ctx *fiber.Ctx) error
+}
+type ownerTokenCrudController struct {
+}
+
+var ownerTokenIns *ownerTokenCrudController
+var ownerTokenOnce = sync.Once{}
+
+// OwnerTokenCrudController выпуск токенов владельцев для merchant API
+func OwnerTokenCrudController() IOwnerTokenCrudController {
+	ownerTokenOnce.Do(func() {
+		ownerTokenIns = &ownerTokenCrudController{}
+	})
+	return ownerTokenIns
+}
+
+func (crud *ownerTokenCrudController) Issue(ctx *fiber.Ctx) error {
+	dto, err := ParseJSON(merchant.IssueTokenDto{}, ctx)
+	if err != nil {
+		return InvalidJSON(ctx)
+	}
+
+	token, t, err := services.MerchantService().IssueToken(dto)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"token":       token,
+		"owner_token": merchant.FromOwnerToken(t),
+	})
+}
+
+func (crud *ownerTokenCrudController) Revoke(ctx *fiber.Ctx) error {
+	strId := ctx.Query("id")
+	id, err := strconv.ParseUint(strId, 10, 32)
+	if err != nil || id == 0 {
+		return ErrorJSON(ctx, "Invalid token id passed")
+	}
+
+	t, err := services.MerchantService().RevokeToken(uint(id))
+	if t == nil && err != nil {
+		return ErrorJSON(ctx, "Token not found")
+	} else if err != nil {
+		return ErrorJSON(ctx, "Error while saving")
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"owner_token": merchant.FromOwnerToken(t),
+	})
+}
+
+func (crud *ownerTokenCrudController) List(ctx *fiber.Ctx) error {
+	strOwner := ctx.Query("owner_id")
+	ownerId, err := strconv.ParseUint(strOwner, 10, 32)
+	if err != nil || ownerId == 0 {
+		return ErrorJSON(ctx, "owner_id is required")
+	}
+
+	tokens, err := repositories.OwnerTokenRepository().GetOwnerTokens(uint(ownerId))
+	if err != nil {
+		return ErrorJSON(ctx, "Unable to get tokens.")
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"owner_tokens": merchant.FromOwnerTokens(tokens),
+	})
+}
@@ -0,0 +1,78 @@
+package crud
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"payment-go/internal/services"
+	"payment-go/internal/transport/model/withdraw"
+	"strconv"
+	"sync"
+)
+
+type IPayoutCrudController interface {
+	Execute(ctx *fiber.Ctx) error
+	Confirm(ctx *fiber.Ctx) error
+	Read(ctx *fiber.Ctx) error
+}
+type payoutCrudController struct {
+}
+
+var payoutIns *payoutCrudController
+var payoutOnce = sync.Once{}
+
+func PayoutCrudController() IPayoutCrudController {
+	payoutOnce.Do(func() {
+		payoutIns = &payoutCrudController{}
+	})
+	return payoutIns
+}
+
+// Execute запускает выплату по выводу через провайдера, соответствующего типу вывода
+func (crud *payoutCrudController) Execute(ctx *fiber.Ctx) error {
+	dto, err := withdraw.ApprovalDtoFromJSON(ctx.Body())
+	if err != nil {
+		return InvalidJSON(ctx)
+	}
+
+	p, err := services.PayoutService().Execute(dto.WithdrawID)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"payout": withdraw.FromPayout(p),
+	})
+}
+
+// Confirm подтверждение ручной выплаты оператором
+func (crud *payoutCrudController) Confirm(ctx *fiber.Ctx) error {
+	dto, err := withdraw.PayoutConfirmDtoFromJSON(ctx.Body())
+	if err != nil {
+		return InvalidJSON(ctx)
+	}
+
+	p, err := services.PayoutService().Confirm(dto)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"payout": withdraw.FromPayout(p),
+	})
+}
+
+func (crud *payoutCrudController) Read(ctx *fiber.Ctx) error {
+	strId := ctx.Query("withdraw_id")
+	id, err := strconv.ParseUint(strId, 10, 32)
+	if err != nil || id == 0 {
+		return ErrorJSON(ctx, "Invalid withdraw id passed")
+	}
+
+	p, err := services.PayoutService().GetPayout(uint(id))
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"payout": withdraw.FromPayout(p),
+	})
+}
@@ -0,0 +1,34 @@
+package crud
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"payment-go/internal/providers"
+	"payment-go/internal/transport/model/proxy_pool"
+	"sync"
+)
+
+type IProxyCrudController interface {
+	Health(ctx *fiber.Ctx) error
+}
+type proxyCrudController struct {
+}
+
+var proxyIns *proxyCrudController
+var proxyOnce = sync.Once{}
+
+func ProxyCrudController() IProxyCrudController {
+	proxyOnce.Do(func() {
+		proxyIns = &proxyCrudController{}
+	})
+	return proxyIns
+}
+
+// Health состояние прокси этого узла: доля успешных запросов, задержка и состояние исключения
+func (crud *proxyCrudController) Health(ctx *fiber.Ctx) error {
+	stats := providers.ProxyPoolProvider().GetPool().Stats()
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"node":    providers.StateProvider().NodeID(),
+		"proxies": proxy_pool.FromStatsList(stats),
+	})
+}
@@ -0,0 +1,141 @@
+package crud
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"io"
+	"payment-go/internal/config"
+	"payment-go/internal/repositories"
+	"payment-go/internal/services"
+	"payment-go/internal/transport/model/bank_message"
+	"payment-go/internal/transport/model/order"
+	"payment-go/internal/transport/model/reconciliation"
+	"sync"
+)
+
+type IReconciliationCrudController interface {
+	Import(ctx *fiber.Ctx) error
+	Find(ctx *fiber.Ctx) error
+	Messages(ctx *fiber.Ctx) error
+	Link(ctx *fiber.Ctx) error
+	Ignore(ctx *fiber.Ctx) error
+}
+type reconciliationCrudController struct {
+}
+
+var reconciliationIns *reconciliationCrudController
+var reconciliationOnce = sync.Once{}
+
+func ReconciliationCrudController() IReconciliationCrudController {
+	reconciliationOnce.Do(func() {
+		reconciliationIns = &reconciliationCrudController{}
+	})
+	return reconciliationIns
+}
+
+// Import загрузка выписки банка (multipart: format, file)
+func (crud *reconciliationCrudController) Import(ctx *fiber.Ctx) error {
+	fh, err := ctx.FormFile("file")
+	if err != nil {
+		return ErrorJSON(ctx, "File is not specified")
+	}
+	if fh.Size > config.StatementMaxFileSize {
+		return ErrorJSON(ctx, "File is too large")
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return ErrorJSON(ctx, "Unable to read file")
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return ErrorJSON(ctx, "Unable to read file")
+	}
+
+	stmt, err := services.ReconciliationService().Import(fh.Filename, ctx.FormValue("format"), data)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"statement": reconciliation.FromStatement(stmt),
+		"lines":     reconciliation.FromLines(stmt.Lines),
+	})
+}
+
+// Find строки выписок, по умолчанию - очередь несопоставленных
+func (crud *reconciliationCrudController) Find(ctx *fiber.Ctx) error {
+	p, err := NewPaginator(ctx)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+	page, size, _ := p.GetArgs()
+
+	body := ctx.Body()
+	dto, err := reconciliation.BuildFindLinesDto(body)
+	if err != nil {
+		return ErrorJSON(ctx, "Invalid request.")
+	}
+
+	lines, err := repositories.BankStatementRepository().FindLines(dto, page, size)
+	if err != nil {
+		return ErrorJSON(ctx, "Database error.")
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"total": lines.Total,
+		"lines": reconciliation.FromLines(lines.Items),
+	})
+}
+
+// Messages сообщения банка, не привязанные ни к заказу, ни к выписке
+func (crud *reconciliationCrudController) Messages(ctx *fiber.Ctx) error {
+	p, err := NewPaginator(ctx)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+	page, size, _ := p.GetArgs()
+
+	messages, err := repositories.BankStatementRepository().FindUnmatchedMessages(page, size)
+	if err != nil {
+		return ErrorJSON(ctx, "Database error.")
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"total":    messages.Total,
+		"messages": bank_message.FromBankMessages(messages.Items),
+	})
+}
+
+func (crud *reconciliationCrudController) Link(ctx *fiber.Ctx) error {
+	dto, err := reconciliation.LinkDtoFromJSON(ctx.Body())
+	if err != nil {
+		return InvalidJSON(ctx)
+	}
+
+	ord, err := services.ReconciliationService().Link(dto)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"order": order.FromOrder(ord),
+	})
+}
+
+func (crud *reconciliationCrudController) Ignore(ctx *fiber.Ctx) error {
+	dto, err := reconciliation.IgnoreDtoFromJSON(ctx.Body())
+	if err != nil {
+		return InvalidJSON(ctx)
+	}
+
+	line, err := services.ReconciliationService().Ignore(dto)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"line": reconciliation.FromLine(line),
+	})
+}
@@ -0,0 +1,85 @@
+package crud
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"payment-go/internal/repositories"
+	"payment-go/internal/services"
+	"payment-go/internal/transport/model/scheduler"
+	"sync"
+)
+
+type ISchedulerCrudController interface {
+	Jobs(ctx *fiber.Ctx) error
+	Trigger(ctx *fiber.Ctx) error
+	Runs(ctx *fiber.Ctx) error
+}
+type schedulerCrudController struct {
+}
+
+var schedulerIns *schedulerCrudController
+var schedulerOnce = sync.Once{}
+
+func SchedulerCrudController() ISchedulerCrudController {
+	schedulerOnce.Do(func() {
+		schedulerIns = &schedulerCrudController{}
+	})
+	return schedulerIns
+}
+
+// Jobs список задач планировщика с расписанием и итогом последнего запуска
+func (crud *schedulerCrudController) Jobs(ctx *fiber.Ctx) error {
+	jobs, err := services.SchedulerService().GetJobs()
+	if err != nil {
+		return ErrorJSON(ctx, "Database error.")
+	}
+
+	res := make([]*scheduler.JobResponseDto, len(jobs))
+	for i, job := range jobs {
+		res[i] = scheduler.FromJob(job, services.SchedulerService().IsRunning(job.Name))
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"jobs": res,
+	})
+}
+
+// Trigger внеочередной запуск задачи
+func (crud *schedulerCrudController) Trigger(ctx *fiber.Ctx) error {
+	dto, err := scheduler.TriggerDtoFromJSON(ctx.Body())
+	if err != nil {
+		return InvalidJSON(ctx)
+	}
+	if err = dto.Validate(); err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+
+	if err = services.SchedulerService().Trigger(dto.Name); err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{})
+}
+
+// Runs история запусков
+func (crud *schedulerCrudController) Runs(ctx *fiber.Ctx) error {
+	p, err := NewPaginator(ctx)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+	page, size, _ := p.GetArgs()
+
+	dto, err := scheduler.BuildFindRunsDto(ctx.Body())
+	if err != nil {
+		return ErrorJSON(ctx, "Invalid request.")
+	}
+
+	runs, err := repositories.ScheduledJobRepository().FindRuns(dto, page, size)
+	if err != nil {
+		return ErrorJSON(ctx, "Database error.")
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"total": runs.Total,
+		"runs":  scheduler.FromRuns(runs.Items),
+	})
+}
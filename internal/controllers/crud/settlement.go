@@ -0,0 +1,148 @@
+package crud
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories"
+	"payment-go/internal/services"
+	"payment-go/internal/transport/model/settlement"
+	"strconv"
+	"sync"
+)
+
+type ISettlementCrudController interface {
+	ICrudController
+	Find(ctx *fiber.Ctx) error
+	Close(ctx *fiber.Ctx) error
+	Export(ctx *fiber.Ctx) error
+}
+type settlementCrudController struct {
+}
+
+var settlementIns *settlementCrudController
+var settlementOnce = sync.Once{}
+
+func SettlementCrudController() ISettlementCrudController {
+	settlementOnce.Do(func() {
+		settlementIns = &settlementCrudController{}
+	})
+	return settlementIns
+}
+
+func (crud *settlementCrudController) GetActions() CrudActions {
+	return CrudActions{
+		Create: false,
+		Read:   true,
+		Update: false,
+		Delete: false,
+		List:   false,
+	}
+}
+
+func (crud *settlementCrudController) GetPermissions() CrudPermissions {
+	perms := DefaultCrudPermissions()
+	perms.Create = models.PermissionFinance
+	perms.Update = models.PermissionFinance
+	perms.Delete = models.PermissionFinance
+	return perms
+}
+
+func (crud *settlementCrudController) Create(ctx *fiber.Ctx) error {
+	return ctx.SendStatus(404)
+}
+
+func (crud *settlementCrudController) Read(ctx *fiber.Ctx) error {
+	strId := ctx.Query("id")
+	id, err := strconv.ParseUint(strId, 10, 32)
+	if err != nil || id == 0 {
+		return ErrorJSON(ctx, "Invalid settlement id passed")
+	}
+
+	stl, err := repositories.SettlementRepository().FindById(uint(id))
+	if err != nil {
+		return ErrorJSON(ctx, "Settlement not found")
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"settlement": settlement.FromSettlement(stl),
+	})
+}
+
+func (crud *settlementCrudController) Update(ctx *fiber.Ctx) error {
+	return ctx.SendStatus(404)
+}
+
+func (crud *settlementCrudController) Delete(ctx *fiber.Ctx) error {
+	return ctx.SendStatus(404)
+}
+
+func (crud *settlementCrudController) List(ctx *fiber.Ctx) error {
+	return ctx.SendStatus(404)
+}
+
+func (crud *settlementCrudController) Find(ctx *fiber.Ctx) error {
+	p, err := NewPaginator(ctx)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+	page, size, _ := p.GetArgs()
+
+	body := ctx.Body()
+	dto, err := settlement.BuildFindDto(body)
+	if err != nil {
+		return ErrorJSON(ctx, "Invalid request.")
+	}
+
+	settlements, err := repositories.SettlementRepository().Find(dto, page, size)
+	if err != nil {
+		return ErrorJSON(ctx, "Database error.")
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"total":       settlements.Total,
+		"settlements": settlement.FromSettlements(settlements.Items),
+	})
+}
+
+// Close пересчёт отчёта магазина за день
+func (crud *settlementCrudController) Close(ctx *fiber.Ctx) error {
+	dto, err := settlement.CloseDtoFromJSON(ctx.Body())
+	if err != nil {
+		return InvalidJSON(ctx)
+	}
+
+	stl, err := services.SettlementService().Close(dto)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"settlement": settlement.FromSettlement(stl),
+	})
+}
+
+func (crud *settlementCrudController) Export(ctx *fiber.Ctx) error {
+	strId := ctx.Query("id")
+	id, err := strconv.ParseUint(strId, 10, 32)
+	if err != nil || id == 0 {
+		return ErrorJSON(ctx, "Invalid settlement id passed")
+	}
+
+	stl, err := repositories.SettlementRepository().FindById(uint(id))
+	if err != nil {
+		return ErrorJSON(ctx, "Settlement not found")
+	}
+
+	if ctx.Query("format", settlement.FormatJSON) == settlement.FormatCSV {
+		data, err := settlement.ToCSV(stl)
+		if err != nil {
+			return ErrorJSON(ctx, err.Error())
+		}
+		ctx.Set(fiber.HeaderContentType, "text/csv")
+		ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"settlement_%d_%s.csv\"", stl.ShopID, stl.Date))
+		return ctx.Send(data)
+	}
+
+	return ctx.JSON(settlement.FromSettlement(stl))
+}
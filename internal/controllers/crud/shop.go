@@ -33,6 +33,10 @@ func (crud *shopCrudController) GetActions() CrudActions {
 	return AllCrudActions()
 }
 
+func (crud *shopCrudController) GetPermissions() CrudPermissions {
+	return DefaultCrudPermissions()
+}
+
 func (crud *shopCrudController) Create(ctx *fiber.Ctx) error {
 	dto, err := ParseJSON(shop.CreateShopDto{}, ctx)
 	if err != nil {
@@ -0,0 +1,187 @@
+package crud
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"io"
+	"payment-go/internal/config"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories"
+	"payment-go/internal/services"
+	"payment-go/internal/transport/model/withdraw"
+	"strconv"
+	"sync"
+)
+
+type IWithdrawBatchCrudController interface {
+	ICrudController
+	Find(ctx *fiber.Ctx) error
+	Upload(ctx *fiber.Ctx) error
+	Approve(ctx *fiber.Ctx) error
+	Decline(ctx *fiber.Ctx) error
+}
+type withdrawBatchCrudController struct {
+}
+
+var withdrawBatchIns *withdrawBatchCrudController
+var withdrawBatchOnce = sync.Once{}
+
+func WithdrawBatchCrudController() IWithdrawBatchCrudController {
+	withdrawBatchOnce.Do(func() {
+		withdrawBatchIns = &withdrawBatchCrudController{}
+	})
+	return withdrawBatchIns
+}
+
+func (crud *withdrawBatchCrudController) GetActions() CrudActions {
+	return CrudActions{
+		Create: false,
+		Read:   true,
+		Update: false,
+		Delete: false,
+		List:   false,
+	}
+}
+
+func (crud *withdrawBatchCrudController) GetPermissions() CrudPermissions {
+	perms := DefaultCrudPermissions()
+	perms.Create = models.PermissionFinance
+	perms.Update = models.PermissionFinance
+	perms.Delete = models.PermissionFinance
+	return perms
+}
+
+func (crud *withdrawBatchCrudController) Create(ctx *fiber.Ctx) error {
+	return ctx.SendStatus(404)
+}
+
+func (crud *withdrawBatchCrudController) Read(ctx *fiber.Ctx) error {
+	strId := ctx.Query("id")
+	id, err := strconv.ParseUint(strId, 10, 32)
+	if err != nil || id == 0 {
+		return ErrorJSON(ctx, "Invalid batch id passed")
+	}
+
+	b, err := repositories.WithdrawBatchRepository().FindById(uint(id))
+	if err != nil {
+		return ErrorJSON(ctx, "Withdraw batch not found")
+	}
+
+	progress, err := services.WithdrawBatchService().GetProgress(b.ID)
+	if err != nil {
+		return ErrorJSON(ctx, "Database error.")
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"batch": withdraw.FromBatch(b, progress),
+	})
+}
+
+func (crud *withdrawBatchCrudController) Update(ctx *fiber.Ctx) error {
+	return ctx.SendStatus(404)
+}
+
+func (crud *withdrawBatchCrudController) Delete(ctx *fiber.Ctx) error {
+	return ctx.SendStatus(404)
+}
+
+func (crud *withdrawBatchCrudController) List(ctx *fiber.Ctx) error {
+	return ctx.SendStatus(404)
+}
+
+func (crud *withdrawBatchCrudController) Find(ctx *fiber.Ctx) error {
+	p, err := NewPaginator(ctx)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+	page, size, _ := p.GetArgs()
+
+	body := ctx.Body()
+	dto, err := withdraw.BuildFindBatchDto(body)
+	if err != nil {
+		return ErrorJSON(ctx, "Invalid request.")
+	}
+
+	batches, err := repositories.WithdrawBatchRepository().Find(dto, page, size)
+	if err != nil {
+		return ErrorJSON(ctx, "Database error.")
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"total":   batches.Total,
+		"batches": withdraw.FromBatches(batches.Items),
+	})
+}
+
+// Upload создаёт пакет выводов из csv/xlsx файла (multipart: shop_id, file)
+func (crud *withdrawBatchCrudController) Upload(ctx *fiber.Ctx) error {
+	shopId, err := strconv.ParseUint(ctx.FormValue("shop_id"), 10, 32)
+	if err != nil || shopId == 0 {
+		return ErrorJSON(ctx, "Invalid shop id passed")
+	}
+
+	fh, err := ctx.FormFile("file")
+	if err != nil {
+		return ErrorJSON(ctx, "File is not specified")
+	}
+	if fh.Size > config.WithdrawBatchMaxFileSize {
+		return ErrorJSON(ctx, "File is too large")
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return ErrorJSON(ctx, "Unable to read file")
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return ErrorJSON(ctx, "Unable to read file")
+	}
+
+	b, results, err := services.WithdrawBatchService().CreateFromFile(uint(shopId), fh.Filename, data)
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"items":   results,
+		})
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"batch": withdraw.FromBatch(b, nil),
+		"items": results,
+	})
+}
+
+func (crud *withdrawBatchCrudController) Approve(ctx *fiber.Ctx) error {
+	dto, err := withdraw.BatchApprovalDtoFromJSON(ctx.Body())
+	if err != nil {
+		return InvalidJSON(ctx)
+	}
+
+	b, results, err := services.WithdrawBatchService().Approve(dto.BatchID)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"batch": withdraw.FromBatch(b, nil),
+		"items": results,
+	})
+}
+
+func (crud *withdrawBatchCrudController) Decline(ctx *fiber.Ctx) error {
+	dto, err := withdraw.BatchApprovalDtoFromJSON(ctx.Body())
+	if err != nil {
+		return InvalidJSON(ctx)
+	}
+
+	b, err := services.WithdrawBatchService().Decline(dto.BatchID)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"batch": withdraw.FromBatch(b, nil),
+	})
+}
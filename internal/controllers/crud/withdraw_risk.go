@@ -0,0 +1,116 @@
+package crud
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"payment-go/internal/controllers/auth"
+	"payment-go/internal/repositories"
+	"payment-go/internal/services"
+	"payment-go/internal/transport/model/withdraw"
+	"strconv"
+	"sync"
+)
+
+type IWithdrawRiskCrudController interface {
+	Find(ctx *fiber.Ctx) error
+	Read(ctx *fiber.Ctx) error
+	Approve(ctx *fiber.Ctx) error
+	RequireApproval(ctx *fiber.Ctx) error
+}
+type withdrawRiskCrudController struct {
+}
+
+var withdrawRiskIns *withdrawRiskCrudController
+var withdrawRiskOnce = sync.Once{}
+
+func WithdrawRiskCrudController() IWithdrawRiskCrudController {
+	withdrawRiskOnce.Do(func() {
+		withdrawRiskIns = &withdrawRiskCrudController{}
+	})
+	return withdrawRiskIns
+}
+
+// Find поиск выводов вместе с результатами проверок риска
+func (crud *withdrawRiskCrudController) Find(ctx *fiber.Ctx) error {
+	p, err := NewPaginator(ctx)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+	page, size, _ := p.GetArgs()
+
+	body := ctx.Body()
+	dto, err := withdraw.BuildFindDto(body)
+	if err != nil {
+		return ErrorJSON(ctx, "Invalid request.")
+	}
+
+	withdraws, err := repositories.WithdrawRepository().Find(dto, page, size)
+	if err != nil {
+		return ErrorJSON(ctx, "Database error.")
+	}
+
+	ids := make([]uint, len(withdraws.Items))
+	for i, wd := range withdraws.Items {
+		ids[i] = wd.ID
+	}
+	checks, err := repositories.WithdrawRiskRepository().FindByWithdrawIds(ids)
+	if err != nil {
+		return ErrorJSON(ctx, "Database error.")
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"total":     withdraws.Total,
+		"withdraws": withdraw.FromWithdrawsWithRisk(withdraws.Items, checks),
+	})
+}
+
+func (crud *withdrawRiskCrudController) Read(ctx *fiber.Ctx) error {
+	strId := ctx.Query("withdraw_id")
+	id, err := strconv.ParseUint(strId, 10, 32)
+	if err != nil || id == 0 {
+		return ErrorJSON(ctx, "Invalid withdraw id passed")
+	}
+
+	rc, err := services.WithdrawRiskService().Evaluate(uint(id))
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"risk": withdraw.FromRiskCheck(rc),
+	})
+}
+
+// Approve подтверждение крупного вывода оператором
+func (crud *withdrawRiskCrudController) Approve(ctx *fiber.Ctx) error {
+	dto, err := withdraw.RiskApproveDtoFromJSON(ctx.Body())
+	if err != nil {
+		return InvalidJSON(ctx)
+	}
+	// оператором считается вошедший пользователь, а не указанный в запросе
+	if u := auth.CurrentAdmin(ctx); u != nil {
+		dto.Operator = u.Login
+	}
+
+	rc, err := services.WithdrawRiskService().Approve(dto)
+	if err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+
+	return SuccessAdvancedJSON(ctx, fiber.Map{
+		"risk": withdraw.FromRiskCheck(rc),
+	})
+}
+
+// RequireApproval не пропускает дальше заблокированные и неподтверждённые выводы
+func (crud *withdrawRiskCrudController) RequireApproval(ctx *fiber.Ctx) error {
+	dto, err := withdraw.ApprovalDtoFromJSON(ctx.Body())
+	if err != nil || dto == nil {
+		return InvalidJSON(ctx)
+	}
+
+	if err := services.WithdrawRiskService().AssertApproved(dto.WithdrawID); err != nil {
+		return ErrorJSON(ctx, err.Error())
+	}
+
+	return ctx.Next()
+}
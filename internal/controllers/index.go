@@ -4,7 +4,12 @@ import (
 	"fmt"
 	"github.com/gofiber/fiber/v2"
 	"net/http"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories"
+	"payment-go/internal/services"
+	"payment-go/internal/views"
 	"sync"
+	"time"
 )
 
 type IIndexController interface {
@@ -49,31 +54,73 @@ func (con *indexController) Test(ctx *fiber.Ctx) error {
 	return ctx.SendString("hello from controller")
 }
 
+// Payment страница оплаты заказа для плательщика
 func (con *indexController) Payment(ctx *fiber.Ctx) error {
-	orderNumber := ctx.Params("order_number")
-	html := `<!DOCTYPE html><html>
-	<head><title>Processing...</title></head>
-	<body>
-	<script type="text/javascript">
-        const ev = new EventSource('/api/order/wait-link?order_number=%s')
-        ev.onmessage = (event) => {
-            try{
-				res = JSON.parse(event.data)
-				if(res.link) location.href = res.link
-				else ev.close()
-			}catch(e){
-				console.log(e)
-				ev.close()
-			}
-        }
-		ev.onclose = () => {
-			//history.back()
-			alert('go back')
-		}
-        console.log(ev)
-	</script>
-	</body>
-	</html>`
-	ctx.Response().Header.Set("Content-Type", "text/html")
-	return ctx.SendString(fmt.Sprintf(html, orderNumber))
+	lang := views.DetectLang(ctx.Query("lang"), ctx.Get(fiber.HeaderAcceptLanguage))
+	ctx.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+
+	ord, err := repositories.OrderRepository().FindByNumber(ctx.Params("order_number"))
+	if err != nil {
+		page := &views.ErrorPage{Localized: views.Localized{Lang: lang}}
+		page.Message = page.Tr("not_found")
+		ctx.Status(fiber.StatusNotFound)
+		return views.Render(ctx, "error", page)
+	}
+
+	return views.Render(ctx, "checkout", newCheckoutPage(ord, lang))
+}
+
+func newCheckoutPage(ord *models.Order, lang string) *views.CheckoutPage {
+	expiresAt := services.OrderStreamService().ExpiresAt(ord)
+
+	page := &views.CheckoutPage{
+		Localized:   views.Localized{Lang: lang},
+		OrderNumber: ord.Number.String(),
+		Amount:      fmt.Sprintf("%.2f", ord.Amount),
+		Method:      views.CheckoutMethodLink,
+		ExpiresAt:   expiresAt.Unix(),
+		Status:      views.CheckoutStatusPending,
+		Branding: views.CheckoutBranding{
+			ShopName:        ord.Shop.Name,
+			LogoUrl:         stringOrEmpty(ord.Shop.Branding.LogoUrl),
+			PrimaryColor:    stringOrEmpty(ord.Shop.Branding.PrimaryColor),
+			BackgroundColor: stringOrEmpty(ord.Shop.Branding.BackgroundColor),
+			ReturnUrl:       brandingReturnUrl(&ord.Shop),
+		},
+		SuccessReturn: ord.SuccessUrl != nil || ord.Shop.Redirects.SuccessUrl != nil,
+		FailReturn:    ord.FailUrl != nil || ord.Shop.Redirects.FailUrl != nil,
+	}
+	if !ord.HaveLink() {
+		page.Method = views.CheckoutMethodCard
+		page.CardNumber = stringOrEmpty(ord.GetCardIfPublic())
+	}
+
+	switch {
+	case ord.Status == models.StatusCompleted:
+		page.Status = views.CheckoutStatusSuccess
+	case ord.Status == models.StatusFailed:
+		page.Status = views.CheckoutStatusFailure
+	case time.Now().After(expiresAt):
+		page.Status = views.CheckoutStatusExpired
+	}
+	return page
+}
+
+// brandingReturnUrl адрес, сохранённый до смены хоста магазина, не показывается
+func brandingReturnUrl(sh *models.Shop) string {
+	if sh.Branding.ReturnUrl == nil {
+		return ""
+	}
+	link, err := sh.ResolveUrl(*sh.Branding.ReturnUrl)
+	if err != nil {
+		return ""
+	}
+	return link
+}
+
+func stringOrEmpty(str *string) string {
+	if str == nil {
+		return ""
+	}
+	return *str
 }
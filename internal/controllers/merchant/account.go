@@ -0,0 +1,92 @@
+package merchant
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"log"
+	"payment-go/internal/controllers/crud"
+	"payment-go/internal/services"
+	"payment-go/internal/transport/model/merchant"
+	"strings"
+	"sync"
+)
+
+type IAccountController interface {
+	Login(ctx *fiber.Ctx) error
+	Logout(ctx *fiber.Ctx) error
+	AcceptInvite(ctx *fiber.Ctx) error
+	Me(ctx *fiber.Ctx) error
+}
+type accountController struct {
+}
+
+var accountIns *accountController
+var accountOnce = sync.Once{}
+
+// AccountController вход участников мерчанта в кабинет и принятие приглашений
+func AccountController() IAccountController {
+	accountOnce.Do(func() {
+		accountIns = &accountController{}
+	})
+	return accountIns
+}
+
+func (con *accountController) Login(ctx *fiber.Ctx) error {
+	dto, err := crud.ParseJSON(merchant.LoginDto{}, ctx)
+	if err != nil {
+		return crud.InvalidJSON(ctx)
+	}
+
+	res, err := services.MerchantAccountService().Login(dto)
+	if err != nil {
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	return crud.SuccessAdvancedJSON(ctx, fiber.Map{
+		"session": res,
+	})
+}
+
+func (con *accountController) Logout(ctx *fiber.Ctx) error {
+	token, _ := strings.CutPrefix(ctx.Get(fiber.HeaderAuthorization), "Bearer ")
+	if err := services.MerchantAccountService().Logout(strings.TrimSpace(token)); err != nil {
+		log.Println(err)
+	}
+	return ctx.JSON(fiber.Map{
+		"success": true,
+	})
+}
+
+func (con *accountController) AcceptInvite(ctx *fiber.Ctx) error {
+	dto, err := crud.ParseJSON(merchant.AcceptInviteDto{}, ctx)
+	if err != nil {
+		return crud.InvalidJSON(ctx)
+	}
+
+	u, err := services.MerchantAccountService().AcceptInvite(dto)
+	if err != nil {
+		return crud.ErrorJSON(ctx, err.Error())
+	}
+
+	return crud.SuccessAdvancedJSON(ctx, fiber.Map{
+		"user": merchant.FromMerchantUser(u),
+	})
+}
+
+// Me участник и права текущего запроса. Для ключей магазина и токенов из админки user пустой
+func (con *accountController) Me(ctx *fiber.Ctx) error {
+	scope := getScope(ctx)
+
+	var user *merchant.MemberResponseDto
+	if scope.User != nil {
+		user = merchant.FromMerchantUser(scope.User)
+	}
+	return crud.SuccessAdvancedJSON(ctx, fiber.Map{
+		"merchant_id": scope.OwnerID,
+		"user":        user,
+		"permissions": scope.Permissions,
+		"shop_ids":    scope.ShopIDs(),
+	})
+}
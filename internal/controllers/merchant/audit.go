@@ -0,0 +1,42 @@
+package merchant
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"payment-go/internal/controllers/crud"
+	"payment-go/internal/models"
+)
+
+// Audit записывает успешное действие мерчанта в журнал. Ставится после Require
+func Audit(action, entity, requestKey, responseKey string) fiber.Handler {
+	return crud.AuditAs(auditActor, crud.RequestKey(requestKey), action, entity, responseKey)
+}
+
+// AuditShop действие с магазином: при авторизации ключами магазина shop_id можно не передавать
+func AuditShop(action string) fiber.Handler {
+	return crud.AuditAs(auditActor, auditShop, action, "shop", "")
+}
+
+func auditShop(ctx *fiber.Ctx) uint {
+	scope := getScope(ctx)
+	if scope == nil {
+		return 0
+	}
+	sh, err := scope.FindShop(crud.RequestKey("shop_id")(ctx))
+	if err != nil {
+		return 0
+	}
+	return sh.ID
+}
+
+// auditActor участник кабинета, для ключей магазина и токенов из админки - сам мерчант
+func auditActor(ctx *fiber.Ctx) (string, uint, string) {
+	scope := getScope(ctx)
+	if scope == nil {
+		return models.AuditActorMerchant, 0, ""
+	}
+	if scope.User != nil {
+		return models.AuditActorMerchant, scope.User.ID, scope.User.Email
+	}
+	return models.AuditActorMerchant, 0, fmt.Sprintf("merchant #%d", scope.OwnerID)
+}
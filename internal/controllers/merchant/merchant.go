@@ -0,0 +1,311 @@
+package merchant
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"log"
+	"payment-go/internal/controllers/crud"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories"
+	"payment-go/internal/services"
+	"payment-go/internal/transport/model/merchant"
+	"payment-go/internal/transport/model/order"
+	"payment-go/internal/transport/model/withdraw"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const HeaderPublicKey = "X-Public-Key"
+const HeaderPrivateKey = "X-Private-Key"
+
+const localsScope = "merchant_scope"
+
+var orderSortFields = []string{"id", "created_at", "amount", "status", "date_paid"}
+var withdrawSortFields = []string{"id", "created_at", "amount", "status", "finished_at"}
+
+type IMerchantController interface {
+	Authenticate(ctx *fiber.Ctx) error
+	Shops(ctx *fiber.Ctx) error
+	Balance(ctx *fiber.Ctx) error
+	FindOrders(ctx *fiber.Ctx) error
+	FindWithdraws(ctx *fiber.Ctx) error
+	UpdateWebhooks(ctx *fiber.Ctx) error
+	RegeneratePrivateKey(ctx *fiber.Ctx) error
+	HostValidation(ctx *fiber.Ctx) error
+	ValidateHost(ctx *fiber.Ctx) error
+}
+type merchantController struct {
+}
+
+var merchantIns *merchantController
+var merchantOnce = sync.Once{}
+
+// MerchantController API для владельцев магазинов, доступ только к своим магазинам
+func MerchantController() IMerchantController {
+	merchantOnce.Do(func() {
+		merchantIns = &merchantController{}
+	})
+	return merchantIns
+}
+
+// Authenticate авторизует запрос ключами магазина (X-Public-Key, X-Private-Key),
+// токеном API или сессией кабинета (Authorization: Bearer)
+func (con *merchantController) Authenticate(ctx *fiber.Ctx) error {
+	var scope *services.MerchantScope
+	var err error
+	if token, ok := strings.CutPrefix(ctx.Get(fiber.HeaderAuthorization), "Bearer "); ok {
+		scope, err = services.MerchantService().AuthorizeToken(strings.TrimSpace(token))
+	} else {
+		scope, err = services.MerchantService().AuthorizeKeys(ctx.Get(HeaderPublicKey), ctx.Get(HeaderPrivateKey))
+	}
+	if err != nil {
+		log.Printf("Merchant: unauthorized %s %s from %s", ctx.Method(), ctx.Path(), ctx.IP())
+		return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error":   services.ErrMerchantUnauthorized.Error(),
+		})
+	}
+
+	ctx.Locals(localsScope, scope)
+	return ctx.Next()
+}
+
+// Require пропускает только запросы с правом p. Отказы пишутся в лог
+func Require(p models.MerchantPermission) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		scope := getScope(ctx)
+		if scope == nil || !scope.Can(p) {
+			log.Printf("Merchant: denied %s %s for merchant #%d%s, requires %s", ctx.Method(), ctx.Path(), scopeOwner(scope), scopeUser(scope), p)
+			return forbidden(ctx)
+		}
+		return ctx.Next()
+	}
+}
+
+// RequireMember пропускает только запросы от имени участника: сессия кабинета или его токен
+func RequireMember(ctx *fiber.Ctx) error {
+	if scope := getScope(ctx); scope == nil || scope.User == nil {
+		return forbidden(ctx)
+	}
+	return ctx.Next()
+}
+
+func (con *merchantController) Shops(ctx *fiber.Ctx) error {
+	scope := getScope(ctx)
+
+	res := make([]*merchant.ShopResponseDto, 0, len(scope.Shops))
+	for _, sh := range scope.Shops {
+		res = append(res, merchant.FromShop(sh, services.MerchantService().GetBalance(sh)))
+	}
+	return crud.SuccessAdvancedJSON(ctx, fiber.Map{
+		"shops": res,
+	})
+}
+
+func (con *merchantController) Balance(ctx *fiber.Ctx) error {
+	scope := getScope(ctx)
+
+	total := 0.0
+	res := make([]*merchant.BalanceResponseDto, 0, len(scope.Shops))
+	for _, sh := range scope.Shops {
+		balance := services.MerchantService().GetBalance(sh)
+		total += balance
+		res = append(res, &merchant.BalanceResponseDto{ShopID: sh.ID, Balance: balance})
+	}
+	return crud.SuccessAdvancedJSON(ctx, fiber.Map{
+		"total":    total,
+		"balances": res,
+	})
+}
+
+func (con *merchantController) FindOrders(ctx *fiber.Ctx) error {
+	p, err := crud.NewPaginator(ctx)
+	if err != nil {
+		return crud.ErrorJSON(ctx, err.Error())
+	}
+	page, size, _ := p.GetArgs()
+
+	dto, err := order.BuildFindDto(ctx.Body())
+	if err != nil {
+		return crud.ErrorJSON(ctx, "Invalid request.")
+	}
+
+	scope := getScope(ctx)
+	if dto.ShopID, err = restrictShops(scope, dto.ShopID); err != nil {
+		return crud.ErrorJSON(ctx, err.Error())
+	}
+	dto.OwnerID = []uint{scope.OwnerID}
+	dto.CardID = nil
+	if dto.Sort != nil && !dto.Sort.Allowed(orderSortFields...) {
+		return crud.ErrorJSON(ctx, "Sorting by this field is not supported.")
+	}
+
+	orders, err := repositories.OrderRepository().Find(dto, page, size)
+	if err != nil {
+		return crud.ErrorJSON(ctx, "Database error.")
+	}
+
+	return crud.SuccessAdvancedJSON(ctx, fiber.Map{
+		"total":  orders.Total,
+		"orders": order.FromOrders(orders.Items),
+	})
+}
+
+func (con *merchantController) FindWithdraws(ctx *fiber.Ctx) error {
+	p, err := crud.NewPaginator(ctx)
+	if err != nil {
+		return crud.ErrorJSON(ctx, err.Error())
+	}
+	page, size, _ := p.GetArgs()
+
+	dto, err := withdraw.BuildFindDto(ctx.Body())
+	if err != nil {
+		return crud.ErrorJSON(ctx, "Invalid request.")
+	}
+
+	scope := getScope(ctx)
+	if dto.ShopID, err = restrictShops(scope, dto.ShopID); err != nil {
+		return crud.ErrorJSON(ctx, err.Error())
+	}
+	dto.OwnerID = []uint{scope.OwnerID}
+	if dto.Sort != nil && !dto.Sort.Allowed(withdrawSortFields...) {
+		return crud.ErrorJSON(ctx, "Sorting by this field is not supported.")
+	}
+
+	withdraws, err := repositories.WithdrawRepository().Find(dto, page, size)
+	if err != nil {
+		return crud.ErrorJSON(ctx, "Database error.")
+	}
+
+	return crud.SuccessAdvancedJSON(ctx, fiber.Map{
+		"total":     withdraws.Total,
+		"withdraws": withdraw.FromWithdraws(withdraws.Items),
+	})
+}
+
+func (con *merchantController) UpdateWebhooks(ctx *fiber.Ctx) error {
+	dto, err := crud.ParseJSON(merchant.UpdateWebhooksDto{}, ctx)
+	if err != nil {
+		return crud.InvalidJSON(ctx)
+	}
+
+	sh, err := getScope(ctx).FindShop(dto.ShopID)
+	if err != nil {
+		return crud.ErrorJSON(ctx, err.Error())
+	}
+
+	if err = services.MerchantService().UpdateWebhooks(sh, &dto.Webhooks); err != nil {
+		return crud.ErrorJSON(ctx, err.Error())
+	}
+
+	return crud.SuccessAdvancedJSON(ctx, fiber.Map{
+		"shop": merchant.FromShop(sh, services.MerchantService().GetBalance(sh)),
+	})
+}
+
+// RegeneratePrivateKey меняет ключ магазина, старый ключ сразу перестаёт работать
+func (con *merchantController) RegeneratePrivateKey(ctx *fiber.Ctx) error {
+	dto, err := crud.ParseJSON(merchant.ShopActionDto{}, ctx)
+	if err != nil {
+		return crud.InvalidJSON(ctx)
+	}
+
+	sh, err := getScope(ctx).FindShop(dto.ShopID)
+	if err != nil {
+		return crud.ErrorJSON(ctx, err.Error())
+	}
+
+	if err = services.ShopService().RegeneratePrivateKey(sh); err != nil {
+		return crud.ErrorJSON(ctx, "Error while changing private key")
+	}
+
+	return crud.SuccessAdvancedJSON(ctx, fiber.Map{
+		"message":     "Private key was changed!",
+		"private_key": sh.Keys.PrivateKey,
+	})
+}
+
+// HostValidation код, который нужно разместить на хосте магазина перед проверкой
+func (con *merchantController) HostValidation(ctx *fiber.Ctx) error {
+	shopId, _ := strconv.ParseUint(ctx.Query("shop_id"), 10, 32)
+	sh, err := getScope(ctx).FindShop(uint(shopId))
+	if err != nil {
+		return crud.ErrorJSON(ctx, err.Error())
+	}
+
+	res := &merchant.HostValidationResponseDto{
+		ShopID:        sh.ID,
+		Host:          sh.Host,
+		HostValidated: sh.HostValidated,
+	}
+	if !sh.HostValidated {
+		res.ConfirmCode = services.ShopService().GetShopHostConfirmCode(sh)
+	}
+	return crud.SuccessAdvancedJSON(ctx, fiber.Map{
+		"validation": res,
+	})
+}
+
+func (con *merchantController) ValidateHost(ctx *fiber.Ctx) error {
+	dto, err := crud.ParseJSON(merchant.ShopActionDto{}, ctx)
+	if err != nil {
+		return crud.InvalidJSON(ctx)
+	}
+
+	sh, err := getScope(ctx).FindShop(dto.ShopID)
+	if err != nil {
+		return crud.ErrorJSON(ctx, err.Error())
+	}
+	if sh.HostValidated {
+		return crud.ErrorJSON(ctx, "Shop host is already validated")
+	}
+
+	validated, err := services.ShopService().ValidateHost(sh)
+	if err != nil {
+		return crud.ErrorJSON(ctx, "Error while validating host.")
+	}
+	if !validated {
+		return crud.ErrorJSON(ctx, "Validation is unsuccessful.")
+	}
+	return crud.SuccessJSON(ctx, "Validation completed successfully!")
+}
+
+func getScope(ctx *fiber.Ctx) *services.MerchantScope {
+	scope, _ := ctx.Locals(localsScope).(*services.MerchantScope)
+	return scope
+}
+
+func scopeOwner(scope *services.MerchantScope) uint {
+	if scope == nil {
+		return 0
+	}
+	return scope.OwnerID
+}
+
+func scopeUser(scope *services.MerchantScope) string {
+	if scope == nil || scope.User == nil {
+		return ""
+	}
+	return fmt.Sprintf(" user '%s' (%s)", scope.User.Email, scope.User.Role)
+}
+
+func forbidden(ctx *fiber.Ctx) error {
+	return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"success": false,
+		"error":   "Permission denied",
+	})
+}
+
+// restrictShops ограничивает фильтр магазинами из области доступа
+func restrictShops(scope *services.MerchantScope, requested []uint) ([]uint, error) {
+	if len(requested) == 0 {
+		return scope.ShopIDs(), nil
+	}
+	for _, id := range requested {
+		if _, err := scope.FindShop(id); err != nil {
+			return nil, err
+		}
+	}
+	return requested, nil
+}
@@ -0,0 +1,143 @@
+package merchant
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"payment-go/internal/controllers/crud"
+	"payment-go/internal/repositories"
+	"payment-go/internal/services"
+	"payment-go/internal/transport/model/merchant"
+	"sync"
+)
+
+type ITeamController interface {
+	Members(ctx *fiber.Ctx) error
+	Invite(ctx *fiber.Ctx) error
+	UpdateMember(ctx *fiber.Ctx) error
+	RemoveMember(ctx *fiber.Ctx) error
+	Tokens(ctx *fiber.Ctx) error
+	CreateToken(ctx *fiber.Ctx) error
+	RevokeToken(ctx *fiber.Ctx) error
+}
+type teamController struct {
+}
+
+var teamIns *teamController
+var teamOnce = sync.Once{}
+
+// TeamController участники мерчанта и их токены API
+func TeamController() ITeamController {
+	teamOnce.Do(func() {
+		teamIns = &teamController{}
+	})
+	return teamIns
+}
+
+func (con *teamController) Members(ctx *fiber.Ctx) error {
+	users, err := repositories.MerchantUserRepository().GetMerchantUsers(getScope(ctx).OwnerID)
+	if err != nil {
+		return crud.ErrorJSON(ctx, "Unable to get members.")
+	}
+
+	return crud.SuccessAdvancedJSON(ctx, fiber.Map{
+		"members": merchant.FromMerchantUsers(users),
+	})
+}
+
+// Invite токен приглашения возвращается один раз, его нужно передать приглашённому
+func (con *teamController) Invite(ctx *fiber.Ctx) error {
+	dto, err := crud.ParseJSON(merchant.InviteDto{}, ctx)
+	if err != nil {
+		return crud.InvalidJSON(ctx)
+	}
+
+	scope := getScope(ctx)
+	res, err := services.MerchantAccountService().Invite(scope.OwnerID, scope.User.ID, dto)
+	if err != nil {
+		return crud.ErrorJSON(ctx, err.Error())
+	}
+
+	return crud.SuccessAdvancedJSON(ctx, fiber.Map{
+		"invitation": res,
+	})
+}
+
+func (con *teamController) UpdateMember(ctx *fiber.Ctx) error {
+	dto, err := crud.ParseJSON(merchant.UpdateMemberDto{}, ctx)
+	if err != nil {
+		return crud.InvalidJSON(ctx)
+	}
+
+	scope := getScope(ctx)
+	u, err := services.MerchantAccountService().UpdateMember(scope.OwnerID, scope.User, dto)
+	if u == nil && err != nil {
+		return crud.ErrorJSON(ctx, err.Error())
+	} else if err != nil {
+		return crud.ErrorJSON(ctx, "Error while saving")
+	}
+
+	return crud.SuccessAdvancedJSON(ctx, fiber.Map{
+		"member": merchant.FromMerchantUser(u),
+	})
+}
+
+func (con *teamController) RemoveMember(ctx *fiber.Ctx) error {
+	dto, err := crud.ParseJSON(merchant.MemberActionDto{}, ctx)
+	if err != nil {
+		return crud.InvalidJSON(ctx)
+	}
+
+	scope := getScope(ctx)
+	if err = services.MerchantAccountService().RemoveMember(scope.OwnerID, scope.User, dto.ID); err != nil {
+		return crud.ErrorJSON(ctx, err.Error())
+	}
+
+	return crud.SuccessJSON(ctx, fmt.Sprintf("Member #%d was removed", dto.ID))
+}
+
+func (con *teamController) Tokens(ctx *fiber.Ctx) error {
+	tokens, err := services.MerchantService().GetScopeTokens(getScope(ctx))
+	if err != nil {
+		return crud.ErrorJSON(ctx, "Unable to get tokens.")
+	}
+
+	return crud.SuccessAdvancedJSON(ctx, fiber.Map{
+		"tokens": merchant.FromOwnerTokens(tokens),
+	})
+}
+
+func (con *teamController) CreateToken(ctx *fiber.Ctx) error {
+	dto, err := crud.ParseJSON(merchant.CreateTokenDto{}, ctx)
+	if err != nil {
+		return crud.InvalidJSON(ctx)
+	}
+
+	token, t, err := services.MerchantService().IssueUserToken(getScope(ctx).User, dto)
+	if err != nil {
+		return crud.ErrorJSON(ctx, err.Error())
+	}
+
+	return crud.SuccessAdvancedJSON(ctx, fiber.Map{
+		"token":       token,
+		"owner_token": merchant.FromOwnerToken(t),
+	})
+}
+
+// RevokeToken участник отзывает свои токены, владелец любые токены мерчанта
+func (con *teamController) RevokeToken(ctx *fiber.Ctx) error {
+	dto, err := crud.ParseJSON(merchant.TokenActionDto{}, ctx)
+	if err != nil {
+		return crud.InvalidJSON(ctx)
+	}
+
+	t, err := services.MerchantService().RevokeScopeToken(getScope(ctx), dto.ID)
+	if t == nil && err != nil {
+		return crud.ErrorJSON(ctx, err.Error())
+	} else if err != nil {
+		return crud.ErrorJSON(ctx, "Error while saving")
+	}
+
+	return crud.SuccessAdvancedJSON(ctx, fiber.Map{
+		"owner_token": merchant.FromOwnerToken(t),
+	})
+}
@@ -2,29 +2,41 @@ package controllers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gofiber/fiber/v2"
+	"log"
 	"net/http"
+	"net/url"
 	"payment-go/internal/config"
+	"payment-go/internal/events"
 	"payment-go/internal/models"
 	"payment-go/internal/repositories"
 	"payment-go/internal/services"
 	"payment-go/internal/transport/model/order"
 	"payment-go/internal/utils/card_manager"
+	"payment-go/internal/utils/eventbus"
 	"sync"
-	"time"
 )
 
 type IOrderController interface {
 	Create(ctx *fiber.Ctx) error
 	CreateLinkSSE(w http.ResponseWriter, r *http.Request)
+	Stream(ctx *fiber.Ctx) error
+	StreamWebSocket(ctx *fiber.Ctx) error
+	Poll(ctx *fiber.Ctx) error
+	Return(ctx *fiber.Ctx) error
 	//CreateAndWaitForLink(ctx *fiber.Ctx) error
 	CheckStatus(ctx *fiber.Ctx) error
 	GetPaymentInfo(ctx *fiber.Ctx) error
 	//StartChecking(ctx *fiber.Ctx) error
+	Shutdown()
 }
 type orderController struct {
+	closing     chan struct{}
+	closingOnce sync.Once
 }
 
 var orderIns IOrderController
@@ -32,7 +44,9 @@ var orderOnce = sync.Once{}
 
 func OrderController() IOrderController {
 	orderOnce.Do(func() {
-		orderIns = &orderController{}
+		orderIns = &orderController{
+			closing: make(chan struct{}),
+		}
 	})
 	return orderIns
 }
@@ -194,6 +208,8 @@ type SSEOrderClient struct {
 	events chan string
 }
 
+// CreateLinkSSE ожидание ссылки на оплату, одно событие.
+// Deprecated: используйте поток этапов заказа Stream, StreamWebSocket или Poll
 func (c *orderController) CreateLinkSSE(w http.ResponseWriter, r *http.Request) {
 
 	orderNumber := r.URL.Query().Get("order_number")
@@ -210,11 +226,25 @@ func (c *orderController) CreateLinkSSE(w http.ResponseWriter, r *http.Request)
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 
+	// подписываемся до проверки ссылки, чтобы не пропустить событие её создания
+	ctx, cancel := context.WithTimeout(r.Context(), config.CreateLinkTimeout)
+	defer cancel()
+
+	linkChan := make(chan string, 1)
+	eventbus.Subscribe(ctx, services.EventBus(), func(e events.LinkReady) {
+		if e.OrderID != orderId {
+			return
+		}
+		select {
+		case linkChan <- e.URL:
+		default:
+		}
+	})
+
 	// если ссылка готова
 	link, err := repositories.PaymentLinkRepository().FindByOrderId(orderId)
 	if err == nil && link.Status == models.StatusPending {
 		fmt.Fprintf(w, "data: {\"link\":\"%v\"}\n\n", link.URL)
-		w.WriteHeader(200)
 		return
 	}
 
@@ -224,27 +254,6 @@ func (c *orderController) CreateLinkSSE(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	linkChan := make(chan string, 1)
-	defer func() {
-		close(linkChan)
-		linkChan = nil
-	}()
-
-	cb := func(entity any) {
-		//fmt.Printf("callback %#v\n", entity)
-		lnk, ok := entity.(*models.PaymentLink)
-		if ok {
-			linkChan <- lnk.URL
-		}
-	}
-
-	err = services.SubscriptionService().Subscribe(cb, services.FByID(link.ID, link))
-	if err != nil {
-		fmt.Println("--unable to subscribe", orderId, err)
-		w.WriteHeader(502)
-		return
-	}
-
 	select {
 	case lnk := <-linkChan:
 		if lnk == "" {
@@ -258,16 +267,45 @@ func (c *orderController) CreateLinkSSE(w http.ResponseWriter, r *http.Request)
 			"link": lnk,
 		})
 		fmt.Fprintf(w, "data: %v\n\n", buf.String())
-	case <-time.After(config.CreateLinkTimeout):
-		w.WriteHeader(504)
-		return
-		//fmt.Fprintf(w, ": nothing to sent\n\n")
-	case <-r.Context().Done():
+	case <-c.closing:
+		// сервер останавливается, клиент переподключится к другому узлу
+		fmt.Fprintf(w, "retry: %d\nevent: retry\ndata: {}\n\n", config.SSERetryDelay.Milliseconds())
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			w.WriteHeader(504)
+		}
 		return
 	}
 	f.Flush()
 }
 
+// Shutdown закрывает открытые SSE соединения, предлагая клиентам переподключиться
+func (c *orderController) Shutdown() {
+	c.closingOnce.Do(func() {
+		close(c.closing)
+	})
+}
+
+// Return перенаправляет плательщика в магазин после завершения оплаты
+func (c *orderController) Return(ctx *fiber.Ctx) error {
+	orderNumber := ctx.Params("order_number")
+	paymentPage := "/api/order/" + url.PathEscape(orderNumber) + "/process_to_payment"
+
+	ord, err := repositories.OrderRepository().FindByNumber(orderNumber)
+	if err != nil {
+		return ctx.Redirect(paymentPage)
+	}
+
+	link, err := services.OrderService().GetReturnUrl(ord)
+	if err != nil {
+		if !errors.Is(err, services.ErrNoReturnUrl) {
+			log.Printf("order %s return url: %v", orderNumber, err)
+		}
+		return ctx.Redirect(paymentPage)
+	}
+	return ctx.Redirect(link)
+}
+
 func (c *orderController) CheckStatus(ctx *fiber.Ctx) error {
 	orderNumber := ctx.Params("order_number")
 
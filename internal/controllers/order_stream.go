@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"net/url"
+	"payment-go/internal/config"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories"
+	"payment-go/internal/services"
+	"payment-go/internal/transport/model/order"
+	"payment-go/internal/utils/websocket"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Stream поток этапов заказа по SSE. Last-Event-ID (или last_event_id) продолжает поток после переподключения
+func (c *orderController) Stream(ctx *fiber.Ctx) error {
+	ord, lastEventId, err := findStreamOrder(ctx)
+	if err != nil {
+		return ctx.SendStatus(fiber.StatusNotFound)
+	}
+	// 204 останавливает переподключения EventSource, когда итоговый статус уже получен
+	if lastEventId >= order.StatusEventFinalStage {
+		return ctx.SendStatus(fiber.StatusNoContent)
+	}
+
+	ctx.Set(fiber.HeaderContentType, "text/event-stream")
+	ctx.Set(fiber.HeaderCacheControl, "no-cache")
+	ctx.Set(fiber.HeaderConnection, "keep-alive")
+	ctx.Set("X-Accel-Buffering", "no")
+
+	ctx.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		streamCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		stream := services.OrderStreamService().Subscribe(streamCtx, ord, lastEventId)
+		heartbeat := time.NewTicker(config.StreamHeartbeat)
+		defer heartbeat.Stop()
+
+		fmt.Fprintf(w, "retry: %d\n\n", config.SSERetryDelay.Milliseconds())
+		for {
+			// ошибка записи означает, что клиент отключился
+			if err := w.Flush(); err != nil {
+				return
+			}
+
+			select {
+			case ev, ok := <-stream:
+				if !ok {
+					return
+				}
+				data, err := json.Marshal(ev)
+				if err != nil {
+					return
+				}
+				fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.Id, ev.Event, data)
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": ping\n\n")
+			case <-c.closing:
+				// сервер останавливается, клиент переподключится к другому узлу
+				fmt.Fprintf(w, "retry: %d\nevent: retry\ndata: {}\n\n", config.SSERetryDelay.Milliseconds())
+				_ = w.Flush()
+				return
+			}
+		}
+	})
+	return nil
+}
+
+// StreamWebSocket поток этапов заказа по WebSocket, каждое сообщение - StatusEventDto
+func (c *orderController) StreamWebSocket(ctx *fiber.Ctx) error {
+	ord, lastEventId, err := findStreamOrder(ctx)
+	if err != nil {
+		return ctx.SendStatus(fiber.StatusNotFound)
+	}
+
+	return websocket.Upgrade(ctx, streamOriginChecker(ord), func(conn *websocket.Conn) {
+		streamCtx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go func() {
+			_ = conn.ReadLoop()
+			cancel()
+		}()
+
+		stream := services.OrderStreamService().Subscribe(streamCtx, ord, lastEventId)
+		heartbeat := time.NewTicker(config.StreamHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			var err error
+			select {
+			case ev, ok := <-stream:
+				if !ok {
+					_ = conn.Close(websocket.CloseNormal)
+					return
+				}
+				var data []byte
+				if data, err = json.Marshal(ev); err == nil {
+					err = conn.WriteText(data)
+				}
+			case <-heartbeat.C:
+				err = conn.Ping()
+			case <-c.closing:
+				_ = conn.Close(websocket.CloseGoingAway)
+				return
+			case <-streamCtx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	})
+}
+
+// streamOriginChecker поток открывают страница оплаты и сайт магазина, которому принадлежит заказ
+func streamOriginChecker(ord *models.Order) websocket.OriginChecker {
+	return func(origin *url.URL) bool {
+		for _, allowed := range []string{config.GetConfig().AppHost, ord.Shop.Host} {
+			u, err := url.Parse(allowed)
+			if err == nil && len(u.Host) != 0 && strings.EqualFold(u.Host, origin.Host) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Poll long-poll этапов заказа: ждёт следующие после last_event_id не дольше LongPollTimeout
+func (c *orderController) Poll(ctx *fiber.Ctx) error {
+	ord, lastEventId, err := findStreamOrder(ctx)
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"success": false,
+			"error":   fmt.Sprintf("Order with number '%s' not found.", ctx.Params("order_number")),
+		})
+	}
+
+	pollCtx, cancel := context.WithTimeout(context.Background(), config.LongPollTimeout)
+	defer cancel()
+
+	res := &order.StatusEventsResponseDto{
+		Success:     true,
+		Events:      make([]*order.StatusEventDto, 0),
+		LastEventId: lastEventId,
+	}
+	stream := services.OrderStreamService().Subscribe(pollCtx, ord, lastEventId)
+
+	// ждём первое событие, остальные уже доступные забираем без ожидания
+	select {
+	case ev, ok := <-stream:
+		for ok {
+			res.Events = append(res.Events, ev)
+			res.LastEventId = ev.Id
+			select {
+			case ev, ok = <-stream:
+			case <-time.After(10 * time.Millisecond):
+				ok = false
+			}
+		}
+	case <-c.closing:
+	case <-pollCtx.Done():
+	}
+	return ctx.JSON(res)
+}
+
+func findStreamOrder(ctx *fiber.Ctx) (*models.Order, uint, error) {
+	ord, err := repositories.OrderRepository().FindByNumber(ctx.Params("order_number"))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	lastEventId := ctx.Get("Last-Event-ID", ctx.Query("last_event_id"))
+	id, err := strconv.ParseUint(lastEventId, 10, 32)
+	if err != nil {
+		id = 0
+	}
+	return ord, uint(id), nil
+}
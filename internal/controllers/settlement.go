@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"payment-go/internal/services"
+	"payment-go/internal/transport/model/settlement"
+	"strconv"
+	"sync"
+)
+
+type ISettlementController interface {
+	Download(ctx *fiber.Ctx) error
+}
+type settlementController struct {
+}
+
+var settlementIns ISettlementController
+var settlementOnce = sync.Once{}
+
+func SettlementController() ISettlementController {
+	settlementOnce.Do(func() {
+		settlementIns = &settlementController{}
+	})
+	return settlementIns
+}
+
+// Download выгрузка отчёта магазином по подписанному запросу
+func (c *settlementController) Download(ctx *fiber.Ctx) error {
+	timestamp, err := strconv.ParseInt(ctx.Query("timestamp"), 10, 64)
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"success": false,
+			"error":   "timestamp is invalid or not specified",
+		})
+	}
+
+	dto := &settlement.DownloadSettlementDto{
+		PublicKey: ctx.Query("public_key"),
+		Date:      ctx.Query("date"),
+		Format:    ctx.Query("format"),
+		Timestamp: timestamp,
+		Signature: ctx.Query("signature"),
+	}
+
+	stl, err := services.SettlementService().GetForDownload(dto)
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	if dto.Format == settlement.FormatCSV {
+		data, err := settlement.ToCSV(stl)
+		if err != nil {
+			return ctx.JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+		ctx.Set(fiber.HeaderContentType, "text/csv")
+		ctx.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=\"settlement_%s.csv\"", stl.Date))
+		return ctx.Send(data)
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success":    true,
+		"settlement": settlement.FromSettlement(stl),
+	})
+}
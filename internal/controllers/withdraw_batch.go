@@ -0,0 +1,52 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"payment-go/internal/services"
+	"payment-go/internal/transport/model/withdraw"
+	"sync"
+)
+
+type IWithdrawBatchController interface {
+	Create(ctx *fiber.Ctx) error
+}
+type withdrawBatchController struct {
+}
+
+var withdrawBatchIns IWithdrawBatchController
+var withdrawBatchOnce = sync.Once{}
+
+func WithdrawBatchController() IWithdrawBatchController {
+	withdrawBatchOnce.Do(func() {
+		withdrawBatchIns = &withdrawBatchController{}
+	})
+	return withdrawBatchIns
+}
+
+func (c *withdrawBatchController) Create(ctx *fiber.Ctx) error {
+	dto, err := withdraw.ParseCreateBatchDtoFromJSON(ctx.Body())
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	b, results, err := services.WithdrawBatchService().CreateFromDto(dto)
+	if err != nil {
+		return ctx.JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+			"items":   results,
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success":      true,
+		"message":      "Withdraw batch successfully created.",
+		"batch_number": b.Number.String(),
+		"total":        b.Total,
+		"total_amount": b.TotalAmount,
+		"items":        results,
+	})
+}
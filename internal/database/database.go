@@ -31,6 +31,18 @@ func GetDSNFromConfig(config *config.Config) string {
 	)
 }
 
+// Close закрывает пул соединений при остановке приложения
+func Close() error {
+	if db == nil {
+		return nil
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDb.Close()
+}
+
 func MakeMigrations(dst []interface{}) error {
 	return db.AutoMigrate(dst...)
 }
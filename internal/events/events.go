@@ -0,0 +1,89 @@
+package events
+
+import (
+	"github.com/google/uuid"
+	"payment-go/internal/models"
+	"payment-go/internal/transport/model/order"
+)
+
+// OrderCreated заказ сохранён, карта для оплаты выбрана
+type OrderCreated struct {
+	Order *models.Order
+	Dto   *order.CreateOrderDto
+}
+
+// OrderUpdated заказ изменён вручную
+type OrderUpdated struct {
+	Old *models.Order
+	New *models.Order
+}
+
+// OrderFinished заказ завершён с итоговым статусом. Доставляется через outbox, возможны повторы с тем же EventID
+type OrderFinished struct {
+	EventID uuid.UUID
+	Order   *models.Order
+}
+
+// LinkCreated фоновое создание ссылки на оплату закончилось (успешно или со статусом failed)
+type LinkCreated struct {
+	Link *models.PaymentLink
+}
+
+// LinkReady уведомление об итоге создания ссылки, рассылается всем экземплярам приложения
+type LinkReady struct {
+	OrderID uint   `json:"order_id"`
+	URL     string `json:"url"`
+	Status  string `json:"status"`
+}
+
+// PaymentDetected банк или смс подтвердили оплату, заказ ещё завершается
+type PaymentDetected struct {
+	Order *models.Order
+}
+
+// OrderStatusChanged этап потока статусов заказа, рассылается всем экземплярам приложения
+type OrderStatusChanged struct {
+	OrderID uint                 `json:"order_id"`
+	Event   order.StatusEventDto `json:"event"`
+}
+
+// CardBalanceIncreased баланс карты увеличен
+type CardBalanceIncreased struct {
+	Card *models.Card
+}
+
+// CardDisabled карта отключена автоматически
+type CardDisabled struct {
+	Card   *models.Card
+	Reason string
+}
+
+// NoCardsAvailable не нашлось карты для оплаты. PaymentMethod nil, если карт нет совсем
+type NoCardsAvailable struct {
+	PaymentMethod *string
+}
+
+// DisputeLost спор по заказу проигран
+type DisputeLost struct {
+	Dispute *models.Dispute
+}
+
+// SettlementMismatch сумма заказов не сходится с суммой банковских сообщений
+type SettlementMismatch struct {
+	Settlement *models.Settlement
+}
+
+// WithdrawUpdated статус вывода изменён. Доставляется через outbox, возможны повторы с тем же EventID
+type WithdrawUpdated struct {
+	EventID  uuid.UUID
+	Withdraw *models.Withdraw
+}
+
+// WithdrawBatchCompleted пакет выводов завершён или отклонён. Доставляется через outbox, возможны повторы с тем же EventID
+type WithdrawBatchCompleted struct {
+	EventID uuid.UUID
+	Batch   *models.WithdrawBatch
+}
+
+const CardDisabledReasonBalance = "balance"
+const CardDisabledReasonDisputes = "disputes"
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+const AdminRoleViewer = "viewer"
+const AdminRoleOperator = "operator"
+const AdminRoleFinance = "finance"
+const AdminRoleAdmin = "admin"
+
+// Permission право на группу действий в /crud и /analytics
+type Permission string
+
+const PermissionView Permission = "view"       // просмотр и поиск
+const PermissionOperate Permission = "operate" // смс, споры, ручная работа с заказами
+const PermissionFinance Permission = "finance" // выводы, балансы, сверки
+const PermissionManage Permission = "manage"   // магазины, карты, пользователи, настройки
+
+var rolePermissions = map[string][]Permission{
+	AdminRoleViewer:   {PermissionView},
+	AdminRoleOperator: {PermissionView, PermissionOperate},
+	AdminRoleFinance:  {PermissionView, PermissionFinance},
+	AdminRoleAdmin:    {PermissionView, PermissionOperate, PermissionFinance, PermissionManage},
+}
+
+// AdminUser пользователь админки
+type AdminUser struct {
+	gorm.Model
+	Login        string `gorm:"column:login;type:char(63);unique;not null;<-:create"`
+	PasswordHash string `gorm:"column:password_hash;type:char(255);not null"`
+	Role         string `gorm:"column:role;type:char(31);not null"`
+	Disabled     bool   `gorm:"column:disabled;not null;default:false"`
+	LastLoginAt  *uint  `gorm:"column:last_login_at"`
+}
+
+func IsAdminRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+func (u *AdminUser) Can(p Permission) bool {
+	if u.Disabled {
+		return false
+	}
+	for _, perm := range rolePermissions[u.Role] {
+		if perm == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"errors"
+	"gorm.io/gorm"
+	"time"
+)
+
+var ErrAuditAppendOnly = errors.New("audit log is append-only")
+
+const (
+	AuditActorAdmin    = "admin"
+	AuditActorMerchant = "merchant"
+)
+
+// AuditLog действие пользователя админки или мерчанта. Записи только добавляются
+type AuditLog struct {
+	ID        uint      `gorm:"primarykey"`
+	CreatedAt time.Time `gorm:"index"`
+	// ActorType admin - ActorID из admin_users, merchant - из merchant_users (0 для владельца по ключу магазина)
+	ActorType  string `gorm:"column:actor_type;type:char(31);not null;default:admin;<-:create"`
+	ActorID    uint   `gorm:"column:actor_id;index;not null;<-:create"`
+	ActorLogin string `gorm:"column:actor_login;type:char(63);not null;<-:create"`
+	Action     string `gorm:"column:action;type:char(63);index;not null;<-:create"`
+	EntityType string `gorm:"column:entity_type;type:char(63);index:idx_audit_entity;not null;<-:create"`
+	EntityID   uint   `gorm:"column:entity_id;index:idx_audit_entity;not null;<-:create"`
+	// Changes json {"поле": {"before": ..., "after": ...}}, секреты скрыты
+	Changes   string `gorm:"column:changes;type:text;<-:create"`
+	IP        string `gorm:"column:ip;type:char(63);not null;<-:create"`
+	UserAgent string `gorm:"column:user_agent;type:text(511);<-:create"`
+}
+
+func (l *AuditLog) BeforeUpdate(tx *gorm.DB) error {
+	return ErrAuditAppendOnly
+}
+
+func (l *AuditLog) BeforeDelete(tx *gorm.DB) error {
+	return ErrAuditAppendOnly
+}
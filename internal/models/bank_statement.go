@@ -0,0 +1,76 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+const StatementLineStatusUnmatched = "unmatched"
+const StatementLineStatusMatched = "matched"
+const StatementLineStatusLinked = "linked"
+const StatementLineStatusIgnored = "ignored"
+
+// BankStatement загруженный файл выписки банка
+type BankStatement struct {
+	gorm.Model
+	FileName       string               `gorm:"column:file_name;type:char(255);not null"`
+	Format         string               `gorm:"column:format;type:char(63);not null"`
+	FileHash       *string              `gorm:"column:file_hash;type:char(64);unique;<-:create"` // sha256 файла, у загруженных до проверки пустой
+	LinesCount     uint                 `gorm:"column:lines_count;not null;default:0"`
+	MatchedCount   uint                 `gorm:"column:matched_count;not null;default:0"`
+	DuplicateCount uint                 `gorm:"column:duplicate_count;not null;default:0"` // строки, уже загруженные с другими выписками
+	Lines          []*BankStatementLine `gorm:"foreignKey:StatementID"`
+}
+
+// BankStatementLine зачисление из выписки
+type BankStatementLine struct {
+	gorm.Model
+	StatementID   uint    `gorm:"column:statement_id;index;not null;<-:create"`
+	Account       string  `gorm:"column:account;type:char(63);index;not null"`
+	Amount        float64 `gorm:"column:amount;not null"`
+	BookedAt      uint    `gorm:"column:booked_at;index;not null"`
+	Reference     string  `gorm:"column:reference;type:char(255)"`
+	Description   string  `gorm:"column:description;type:text(1023)"`
+	BankMessageID *uint   `gorm:"column:bank_message_id;index"`
+	BankMessage   *BankMessage
+	OrderID       *uint `gorm:"column:order_id;index"`
+	Order         *Order
+	Status        string `gorm:"column:status;type:char(63);not null"`
+}
+
+func (l *BankStatementLine) BeforeSave(tx *gorm.DB) error {
+	if len(l.Status) == 0 {
+		l.Status = StatementLineStatusUnmatched
+	}
+	switch l.Status {
+	case StatementLineStatusUnmatched:
+	case StatementLineStatusMatched:
+	case StatementLineStatusLinked:
+	case StatementLineStatusIgnored:
+		break
+	default:
+		return ErrUnknownStatus
+	}
+	return nil
+}
+
+func (l *BankStatementLine) IsResolved() bool {
+	return l.Status != StatementLineStatusUnmatched
+}
+
+// Match строка сопоставлена автоматически
+func (l *BankStatementLine) Match(msg *BankMessage, orderId uint) {
+	if msg != nil {
+		l.BankMessageID = &msg.ID
+		l.BankMessage = msg
+	}
+	if orderId != 0 {
+		l.OrderID = &orderId
+	}
+	l.Status = StatementLineStatusMatched
+}
+
+// Link строка привязана к заказу оператором
+func (l *BankStatementLine) Link(orderId uint) {
+	l.OrderID = &orderId
+	l.Status = StatementLineStatusLinked
+}
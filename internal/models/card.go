@@ -21,6 +21,7 @@ type Card struct {
 
 type CardStats struct {
 	TotalPaymentSum float64 `gorm:"column:total_payment_sum"`
+	DisputesLost    uint    `gorm:"column:disputes_lost;not null;default:0"`
 }
 
 const CardStatusEnabled = "enabled"
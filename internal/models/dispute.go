@@ -0,0 +1,100 @@
+package models
+
+import (
+	"errors"
+	"gorm.io/gorm"
+)
+
+const DisputeStatusOpened = "opened"
+const DisputeStatusEvidenceSubmitted = "evidence_submitted"
+const DisputeStatusWon = "won"
+const DisputeStatusLost = "lost"
+
+type Dispute struct {
+	gorm.Model
+	OrderID    uint    `gorm:"column:order_id;index;not null;<-:create"`
+	Order      Order   //`gorm:"foreignKey:ID;references:order_id"`
+	CardID     uint    `gorm:"column:card_id;index;not null;<-:create"`
+	Card       Card    //`gorm:"foreignKey:ID;references:card_id"`
+	ShopID     uint    `gorm:"column:shop_id;index;not null;<-:create"`
+	Amount     float64 `gorm:"column:amount;not null"`
+	Reason     string  `gorm:"column:reason;type:text(1023)"`
+	DeadlineAt *uint   `gorm:"column:deadline_at;index"`
+	ClosedAt   *uint   `gorm:"column:closed_at"`
+	Status     string  `gorm:"column:status;type:char(63);not null"`
+	Evidence   []*DisputeEvidence
+}
+
+// DisputeEvidence доказательство по спору: сообщение банка и/или произвольный payload
+type DisputeEvidence struct {
+	gorm.Model
+	DisputeID     uint  `gorm:"column:dispute_id;index;not null;<-:create"`
+	BankMessageID *uint `gorm:"column:bank_message_id"`
+	BankMessage   *BankMessage
+	Payload       string `gorm:"column:payload;type:text"`
+}
+
+var ErrDisputeClosed = errors.New("dispute is already closed")
+var ErrDisputeExpired = errors.New("dispute evidence deadline has passed")
+
+func NewDispute(ord *Order, amount float64, reason string, deadline *uint) *Dispute {
+	return &Dispute{
+		OrderID:    ord.ID,
+		Order:      *ord,
+		CardID:     ord.CardID,
+		ShopID:     ord.ShopID,
+		Amount:     amount,
+		Reason:     reason,
+		DeadlineAt: deadline,
+		Status:     DisputeStatusOpened,
+	}
+}
+
+func (d *Dispute) BeforeSave(tx *gorm.DB) error {
+	if len(d.Status) == 0 {
+		d.Status = DisputeStatusOpened
+	}
+	switch d.Status {
+	case DisputeStatusOpened:
+	case DisputeStatusEvidenceSubmitted:
+	case DisputeStatusWon:
+	case DisputeStatusLost:
+		break
+	default:
+		return ErrUnknownStatus
+	}
+	return nil
+}
+
+func (d *Dispute) IsClosed() bool {
+	return d.Status == DisputeStatusWon || d.Status == DisputeStatusLost
+}
+
+// IsExpired срок подачи доказательств истёк
+func (d *Dispute) IsExpired(now uint) bool {
+	return !d.IsClosed() && d.DeadlineAt != nil && *d.DeadlineAt < now
+}
+
+func (d *Dispute) SubmitEvidence(now uint) error {
+	if d.IsClosed() {
+		return ErrDisputeClosed
+	}
+	if d.IsExpired(now) {
+		return ErrDisputeExpired
+	}
+	d.Status = DisputeStatusEvidenceSubmitted
+	return nil
+}
+
+func (d *Dispute) Resolve(won bool, moment uint) error {
+	if d.IsClosed() {
+		return ErrDisputeClosed
+	}
+	if won {
+		d.Status = DisputeStatusWon
+	} else {
+		d.Status = DisputeStatusLost
+	}
+	d.ClosedAt = &moment
+	return nil
+}
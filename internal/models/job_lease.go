@@ -0,0 +1,19 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// JobLease аренда фоновой задачи: задачу выполняет только владелец, пока продлевает аренду
+type JobLease struct {
+	gorm.Model
+	Name        string `gorm:"column:name;type:char(63);unique;not null;<-:create"`
+	Owner       string `gorm:"column:owner;type:char(255);not null"`
+	ExpiresAt   uint   `gorm:"column:expires_at;not null"`
+	HeartbeatAt uint   `gorm:"column:heartbeat_at;not null"`
+	Renewals    uint   `gorm:"column:renewals;not null;default:0"`
+}
+
+func (l *JobLease) IsExpired(moment uint) bool {
+	return l.ExpiresAt < moment
+}
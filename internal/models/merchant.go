@@ -0,0 +1,75 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+const MerchantRoleOwner = "owner"
+const MerchantRoleDeveloper = "developer"
+const MerchantRoleAccountant = "accountant"
+
+// MerchantPermission право участника мерчанта в merchant API
+type MerchantPermission string
+
+const MerchantPermissionOrders MerchantPermission = "orders"           // просмотр заказов
+const MerchantPermissionFinance MerchantPermission = "finance"         // балансы и выводы
+const MerchantPermissionIntegration MerchantPermission = "integration" // вебхуки, ключи, проверка хоста
+const MerchantPermissionTeam MerchantPermission = "team"               // участники и приглашения
+
+var merchantRolePermissions = map[string][]MerchantPermission{
+	MerchantRoleOwner:      {MerchantPermissionOrders, MerchantPermissionFinance, MerchantPermissionIntegration, MerchantPermissionTeam},
+	MerchantRoleDeveloper:  {MerchantPermissionOrders, MerchantPermissionIntegration},
+	MerchantRoleAccountant: {MerchantPermissionOrders, MerchantPermissionFinance},
+}
+
+// ShopKeyPermissions права запроса с ключами магазина или токена, выпущенного на весь мерчант. Команда им недоступна
+var ShopKeyPermissions = []MerchantPermission{MerchantPermissionOrders, MerchantPermissionFinance, MerchantPermissionIntegration}
+
+// Merchant владелец магазинов. Его id хранится в Shop.OwnerId
+type Merchant struct {
+	gorm.Model
+	Name   string `gorm:"column:name;type:char(255);not null"`
+	Active bool   `gorm:"column:active;not null;default:true"`
+}
+
+// MerchantUser участник мерчанта. Пока приглашение не принято, пароля нет и войти нельзя
+type MerchantUser struct {
+	gorm.Model
+	MerchantId      uint   `gorm:"column:merchant_id;index;not null;<-:create"`
+	Email           string `gorm:"column:email;type:char(255);unique;not null;<-:create"`
+	Name            string `gorm:"column:name;type:char(255)"`
+	Role            string `gorm:"column:role;type:char(31);not null"`
+	PasswordHash    string `gorm:"column:password_hash;type:char(255)"`
+	InviteHash      string `gorm:"column:invite_hash;type:char(64);index"`
+	InviteExpiresAt *uint  `gorm:"column:invite_expires_at"`
+	InvitedBy       uint   `gorm:"column:invited_by"`
+	JoinedAt        *uint  `gorm:"column:joined_at"`
+	Disabled        bool   `gorm:"column:disabled;not null;default:false"`
+	LastLoginAt     *uint  `gorm:"column:last_login_at"`
+}
+
+func IsMerchantRole(role string) bool {
+	_, ok := merchantRolePermissions[role]
+	return ok
+}
+
+func MerchantRolePermissions(role string) []MerchantPermission {
+	return merchantRolePermissions[role]
+}
+
+// IsActive участник принял приглашение и не заблокирован
+func (u *MerchantUser) IsActive() bool {
+	return u.JoinedAt != nil && !u.Disabled
+}
+
+func (u *MerchantUser) Can(p MerchantPermission) bool {
+	if !u.IsActive() {
+		return false
+	}
+	for _, perm := range merchantRolePermissions[u.Role] {
+		if perm == p {
+			return true
+		}
+	}
+	return false
+}
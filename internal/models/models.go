@@ -11,6 +11,28 @@ func GetModels() []interface{} {
 		&CardInfo{},
 		&BankMessage{},
 		&Withdraw{},
+		&Dispute{},
+		&DisputeEvidence{},
+		&WithdrawPayout{},
+		&WithdrawBatch{},
+		&WithdrawBatchItem{},
+		&WithdrawRiskCheck{},
+		&WithdrawRiskOutcome{},
+		&WithdrawApproval{},
+		&Settlement{},
+		&SettlementLine{},
+		&BankStatement{},
+		&BankStatementLine{},
+		&OutboxEvent{},
+		&OutboxConsumption{},
+		&JobLease{},
+		&ScheduledJob{},
+		&ScheduledJobRun{},
+		&AdminUser{},
+		&AuditLog{},
+		&Merchant{},
+		&MerchantUser{},
+		&OwnerToken{},
 	)
 	return models
 }
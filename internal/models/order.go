@@ -23,6 +23,10 @@ type Order struct {
 	Amount        float64 `gorm:"column:amount;not null"`
 	DatePaid      *uint   `gorm:"column:date_paid;index"`
 	Status        string  `gorm:"column:status;type:char(63);not null"`
+
+	// куда вернуть плательщика, если не указано - используются адреса магазина
+	SuccessUrl *string `gorm:"column:success_url;type:text(1023);<-:create"`
+	FailUrl    *string `gorm:"column:fail_url;type:text(1023);<-:create"`
 }
 
 func (o *Order) BeforeCreate(tx *gorm.DB) (err error) {
@@ -64,7 +68,7 @@ func (o *Order) GetCardType() string {
 }
 
 func (o *Order) HaveLink() bool {
-	return o.PaymentMethod == config.PaymentMethodKapitalBank
+	return len(config.GetConfig().Bank.GetMethodBanks(o.PaymentMethod)) != 0
 }
 
 func (o *Order) GetCardIfPublic() *string {
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const OutboxStatusPending = "pending"
+const OutboxStatusDone = "done"
+const OutboxStatusFailed = "failed"
+
+const OutboxTypeOrderFinished = "order.finished"
+const OutboxTypeWithdrawUpdated = "withdraw.updated"
+const OutboxTypeSettlementMismatch = "settlement.mismatch"
+const OutboxTypeWithdrawBatchCompleted = "withdraw_batch.completed"
+
+// OutboxEvent доменное событие, записанное в одной транзакции с изменением сущности.
+// EventID служит ключом дедупликации для получателей.
+type OutboxEvent struct {
+	gorm.Model
+	EventID       uuid.UUID `gorm:"column:event_id;type:char(36);unique;not null;<-:create"`
+	Type          string    `gorm:"column:type;type:char(63);not null;<-:create"`
+	AggregateID   uint      `gorm:"column:aggregate_id;not null;<-:create"`
+	Attempts      uint      `gorm:"column:attempts;not null;default:0"`
+	NextAttemptAt uint      `gorm:"column:next_attempt_at;index;not null;default:0"`
+	PublishedAt   *uint     `gorm:"column:published_at"` // событие отправлено в шину
+	DeliveredAt   *uint     `gorm:"column:delivered_at"` // вебхук доставлен
+	LastError     string    `gorm:"column:last_error;type:text(1023)"`
+	Status        string    `gorm:"column:status;type:char(63);index;not null"`
+}
+
+func NewOutboxEvent(typ string, aggregateId uint) *OutboxEvent {
+	return &OutboxEvent{
+		EventID:     uuid.New(),
+		Type:        typ,
+		AggregateID: aggregateId,
+		Status:      OutboxStatusPending,
+	}
+}
+
+func (e *OutboxEvent) BeforeSave(tx *gorm.DB) error {
+	switch e.Status {
+	case OutboxStatusPending:
+	case OutboxStatusDone:
+	case OutboxStatusFailed:
+		break
+	default:
+		return ErrUnknownStatus
+	}
+	return nil
+}
+
+// OutboxConsumption отметка об обработке события подписчиком, защищает побочные эффекты от повторной доставки
+type OutboxConsumption struct {
+	gorm.Model
+	EventID  uuid.UUID `gorm:"column:event_id;type:char(36);uniqueIndex:idx_outbox_consumer;not null;<-:create"`
+	Consumer string    `gorm:"column:consumer;type:char(63);uniqueIndex:idx_outbox_consumer;not null;<-:create"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// OwnerToken токен мерчанта для merchant API. Сам токен не хранится, только его sha256.
+// Токен участника действует с правами его роли, токен без участника выпущен из админки на весь мерчант
+type OwnerToken struct {
+	gorm.Model
+	OwnerId        uint   `gorm:"column:owner_id;index;not null;<-:create"`
+	MerchantUserId uint   `gorm:"column:merchant_user_id;index;not null;default:0;<-:create"`
+	Name           string `gorm:"column:name;type:char(63);not null"`
+	TokenHash      string `gorm:"column:token_hash;type:char(64);unique;not null;<-:create"`
+	LastUsedAt     *uint  `gorm:"column:last_used_at"`
+	RevokedAt      *uint  `gorm:"column:revoked_at"`
+}
+
+func (t *OwnerToken) IsActive() bool {
+	return t.RevokedAt == nil
+}
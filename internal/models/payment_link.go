@@ -3,12 +3,14 @@ package models
 import (
 	"fmt"
 	"gorm.io/gorm"
+	"time"
 )
 
 type PaymentLink struct {
 	gorm.Model
 	OrderID         uint `gorm:"index"`
 	Order           Order
+	Bank            string  `gorm:"column:bank;type:char(63);not null;default:''"` // банк, выдавший ссылку, пусто - банк по умолчанию
 	CheckMerchantId string  `gorm:"column:check_merchant_id;type:char(255);not null"`
 	Amount          float64 `gorm:"column:amount;not null"`
 	CardType        string  `gorm:"column:card_type;type:char(63);not null"`
@@ -16,6 +18,13 @@ type PaymentLink struct {
 	TransactionId   string  `gorm:"column:transaction_id;type:char(127)"`
 	DatePaid        *uint   `gorm:"column:date_paid"`
 	Status          string  `gorm:"column:status;type:char(63);not null"`
+
+	// состояние проверки сохраняется, чтобы она продолжилась после перезапуска
+	CheckAttempts    uint       `gorm:"column:check_attempts;not null;default:0"`
+	CheckStartedAt   *time.Time `gorm:"column:check_started_at"`
+	LastCheckedAt    *time.Time `gorm:"column:last_checked_at"`
+	NextCheckAt      *time.Time `gorm:"column:next_check_at"`
+	LastBankResponse string     `gorm:"column:last_bank_response;type:text"`
 }
 
 const StatusNew = "new"
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+const JobRunTriggerSchedule = "schedule"
+const JobRunTriggerManual = "manual"
+
+// ScheduledJob состояние задачи планировщика. Для общих задач время следующего запуска переживает перезапуск
+type ScheduledJob struct {
+	gorm.Model
+	Name         string `gorm:"column:name;type:char(63);unique;not null;<-:create"`
+	Spec         string `gorm:"column:spec;type:char(127);not null"`
+	Local        bool   `gorm:"column:local;not null;default:false"` // выполняется на каждом узле
+	NextRunAt    *uint  `gorm:"column:next_run_at"`
+	LastRunAt    *uint  `gorm:"column:last_run_at"`
+	LastDuration uint   `gorm:"column:last_duration;not null;default:0"` // мс
+	LastError    string `gorm:"column:last_error;type:text(1023)"`
+	Triggered    bool   `gorm:"column:triggered;not null;default:false"` // запрошен ручной запуск
+}
+
+// ScheduledJobRun запуск задачи планировщика
+type ScheduledJobRun struct {
+	gorm.Model
+	JobName    string `gorm:"column:job_name;type:char(63);index;not null;<-:create"`
+	Node       string `gorm:"column:node;type:char(255);not null;<-:create"`
+	Trigger    string `gorm:"column:trigger_type;type:char(63);not null;<-:create"`
+	StartedAt  uint   `gorm:"column:started_at;not null;<-:create"`
+	FinishedAt *uint  `gorm:"column:finished_at"`
+	Duration   uint   `gorm:"column:duration;not null;default:0"` // мс
+	Error      string `gorm:"column:error;type:text(1023)"`
+}
+
+func (r *ScheduledJobRun) Finish(moment uint, duration uint, err error) {
+	r.FinishedAt = &moment
+	r.Duration = duration
+	if err != nil {
+		r.Error = err.Error()
+	}
+}
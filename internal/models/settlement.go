@@ -0,0 +1,81 @@
+package models
+
+import (
+	"gorm.io/gorm"
+	"math"
+)
+
+const SettlementLineOrder = "order"
+const SettlementLineFee = "fee"
+const SettlementLineRefund = "refund"
+const SettlementLineWithdraw = "withdraw"
+
+// Settlement итоги дня по магазину
+type Settlement struct {
+	gorm.Model
+	ShopID             uint              `gorm:"column:shop_id;uniqueIndex:idx_settlement_shop_date;not null;<-:create"`
+	Shop               Shop              //`gorm:"foreignKey:ID;references:shop_id"`
+	Date               string            `gorm:"column:date;type:char(10);uniqueIndex:idx_settlement_shop_date;not null;<-:create"`
+	PeriodFrom         uint              `gorm:"column:period_from;not null"`
+	PeriodTo           uint              `gorm:"column:period_to;not null"`
+	OrdersCount        uint              `gorm:"column:orders_count;not null;default:0"`
+	OrdersAmount       float64           `gorm:"column:orders_amount;not null;default:0"`
+	Fees               float64           `gorm:"column:fees;not null;default:0"`
+	Refunds            float64           `gorm:"column:refunds;not null;default:0"`
+	Withdrawals        float64           `gorm:"column:withdrawals;not null;default:0"`
+	Net                float64           `gorm:"column:net;not null;default:0"`
+	BankMessagesAmount float64           `gorm:"column:bank_messages_amount;not null;default:0"`
+	Mismatch           bool              `gorm:"column:mismatch;not null;default:false"`
+	Lines              []*SettlementLine `gorm:"foreignKey:SettlementID"`
+}
+
+// SettlementLine строка отчёта. Суммы списаний отрицательные
+type SettlementLine struct {
+	gorm.Model
+	SettlementID uint    `gorm:"column:settlement_id;index;not null;<-:create"`
+	Type         string  `gorm:"column:type;type:char(63);not null"`
+	ReferenceID  uint    `gorm:"column:reference_id;not null"`
+	Reference    string  `gorm:"column:reference;type:char(255)"`
+	Amount       float64 `gorm:"column:amount;not null"`
+	Moment       uint    `gorm:"column:moment;not null"`
+}
+
+func NewSettlement(shopId uint, date string, from, to uint) *Settlement {
+	return &Settlement{
+		ShopID:     shopId,
+		Date:       date,
+		PeriodFrom: from,
+		PeriodTo:   to,
+	}
+}
+
+func (s *Settlement) AddLine(typ string, referenceId uint, reference string, amount float64, moment uint) {
+	s.Lines = append(s.Lines, &SettlementLine{
+		SettlementID: s.ID,
+		Type:         typ,
+		ReferenceID:  referenceId,
+		Reference:    reference,
+		Amount:       amount,
+		Moment:       moment,
+	})
+
+	switch typ {
+	case SettlementLineOrder:
+		s.OrdersCount++
+		s.OrdersAmount += amount
+	case SettlementLineFee:
+		s.Fees -= amount
+	case SettlementLineRefund:
+		s.Refunds -= amount
+	case SettlementLineWithdraw:
+		s.Withdrawals -= amount
+	}
+	s.Net += amount
+}
+
+// CheckBankMessages сравнивает сумму заказов с суммой сообщений банка за тот же период
+func (s *Settlement) CheckBankMessages(amount float64, tolerance float64) bool {
+	s.BankMessagesAmount = amount
+	s.Mismatch = math.Abs(s.OrdersAmount-amount) > tolerance
+	return !s.Mismatch
+}
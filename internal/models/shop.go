@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"net/url"
 	string2 "payment-go/internal/utils/random/string"
 	"regexp"
 	"strings"
@@ -11,14 +12,16 @@ import (
 
 type Shop struct {
 	gorm.Model
-	Name          string       `gorm:"column:name;type:char(63);not null"`
-	Host          string       `gorm:"column:host;type:char(255)"`
-	OwnerId       uint         `gorm:"column:owner_id"`
-	Active        bool         `gorm:"column:active;not null;default:false"`
-	HostValidated bool         `gorm:"column:host_validated;not null;default:false"`
-	Moderated     bool         `gorm:"column:moderated;not null;default:false"`
-	Keys          ShopKeys     `gorm:"embedded"`
-	Webhooks      ShopWebhooks `gorm:"embedded;embeddedPrefix:webhook_"`
+	Name          string        `gorm:"column:name;type:char(63);not null"`
+	Host          string        `gorm:"column:host;type:char(255)"`
+	OwnerId       uint          `gorm:"column:owner_id"`
+	Active        bool          `gorm:"column:active;not null;default:false"`
+	HostValidated bool          `gorm:"column:host_validated;not null;default:false"`
+	Moderated     bool          `gorm:"column:moderated;not null;default:false"`
+	Keys          ShopKeys      `gorm:"embedded"`
+	Webhooks      ShopWebhooks  `gorm:"embedded;embeddedPrefix:webhook_"`
+	Branding      ShopBranding  `gorm:"embedded;embeddedPrefix:branding_"`
+	Redirects     ShopRedirects `gorm:"embedded;embeddedPrefix:redirect_"`
 }
 
 type ShopKeys struct {
@@ -31,6 +34,21 @@ type ShopWebhooks struct {
 	OnSuccess         *string `gorm:"column:on_success;type:text(1023)"`
 	OnFailure         *string `gorm:"column:on_failure;type:text(1023)"`
 	OnWithdrawUpdated *string `gorm:"column:on_withdraw_updated;type:text(1023)"`
+	OnBatchCompleted  *string `gorm:"column:on_batch_completed;type:text(1023)"`
+}
+
+// ShopBranding оформление страницы оплаты магазина
+type ShopBranding struct {
+	LogoUrl         *string `gorm:"column:logo_url;type:text(1023)"`
+	PrimaryColor    *string `gorm:"column:primary_color;type:char(7)"`
+	BackgroundColor *string `gorm:"column:background_color;type:char(7)"`
+	ReturnUrl       *string `gorm:"column:return_url;type:text(1023)"`
+}
+
+// ShopRedirects адреса возврата плательщика по умолчанию, как и вебхуки - пути на хосте магазина
+type ShopRedirects struct {
+	SuccessUrl *string `gorm:"column:success_url;type:text(1023)"`
+	FailUrl    *string `gorm:"column:fail_url;type:text(1023)"`
 }
 
 var rsg = string2.New(string2.LettersAnyCase + string2.Numbers)
@@ -105,6 +123,12 @@ func (shop *Shop) BeforeSave(tx *gorm.DB) error {
 	if err := shop.ValidateWebhooks(); err != nil {
 		return err
 	}
+	if err := shop.ValidateBranding(); err != nil {
+		return err
+	}
+	if err := shop.ValidateRedirects(); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -123,9 +147,65 @@ func (shop *Shop) ValidateWebhooks() error {
 	if wh.OnWithdrawUpdated != nil && len(*wh.OnWithdrawUpdated) != 0 && (*wh.OnWithdrawUpdated)[0] != '/' {
 		return fmt.Errorf("webhook must start from / if specified")
 	}
+	if wh.OnBatchCompleted != nil && len(*wh.OnBatchCompleted) != 0 && (*wh.OnBatchCompleted)[0] != '/' {
+		return fmt.Errorf("webhook must start from / if specified")
+	}
+	return nil
+}
+
+var colorRegexp = regexp.MustCompile("^#[0-9a-fA-F]{6}$")
+
+func (shop *Shop) ValidateBranding() error {
+	br := shop.Branding
+	for _, color := range []*string{br.PrimaryColor, br.BackgroundColor} {
+		if color != nil && len(*color) != 0 && !colorRegexp.MatchString(*color) {
+			return fmt.Errorf("branding color must be in #rrggbb format")
+		}
+	}
+	if link := br.LogoUrl; link != nil && len(*link) != 0 && !strings.HasPrefix(*link, "https://") && !strings.HasPrefix(*link, "http://") {
+		return fmt.Errorf("branding url must be absolute")
+	}
+	// адрес возврата только на хост магазина, иначе страница оплаты становится открытым редиректом
+	if br.ReturnUrl != nil && len(*br.ReturnUrl) != 0 {
+		link, err := shop.ResolveUrl(*br.ReturnUrl)
+		if err != nil {
+			return fmt.Errorf("branding return url: %w", err)
+		}
+		shop.Branding.ReturnUrl = &link
+	}
+	return nil
+}
+
+func (shop *Shop) ValidateRedirects() error {
+	rd := shop.Redirects
+	for _, path := range []*string{rd.SuccessUrl, rd.FailUrl} {
+		if path != nil && len(*path) != 0 && (*path)[0] != '/' {
+			return fmt.Errorf("redirect must start from / if specified")
+		}
+	}
 	return nil
 }
 
+// ResolveUrl возвращает абсолютный адрес на хосте магазина. Принимает путь или полный адрес с тем же хостом
+func (shop *Shop) ResolveUrl(link string) (string, error) {
+	if strings.HasPrefix(link, "/") {
+		return shop.Host + link, nil
+	}
+
+	shopUrl, err := url.Parse(shop.Host)
+	if err != nil {
+		return "", fmt.Errorf("invalid shop host")
+	}
+	u, err := url.Parse(link)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", fmt.Errorf("invalid url %s", link)
+	}
+	if !strings.EqualFold(u.Host, shopUrl.Host) {
+		return "", fmt.Errorf("url %s does not belong to the shop host", link)
+	}
+	return u.String(), nil
+}
+
 func (shop *Shop) IsAvailable() bool {
 	return shop.Active && shop.Moderated
 }
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const WithdrawBatchStatusNew = "new"
+const WithdrawBatchStatusApproved = "approved"
+const WithdrawBatchStatusDeclined = "declined"
+const WithdrawBatchStatusCompleted = "completed"
+
+const WithdrawBatchSourceApi = "api"
+const WithdrawBatchSourceFile = "file"
+
+// WithdrawBatch пакет выводов, созданный одним запросом или загрузкой файла
+type WithdrawBatch struct {
+	gorm.Model
+	Number      uuid.UUID            `gorm:"column:number;type:char(36);unique;not null;<-:create"`
+	ShopID      uint                 `gorm:"column:shop_id;index;not null;<-:create"`
+	Shop        Shop                 //`gorm:"foreignKey:ID;references:shop_id"`
+	Source      string               `gorm:"column:source;type:char(63);not null;<-:create"`
+	FileName    string               `gorm:"column:file_name;type:char(255)"`
+	Total       uint                 `gorm:"column:total;not null;default:0"`
+	TotalAmount float64              `gorm:"column:total_amount;not null;default:0"`
+	FinishedAt  *uint                `gorm:"column:finished_at"`
+	Status      string               `gorm:"column:status;type:char(63);not null"`
+	Items       []*WithdrawBatchItem `gorm:"foreignKey:BatchID"`
+}
+
+// WithdrawBatchItem связь пакета с выводом
+type WithdrawBatchItem struct {
+	gorm.Model
+	BatchID    uint     `gorm:"column:batch_id;index;not null;<-:create"`
+	WithdrawID uint     `gorm:"column:withdraw_id;unique;not null;<-:create"`
+	Withdraw   Withdraw //`gorm:"foreignKey:ID;references:withdraw_id"`
+}
+
+var ErrBatchFinished = errors.New("withdraw batch is already finished")
+var ErrBatchNotNew = errors.New("withdraw batch is already approved or declined")
+
+func NewWithdrawBatch(sh *Shop, source string) *WithdrawBatch {
+	return &WithdrawBatch{
+		Number: uuid.New(),
+		ShopID: sh.ID,
+		Shop:   *sh,
+		Source: source,
+		Status: WithdrawBatchStatusNew,
+	}
+}
+
+func (b *WithdrawBatch) BeforeSave(tx *gorm.DB) error {
+	if len(b.Status) == 0 {
+		b.Status = WithdrawBatchStatusNew
+	}
+	switch b.Status {
+	case WithdrawBatchStatusNew:
+	case WithdrawBatchStatusApproved:
+	case WithdrawBatchStatusDeclined:
+	case WithdrawBatchStatusCompleted:
+		break
+	default:
+		return ErrUnknownStatus
+	}
+	return nil
+}
+
+func (b *WithdrawBatch) IsFinished() bool {
+	return b.FinishedAt != nil
+}
+
+func (b *WithdrawBatch) AddWithdraw(wd *Withdraw) {
+	b.Items = append(b.Items, &WithdrawBatchItem{
+		BatchID:    b.ID,
+		WithdrawID: wd.ID,
+		Withdraw:   *wd,
+	})
+	b.Total++
+	b.TotalAmount += wd.Amount
+}
+
+func (b *WithdrawBatch) Approve() error {
+	if b.Status != WithdrawBatchStatusNew {
+		return ErrBatchNotNew
+	}
+	b.Status = WithdrawBatchStatusApproved
+	return nil
+}
+
+func (b *WithdrawBatch) Decline(moment uint) error {
+	if b.Status != WithdrawBatchStatusNew {
+		return ErrBatchNotNew
+	}
+	b.Status = WithdrawBatchStatusDeclined
+	b.FinishedAt = &moment
+	return nil
+}
+
+func (b *WithdrawBatch) Complete(moment uint) error {
+	if b.IsFinished() {
+		return ErrBatchFinished
+	}
+	b.Status = WithdrawBatchStatusCompleted
+	b.FinishedAt = &moment
+	return nil
+}
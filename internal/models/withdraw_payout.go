@@ -0,0 +1,65 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+const PayoutStatusPending = "pending"
+const PayoutStatusSucceeded = "succeeded"
+const PayoutStatusFailed = "failed"
+const PayoutStatusReview = "review" // исход неизвестен, выплату проверяет оператор
+
+// WithdrawStatusReview вывод ждёт ручной проверки выплаты
+const WithdrawStatusReview = "review"
+
+// WithdrawPayout исполнение вывода через провайдера выплат
+type WithdrawPayout struct {
+	gorm.Model
+	WithdrawID    uint     `gorm:"column:withdraw_id;unique;not null;<-:create"`
+	Withdraw      Withdraw //`gorm:"foreignKey:ID;references:withdraw_id"`
+	Provider      string   `gorm:"column:provider;type:char(63);not null"`
+	ExternalID    string   `gorm:"column:external_id;type:char(255)"`
+	Attempts      uint     `gorm:"column:attempts;not null;default:0"`
+	NextCheckAt   *uint    `gorm:"column:next_check_at;index"`
+	FailureReason string   `gorm:"column:failure_reason;type:text(1023)"`
+	FinishedAt    *uint    `gorm:"column:finished_at"`
+	Status        string   `gorm:"column:status;type:char(63);not null"`
+}
+
+func (p *WithdrawPayout) BeforeSave(tx *gorm.DB) error {
+	if len(p.Status) == 0 {
+		p.Status = PayoutStatusPending
+	}
+	switch p.Status {
+	case PayoutStatusPending:
+	case PayoutStatusSucceeded:
+	case PayoutStatusFailed:
+	case PayoutStatusReview:
+		break
+	default:
+		return ErrUnknownStatus
+	}
+	return nil
+}
+
+func (p *WithdrawPayout) IsFinished() bool {
+	return p.Status == PayoutStatusSucceeded || p.Status == PayoutStatusFailed
+}
+
+func (p *WithdrawPayout) IsUnderReview() bool {
+	return p.Status == PayoutStatusReview
+}
+
+// Review передаёт выплату оператору: опрос прекращается, итог задаётся через подтверждение
+func (p *WithdrawPayout) Review(reason string) {
+	p.Status = PayoutStatusReview
+	p.FailureReason = reason
+	p.NextCheckAt = nil
+}
+
+func (p *WithdrawPayout) Finish(status string, reason string, moment uint) {
+	p.Status = status
+	p.FailureReason = reason
+	p.FinishedAt = &moment
+	p.NextCheckAt = nil
+}
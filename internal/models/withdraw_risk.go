@@ -0,0 +1,127 @@
+package models
+
+import (
+	"errors"
+	"gorm.io/gorm"
+)
+
+const RiskStatusPassed = "passed"
+const RiskStatusFlagged = "flagged"
+const RiskStatusBlocked = "blocked"
+
+const RiskResultPass = "pass"
+const RiskResultFlag = "flag"
+const RiskResultBlock = "block"
+
+const RiskRuleRecipientDailyCount = "recipient_daily_count"
+const RiskRuleRecipientDailyAmount = "recipient_daily_amount"
+const RiskRuleShopDailyAmount = "shop_daily_amount"
+const RiskRuleBlockedCard = "blocked_card"
+const RiskRuleBlockedPhone = "blocked_phone"
+const RiskRuleFirstTimeRecipient = "first_time_recipient"
+const RiskRuleTwoPersonApproval = "two_person_approval"
+
+// WithdrawRiskCheck результат проверки вывода по правилам риска
+type WithdrawRiskCheck struct {
+	gorm.Model
+	WithdrawID        uint                   `gorm:"column:withdraw_id;unique;not null;<-:create"`
+	ShopID            uint                   `gorm:"column:shop_id;index;not null;<-:create"`
+	Status            string                 `gorm:"column:status;type:char(63);not null"`
+	RequiredApprovals uint                   `gorm:"column:required_approvals;not null;default:0"`
+	Outcomes          []*WithdrawRiskOutcome `gorm:"foreignKey:RiskCheckID"`
+	Approvals         []*WithdrawApproval    `gorm:"foreignKey:RiskCheckID"`
+}
+
+// WithdrawRiskOutcome результат одного правила
+type WithdrawRiskOutcome struct {
+	gorm.Model
+	RiskCheckID uint   `gorm:"column:risk_check_id;index;not null;<-:create"`
+	Rule        string `gorm:"column:rule;type:char(63);not null"`
+	Result      string `gorm:"column:result;type:char(63);not null"`
+	Message     string `gorm:"column:message;type:text(1023)"`
+}
+
+// WithdrawApproval подтверждение вывода оператором
+type WithdrawApproval struct {
+	gorm.Model
+	RiskCheckID uint   `gorm:"column:risk_check_id;uniqueIndex:idx_risk_check_operator;not null;<-:create"`
+	WithdrawID  uint   `gorm:"column:withdraw_id;index;not null;<-:create"`
+	Operator    string `gorm:"column:operator;type:char(255);uniqueIndex:idx_risk_check_operator;not null;<-:create"`
+}
+
+var ErrWithdrawBlocked = errors.New("withdraw is blocked by risk rules")
+var ErrAlreadyApproved = errors.New("withdraw is already approved by this operator")
+
+func NewWithdrawRiskCheck(wd *Withdraw) *WithdrawRiskCheck {
+	return &WithdrawRiskCheck{
+		WithdrawID: wd.ID,
+		ShopID:     wd.ShopID,
+		Status:     RiskStatusPassed,
+	}
+}
+
+func (rc *WithdrawRiskCheck) BeforeSave(tx *gorm.DB) error {
+	if len(rc.Status) == 0 {
+		rc.Status = RiskStatusPassed
+	}
+	switch rc.Status {
+	case RiskStatusPassed:
+	case RiskStatusFlagged:
+	case RiskStatusBlocked:
+		break
+	default:
+		return ErrUnknownStatus
+	}
+	return nil
+}
+
+// AddOutcome добавляет результат правила, статус проверки берётся по худшему результату
+func (rc *WithdrawRiskCheck) AddOutcome(rule, result, message string) {
+	rc.Outcomes = append(rc.Outcomes, &WithdrawRiskOutcome{
+		RiskCheckID: rc.ID,
+		Rule:        rule,
+		Result:      result,
+		Message:     message,
+	})
+
+	switch result {
+	case RiskResultBlock:
+		rc.Status = RiskStatusBlocked
+	case RiskResultFlag:
+		if rc.Status != RiskStatusBlocked {
+			rc.Status = RiskStatusFlagged
+		}
+	}
+}
+
+func (rc *WithdrawRiskCheck) IsBlocked() bool {
+	return rc.Status == RiskStatusBlocked
+}
+
+func (rc *WithdrawRiskCheck) IsApproved() bool {
+	required := rc.RequiredApprovals
+	// помеченный вывод подтверждает хотя бы один оператор
+	if rc.Status == RiskStatusFlagged && required == 0 {
+		required = 1
+	}
+	return !rc.IsBlocked() && uint(len(rc.Approvals)) >= required
+}
+
+func (rc *WithdrawRiskCheck) Approve(operator string) (*WithdrawApproval, error) {
+	if rc.IsBlocked() {
+		return nil, ErrWithdrawBlocked
+	}
+	for _, a := range rc.Approvals {
+		if a.Operator == operator {
+			return nil, ErrAlreadyApproved
+		}
+	}
+
+	approval := &WithdrawApproval{
+		RiskCheckID: rc.ID,
+		WithdrawID:  rc.WithdrawID,
+		Operator:    operator,
+	}
+	rc.Approvals = append(rc.Approvals, approval)
+	return approval, nil
+}
@@ -0,0 +1,40 @@
+package providers
+
+import (
+	"log"
+	"payment-go/internal/config"
+	"payment-go/internal/utils/proxy_pool"
+	"sync"
+)
+
+type IProxyPoolProvider interface {
+	GetPool() proxy_pool.IPool
+}
+type proxyPoolProvider struct {
+	pool proxy_pool.IPool
+}
+
+var ppProvider IProxyPoolProvider
+var ppOnce = sync.Once{}
+
+func ProxyPoolProvider() IProxyPoolProvider {
+	ppOnce.Do(func() {
+		pool, err := proxy_pool.NewPool(config.GetConfig().Proxy.Proxies, proxy_pool.Options{
+			FailureThreshold: config.ProxyFailureThreshold,
+			OpenTime:         config.ProxyOpenTime,
+			Timeout:          config.ProxyRequestTimeout,
+		})
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		ppProvider = &proxyPoolProvider{
+			pool: pool,
+		}
+	})
+	return ppProvider
+}
+
+func (p *proxyPoolProvider) GetPool() proxy_pool.IPool {
+	return p.pool
+}
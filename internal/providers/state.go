@@ -0,0 +1,74 @@
+package providers
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"os"
+	"payment-go/internal/config"
+	"payment-go/internal/utils/redis"
+	"payment-go/internal/utils/state"
+	"sync"
+)
+
+type IStateProvider interface {
+	GetStore() state.IStore
+	GetPubSub() state.IPubSub
+	NodeID() string
+}
+type stateProvider struct {
+	store  state.IStore
+	pubSub state.IPubSub
+	nodeId string
+}
+
+var stProvider IStateProvider
+var stOnce = sync.Once{}
+
+// StateProvider общее состояние экземпляров приложения: в памяти процесса или в Redis
+func StateProvider() IStateProvider {
+	stOnce.Do(func() {
+		conf := config.GetConfig()
+		p := &stateProvider{
+			nodeId: newNodeId(),
+		}
+
+		switch conf.StateBackend {
+		case config.StateBackendMemory, "":
+			p.store = state.NewMemoryStore()
+			p.pubSub = state.NewMemoryPubSub()
+		case config.StateBackendRedis:
+			client := redis.NewClient(conf.RedisAddr, conf.RedisPassword, conf.RedisDB, config.RedisPoolSize, config.RedisTimeout)
+			if _, err := client.Do("PING"); err != nil {
+				log.Fatal("unable to connect to redis: ", err)
+			}
+			p.store = state.NewRedisStore(client, config.StateKeyPrefix)
+			p.pubSub = state.NewRedisPubSub(client, config.StateKeyPrefix)
+		default:
+			log.Fatalf("unknown state backend: %s", conf.StateBackend)
+		}
+
+		stProvider = p
+	})
+	return stProvider
+}
+
+func (p *stateProvider) GetStore() state.IStore {
+	return p.store
+}
+
+func (p *stateProvider) GetPubSub() state.IPubSub {
+	return p.pubSub
+}
+
+func (p *stateProvider) NodeID() string {
+	return p.nodeId
+}
+
+func newNodeId() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), uuid.NewString()[:8])
+}
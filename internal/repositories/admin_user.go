@@ -0,0 +1,86 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"payment-go/internal/database"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories/include"
+	"strings"
+	"sync"
+)
+
+type IAdminUserRepository interface {
+	CountAll() (uint, error)
+	Delete(id uint) error
+	FindById(id uint) (*models.AdminUser, error)
+	FindByLogin(login string) (*models.AdminUser, error)
+	GetPaged(page uint, size uint, order string) (*include.PagedResultsList[models.AdminUser], error)
+	Save(entity *models.AdminUser) error
+}
+type adminUserRepository struct {
+	db *gorm.DB
+}
+
+var adminUserIns *adminUserRepository
+var adminUserOnce = sync.Once{}
+
+func AdminUserRepository() IAdminUserRepository {
+	adminUserOnce.Do(func() {
+		adminUserIns = &adminUserRepository{db: database.GetConnection()}
+	})
+	return adminUserIns
+}
+
+func (repo *adminUserRepository) CountAll() (uint, error) {
+	var result int64
+	if err := repo.db.Model(&models.AdminUser{}).Count(&result).Error; err != nil {
+		return 0, err
+	}
+	return uint(result), nil
+}
+
+func (repo *adminUserRepository) Delete(id uint) error {
+	return repo.db.Delete(&models.AdminUser{}, id).Error
+}
+
+func (repo *adminUserRepository) FindById(id uint) (*models.AdminUser, error) {
+	var u = &models.AdminUser{}
+	if err := repo.db.First(u, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (repo *adminUserRepository) FindByLogin(login string) (*models.AdminUser, error) {
+	var u = &models.AdminUser{}
+	if err := repo.db.First(u, "login = ?", login).Error; err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (repo *adminUserRepository) GetPaged(page uint, size uint, order string) (*include.PagedResultsList[models.AdminUser], error) {
+	var res []*models.AdminUser
+	query := repo.db.Model(&models.AdminUser{})
+	if strings.ToUpper(order) == "DESC" {
+		query.Order("id DESC")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	if err := query.Limit(int(size)).Offset(int(page * size)).Find(&res).Error; err != nil {
+		return nil, err
+	}
+
+	return &include.PagedResultsList[models.AdminUser]{
+		Items: res,
+		Total: uint(total),
+	}, nil
+}
+
+func (repo *adminUserRepository) Save(entity *models.AdminUser) error {
+	return repo.db.Save(entity).Error
+}
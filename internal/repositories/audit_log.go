@@ -0,0 +1,99 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"payment-go/internal/database"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories/include"
+	"payment-go/internal/transport/model/audit"
+	"strings"
+	"sync"
+	"time"
+)
+
+type IAuditLogRepository interface {
+	Create(entity *models.AuditLog) error
+	Find(dto *audit.FindAuditDto, page, size uint) (*include.PagedResultsList[models.AuditLog], error)
+}
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+var auditLogIns *auditLogRepository
+var auditLogOnce = sync.Once{}
+
+func AuditLogRepository() IAuditLogRepository {
+	auditLogOnce.Do(func() {
+		auditLogIns = &auditLogRepository{db: database.GetConnection()}
+	})
+	return auditLogIns
+}
+
+// сортировка только по этим полям, значение из запроса попадает в ORDER BY
+var auditSortFields = map[string]bool{
+	"id": true, "created_at": true, "actor_id": true, "action": true, "entity_type": true, "entity_id": true,
+}
+
+func (repo *auditLogRepository) Create(entity *models.AuditLog) error {
+	return repo.db.Create(entity).Error
+}
+
+func (repo *auditLogRepository) Find(dto *audit.FindAuditDto, page, size uint) (*include.PagedResultsList[models.AuditLog], error) {
+	var res []*models.AuditLog
+	query := repo.db.Model(&models.AuditLog{})
+
+	if len(dto.ID) != 0 {
+		query.Where("id IN (?)", dto.ID)
+	}
+	if len(dto.ActorType) != 0 {
+		query.Where("actor_type IN (?)", dto.ActorType)
+	}
+	if len(dto.ActorID) != 0 {
+		query.Where("actor_id IN (?)", dto.ActorID)
+	}
+	if len(dto.Action) != 0 {
+		query.Where("action IN (?)", dto.Action)
+	}
+	if len(dto.EntityType) != 0 {
+		query.Where("entity_type IN (?)", dto.EntityType)
+	}
+	if len(dto.EntityID) != 0 {
+		query.Where("entity_id IN (?)", dto.EntityID)
+	}
+
+	if dto.CreatedAt != nil {
+		query.Where("created_at >= ? AND created_at <= ?",
+			time.Unix(dto.CreatedAt.Min, 0), time.Unix(dto.CreatedAt.Max, 0))
+	}
+
+	if dto.Sort != nil && auditSortFields[dto.Sort.Field] {
+		var direction = "ASC"
+		if strings.ToUpper(dto.Sort.Direction) != "ASC" {
+			direction = "DESC"
+		}
+		query.Order(dto.Sort.Field + " " + direction)
+	} else {
+		query.Order("id DESC")
+	}
+
+	if len(dto.Search) != 0 {
+		search := "%" + dto.Search + "%"
+		query.Where("actor_login LIKE ? OR action LIKE ? OR changes LIKE ?", search, search, search)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	query.Limit(int(size)).Offset(int(page * size))
+
+	if err := query.Find(&res).Error; err != nil {
+		return nil, err
+	}
+
+	return &include.PagedResultsList[models.AuditLog]{
+		Items: res,
+		Total: uint(total),
+	}, nil
+}
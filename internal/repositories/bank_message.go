@@ -14,6 +14,7 @@ type IBankMessageRepository interface {
 	Find(dto *bank_message.FindBankMessageDto, page, size uint) (*include.PagedResultsList[models.BankMessage], error)
 	FindById(id uint) (*models.BankMessage, error)
 	Save(msg *models.BankMessage) error
+	WithTx(tx *gorm.DB) IBankMessageRepository
 }
 type bankMessageRepository struct {
 	db *gorm.DB
@@ -31,6 +32,11 @@ func BankMessageRepository() IBankMessageRepository {
 	return bmIns
 }
 
+// WithTx репозиторий, работающий в транзакции tx
+func (repo *bankMessageRepository) WithTx(tx *gorm.DB) IBankMessageRepository {
+	return &bankMessageRepository{db: tx}
+}
+
 func (repo *bankMessageRepository) Find(dto *bank_message.FindBankMessageDto, page, size uint) (*include.PagedResultsList[models.BankMessage], error) {
 	var res []*models.BankMessage
 	query := repo.preload()
@@ -0,0 +1,200 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"payment-go/internal/database"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories/include"
+	"payment-go/internal/transport/model/reconciliation"
+	"strings"
+	"sync"
+	"time"
+)
+
+type IBankStatementRepository interface {
+	FindByFileHash(hash string) (*models.BankStatement, error)
+	FindLines(dto *reconciliation.FindLinesDto, page, size uint) (*include.PagedResultsList[models.BankStatementLine], error)
+	FindLineById(id uint) (*models.BankStatementLine, error)
+	FindMatchingMessage(account string, amount, tolerance float64, from, to time.Time) (*models.BankMessage, error)
+	FindPendingOrders(cardId uint, amount, tolerance float64, from, to time.Time) ([]*models.Order, error)
+	FindUnmatchedMessages(page, size uint) (*include.PagedResultsList[models.BankMessage], error)
+	HasLine(account, reference string, bookedAt uint, amount float64) (bool, error)
+	Save(entity *models.BankStatement) error
+	SaveLine(entity *models.BankStatementLine) error
+	WithTx(tx *gorm.DB) IBankStatementRepository
+}
+type bankStatementRepository struct {
+	db *gorm.DB
+}
+
+var bsIns *bankStatementRepository
+var bsOnce = sync.Once{}
+
+func BankStatementRepository() IBankStatementRepository {
+	bsOnce.Do(func() {
+		bsIns = &bankStatementRepository{
+			db: database.GetConnection(),
+		}
+	})
+	return bsIns
+}
+
+// WithTx репозиторий, работающий в транзакции tx
+func (repo *bankStatementRepository) WithTx(tx *gorm.DB) IBankStatementRepository {
+	return &bankStatementRepository{db: tx}
+}
+
+func (repo *bankStatementRepository) Save(entity *models.BankStatement) error {
+	return repo.db.Omit("Lines").Save(entity).Error
+}
+
+func (repo *bankStatementRepository) SaveLine(entity *models.BankStatementLine) error {
+	return repo.db.Omit("BankMessage", "Order").Save(entity).Error
+}
+
+func (repo *bankStatementRepository) FindByFileHash(hash string) (*models.BankStatement, error) {
+	var st = &models.BankStatement{}
+	err := repo.db.First(st, "file_hash = ?", hash).Error
+	if err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// HasLine зачисление уже есть в одной из загруженных выписок
+func (repo *bankStatementRepository) HasLine(account, reference string, bookedAt uint, amount float64) (bool, error) {
+	var count int64
+	err := repo.db.Model(&models.BankStatementLine{}).
+		Where("account = ? AND reference = ? AND booked_at = ? AND amount = ?", account, reference, bookedAt, amount).
+		Count(&count).Error
+	return count != 0, err
+}
+
+func (repo *bankStatementRepository) FindLineById(id uint) (*models.BankStatementLine, error) {
+	var l = &models.BankStatementLine{}
+	err := repo.preload().First(l, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (repo *bankStatementRepository) FindLines(dto *reconciliation.FindLinesDto, page, size uint) (*include.PagedResultsList[models.BankStatementLine], error) {
+	var res []*models.BankStatementLine
+	query := repo.preload()
+
+	if len(dto.ID) != 0 {
+		query.Where("id IN (?)", dto.ID)
+	}
+	if len(dto.StatementID) != 0 {
+		query.Where("statement_id IN (?)", dto.StatementID)
+	}
+	if len(dto.Account) != 0 {
+		query.Where("account IN (?)", dto.Account)
+	}
+	if dto.Amount != nil {
+		query.Where("amount >= ? AND amount <= ?", dto.Amount.Min, dto.Amount.Max)
+	}
+	if dto.BookedAt != nil {
+		query.Where("booked_at >= ? AND booked_at <= ?", dto.BookedAt.Min, dto.BookedAt.Max)
+	}
+	if len(dto.Status) != 0 {
+		query.Where("status IN (?)", dto.Status)
+	}
+
+	if dto.Sort != nil {
+		var direction = "ASC"
+		if strings.ToUpper(dto.Sort.Direction) != "ASC" {
+			direction = "DESC"
+		}
+		query.Order(dto.Sort.Field + " " + direction)
+	}
+
+	if len(dto.Search) != 0 {
+		search := "%" + dto.Search + "%"
+		query.Where("reference LIKE ? OR description LIKE ?", search, search)
+	}
+
+	var total int64
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, err
+	}
+
+	query.Limit(int(size)).Offset(int(page * size))
+
+	err = query.Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &include.PagedResultsList[models.BankStatementLine]{
+		Items: res,
+		Total: uint(total),
+	}, nil
+}
+
+// FindMatchingMessage сообщение банка о том же зачислении, ещё не сопоставленное с выпиской
+func (repo *bankStatementRepository) FindMatchingMessage(account string, amount, tolerance float64, from, to time.Time) (*models.BankMessage, error) {
+	var msg = &models.BankMessage{}
+	err := repo.db.Model(&models.BankMessage{}).
+		Where("card_number = ? AND amount >= ? AND amount <= ?", account, amount-tolerance, amount+tolerance).
+		Where("created_at >= ? AND created_at <= ?", from, to).
+		Where("id NOT IN (?)", repo.linkedMessages()).
+		Order("created_at ASC").
+		First(msg).Error
+	if err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// FindPendingOrders незавершённые заказы на карту с той же суммой
+func (repo *bankStatementRepository) FindPendingOrders(cardId uint, amount, tolerance float64, from, to time.Time) ([]*models.Order, error) {
+	var res []*models.Order
+	err := repo.db.Model(&models.Order{}).
+		Preload("Shop").
+		Preload("Card").
+		Where("card_id = ? AND status IN (?)", cardId, []string{models.StatusNew, models.StatusPending}).
+		Where("amount >= ? AND amount <= ?", amount-tolerance, amount+tolerance).
+		Where("created_at >= ? AND created_at <= ?", from, to).
+		Find(&res).Error
+	return res, err
+}
+
+// FindUnmatchedMessages сообщения банка, которые не удалось привязать к заказу и которых нет в выписках
+func (repo *bankStatementRepository) FindUnmatchedMessages(page, size uint) (*include.PagedResultsList[models.BankMessage], error) {
+	var res []*models.BankMessage
+	query := repo.db.Model(&models.BankMessage{}).
+		Where("status = ?", models.BankMessageStatusError).
+		Where("id NOT IN (?)", repo.linkedMessages())
+
+	var total int64
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, err
+	}
+
+	query.Order("created_at DESC").Limit(int(size)).Offset(int(page * size))
+
+	err = query.Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &include.PagedResultsList[models.BankMessage]{
+		Items: res,
+		Total: uint(total),
+	}, nil
+}
+
+func (repo *bankStatementRepository) linkedMessages() *gorm.DB {
+	return repo.db.Model(&models.BankStatementLine{}).Select("bank_message_id").Where("bank_message_id IS NOT NULL")
+}
+
+func (repo *bankStatementRepository) preload() *gorm.DB {
+	res := repo.db.Model(&models.BankStatementLine{})
+	res.Preload("BankMessage")
+	res.Preload("Order")
+	return res
+}
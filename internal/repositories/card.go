@@ -19,8 +19,10 @@ type ICardRepository interface {
 	GetPaged(page uint, size uint, order string) ([]*models.Card, error)
 	GetCardInfo(cardId uint) (*models.CardInfo, error)
 	IncreaseBalance(cardId uint, amount float64) error
+	IncreaseDisputesLost(cardId uint) error
 	DecreaseBalance(cardId uint, amount float64) error
 	Save(entity *models.Card) error
+	WithTx(tx *gorm.DB) ICardRepository
 }
 type cardRepository struct {
 	db *gorm.DB
@@ -38,6 +40,11 @@ func CardRepository() ICardRepository {
 	return cardIns
 }
 
+// WithTx репозиторий, работающий в транзакции tx
+func (repo *cardRepository) WithTx(tx *gorm.DB) ICardRepository {
+	return &cardRepository{db: tx}
+}
+
 func (repo *cardRepository) Save(entity *models.Card) error {
 	err := repo.db.Save(entity).Error
 	if err != nil {
@@ -159,3 +166,10 @@ func (repo *cardRepository) DecreaseBalance(cardId uint, amount float64) error {
 	query.Where("card_id = ?", cardId)
 	return query.Update("balance", gorm.Expr("balance - ?", amount)).Error
 }
+
+func (repo *cardRepository) IncreaseDisputesLost(cardId uint) error {
+	// без модели, чтобы не вызывать хуки models.Card
+	query := repo.db.Table("cards")
+	query.Where("id = ?", cardId)
+	return query.Update("stats_disputes_lost", gorm.Expr("stats_disputes_lost + 1")).Error
+}
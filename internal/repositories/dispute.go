@@ -0,0 +1,238 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"payment-go/internal/database"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories/include"
+	analytics "payment-go/internal/transport/analytics/dispute"
+	"payment-go/internal/transport/model/dispute"
+	"strings"
+	"sync"
+)
+
+type IDisputeRepository interface {
+	Find(dto *dispute.FindDisputeDto, page, size uint) (*include.PagedResultsList[models.Dispute], error)
+	FindById(id uint) (*models.Dispute, error)
+	FindExpired(now uint, limit int) ([]*models.Dispute, error)
+	GetRates(dto *analytics.GetDisputeRatesDto) ([]*DisputeRateResultDto, error)
+	Save(entity *models.Dispute) error
+	SaveEvidence(entity *models.DisputeEvidence) error
+	SubmitEvidence(id uint) (bool, error)
+	Close(id uint, status string, closedAt uint) (bool, error)
+	WithTx(tx *gorm.DB) IDisputeRepository
+}
+type disputeRepository struct {
+	db *gorm.DB
+}
+
+var dsIns *disputeRepository
+var dsOnce = sync.Once{}
+
+func DisputeRepository() IDisputeRepository {
+	dsOnce.Do(func() {
+		dsIns = &disputeRepository{
+			db: database.GetConnection(),
+		}
+	})
+	return dsIns
+}
+
+// WithTx репозиторий, работающий в транзакции tx
+func (repo *disputeRepository) WithTx(tx *gorm.DB) IDisputeRepository {
+	return &disputeRepository{db: tx}
+}
+
+func (repo *disputeRepository) Save(entity *models.Dispute) error {
+	return repo.db.Omit("Order", "Card", "Evidence").Save(entity).Error
+}
+
+func (repo *disputeRepository) SaveEvidence(entity *models.DisputeEvidence) error {
+	return repo.db.Omit("BankMessage").Save(entity).Error
+}
+
+// SubmitEvidence переводит открытый спор в ожидание решения. Возвращает false, если спор уже закрыт
+func (repo *disputeRepository) SubmitEvidence(id uint) (bool, error) {
+	res := repo.db.Model(&models.Dispute{}).
+		Where("id = ? AND status NOT IN (?)", id, []string{models.DisputeStatusWon, models.DisputeStatusLost}).
+		Update("status", models.DisputeStatusEvidenceSubmitted)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// Close закрывает спор, если он ещё не закрыт. Возвращает false, если его уже закрыл другой запрос
+func (repo *disputeRepository) Close(id uint, status string, closedAt uint) (bool, error) {
+	res := repo.db.Model(&models.Dispute{}).
+		Where("id = ? AND status NOT IN (?)", id, []string{models.DisputeStatusWon, models.DisputeStatusLost}).
+		Updates(map[string]any{
+			"status":    status,
+			"closed_at": closedAt,
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// FindExpired открытые споры без доказательств, срок подачи которых истёк
+func (repo *disputeRepository) FindExpired(now uint, limit int) ([]*models.Dispute, error) {
+	var res []*models.Dispute
+	err := repo.db.Model(&models.Dispute{}).
+		Where("status = ? AND deadline_at < ?", models.DisputeStatusOpened, now).
+		Order("deadline_at ASC").
+		Limit(limit).
+		Find(&res).Error
+	return res, err
+}
+
+func (repo *disputeRepository) FindById(id uint) (*models.Dispute, error) {
+	var d = &models.Dispute{}
+	err := repo.preload().First(d, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (repo *disputeRepository) Find(dto *dispute.FindDisputeDto, page, size uint) (*include.PagedResultsList[models.Dispute], error) {
+	var res []*models.Dispute
+	query := repo.preload()
+
+	if len(dto.ID) != 0 {
+		query.Where("id IN (?)", dto.ID)
+	}
+	if len(dto.OrderID) != 0 {
+		query.Where("order_id IN (?)", dto.OrderID)
+	}
+	if len(dto.CardID) != 0 {
+		query.Where("card_id IN (?)", dto.CardID)
+	}
+	if len(dto.ShopID) != 0 {
+		query.Where("shop_id IN (?)", dto.ShopID)
+	}
+
+	if dto.Amount != nil {
+		query.Where("amount >= ? AND amount <= ?", dto.Amount.Min, dto.Amount.Max)
+	}
+
+	if len(dto.Status) != 0 {
+		query.Where("status IN (?)", dto.Status)
+	}
+
+	if dto.Sort != nil {
+		var direction = "ASC"
+		if strings.ToUpper(dto.Sort.Direction) != "ASC" {
+			direction = "DESC"
+		}
+		query.Order(dto.Sort.Field + " " + direction)
+	}
+
+	if len(dto.Search) != 0 {
+		search := "%" + dto.Search + "%"
+		query.Where("id LIKE ? OR reason LIKE ?", search, search)
+	}
+
+	var total int64
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, err
+	}
+
+	query.Limit(int(size)).Offset(int(page * size))
+
+	err = query.Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &include.PagedResultsList[models.Dispute]{
+		Items: res,
+		Total: uint(total),
+	}, nil
+}
+
+type DisputeRateResultDto struct {
+	ShopID        uint    `json:"shop_id"`
+	OrdersCount   uint    `json:"orders_count"`
+	DisputesCount uint    `json:"disputes_count"`
+	LostCount     uint    `json:"lost_count"`
+	LostAmount    float64 `json:"lost_amount"`
+}
+
+func (repo *disputeRepository) GetRates(dto *analytics.GetDisputeRatesDto) ([]*DisputeRateResultDto, error) {
+	// завершённые заказы за период
+	orders := repo.db.Model(&models.Order{}).
+		Select("shop_id, COUNT(*) AS orders_count").
+		Where("status = ?", models.StatusCompleted)
+	if len(dto.ShopID) != 0 {
+		orders.Where("shop_id IN (?)", dto.ShopID)
+	}
+	if dto.DateFrom != nil {
+		orders.Where("date_paid >= ?", *dto.DateFrom)
+	}
+	if dto.DateTo != nil {
+		orders.Where("date_paid < ?", *dto.DateTo)
+	}
+	orders.Group("shop_id")
+
+	var ordersResult []*DisputeRateResultDto
+	if err := orders.Scan(&ordersResult).Error; err != nil {
+		return nil, err
+	}
+
+	// споры по заказам за тот же период
+	paidOrders := repo.db.Model(&models.Order{}).Select("id").Where("date_paid IS NOT NULL")
+	if dto.DateFrom != nil {
+		paidOrders.Where("date_paid >= ?", *dto.DateFrom)
+	}
+	if dto.DateTo != nil {
+		paidOrders.Where("date_paid < ?", *dto.DateTo)
+	}
+	disputes := repo.db.Model(&models.Dispute{}).
+		Select(
+			"shop_id, COUNT(*) AS disputes_count, "+
+				"SUM(CASE WHEN status = ? THEN 1 ELSE 0 END) AS lost_count, "+
+				"SUM(CASE WHEN status = ? THEN amount ELSE 0 END) AS lost_amount",
+			models.DisputeStatusLost, models.DisputeStatusLost,
+		).
+		Where("order_id IN (?)", paidOrders)
+	if len(dto.ShopID) != 0 {
+		disputes.Where("shop_id IN (?)", dto.ShopID)
+	}
+	disputes.Group("shop_id")
+
+	var disputesResult []*DisputeRateResultDto
+	if err := disputes.Scan(&disputesResult).Error; err != nil {
+		return nil, err
+	}
+
+	// объединим результаты по магазинам
+	var byShop = make(map[uint]*DisputeRateResultDto)
+	var result = make([]*DisputeRateResultDto, 0, len(ordersResult))
+	for _, r := range ordersResult {
+		byShop[r.ShopID] = r
+		result = append(result, r)
+	}
+	for _, r := range disputesResult {
+		if row, ok := byShop[r.ShopID]; ok {
+			row.DisputesCount = r.DisputesCount
+			row.LostCount = r.LostCount
+			row.LostAmount = r.LostAmount
+		} else {
+			result = append(result, r)
+		}
+	}
+
+	return result, nil
+}
+
+func (repo *disputeRepository) preload() *gorm.DB {
+	res := repo.db.Model(&models.Dispute{})
+	res.Preload("Order")
+	res.Preload("Card")
+	res.Preload("Evidence")
+	res.Preload("Evidence.BankMessage")
+	return res
+}
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"payment-go/internal/database"
+	"payment-go/internal/models"
+	"sync"
+	"time"
+)
+
+type IJobLeaseRepository interface {
+	FindAll() ([]*models.JobLease, error)
+	TryAcquire(name, owner string, ttl time.Duration) (bool, error)
+	Release(name, owner string) error
+}
+type jobLeaseRepository struct {
+	db *gorm.DB
+}
+
+var jlIns *jobLeaseRepository
+var jlOnce = sync.Once{}
+
+func JobLeaseRepository() IJobLeaseRepository {
+	jlOnce.Do(func() {
+		jlIns = &jobLeaseRepository{
+			db: database.GetConnection(),
+		}
+	})
+	return jlIns
+}
+
+func (repo *jobLeaseRepository) FindAll() ([]*models.JobLease, error) {
+	var res []*models.JobLease
+	if err := repo.db.Model(&models.JobLease{}).Order("name").Find(&res).Error; err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// TryAcquire забирает аренду, если она своя или просрочена, иначе пытается создать её.
+// renewals увеличивается при каждом продлении, чтобы UPDATE всегда менял строку
+func (repo *jobLeaseRepository) TryAcquire(name, owner string, ttl time.Duration) (bool, error) {
+	now := uint(time.Now().Unix())
+	expiresAt := uint(time.Now().Add(ttl).Unix())
+
+	res := repo.db.Model(&models.JobLease{}).
+		Where("name = ? AND (owner = ? OR expires_at < ?)", name, owner, now).
+		Updates(map[string]any{
+			"owner":        owner,
+			"expires_at":   expiresAt,
+			"heartbeat_at": now,
+			"renewals":     gorm.Expr("renewals + 1"),
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected == 1 {
+		return true, nil
+	}
+
+	lease := &models.JobLease{
+		Name:        name,
+		Owner:       owner,
+		ExpiresAt:   expiresAt,
+		HeartbeatAt: now,
+	}
+	res = repo.db.Clauses(clause.OnConflict{DoNothing: true}).Create(lease)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (repo *jobLeaseRepository) Release(name, owner string) error {
+	return repo.db.Model(&models.JobLease{}).
+		Where("name = ? AND owner = ?", name, owner).
+		Update("expires_at", 0).Error
+}
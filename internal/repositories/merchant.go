@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"payment-go/internal/database"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories/include"
+	"strings"
+	"sync"
+)
+
+type IMerchantRepository interface {
+	Create(entity *models.Merchant) error
+	FindById(id uint) (*models.Merchant, error)
+	GetPaged(page uint, size uint, order string) (*include.PagedResultsList[models.Merchant], error)
+	GetOrphanOwners() ([]uint, error)
+	Save(entity *models.Merchant) error
+}
+type merchantRepository struct {
+	db *gorm.DB
+}
+
+var merchantIns *merchantRepository
+var merchantOnce = sync.Once{}
+
+func MerchantRepository() IMerchantRepository {
+	merchantOnce.Do(func() {
+		merchantIns = &merchantRepository{db: database.GetConnection()}
+	})
+	return merchantIns
+}
+
+// Create вставляет мерчанта с заданным id, Save при ненулевом id начал бы с обновления
+func (repo *merchantRepository) Create(entity *models.Merchant) error {
+	return repo.db.Create(entity).Error
+}
+
+func (repo *merchantRepository) FindById(id uint) (*models.Merchant, error) {
+	var m = &models.Merchant{}
+	if err := repo.db.First(m, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (repo *merchantRepository) GetPaged(page uint, size uint, order string) (*include.PagedResultsList[models.Merchant], error) {
+	var res []*models.Merchant
+	query := repo.db.Model(&models.Merchant{})
+	if strings.ToUpper(order) == "DESC" {
+		query.Order("id DESC")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	if err := query.Limit(int(size)).Offset(int(page * size)).Find(&res).Error; err != nil {
+		return nil, err
+	}
+
+	return &include.PagedResultsList[models.Merchant]{
+		Items: res,
+		Total: uint(total),
+	}, nil
+}
+
+// GetOrphanOwners owner_id магазинов, для которых ещё нет мерчанта
+func (repo *merchantRepository) GetOrphanOwners() ([]uint, error) {
+	var res = make([]uint, 0)
+	err := repo.db.Model(&models.Shop{}).
+		Distinct("owner_id").
+		Where("owner_id <> 0").
+		Where("owner_id NOT IN (?)", repo.db.Unscoped().Model(&models.Merchant{}).Select("id")).
+		Pluck("owner_id", &res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (repo *merchantRepository) Save(entity *models.Merchant) error {
+	return repo.db.Save(entity).Error
+}
+
+// merchantShops подзапрос id магазинов, принадлежащих действующим мерчантам
+func merchantShops(db *gorm.DB, merchantIds []uint) *gorm.DB {
+	return db.Model(&models.Shop{}).
+		Select("shops.id").
+		Joins("JOIN merchants ON merchants.id = shops.owner_id AND merchants.deleted_at IS NULL").
+		Where("merchants.id IN (?)", merchantIds)
+}
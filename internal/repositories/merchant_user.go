@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"payment-go/internal/database"
+	"payment-go/internal/models"
+	"sync"
+)
+
+type IMerchantUserRepository interface {
+	CountOwners(merchantId uint) (uint, error)
+	Delete(id uint) error
+	FindById(id uint) (*models.MerchantUser, error)
+	FindByEmail(email string) (*models.MerchantUser, error)
+	FindByInviteHash(hash string) (*models.MerchantUser, error)
+	GetMerchantUsers(merchantId uint) ([]*models.MerchantUser, error)
+	Save(entity *models.MerchantUser) error
+}
+type merchantUserRepository struct {
+	db *gorm.DB
+}
+
+var merchantUserIns *merchantUserRepository
+var merchantUserOnce = sync.Once{}
+
+func MerchantUserRepository() IMerchantUserRepository {
+	merchantUserOnce.Do(func() {
+		merchantUserIns = &merchantUserRepository{db: database.GetConnection()}
+	})
+	return merchantUserIns
+}
+
+// CountOwners действующие владельцы мерчанта, приглашённые без принятия не считаются
+func (repo *merchantUserRepository) CountOwners(merchantId uint) (uint, error) {
+	var result int64
+	err := repo.db.Model(&models.MerchantUser{}).
+		Where("merchant_id = ? AND role = ? AND disabled = ? AND joined_at IS NOT NULL", merchantId, models.MerchantRoleOwner, false).
+		Count(&result).Error
+	if err != nil {
+		return 0, err
+	}
+	return uint(result), nil
+}
+
+// Delete удаляет участника насовсем, чтобы email можно было пригласить снова
+func (repo *merchantUserRepository) Delete(id uint) error {
+	return repo.db.Unscoped().Delete(&models.MerchantUser{}, id).Error
+}
+
+func (repo *merchantUserRepository) FindById(id uint) (*models.MerchantUser, error) {
+	var u = &models.MerchantUser{}
+	if err := repo.db.First(u, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (repo *merchantUserRepository) FindByEmail(email string) (*models.MerchantUser, error) {
+	var u = &models.MerchantUser{}
+	if err := repo.db.First(u, "email = ?", email).Error; err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (repo *merchantUserRepository) FindByInviteHash(hash string) (*models.MerchantUser, error) {
+	var u = &models.MerchantUser{}
+	if err := repo.db.First(u, "invite_hash = ?", hash).Error; err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func (repo *merchantUserRepository) GetMerchantUsers(merchantId uint) ([]*models.MerchantUser, error) {
+	var res = make([]*models.MerchantUser, 0)
+	if err := repo.db.Where("merchant_id = ?", merchantId).Order("id").Find(&res).Error; err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (repo *merchantUserRepository) Save(entity *models.MerchantUser) error {
+	return repo.db.Save(entity).Error
+}
@@ -18,9 +18,11 @@ type IOrderRepository interface {
 	Find(dto *order.ReadOrderDto, page, size uint) (*include.PagedResultsList[models.Order], error)
 	FindById(id uint) (*models.Order, error)
 	FindByNumber(number string) (*models.Order, error)
+	Finish(id uint, status string, amount float64, datePaid uint, reopen bool) (bool, error)
 	GetPaged(page uint, size uint, order string, shopId uint, ownerId uint) (*include.PagedResultsList[models.Order], error)
 	GetTotals(dto *card.GetTotalsDto) ([]*TotalsResultDto, error)
 	Save(entity *models.Order) error
+	WithTx(tx *gorm.DB) IOrderRepository
 }
 type orderRepository struct {
 	db *gorm.DB
@@ -38,10 +40,36 @@ func OrderRepository() IOrderRepository {
 	return orderIns
 }
 
+// WithTx репозиторий, работающий в транзакции tx
+func (repo *orderRepository) WithTx(tx *gorm.DB) IOrderRepository {
+	return &orderRepository{db: tx}
+}
+
 func (repo *orderRepository) Save(entity *models.Order) error {
 	return repo.db.Save(entity).Error
 }
 
+// Finish завершает заказ условным UPDATE, поэтому из параллельных обработчиков заказ завершает один.
+// Завершённый заказ не меняется, при reopen оплатой можно завершить заказ с отказом.
+// Возвращает false, если заказ уже завершён
+func (repo *orderRepository) Finish(id uint, status string, amount float64, datePaid uint, reopen bool) (bool, error) {
+	finished := []string{models.StatusCompleted, models.StatusFailed}
+	if reopen {
+		finished = []string{models.StatusCompleted}
+	}
+	res := repo.db.Model(&models.Order{}).
+		Where("id = ? AND status NOT IN (?)", id, finished).
+		Updates(map[string]any{
+			"status":    status,
+			"amount":    amount,
+			"date_paid": datePaid,
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
 func (repo *orderRepository) Delete(id uint) error {
 	return repo.db.Delete(&models.Order{}, id).Error
 }
@@ -131,8 +159,7 @@ func (repo *orderRepository) Find(dto *order.ReadOrderDto, page, size uint) (*in
 	}
 
 	if len(dto.OwnerID) != 0 {
-		owners := repo.db.Model(&models.Shop{}).Select("id").Where("owner_id IN (?)", dto.OwnerID)
-		query.Where("shop_id IN (?)", owners)
+		query.Where("shop_id IN (?)", merchantShops(repo.db, dto.OwnerID))
 	}
 
 	if len(dto.ID) != 0 {
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"payment-go/internal/database"
+	"payment-go/internal/models"
+	"sync"
+)
+
+type IOutboxRepository interface {
+	Claim(eventId uuid.UUID, consumer string) (bool, error)
+	GetDue(moment uint, limit int) ([]*models.OutboxEvent, error)
+	Save(entity *models.OutboxEvent) error
+	SaveWithEvents(entity any, events ...*models.OutboxEvent) error
+	WithTx(tx *gorm.DB) IOutboxRepository
+}
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+var obIns *outboxRepository
+var obOnce = sync.Once{}
+
+func OutboxRepository() IOutboxRepository {
+	obOnce.Do(func() {
+		obIns = &outboxRepository{
+			db: database.GetConnection(),
+		}
+	})
+	return obIns
+}
+
+// WithTx репозиторий, работающий в транзакции tx
+func (repo *outboxRepository) WithTx(tx *gorm.DB) IOutboxRepository {
+	return &outboxRepository{db: tx}
+}
+
+// SaveWithEvents сохраняет сущность и события о её изменении в одной транзакции
+func (repo *outboxRepository) SaveWithEvents(entity any, events ...*models.OutboxEvent) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(entity).Error; err != nil {
+			return err
+		}
+		for _, e := range events {
+			if err := tx.Create(e).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetDue Возвращает неотправленные события, которые пора обработать
+func (repo *outboxRepository) GetDue(moment uint, limit int) ([]*models.OutboxEvent, error) {
+	var res []*models.OutboxEvent
+	query := repo.db.Model(&models.OutboxEvent{}).Where(
+		"status = ? AND next_attempt_at <= ?",
+		models.OutboxStatusPending, moment,
+	)
+	if err := query.Order("id").Limit(limit).Find(&res).Error; err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Claim отмечает событие обработанным подписчиком. Возвращает false, если отметка уже была
+func (repo *outboxRepository) Claim(eventId uuid.UUID, consumer string) (bool, error) {
+	c := &models.OutboxConsumption{
+		EventID:  eventId,
+		Consumer: consumer,
+	}
+	res := repo.db.Clauses(clause.OnConflict{DoNothing: true}).Create(c)
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (repo *outboxRepository) Save(entity *models.OutboxEvent) error {
+	return repo.db.Save(entity).Error
+}
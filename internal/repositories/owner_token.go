@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"payment-go/internal/database"
+	"payment-go/internal/models"
+	"sync"
+	"time"
+)
+
+type IOwnerTokenRepository interface {
+	FindById(id uint) (*models.OwnerToken, error)
+	FindByHash(hash string) (*models.OwnerToken, error)
+	GetOwnerTokens(ownerId uint) ([]*models.OwnerToken, error)
+	GetUserTokens(merchantUserId uint) ([]*models.OwnerToken, error)
+	RevokeUserTokens(merchantUserId uint) error
+	Save(entity *models.OwnerToken) error
+	Touch(id uint) error
+}
+type ownerTokenRepository struct {
+	db *gorm.DB
+}
+
+var ownerTokenIns *ownerTokenRepository
+var ownerTokenOnce = sync.Once{}
+
+func OwnerTokenRepository() IOwnerTokenRepository {
+	ownerTokenOnce.Do(func() {
+		ownerTokenIns = &ownerTokenRepository{db: database.GetConnection()}
+	})
+	return ownerTokenIns
+}
+
+func (repo *ownerTokenRepository) FindById(id uint) (*models.OwnerToken, error) {
+	var t = &models.OwnerToken{}
+	if err := repo.db.First(t, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (repo *ownerTokenRepository) FindByHash(hash string) (*models.OwnerToken, error) {
+	var t = &models.OwnerToken{}
+	if err := repo.db.First(t, "token_hash = ?", hash).Error; err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (repo *ownerTokenRepository) GetOwnerTokens(ownerId uint) ([]*models.OwnerToken, error) {
+	var res = make([]*models.OwnerToken, 0)
+	if err := repo.db.Where("owner_id = ?", ownerId).Order("id").Find(&res).Error; err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (repo *ownerTokenRepository) GetUserTokens(merchantUserId uint) ([]*models.OwnerToken, error) {
+	var res = make([]*models.OwnerToken, 0)
+	if err := repo.db.Where("merchant_user_id = ?", merchantUserId).Order("id").Find(&res).Error; err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// RevokeUserTokens отзывает все действующие токены участника
+func (repo *ownerTokenRepository) RevokeUserTokens(merchantUserId uint) error {
+	return repo.db.Model(&models.OwnerToken{}).
+		Where("merchant_user_id = ? AND revoked_at IS NULL", merchantUserId).
+		UpdateColumn("revoked_at", uint(time.Now().Unix())).Error
+}
+
+func (repo *ownerTokenRepository) Save(entity *models.OwnerToken) error {
+	return repo.db.Save(entity).Error
+}
+
+// Touch отмечает использование токена без изменения остальных полей
+func (repo *ownerTokenRepository) Touch(id uint) error {
+	return repo.db.Model(&models.OwnerToken{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", uint(time.Now().Unix())).Error
+}
@@ -2,7 +2,6 @@ package repositories
 
 import (
 	"gorm.io/gorm"
-	"payment-go/internal/config"
 	"payment-go/internal/database"
 	"payment-go/internal/models"
 	"payment-go/internal/repositories/include"
@@ -17,7 +16,9 @@ type IPaymentLinkRepository interface {
 	Find(dto *payment_link.FindPaymentLinkDto, page, size uint) (*include.PagedResultsList[models.PaymentLink], error)
 	FindById(id uint) (*models.PaymentLink, error)
 	FindByOrderId(orderId uint) (*models.PaymentLink, error)
-	GetPending() ([]*models.PaymentLink, error)
+	GetPending(from time.Time) ([]*models.PaymentLink, error)
+	GetStaleNew(from, to time.Time) ([]*models.PaymentLink, error)
+	SaveCheckState(link *models.PaymentLink) error
 	GetPaged(page uint, size uint, order string, shopId uint, ownerId uint) (*PagedPaymentLinks, error)
 	Save(entity *models.PaymentLink) error
 }
@@ -115,10 +116,21 @@ func (repo *paymentLinkRepository) GetPaged(page uint, size uint, order string,
 	}, nil
 }
 
-func (repo *paymentLinkRepository) GetPending() ([]*models.PaymentLink, error) {
-	timeActive := time.Now().Add(-config.CheckLinkTimeout)
+// GetPending Возвращает ожидающие оплаты ссылки, обновлённые после from. Устаревшие из них завершит проверка
+func (repo *paymentLinkRepository) GetPending(from time.Time) ([]*models.PaymentLink, error) {
+	query := repo.preload().Where("updated_at >= ? AND status = ?", from, models.StatusPending)
 
-	query := repo.preload().Where("updated_at >= ? and status = ?", timeActive, models.StatusPending)
+	var res []*models.PaymentLink
+	err := query.Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetStaleNew Возвращает ссылки, создание которых было начато в указанный период, но не закончено
+func (repo *paymentLinkRepository) GetStaleNew(from, to time.Time) ([]*models.PaymentLink, error) {
+	query := repo.preload().Where("created_at >= ? AND created_at < ? AND status = ?", from, to, models.StatusNew)
 
 	var res []*models.PaymentLink
 	err := query.Find(&res).Error
@@ -128,6 +140,18 @@ func (repo *paymentLinkRepository) GetPending() ([]*models.PaymentLink, error) {
 	return res, nil
 }
 
+// SaveCheckState сохраняет состояние проверки ссылки, не меняя updated_at
+func (repo *paymentLinkRepository) SaveCheckState(link *models.PaymentLink) error {
+	return repo.db.Model(&models.PaymentLink{}).Where("id = ?", link.ID).UpdateColumns(map[string]any{
+		"check_attempts":     link.CheckAttempts,
+		"check_started_at":   link.CheckStartedAt,
+		"last_checked_at":    link.LastCheckedAt,
+		"next_check_at":      link.NextCheckAt,
+		"last_bank_response": link.LastBankResponse,
+		"transaction_id":     link.TransactionId,
+	}).Error
+}
+
 func (repo *paymentLinkRepository) Find(dto *payment_link.FindPaymentLinkDto, page, size uint) (*include.PagedResultsList[models.PaymentLink], error) {
 	var res []*models.PaymentLink
 	query := repo.preload()
@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"payment-go/internal/database"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories/include"
+	"payment-go/internal/transport/model/scheduler"
+	"strings"
+	"sync"
+)
+
+type IScheduledJobRepository interface {
+	FindAll() ([]*models.ScheduledJob, error)
+	FindByName(name string) (*models.ScheduledJob, error)
+	FindRuns(dto *scheduler.FindRunsDto, page, size uint) (*include.PagedResultsList[models.ScheduledJobRun], error)
+	Save(entity *models.ScheduledJob) error
+	SaveResult(entity *models.ScheduledJob) error
+	SaveRun(entity *models.ScheduledJobRun) error
+	SetTriggered(name string) error
+}
+type scheduledJobRepository struct {
+	db *gorm.DB
+}
+
+var sjIns *scheduledJobRepository
+var sjOnce = sync.Once{}
+
+func ScheduledJobRepository() IScheduledJobRepository {
+	sjOnce.Do(func() {
+		sjIns = &scheduledJobRepository{
+			db: database.GetConnection(),
+		}
+	})
+	return sjIns
+}
+
+func (repo *scheduledJobRepository) FindAll() ([]*models.ScheduledJob, error) {
+	var res []*models.ScheduledJob
+	if err := repo.db.Model(&models.ScheduledJob{}).Order("name").Find(&res).Error; err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (repo *scheduledJobRepository) FindByName(name string) (*models.ScheduledJob, error) {
+	var job = &models.ScheduledJob{}
+	if err := repo.db.First(job, "name = ?", name).Error; err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (repo *scheduledJobRepository) FindRuns(dto *scheduler.FindRunsDto, page, size uint) (*include.PagedResultsList[models.ScheduledJobRun], error) {
+	var res []*models.ScheduledJobRun
+	query := repo.db.Model(&models.ScheduledJobRun{})
+
+	if len(dto.JobName) != 0 {
+		query.Where("job_name IN (?)", dto.JobName)
+	}
+	if len(dto.Node) != 0 {
+		query.Where("node = ?", dto.Node)
+	}
+	if dto.Failed != nil {
+		if *dto.Failed {
+			query.Where("error <> ''")
+		} else {
+			query.Where("error = '' OR error IS NULL")
+		}
+	}
+
+	if dto.Sort != nil {
+		var direction = "ASC"
+		if strings.ToUpper(dto.Sort.Direction) != "ASC" {
+			direction = "DESC"
+		}
+		query.Order(dto.Sort.Field + " " + direction)
+	} else {
+		query.Order("id DESC")
+	}
+
+	var total int64
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, err
+	}
+
+	query.Limit(int(size)).Offset(int(page * size))
+
+	err = query.Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &include.PagedResultsList[models.ScheduledJobRun]{
+		Items: res,
+		Total: uint(total),
+	}, nil
+}
+
+// SetTriggered запрашивает внеочередной запуск, его выполнит узел, на котором работает задача
+func (repo *scheduledJobRepository) SetTriggered(name string) error {
+	res := repo.db.Model(&models.ScheduledJob{}).Where("name = ?", name).Update("triggered", true)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (repo *scheduledJobRepository) Save(entity *models.ScheduledJob) error {
+	return repo.db.Save(entity).Error
+}
+
+// SaveResult сохраняет итог запуска, не сбрасывая ручной запуск, запрошенный во время работы задачи
+func (repo *scheduledJobRepository) SaveResult(entity *models.ScheduledJob) error {
+	return repo.db.Omit("Triggered").Save(entity).Error
+}
+
+func (repo *scheduledJobRepository) SaveRun(entity *models.ScheduledJobRun) error {
+	return repo.db.Save(entity).Error
+}
@@ -0,0 +1,202 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"payment-go/internal/database"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories/include"
+	"payment-go/internal/transport/model/settlement"
+	"strings"
+	"sync"
+	"time"
+)
+
+type ISettlementRepository interface {
+	Find(dto *settlement.FindSettlementDto, page, size uint) (*include.PagedResultsList[models.Settlement], error)
+	FindById(id uint) (*models.Settlement, error)
+	FindByShopAndDate(shopId uint, date string) (*models.Settlement, error)
+	GetProgress() ([]*SettlementProgressResultDto, error)
+	GetNetTotal(shopId uint) (float64, uint, error)
+	GetCompletedOrders(shopId uint, from, to uint) ([]*models.Order, error)
+	GetLostDisputes(shopId uint, from, to uint) ([]*models.Dispute, error)
+	GetCompletedWithdraws(shopId uint, from, to uint) ([]*models.Withdraw, error)
+	GetBankMessagesAmount(shopId uint, from, to time.Time) (float64, error)
+	Save(entity *models.Settlement) error
+	SaveLine(entity *models.SettlementLine) error
+	DeleteLines(settlementId uint) error
+	WithTx(tx *gorm.DB) ISettlementRepository
+}
+type settlementRepository struct {
+	db *gorm.DB
+}
+
+var stlIns *settlementRepository
+var stlOnce = sync.Once{}
+
+func SettlementRepository() ISettlementRepository {
+	stlOnce.Do(func() {
+		stlIns = &settlementRepository{
+			db: database.GetConnection(),
+		}
+	})
+	return stlIns
+}
+
+// WithTx репозиторий, работающий в транзакции tx
+func (repo *settlementRepository) WithTx(tx *gorm.DB) ISettlementRepository {
+	return &settlementRepository{db: tx}
+}
+
+func (repo *settlementRepository) Save(entity *models.Settlement) error {
+	return repo.db.Omit("Shop", "Lines").Save(entity).Error
+}
+
+func (repo *settlementRepository) SaveLine(entity *models.SettlementLine) error {
+	return repo.db.Save(entity).Error
+}
+
+func (repo *settlementRepository) DeleteLines(settlementId uint) error {
+	return repo.db.Unscoped().Where("settlement_id = ?", settlementId).Delete(&models.SettlementLine{}).Error
+}
+
+func (repo *settlementRepository) FindById(id uint) (*models.Settlement, error) {
+	var s = &models.Settlement{}
+	err := repo.preload().Preload("Lines").First(s, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (repo *settlementRepository) FindByShopAndDate(shopId uint, date string) (*models.Settlement, error) {
+	var s = &models.Settlement{}
+	err := repo.preload().Preload("Lines").First(s, "shop_id = ? AND date = ?", shopId, date).Error
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (repo *settlementRepository) Find(dto *settlement.FindSettlementDto, page, size uint) (*include.PagedResultsList[models.Settlement], error) {
+	var res []*models.Settlement
+	query := repo.preload()
+
+	if len(dto.ID) != 0 {
+		query.Where("id IN (?)", dto.ID)
+	}
+	if len(dto.ShopID) != 0 {
+		query.Where("shop_id IN (?)", dto.ShopID)
+	}
+	// даты в формате YYYY-MM-DD сравниваются как строки
+	if len(dto.DateFrom) != 0 {
+		query.Where("date >= ?", dto.DateFrom)
+	}
+	if len(dto.DateTo) != 0 {
+		query.Where("date <= ?", dto.DateTo)
+	}
+	if dto.Net != nil {
+		query.Where("net >= ? AND net <= ?", dto.Net.Min, dto.Net.Max)
+	}
+	if dto.Mismatch != nil {
+		query.Where("mismatch = ?", *dto.Mismatch)
+	}
+
+	if dto.Sort != nil {
+		var direction = "ASC"
+		if strings.ToUpper(dto.Sort.Direction) != "ASC" {
+			direction = "DESC"
+		}
+		query.Order(dto.Sort.Field + " " + direction)
+	}
+
+	var total int64
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, err
+	}
+
+	query.Limit(int(size)).Offset(int(page * size))
+
+	err = query.Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &include.PagedResultsList[models.Settlement]{
+		Items: res,
+		Total: uint(total),
+	}, nil
+}
+
+type SettlementProgressResultDto struct {
+	ShopID    uint
+	CreatedAt time.Time
+	SettledTo uint // конец последнего закрытого периода, 0 - отчётов ещё нет
+}
+
+// GetProgress до какого момента закрыты дни каждого магазина
+func (repo *settlementRepository) GetProgress() ([]*SettlementProgressResultDto, error) {
+	var res []*SettlementProgressResultDto
+	err := repo.db.Model(&models.Shop{}).
+		Select("shops.id AS shop_id, shops.created_at, COALESCE(MAX(settlements.period_to), 0) AS settled_to").
+		Joins("LEFT JOIN settlements ON settlements.shop_id = shops.id AND settlements.deleted_at IS NULL").
+		Group("shops.id, shops.created_at").
+		Scan(&res).Error
+	return res, err
+}
+
+// GetNetTotal сумма итогов закрытых дней магазина и конец последнего закрытого периода
+func (repo *settlementRepository) GetNetTotal(shopId uint) (float64, uint, error) {
+	var res struct {
+		Net      float64
+		PeriodTo uint
+	}
+	err := repo.db.Model(&models.Settlement{}).
+		Select("COALESCE(SUM(net), 0) AS net, COALESCE(MAX(period_to), 0) AS period_to").
+		Where("shop_id = ?", shopId).
+		Scan(&res).Error
+	return res.Net, res.PeriodTo, err
+}
+
+func (repo *settlementRepository) GetCompletedOrders(shopId uint, from, to uint) ([]*models.Order, error) {
+	var res []*models.Order
+	err := repo.db.Model(&models.Order{}).
+		Where("shop_id = ? AND status = ? AND date_paid >= ? AND date_paid < ?", shopId, models.StatusCompleted, from, to).
+		Order("date_paid ASC").
+		Find(&res).Error
+	return res, err
+}
+
+func (repo *settlementRepository) GetLostDisputes(shopId uint, from, to uint) ([]*models.Dispute, error) {
+	var res []*models.Dispute
+	err := repo.db.Model(&models.Dispute{}).
+		Where("shop_id = ? AND status = ? AND closed_at >= ? AND closed_at < ?", shopId, models.DisputeStatusLost, from, to).
+		Order("closed_at ASC").
+		Find(&res).Error
+	return res, err
+}
+
+func (repo *settlementRepository) GetCompletedWithdraws(shopId uint, from, to uint) ([]*models.Withdraw, error) {
+	var res []*models.Withdraw
+	err := repo.db.Model(&models.Withdraw{}).
+		Where("shop_id = ? AND status = ? AND finished_at >= ? AND finished_at < ?", shopId, models.StatusCompleted, from, to).
+		Order("finished_at ASC").
+		Find(&res).Error
+	return res, err
+}
+
+// GetBankMessagesAmount сумма подтверждённых сообщений банка по магазину за период
+func (repo *settlementRepository) GetBankMessagesAmount(shopId uint, from, to time.Time) (float64, error) {
+	var amount float64
+	err := repo.db.Model(&models.BankMessage{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("shop_id = ? AND status = ? AND created_at >= ? AND created_at < ?", shopId, models.BankMessageStatusSuccess, from, to).
+		Scan(&amount).Error
+	return amount, err
+}
+
+func (repo *settlementRepository) preload() *gorm.DB {
+	res := repo.db.Model(&models.Settlement{})
+	res.Preload("Shop")
+	return res
+}
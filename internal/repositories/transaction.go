@@ -0,0 +1,11 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"payment-go/internal/database"
+)
+
+// Transaction выполняет fn в одной транзакции. Внутри fn репозитории берутся через WithTx(tx)
+func Transaction(fn func(tx *gorm.DB) error) error {
+	return database.GetConnection().Transaction(fn)
+}
@@ -16,6 +16,7 @@ type IWithdrawRepository interface {
 	FindByNumber(number string) (*models.Withdraw, error)
 	Find(dto *withdraw.FindWithdrawDto, page, size uint) (*include.PagedResultsList[models.Withdraw], error)
 	Save(entity *models.Withdraw) error
+	WithTx(tx *gorm.DB) IWithdrawRepository
 }
 type withdrawRepository struct {
 	db *gorm.DB
@@ -33,6 +34,11 @@ func WithdrawRepository() IWithdrawRepository {
 	return wdIns
 }
 
+// WithTx репозиторий, работающий в транзакции tx
+func (repo *withdrawRepository) WithTx(tx *gorm.DB) IWithdrawRepository {
+	return &withdrawRepository{db: tx}
+}
+
 func (repo *withdrawRepository) FindById(id uint) (*models.Withdraw, error) {
 	var wd = &models.Withdraw{}
 	err := repo.preload().First(wd, "id = ?", id).Error
@@ -64,8 +70,7 @@ func (repo *withdrawRepository) Find(dto *withdraw.FindWithdrawDto, page, size u
 	}
 
 	if len(dto.OwnerID) != 0 {
-		owners := repo.db.Model(&models.Shop{}).Select("id").Where("owner_id IN (?)", dto.OwnerID)
-		query.Where("shop_id IN (?)", owners)
+		query.Where("shop_id IN (?)", merchantShops(repo.db, dto.OwnerID))
 	}
 
 	if len(dto.ID) != 0 {
@@ -96,6 +101,11 @@ func (repo *withdrawRepository) Find(dto *withdraw.FindWithdrawDto, page, size u
 		query.Where("status IN (?)", dto.Status)
 	}
 
+	if len(dto.RiskStatus) != 0 {
+		checks := repo.db.Model(&models.WithdrawRiskCheck{}).Select("withdraw_id").Where("status IN (?)", dto.RiskStatus)
+		query.Where("id IN (?)", checks)
+	}
+
 	if dto.Amount != nil {
 		query.Where("amount >= ? AND amount <= ?", dto.Amount.Min, dto.Amount.Max)
 	}
@@ -0,0 +1,169 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"payment-go/internal/database"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories/include"
+	"payment-go/internal/transport/model/withdraw"
+	"strings"
+	"sync"
+)
+
+type IWithdrawBatchRepository interface {
+	Find(dto *withdraw.FindBatchDto, page, size uint) (*include.PagedResultsList[models.WithdrawBatch], error)
+	FindById(id uint) (*models.WithdrawBatch, error)
+	FindByWithdrawId(withdrawId uint) (*models.WithdrawBatch, error)
+	GetProgress(batchId uint) (*withdraw.BatchProgressDto, error)
+	Save(entity *models.WithdrawBatch) error
+	SaveItem(entity *models.WithdrawBatchItem) error
+	Transition(entity *models.WithdrawBatch, from string) (bool, error)
+	WithTx(tx *gorm.DB) IWithdrawBatchRepository
+}
+type withdrawBatchRepository struct {
+	db *gorm.DB
+}
+
+var wbIns *withdrawBatchRepository
+var wbOnce = sync.Once{}
+
+func WithdrawBatchRepository() IWithdrawBatchRepository {
+	wbOnce.Do(func() {
+		wbIns = &withdrawBatchRepository{
+			db: database.GetConnection(),
+		}
+	})
+	return wbIns
+}
+
+// WithTx репозиторий, работающий в транзакции tx
+func (repo *withdrawBatchRepository) WithTx(tx *gorm.DB) IWithdrawBatchRepository {
+	return &withdrawBatchRepository{db: tx}
+}
+
+func (repo *withdrawBatchRepository) Save(entity *models.WithdrawBatch) error {
+	return repo.db.Omit("Shop", "Items").Save(entity).Error
+}
+
+// Transition сохраняет новый статус пакета, только если он ещё в статусе from.
+// Возвращает false, если пакет уже изменил параллельный запрос
+func (repo *withdrawBatchRepository) Transition(entity *models.WithdrawBatch, from string) (bool, error) {
+	res := repo.db.Model(&models.WithdrawBatch{}).
+		Where("id = ? AND status = ?", entity.ID, from).
+		Updates(map[string]any{
+			"status":      entity.Status,
+			"finished_at": entity.FinishedAt,
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func (repo *withdrawBatchRepository) SaveItem(entity *models.WithdrawBatchItem) error {
+	return repo.db.Omit("Withdraw").Save(entity).Error
+}
+
+func (repo *withdrawBatchRepository) FindById(id uint) (*models.WithdrawBatch, error) {
+	var b = &models.WithdrawBatch{}
+	err := repo.preload().Preload("Items.Withdraw.Shop").First(b, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (repo *withdrawBatchRepository) FindByWithdrawId(withdrawId uint) (*models.WithdrawBatch, error) {
+	items := repo.db.Model(&models.WithdrawBatchItem{}).Select("batch_id").Where("withdraw_id = ?", withdrawId)
+
+	var b = &models.WithdrawBatch{}
+	err := repo.preload().First(b, "id IN (?)", items).Error
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (repo *withdrawBatchRepository) Find(dto *withdraw.FindBatchDto, page, size uint) (*include.PagedResultsList[models.WithdrawBatch], error) {
+	var res []*models.WithdrawBatch
+	query := repo.preload()
+
+	if len(dto.ID) != 0 {
+		query.Where("id IN (?)", dto.ID)
+	}
+	if len(dto.Number) != 0 {
+		query.Where("number = ?", dto.Number)
+	}
+	if len(dto.ShopID) != 0 {
+		query.Where("shop_id IN (?)", dto.ShopID)
+	}
+	if len(dto.Source) != 0 {
+		query.Where("source IN (?)", dto.Source)
+	}
+	if dto.TotalAmount != nil {
+		query.Where("total_amount >= ? AND total_amount <= ?", dto.TotalAmount.Min, dto.TotalAmount.Max)
+	}
+	if len(dto.Status) != 0 {
+		query.Where("status IN (?)", dto.Status)
+	}
+
+	if dto.Sort != nil {
+		var direction = "ASC"
+		if strings.ToUpper(dto.Sort.Direction) != "ASC" {
+			direction = "DESC"
+		}
+		query.Order(dto.Sort.Field + " " + direction)
+	}
+
+	if len(dto.Search) != 0 {
+		search := "%" + dto.Search + "%"
+		query.Where("id LIKE ? OR number LIKE ? OR file_name LIKE ?", search, search, search)
+	}
+
+	var total int64
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, err
+	}
+
+	query.Limit(int(size)).Offset(int(page * size))
+
+	err = query.Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &include.PagedResultsList[models.WithdrawBatch]{
+		Items: res,
+		Total: uint(total),
+	}, nil
+}
+
+// GetProgress считает завершённые выводы пакета по их статусам
+func (repo *withdrawBatchRepository) GetProgress(batchId uint) (*withdraw.BatchProgressDto, error) {
+	items := repo.db.Model(&models.WithdrawBatchItem{}).Select("withdraw_id").Where("batch_id = ?", batchId)
+
+	var res = &withdraw.BatchProgressDto{}
+	err := repo.db.Model(&models.Withdraw{}).
+		Select(
+			"COUNT(*) AS total, "+
+				"COALESCE(SUM(CASE WHEN finished_at IS NOT NULL THEN 1 ELSE 0 END), 0) AS finished, "+
+				"COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS completed, "+
+				"COALESCE(SUM(CASE WHEN status = ? THEN 1 ELSE 0 END), 0) AS failed, "+
+				"COALESCE(SUM(CASE WHEN status = ? THEN amount ELSE 0 END), 0) AS completed_amount, "+
+				"COALESCE(SUM(CASE WHEN status = ? THEN amount ELSE 0 END), 0) AS failed_amount",
+			models.StatusCompleted, models.StatusFailed, models.StatusCompleted, models.StatusFailed,
+		).
+		Where("id IN (?)", items).
+		Scan(res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (repo *withdrawBatchRepository) preload() *gorm.DB {
+	res := repo.db.Model(&models.WithdrawBatch{})
+	res.Preload("Shop")
+	return res
+}
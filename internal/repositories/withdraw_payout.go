@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"payment-go/internal/database"
+	"payment-go/internal/models"
+	"sync"
+)
+
+type IWithdrawPayoutRepository interface {
+	FindByWithdrawId(withdrawId uint) (*models.WithdrawPayout, error)
+	GetDue(moment uint) ([]*models.WithdrawPayout, error)
+	Save(entity *models.WithdrawPayout) error
+}
+type withdrawPayoutRepository struct {
+	db *gorm.DB
+}
+
+var wpIns *withdrawPayoutRepository
+var wpOnce = sync.Once{}
+
+func WithdrawPayoutRepository() IWithdrawPayoutRepository {
+	wpOnce.Do(func() {
+		wpIns = &withdrawPayoutRepository{
+			db: database.GetConnection(),
+		}
+	})
+	return wpIns
+}
+
+func (repo *withdrawPayoutRepository) FindByWithdrawId(withdrawId uint) (*models.WithdrawPayout, error) {
+	var p = &models.WithdrawPayout{}
+	err := repo.preload().First(p, "withdraw_id = ?", withdrawId).Error
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetDue Возвращает незавершённые выплаты, которые пора проверить
+func (repo *withdrawPayoutRepository) GetDue(moment uint) ([]*models.WithdrawPayout, error) {
+	var res []*models.WithdrawPayout
+	query := repo.preload().Where(
+		"status = ? AND next_check_at IS NOT NULL AND next_check_at <= ?",
+		models.PayoutStatusPending, moment,
+	)
+	if err := query.Find(&res).Error; err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (repo *withdrawPayoutRepository) Save(entity *models.WithdrawPayout) error {
+	return repo.db.Omit("Withdraw").Save(entity).Error
+}
+
+func (repo *withdrawPayoutRepository) preload() *gorm.DB {
+	res := repo.db.Model(&models.WithdrawPayout{})
+	res.Preload("Withdraw")
+	res.Preload("Withdraw.Shop")
+	return res
+}
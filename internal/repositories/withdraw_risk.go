@@ -0,0 +1,152 @@
+package repositories
+
+import (
+	"gorm.io/gorm"
+	"payment-go/internal/database"
+	"payment-go/internal/models"
+	"sync"
+	"time"
+)
+
+type IWithdrawRiskRepository interface {
+	FindByWithdrawId(withdrawId uint) (*models.WithdrawRiskCheck, error)
+	FindByWithdrawIds(withdrawIds []uint) ([]*models.WithdrawRiskCheck, error)
+	FindUnchecked(limit int) ([]uint, error)
+	GetRecipientStats(wd *models.Withdraw, since time.Time) (*WithdrawStatsResultDto, error)
+	GetShopStats(wd *models.Withdraw, since time.Time) (*WithdrawStatsResultDto, error)
+	CountPaidToRecipient(wd *models.Withdraw) (uint, error)
+	Save(entity *models.WithdrawRiskCheck) error
+	SaveOutcome(entity *models.WithdrawRiskOutcome) error
+	SaveApproval(entity *models.WithdrawApproval) error
+	WithTx(tx *gorm.DB) IWithdrawRiskRepository
+}
+type withdrawRiskRepository struct {
+	db *gorm.DB
+}
+
+var wrIns *withdrawRiskRepository
+var wrOnce = sync.Once{}
+
+func WithdrawRiskRepository() IWithdrawRiskRepository {
+	wrOnce.Do(func() {
+		wrIns = &withdrawRiskRepository{
+			db: database.GetConnection(),
+		}
+	})
+	return wrIns
+}
+
+// WithTx репозиторий, работающий в транзакции tx
+func (repo *withdrawRiskRepository) WithTx(tx *gorm.DB) IWithdrawRiskRepository {
+	return &withdrawRiskRepository{db: tx}
+}
+
+type WithdrawStatsResultDto struct {
+	Count  uint    `json:"count"`
+	Amount float64 `json:"amount"`
+}
+
+func (repo *withdrawRiskRepository) Save(entity *models.WithdrawRiskCheck) error {
+	return repo.db.Omit("Outcomes", "Approvals").Save(entity).Error
+}
+
+func (repo *withdrawRiskRepository) SaveOutcome(entity *models.WithdrawRiskOutcome) error {
+	return repo.db.Save(entity).Error
+}
+
+func (repo *withdrawRiskRepository) SaveApproval(entity *models.WithdrawApproval) error {
+	return repo.db.Save(entity).Error
+}
+
+func (repo *withdrawRiskRepository) FindByWithdrawId(withdrawId uint) (*models.WithdrawRiskCheck, error) {
+	var rc = &models.WithdrawRiskCheck{}
+	err := repo.preload().First(rc, "withdraw_id = ?", withdrawId).Error
+	if err != nil {
+		return nil, err
+	}
+	return rc, nil
+}
+
+func (repo *withdrawRiskRepository) FindByWithdrawIds(withdrawIds []uint) ([]*models.WithdrawRiskCheck, error) {
+	var res []*models.WithdrawRiskCheck
+	if len(withdrawIds) == 0 {
+		return res, nil
+	}
+	err := repo.preload().Where("withdraw_id IN (?)", withdrawIds).Find(&res).Error
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetRecipientStats выводы тому же получателю (по карте или телефону) во всех магазинах
+func (repo *withdrawRiskRepository) GetRecipientStats(wd *models.Withdraw, since time.Time) (*WithdrawStatsResultDto, error) {
+	query := repo.stats(wd, since)
+	repo.whereRecipient(query, wd)
+
+	var res = &WithdrawStatsResultDto{}
+	if err := query.Scan(res).Error; err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetShopStats выводы магазина
+func (repo *withdrawRiskRepository) GetShopStats(wd *models.Withdraw, since time.Time) (*WithdrawStatsResultDto, error) {
+	query := repo.stats(wd, since)
+	query.Where("shop_id = ?", wd.ShopID)
+
+	var res = &WithdrawStatsResultDto{}
+	if err := query.Scan(res).Error; err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// FindUnchecked id незавершённых выводов без риск-проверки
+func (repo *withdrawRiskRepository) FindUnchecked(limit int) ([]uint, error) {
+	var ids []uint
+	err := repo.db.Model(&models.Withdraw{}).
+		Joins("LEFT JOIN withdraw_risk_checks rc ON rc.withdraw_id = withdraws.id AND rc.deleted_at IS NULL").
+		Where("rc.id IS NULL AND withdraws.finished_at IS NULL").
+		Order("withdraws.id").
+		Limit(limit).
+		Pluck("withdraws.id", &ids).Error
+	return ids, err
+}
+
+// CountPaidToRecipient количество завершённых выводов магазина этому получателю
+func (repo *withdrawRiskRepository) CountPaidToRecipient(wd *models.Withdraw) (uint, error) {
+	query := repo.db.Model(&models.Withdraw{}).
+		Where("shop_id = ? AND status = ? AND id != ?", wd.ShopID, models.StatusCompleted, wd.ID)
+	repo.whereRecipient(query, wd)
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return uint(count), nil
+}
+
+func (repo *withdrawRiskRepository) stats(wd *models.Withdraw, since time.Time) *gorm.DB {
+	return repo.db.Model(&models.Withdraw{}).
+		Select("COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount").
+		Where("status != ? AND created_at >= ? AND id < ?", models.StatusFailed, since, wd.ID)
+}
+
+func (repo *withdrawRiskRepository) whereRecipient(query *gorm.DB, wd *models.Withdraw) {
+	if len(wd.CardNumber) != 0 && len(wd.Phone) != 0 {
+		query.Where("card_number = ? OR phone = ?", wd.CardNumber, wd.Phone)
+	} else if len(wd.CardNumber) != 0 {
+		query.Where("card_number = ?", wd.CardNumber)
+	} else {
+		query.Where("phone = ?", wd.Phone)
+	}
+}
+
+func (repo *withdrawRiskRepository) preload() *gorm.DB {
+	res := repo.db.Model(&models.WithdrawRiskCheck{})
+	res.Preload("Outcomes")
+	res.Preload("Approvals")
+	return res
+}
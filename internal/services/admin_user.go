@@ -0,0 +1,195 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"payment-go/internal/config"
+	"payment-go/internal/models"
+	"payment-go/internal/providers"
+	"payment-go/internal/repositories"
+	"payment-go/internal/transport/model/admin"
+	"payment-go/internal/utils/password"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const adminSessionPrefix = "admin_session:"
+
+type IAdminUserService interface {
+	Authenticate(token string) (*models.AdminUser, error)
+	Bootstrap() error
+	CreateFromDto(dto *admin.CreateAdminUserDto) (*models.AdminUser, error)
+	Login(dto *admin.LoginDto) (*admin.LoginResponseDto, error)
+	Logout(token string) error
+	UpdateFromDto(dto *admin.UpdateAdminUserDto) (*models.AdminUser, error)
+}
+type adminUserService struct {
+}
+
+var adminUserIns *adminUserService
+var adminUserOnce = sync.Once{}
+
+var ErrInvalidCredentials = errors.New("invalid login or password")
+var ErrSessionExpired = errors.New("session is expired")
+
+// AdminUserService пользователи админки и их сессии. Сессии хранятся в общем хранилище, поэтому работают на всех узлах
+func AdminUserService() IAdminUserService {
+	adminUserOnce.Do(func() {
+		adminUserIns = &adminUserService{}
+	})
+	return adminUserIns
+}
+
+// Bootstrap создаёт администратора из ADMIN_LOGIN/ADMIN_PASSWORD, если пользователей ещё нет
+func (s *adminUserService) Bootstrap() error {
+	conf := config.GetConfig()
+	if len(conf.AdminLogin) == 0 {
+		return nil
+	}
+
+	count, err := repositories.AdminUserRepository().CountAll()
+	if err != nil || count != 0 {
+		return err
+	}
+	if conf.AdminPassword == config.AdminPasswordPlaceholder {
+		return fmt.Errorf("unable to create first admin: ADMIN_PASSWORD is a placeholder")
+	}
+
+	_, err = s.CreateFromDto(&admin.CreateAdminUserDto{
+		Login:    conf.AdminLogin,
+		Password: conf.AdminPassword,
+		Role:     models.AdminRoleAdmin,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to create first admin: %w", err)
+	}
+	log.Printf("Auth: created admin user '%s'", conf.AdminLogin)
+	return nil
+}
+
+func (s *adminUserService) CreateFromDto(dto *admin.CreateAdminUserDto) (*models.AdminUser, error) {
+	if err := dto.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := repositories.AdminUserRepository().FindByLogin(dto.Login); err == nil {
+		return nil, fmt.Errorf("login '%s' is already taken", dto.Login)
+	}
+
+	hash, err := password.Hash(dto.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &models.AdminUser{
+		Login:        dto.Login,
+		PasswordHash: hash,
+		Role:         dto.Role,
+	}
+	if err = repositories.AdminUserRepository().Save(u); err != nil {
+		return nil, fmt.Errorf("error while creating admin user")
+	}
+	return u, nil
+}
+
+func (s *adminUserService) UpdateFromDto(dto *admin.UpdateAdminUserDto) (*models.AdminUser, error) {
+	if err := dto.Validate(); err != nil {
+		return nil, err
+	}
+
+	u, err := repositories.AdminUserRepository().FindById(dto.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Role = dto.Role
+	u.Disabled = dto.Disabled
+	if len(dto.Password) != 0 {
+		if u.PasswordHash, err = password.Hash(dto.Password); err != nil {
+			return u, err
+		}
+	}
+
+	if err = repositories.AdminUserRepository().Save(u); err != nil {
+		return u, err
+	}
+	return u, nil
+}
+
+// Login проверяет пароль и открывает сессию. Токен отдаётся клиенту один раз, в хранилище лежит его хеш
+func (s *adminUserService) Login(dto *admin.LoginDto) (*admin.LoginResponseDto, error) {
+	if err := dto.Validate(); err != nil {
+		return nil, err
+	}
+
+	u, err := repositories.AdminUserRepository().FindByLogin(dto.Login)
+	if err != nil || u.Disabled || !password.Verify(dto.Password, u.PasswordHash) {
+		log.Printf("Auth: failed login attempt for '%s'", dto.Login)
+		return nil, ErrInvalidCredentials
+	}
+
+	raw := make([]byte, 32)
+	if _, err = rand.Read(raw); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(raw)
+
+	store := providers.StateProvider().GetStore()
+	if err = store.Set(sessionKey(token), strconv.Itoa(int(u.ID)), config.AdminSessionTTL); err != nil {
+		return nil, err
+	}
+
+	now := uint(time.Now().Unix())
+	u.LastLoginAt = &now
+	if err = repositories.AdminUserRepository().Save(u); err != nil {
+		log.Println(err)
+	}
+
+	return &admin.LoginResponseDto{
+		Token:     token,
+		ExpiresAt: time.Now().Add(config.AdminSessionTTL).Unix(),
+		User:      admin.FromAdminUser(u),
+	}, nil
+}
+
+func (s *adminUserService) Logout(token string) error {
+	return providers.StateProvider().GetStore().Delete(sessionKey(token))
+}
+
+// Authenticate находит пользователя сессии и продлевает её. Роль и блокировка читаются из базы на каждый запрос
+func (s *adminUserService) Authenticate(token string) (*models.AdminUser, error) {
+	if len(token) == 0 {
+		return nil, ErrSessionExpired
+	}
+
+	store := providers.StateProvider().GetStore()
+	key := sessionKey(token)
+	value, err := store.Get(key)
+	if err != nil {
+		return nil, ErrSessionExpired
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return nil, ErrSessionExpired
+	}
+
+	u, err := repositories.AdminUserRepository().FindById(uint(id))
+	if err != nil || u.Disabled {
+		_ = store.Delete(key)
+		return nil, ErrSessionExpired
+	}
+
+	if _, err = store.CompareAndExpire(key, value, config.AdminSessionTTL); err != nil {
+		log.Println(err)
+	}
+	return u, nil
+}
+
+func sessionKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return adminSessionPrefix + hex.EncodeToString(sum[:])
+}
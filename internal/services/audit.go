@@ -0,0 +1,40 @@
+package services
+
+import (
+	"encoding/json"
+	"log"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories"
+	"payment-go/internal/utils/audit"
+	"sync"
+)
+
+type IAuditService interface {
+	Record(entry *models.AuditLog, before, after any)
+}
+type auditService struct {
+}
+
+var auditIns *auditService
+var auditOnce = sync.Once{}
+
+// AuditService журнал действий пользователей админки
+func AuditService() IAuditService {
+	auditOnce.Do(func() {
+		auditIns = &auditService{}
+	})
+	return auditIns
+}
+
+// Record сохраняет запись с изменениями между версиями сущности. Ошибка записи не отменяет само действие
+func (s *auditService) Record(entry *models.AuditLog, before, after any) {
+	changes, err := json.Marshal(audit.Diff(before, after))
+	if err == nil {
+		entry.Changes = string(changes)
+	}
+
+	if err = repositories.AuditLogRepository().Create(entry); err != nil {
+		log.Printf("Audit: unable to save %s of %s #%d by '%s': %v",
+			entry.Action, entry.EntityType, entry.EntityID, entry.ActorLogin, err)
+	}
+}
@@ -1,26 +1,34 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"payment-go/internal/config"
+	"payment-go/internal/events"
 	"payment-go/internal/models"
 	"payment-go/internal/providers"
 	"payment-go/internal/repositories"
 	"payment-go/internal/transport/bank"
 	"payment-go/internal/utils/bank_api"
 	"payment-go/internal/utils/proxy"
+	"payment-go/internal/utils/proxy_pool"
+	"payment-go/internal/utils/scheduler"
 	"strconv"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type IBankApiService interface {
 	TaskCreateLink(ord *models.Order, cb func(link *models.PaymentLink))
-	TaskCheckLink(link *models.PaymentLink, cb func(*models.PaymentLink))
+	TaskCheckLink(ctx context.Context, link *models.PaymentLink, cb func(*models.PaymentLink))
+	Shutdown(ctx context.Context) error
 }
 type bankApiService struct {
+	closing  atomic.Bool
+	inflight atomic.Int64
 }
 
 var baIns IBankApiService
@@ -28,79 +36,247 @@ var baOnce = sync.Once{}
 
 func BankApiService() IBankApiService {
 	baOnce.Do(func() {
-		baIns = &bankApiService{}
+		s := &bankApiService{}
+		s.init()
+		baIns = s
 	})
 	return baIns
 }
 
+func (s *bankApiService) init() {
+	// у каждого узла свой пул прокси, поэтому задача локальная
+	SchedulerService().Register(config.JobProxyProbe, scheduler.Every(config.ProxyProbeInterval), true, Periodic(func() {
+		if host := config.GetConfig().Bank.ProbeHost(); len(host) != 0 {
+			providers.ProxyPoolProvider().GetPool().Probe(host)
+		}
+	}))
+}
+
+// withProxy выполняет запросы fn через прокси из пула и при отказе прокси повторяет их через другой.
+// Очередь задаёт темп запросов, её прокси используется, только если пул пуст
+func (s *bankApiService) withProxy(fallback proxy.IProxy, fn func(pr proxy.IProxy) error) error {
+	pool := providers.ProxyPoolProvider().GetPool()
+	if pool.Len() == 0 {
+		return fn(fallback)
+	}
+
+	tried := make(map[string]bool)
+	var err error
+	for attempt := 0; attempt < config.ProxyTaskAttempts; attempt++ {
+		member, acquireErr := pool.Acquire(tried)
+		if acquireErr != nil {
+			if err == nil {
+				err = acquireErr
+			}
+			return err
+		}
+		tried[member.Addr()] = true
+
+		err = fn(member)
+		var proxyErr *proxy_pool.ProxyError
+		if !errors.As(err, &proxyErr) {
+			return err
+		}
+		log.Println(err)
+	}
+	return err
+}
+
+// addTask ставит задачу в очередь и учитывает её до завершения
+func (s *bankApiService) addTask(fn func(pr proxy.IProxy)) bool {
+	if s.closing.Load() {
+		return false
+	}
+	s.inflight.Add(1)
+	providers.TaskQueueProvider().GetQueue().AddTask(proxy.NewTask(func(pr proxy.IProxy) {
+		defer s.inflight.Add(-1)
+		fn(pr)
+	}))
+	return true
+}
+
+// Shutdown перестаёт принимать задачи и ждёт завершения запущенных
+func (s *bankApiService) Shutdown(ctx context.Context) error {
+	s.closing.Store(true)
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for s.inflight.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("bank api: %d tasks still running: %w", s.inflight.Load(), ctx.Err())
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
 func (s *bankApiService) TaskCreateLink(ord *models.Order, cb func(*models.PaymentLink)) {
 
 	orderRepo := repositories.OrderRepository()
 	linkRepo := repositories.PaymentLinkRepository()
 
-	// создадим объект ссылки на оплату
-	link := &models.PaymentLink{
-		OrderID:  ord.ID,
-		Order:    *ord,
-		Amount:   ord.Amount,
-		CardType: ord.GetCardType(),
-		Status:   models.StatusNew,
+	// продолжим с ссылкой, создание которой прервала остановка приложения
+	link, err := linkRepo.FindByOrderId(ord.ID)
+	if err != nil || link.Status != models.StatusNew {
+		// создадим объект ссылки на оплату
+		link = &models.PaymentLink{
+			OrderID:  ord.ID,
+			Amount:   ord.Amount,
+			CardType: ord.GetCardType(),
+			Status:   models.StatusNew,
+		}
 	}
+	link.Order = *ord
 
 	// на случай ошибки
 	taskFailed := func(err error) {
+		log.Printf("Unable to create payment link for order #%d: %v", ord.ID, err)
 		err = PaymentLinkService().FinishLinkWithStatus(link, ord, models.StatusFailed)
 		if err != nil {
-			log.Printf("Error while finishing order #%d: %e", ord.ID, err)
+			log.Printf("Error while finishing order #%d: %v", ord.ID, err)
 		} else if cb != nil {
 			cb(link)
 		}
-		SubscriptionService().RunSubscribers(link)
-		SubscriptionService().RunSubscribers(ord)
+		EventBus().Publish(events.LinkCreated{Link: link})
 	}
 
-	if ord.Card.PhonePrefix == nil || ord.Card.PhoneNumber == nil {
-		taskFailed(fmt.Errorf("got nil in card phone"))
+	phone, err := getCardPhone(&ord.Card)
+	if err != nil {
+		taskFailed(err)
+		return
 	}
-	phone := &config.Phone{
-		Prefix: *ord.Card.PhonePrefix,
-		Number: *ord.Card.PhoneNumber,
+
+	// банки способа оплаты в порядке переключения, продолженная ссылка начинает со своего банка
+	banks := config.GetConfig().Bank.GetMethodBanks(ord.PaymentMethod)
+	if len(banks) == 0 {
+		taskFailed(fmt.Errorf("no banks for payment method %s", ord.PaymentMethod))
+		return
 	}
+	bankIdx := 0
+	for i, name := range banks {
+		if name == link.Bank {
+			bankIdx = i
+		}
+	}
+	link.Bank = banks[bankIdx]
 
 	if err := linkRepo.Save(link); err != nil {
 		taskFailed(err)
+		return
 	}
 
-	// запланируем таск на создание ссылки на оплату
-	q := providers.TaskQueueProvider().GetQueue()
-	q.AddTask(proxy.NewTask(func(pr proxy.IProxy) {
-		// получение атрибутов
-		b := bank_api.NewBank()
-		atts, err := b.GetAttributes(pr)
-		if err != nil {
-			taskFailed(err)
-			return
+	conf := config.GetConfig().LinkCreate
+	started := time.Now()
+	var attempts, fallbacks uint
+	var create func(queuePr proxy.IProxy)
+
+	// failover переключает создание ссылки на следующий банк способа оплаты
+	failover := func(err error) bool {
+		if bankIdx+1 >= len(banks) {
+			return false
+		}
+		bankIdx++
+		attempts = 0
+		link.Bank = banks[bankIdx]
+		if saveErr := linkRepo.Save(link); saveErr != nil {
+			log.Println(saveErr)
+		}
+		log.Printf("Switching order #%d to bank %s: %v", ord.ID, link.Bank, err)
+		s.addTask(create)
+		return true
+	}
+
+	// retry повторяет создание ссылки при временной ошибке банка или на другой карте при отказе,
+	// а когда повторы или время исчерпаны, переключается на другой банк. Возвращает false, если банков больше нет
+	retry := func(err error) bool {
+		switch bank_api.Kind(err) {
+		case bank_api.ErrorRejected:
+			if fallbacks >= conf.CardFallbacks {
+				return failover(err)
+			}
+			if switchErr := OrderService().SwitchCard(ord); switchErr != nil {
+				log.Printf("Unable to switch card for order #%d: %v", ord.ID, switchErr)
+				return failover(err)
+			}
+			newPhone, phoneErr := getCardPhone(&ord.Card)
+			if phoneErr != nil {
+				return failover(err)
+			}
+			fallbacks++
+			phone = newPhone
+			link.Order = *ord
+			link.CardType = ord.GetCardType()
+			log.Printf("Bank rejected order #%d, retrying with card #%d: %v", ord.ID, ord.Card.ID, err)
+			s.addTask(create)
+			return true
+		default:
+			attempts++
+			delay := conf.Delay(attempts)
+			if after := bank_api.RetryAfter(err); after > delay {
+				delay = after
+			}
+			if time.Since(started)+delay > config.CreateLinkTimeout {
+				// ждать повтора некогда, но другой банк может ответить сразу
+				return failover(err)
+			}
+			if attempts >= conf.MaxAttempts {
+				return failover(err)
+			}
+			log.Printf("Retrying payment link for order #%d in %v: %v", ord.ID, delay, err)
+			// при остановке повтор не запустится, ссылка останется новой и будет продолжена после запуска
+			time.AfterFunc(delay, func() {
+				s.addTask(create)
+			})
+			return true
 		}
+	}
 
-		// получение checkMerchant
-		requestDto := bank.NewCheckMerchantRequestDto(atts, phone)
-		resp, err := b.CheckMerchant(pr, requestDto)
+	// запланируем таск на создание ссылки на оплату.
+	// при остановке ссылка остаётся новой и будет продолжена после запуска
+	create = func(queuePr proxy.IProxy) {
+		b, bankConf, err := bank_api.NewClientByName(link.Bank)
 		if err != nil {
-			taskFailed(err)
+			if !failover(err) {
+				taskFailed(err)
+			}
 			return
 		}
-
-		// получение ссылки
-		amount := fmt.Sprintf("%.2f", link.Amount)
-		cardType, ok := config.GetConfig().Bank.CardTypeMapping[link.CardType]
+		cardType, ok := bankConf.CardTypeMapping[link.CardType]
 		if !ok {
-			taskFailed(fmt.Errorf("unknown card type"))
+			if !failover(fmt.Errorf("unknown card type %s", link.CardType)) {
+				taskFailed(fmt.Errorf("unknown card type"))
+			}
 			return
 		}
-		linkDto := bank.NewLinkRequestDto(resp, amount, cardType)
-		linkRes, err := b.GetLink(pr, linkDto)
+
+		// создание ссылки не отменяется, каждый запрос ограничен своим таймаутом
+		ctx := context.Background()
+		var linkRes *bank.LinkResponseDto
+		err = s.withProxy(queuePr, func(pr proxy.IProxy) error {
+			// получение атрибутов
+			atts, err := b.GetAttributes(ctx, pr)
+			if err != nil {
+				return err
+			}
+
+			// получение checkMerchant
+			requestDto := bank.NewCheckMerchantRequestDto(atts, phone)
+			resp, err := b.CheckMerchant(ctx, pr, requestDto)
+			if err != nil {
+				return err
+			}
+
+			// получение ссылки
+			amount := fmt.Sprintf("%.2f", link.Amount)
+			linkDto := bank.NewLinkRequestDto(resp, amount, cardType)
+			linkRes, err = b.GetLink(ctx, pr, linkDto)
+			return err
+		})
 		if err != nil {
-			taskFailed(err)
+			if !retry(err) {
+				taskFailed(err)
+			}
 			return
 		}
 
@@ -120,115 +296,165 @@ func (s *bankApiService) TaskCreateLink(ord *models.Order, cb func(*models.Payme
 		if cb != nil {
 			cb(link)
 		}
-		go func() {
-			//time.Sleep(3 * time.Second)
-			SubscriptionService().RunSubscribers(link)
-			SubscriptionService().RunSubscribers(ord)
-		}()
-	}))
+		go EventBus().Publish(events.LinkCreated{Link: link})
+	}
+	s.addTask(create)
+}
+
+func getCardPhone(crd *models.Card) (*config.Phone, error) {
+	if crd.PhonePrefix == nil || crd.PhoneNumber == nil {
+		return nil, fmt.Errorf("got nil in card phone")
+	}
+	return &config.Phone{
+		Prefix: *crd.PhonePrefix,
+		Number: *crd.PhoneNumber,
+	}, nil
 }
 
-func (s *bankApiService) TaskCheckLink(link *models.PaymentLink, cb func(*models.PaymentLink)) {
+// TaskCheckLink проверяет ссылку до её завершения. При отмене ctx проверка прекращается без изменения ссылки.
+// cb вызывается один раз после прекращения проверки по любой причине
+func (s *bankApiService) TaskCheckLink(ctx context.Context, link *models.PaymentLink, cb func(*models.PaymentLink)) {
 
 	ord := &link.Order
 
+	// stop вызывает cb один раз, как бы ни прекратилась проверка: завершением ссылки, ошибкой или отменой ctx
+	var stopOnce sync.Once
+	stop := func() {
+		stopOnce.Do(func() {
+			if cb != nil {
+				cb(link)
+			}
+		})
+	}
+
 	paymentFailed := func(err error) {
-		fmt.Println("paymentFailed()", err)
+		defer stop()
+		log.Printf("Payment link for order #%d failed: %v", ord.ID, err)
 
 		err = PaymentLinkService().FinishLinkWithStatus(link, ord, models.StatusFailed)
 		if err != nil {
-			log.Printf("Error while finishing order #%d: %e", ord.ID, err)
-		} else if cb != nil {
-			cb(link)
+			log.Printf("Error while finishing order #%d: %v", ord.ID, err)
 		}
 	}
 
 	paymentCompleted := func() {
-		fmt.Println("paymentCompleted")
+		defer stop()
 
 		err := PaymentLinkService().FinishLinkWithStatus(link, ord, models.StatusCompleted)
 		if err != nil {
 			log.Println("Error while finishing order #"+strconv.Itoa(int(ord.ID)), err)
-		} else if cb != nil {
-			cb(link)
 		}
 	}
 
-	q := providers.TaskQueueProvider().GetQueue()
-
-	var taskGenerator func(attempt int) proxy.IHttpTask
-	taskGenerator = func(attempt int) proxy.IHttpTask {
-		return proxy.NewTask(func(pr proxy.IProxy) {
-			if attempt > config.CheckLinkMaxAttempts {
-				fmt.Println("reason attempts")
-				paymentFailed(fmt.Errorf("attempt"))
-				return
-			}
+	conf := config.GetConfig().LinkCheck
+	linkRepo := repositories.PaymentLinkRepository()
 
-			// если ссылка устарела
-			if time.Since(link.UpdatedAt) > config.CheckLinkTimeout {
-				fmt.Println("reason timeout")
-				paymentFailed(fmt.Errorf("timeout"))
-				return
-			}
+	saveState := func() {
+		if err := linkRepo.SaveCheckState(link); err != nil {
+			log.Println(err)
+		}
+	}
 
-			doNextIteration := func() {
-				time.Sleep(config.CheckLinkInterval)
-				q.AddTask(taskGenerator(attempt + 1))
-			}
+	var check func(pr proxy.IProxy)
 
-			// переходим по ссылке
-			res, err := pr.Get(link.URL)
-			if err != nil {
-				fmt.Println("pr.Get link.URL do next iteration")
-				fmt.Println(err)
-				go doNextIteration()
+	// schedule ставит проверку в очередь на сохранённое время, при продолжении после перезапуска оно уже известно
+	schedule := func() {
+		if link.NextCheckAt == nil {
+			next := time.Now().Add(conf.Delay(link.CheckAttempts))
+			link.NextCheckAt = &next
+			saveState()
+		}
+		go func() {
+			select {
+			case <-ctx.Done():
+				stop()
 				return
+			case <-time.After(time.Until(*link.NextCheckAt)):
 			}
-			// проверяем наличие редиректа
-			redirect := res.Header.Get("Location")
-			if len(redirect) < 5 {
-				go doNextIteration()
-				return
+			if !s.addTask(check) {
+				stop()
 			}
+		}()
+	}
 
-			// разобьём редирект по /
-			redirectParts := strings.Split(redirect, "/")
-			l := len(redirectParts)
-			if l < 5 {
-				fmt.Println("redirectParts < 5 do next iteration", redirectParts)
-				go doNextIteration()
-				return
-			}
-			// достанем id транзакции
-			transactionId := redirectParts[l-1]
-			if len(transactionId) < 10 {
-				fmt.Println("transaction id < 10", transactionId)
-				go doNextIteration()
-				return
+	// retry завершает ссылку при исчерпании попыток или времени, иначе планирует следующую проверку
+	retry := func(err error) {
+		link.LastBankResponse = err.Error()
+		saveState()
+
+		if link.CheckAttempts >= conf.MaxAttempts {
+			paymentFailed(fmt.Errorf("attempts"))
+			return
+		}
+		if time.Since(*link.CheckStartedAt) > conf.Timeout {
+			paymentFailed(fmt.Errorf("timeout"))
+			return
+		}
+		schedule()
+	}
+
+	check = func(queuePr proxy.IProxy) {
+		if ctx.Err() != nil {
+			stop()
+			return
+		}
+
+		// ссылку проверяет банк, который её выдал
+		b, _, err := bank_api.NewClientByName(link.Bank)
+		if err != nil {
+			paymentFailed(err)
+			return
+		}
+
+		// таймаут отсчитывается от первой проверки, поэтому ссылка проверяется хотя бы раз
+		now := time.Now()
+		if link.CheckStartedAt == nil {
+			link.CheckStartedAt = &now
+		}
+		link.LastCheckedAt = &now
+		link.NextCheckAt = nil
+		link.CheckAttempts++
+
+		var transaction *bank.CheckLinkResponseDto
+		err = s.withProxy(queuePr, func(pr proxy.IProxy) error {
+			// id транзакции достаточно получить один раз
+			if len(link.TransactionId) == 0 {
+				transactionId, err := b.GetTransactionId(ctx, pr, link.URL)
+				if err != nil {
+					return err
+				}
+				link.TransactionId = transactionId
 			}
 
 			// проверим транзакцию
-			b := bank_api.NewBank()
-			completed, err := b.CheckLink(pr, transactionId)
-			if err != nil {
-				fmt.Println("check link err")
-				fmt.Println(err)
-				go doNextIteration()
+			var err error
+			transaction, err = b.CheckLink(ctx, pr, link.TransactionId)
+			return err
+		})
+		if err != nil {
+			// запрос прерван остановкой приложения: попытку не сохраняем, проверку продолжит следующий запуск
+			if ctx.Err() != nil {
+				stop()
 				return
 			}
-			if completed {
-				fmt.Println("paymentCompleted")
-				paymentCompleted()
-			} else {
-				fmt.Println("paymentFailed")
-				paymentFailed(fmt.Errorf("not completed"))
-			}
-		})
+			retry(err)
+			return
+		}
+
+		outcome := transaction.Outcome()
+		link.LastBankResponse = fmt.Sprintf("transaction status %s (%s)", transaction.StatusString(), outcome)
+		switch outcome {
+		case bank.OutcomePaid:
+			saveState()
+			EventBus().Publish(events.PaymentDetected{Order: ord})
+			paymentCompleted()
+		case bank.OutcomeDeclined:
+			saveState()
+			paymentFailed(fmt.Errorf("declined"))
+		default:
+			retry(errors.New(link.LastBankResponse))
+		}
 	}
 
-	go func() {
-		time.Sleep(config.CheckLinkInterval)
-		q.AddTask(taskGenerator(0))
-	}()
+	schedule()
 }
@@ -70,6 +70,9 @@ func (s *bankMessageService) ProcessApproval(dto *bank_message.BankMessageApprov
 		status = models.StatusFailed
 	}
 	if err := OrderService().FinishOrderWithStatus(ord, status, uint(time.Now().Unix())); err != nil {
+		if errors.Is(err, ErrOrderFinished) {
+			return err
+		}
 		return ErrWhileFinishingOrder
 	}
 
@@ -4,11 +4,13 @@ import (
 	"errors"
 	"fmt"
 	"payment-go/internal/config"
+	"payment-go/internal/events"
 	"payment-go/internal/models"
 	"payment-go/internal/repositories"
 	"payment-go/internal/transport/bank/webhook"
 	"payment-go/internal/transport/model/card"
 	"payment-go/internal/utils/card_manager"
+	"payment-go/internal/utils/scheduler"
 	"sync"
 	"time"
 )
@@ -45,15 +47,23 @@ func (s *cardService) resetCardManager() {
 	if err != nil {
 		cards = []*models.Card{}
 	}
+	s.cardManager = card_manager.NewCardManager(cards)
+	fmt.Println(fmt.Sprintf("CardManager resetting. %d cards in use", s.cardManager.CardsCount()))
+}
+
+// maintainCards создаёт недостающую информацию по картам и сообщает, если активных карт нет
+func (s *cardService) maintainCards() {
+	cards, err := repositories.CardRepository().GetAllActiveCards()
+	if err != nil {
+		return
+	}
 	for _, crd := range cards {
 		_ = repositories.CardRepository().AssertInfoExists(crd.ID)
 	}
-	s.cardManager = card_manager.NewCardManager(cards)
-	fmt.Println(fmt.Sprintf("CardManager resetting. %d cards in use", s.cardManager.CardsCount()))
 
-	if s.cardManager.CardsCount() == 0 {
+	if len(cards) == 0 {
 		// запустим событие
-		go EventService().NoCardsAvailable(nil)
+		go EventBus().Publish(events.NoCardsAvailable{})
 	}
 }
 
@@ -64,7 +74,7 @@ func (s *cardService) ChooseCard(ord *models.Order) (card_manager.ISafeCard, err
 	if err != nil {
 		if err == card_manager.ErrNoCardsAvailable {
 			// запустим событие
-			go EventService().NoCardsAvailable(&ord.PaymentMethod)
+			go EventBus().Publish(events.NoCardsAvailable{PaymentMethod: &ord.PaymentMethod})
 		}
 		return nil, err
 	}
@@ -73,16 +83,14 @@ func (s *cardService) ChooseCard(ord *models.Order) (card_manager.ISafeCard, err
 }
 
 func (s *cardService) init() {
-	// запустим автоматическую актуализацию менеджера карт
-	var recursiveManagerResetting func()
-	recursiveManagerResetting = func() {
-		s.resetCardManager()
-		go func() {
-			time.Sleep(config.TaskReloadCardsInterval)
-			go recursiveManagerResetting() // рекурсия
-		}()
-	}
-	recursiveManagerResetting()
+	s.resetCardManager()
+
+	// менеджер карт и блокировки в памяти у каждого узла свои
+	sch := SchedulerService()
+	sch.Register(config.JobCardManagerReset, scheduler.Every(config.TaskReloadCardsInterval), true, Periodic(s.resetCardManager))
+	sch.Register(config.JobCardLockerGC, scheduler.Every(config.CardLockerGCInterval), true, Periodic(card_manager.CardLocker().GC))
+	// обслуживание карт, общее для кластера
+	sch.Register(config.JobCardsMaintenance, scheduler.Every(config.TaskReloadCardsInterval), false, Periodic(s.maintainCards))
 }
 
 func (s *cardService) CreateFromDto(dto *card.CreateCardDto) (*models.Card, error) {
@@ -158,7 +166,7 @@ func (s *cardService) ChangeCardBalance(req card.IChangeBalanceRequest) error {
 			return err
 		}
 		// запустим событие
-		go EventService().CardBalanceIncreased(crd)
+		go EventBus().Publish(events.CardBalanceIncreased{Card: crd})
 	} else {
 		return repositories.CardRepository().DecreaseBalance(crd.ID, req.Amount())
 	}
@@ -224,9 +232,12 @@ func (s *cardService) receivePayment(dto *webhook.BankPaymentInfoDto) (uint, err
 			return ordId, ErrDifferentAmount
 		}
 
+		EventBus().Publish(events.PaymentDetected{Order: ord})
+
 		now := uint(time.Now().Unix())
+		// заказ могла уже завершить проверка ссылки
 		err = OrderService().FinishOrderWithStatus(ord, models.StatusCompleted, now)
-		if err != nil {
+		if err != nil && !errors.Is(err, ErrOrderFinished) {
 			return ordId, fmt.Errorf("unable to finish order")
 		}
 	}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"payment-go/internal/config"
+	"payment-go/internal/events"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories"
+	analytics "payment-go/internal/transport/analytics/dispute"
+	"payment-go/internal/transport/model/dispute"
+	"payment-go/internal/utils/scheduler"
+	"sync"
+	"time"
+)
+
+type IDisputeService interface {
+	Open(dto *dispute.CreateDisputeDto) (*models.Dispute, error)
+	AddEvidence(dto *dispute.AddEvidenceDto) (*models.Dispute, error)
+	Resolve(dto *dispute.ResolveDisputeDto, won bool) (*models.Dispute, error)
+	GetRates(dto *analytics.GetDisputeRatesDto) ([]*DisputeRate, error)
+}
+type disputeService struct {
+}
+
+var dsIns *disputeService
+var dsOnce = sync.Once{}
+
+var ErrDisputeNotFound = errors.New("dispute not found")
+var ErrOrderNotCompleted = errors.New("dispute can be opened only for completed order")
+var ErrDisputeAmount = errors.New("dispute amount exceeds order amount")
+var ErrBankMessageMismatch = errors.New("bank message belongs to another order")
+
+func DisputeService() IDisputeService {
+	dsOnce.Do(func() {
+		dsIns = &disputeService{}
+		dsIns.init()
+	})
+	return dsIns
+}
+
+func (s *disputeService) init() {
+	SchedulerService().Register(config.JobDisputes, scheduler.Every(config.DisputeExpireInterval), false, Periodic(s.expire))
+}
+
+// expire проигрывает споры, по которым не подали доказательства до срока
+func (s *disputeService) expire() {
+	now := uint(time.Now().Unix())
+	disputes, err := repositories.DisputeRepository().FindExpired(now, config.DisputeExpireBatchSize)
+	if err != nil {
+		log.Println("DisputeService: unable to load expired disputes.", err)
+		return
+	}
+
+	for _, d := range disputes {
+		if !d.IsExpired(now) {
+			continue
+		}
+		if err := d.Resolve(false, now); err != nil {
+			continue
+		}
+		// спор мог закрыть администратор после выборки
+		if err := s.close(d); err != nil && !errors.Is(err, models.ErrDisputeClosed) {
+			log.Printf("DisputeService: unable to close expired dispute #%d: %v", d.ID, err)
+		}
+	}
+}
+
+func (s *disputeService) Open(dto *dispute.CreateDisputeDto) (*models.Dispute, error) {
+	if err := dto.Validate(); err != nil {
+		return nil, err
+	}
+
+	ord, err := repositories.OrderRepository().FindById(dto.OrderID)
+	if err != nil {
+		return nil, ErrOrderNotFound
+	}
+	if ord.Status != models.StatusCompleted {
+		return nil, ErrOrderNotCompleted
+	}
+
+	// по умолчанию оспаривается вся сумма заказа
+	amount := dto.Amount
+	if amount == 0 {
+		amount = ord.Amount
+	} else if amount > ord.Amount {
+		return nil, ErrDisputeAmount
+	}
+
+	deadline := dto.DeadlineAt
+	if deadline == nil {
+		moment := uint(time.Now().Add(config.DisputeDefaultDeadline).Unix())
+		deadline = &moment
+	}
+
+	d := models.NewDispute(ord, amount, dto.Reason, deadline)
+	if err := repositories.DisputeRepository().Save(d); err != nil {
+		return nil, ErrWhileSaving
+	}
+
+	return d, nil
+}
+
+func (s *disputeService) AddEvidence(dto *dispute.AddEvidenceDto) (*models.Dispute, error) {
+	if err := dto.Validate(); err != nil {
+		return nil, err
+	}
+
+	d, err := repositories.DisputeRepository().FindById(dto.DisputeID)
+	if err != nil {
+		return nil, ErrDisputeNotFound
+	}
+
+	evidence := &models.DisputeEvidence{
+		DisputeID: d.ID,
+		Payload:   dto.Payload,
+	}
+	if dto.BankMessageID != nil && *dto.BankMessageID != 0 {
+		msg, err := repositories.BankMessageRepository().FindById(*dto.BankMessageID)
+		if err != nil {
+			return nil, ErrMessageNotFound
+		}
+		if msg.OrderID != 0 && msg.OrderID != d.OrderID {
+			return nil, ErrBankMessageMismatch
+		}
+		evidence.BankMessageID = &msg.ID
+		evidence.BankMessage = msg
+	}
+
+	if err := d.SubmitEvidence(uint(time.Now().Unix())); err != nil {
+		return nil, err
+	}
+	// статус меняется, только если спор не закрыли параллельно, доказательство сохраняется вместе с ним
+	err = repositories.Transaction(func(tx *gorm.DB) error {
+		repo := repositories.DisputeRepository().WithTx(tx)
+		submitted, err := repo.SubmitEvidence(d.ID)
+		if err != nil {
+			return err
+		}
+		if !submitted {
+			return models.ErrDisputeClosed
+		}
+		return repo.SaveEvidence(evidence)
+	})
+	if errors.Is(err, models.ErrDisputeClosed) {
+		return nil, err
+	}
+	if err != nil {
+		log.Printf("error while adding evidence to dispute #%d: %v", d.ID, err)
+		return nil, ErrWhileSaving
+	}
+	d.Evidence = append(d.Evidence, evidence)
+
+	return d, nil
+}
+
+func (s *disputeService) Resolve(dto *dispute.ResolveDisputeDto, won bool) (*models.Dispute, error) {
+	d, err := repositories.DisputeRepository().FindById(dto.DisputeID)
+	if err != nil {
+		return nil, ErrDisputeNotFound
+	}
+
+	if err := d.Resolve(won, uint(time.Now().Unix())); err != nil {
+		return nil, err
+	}
+	if err := s.close(d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// close сохраняет решение по спору. Спор закрывается условным UPDATE, поэтому из параллельных запросов
+// проходит один. Проигранный спор попадает в отчёт магазина и уменьшает его баланс, см. SettlementService
+func (s *disputeService) close(d *models.Dispute) error {
+	closed, err := repositories.DisputeRepository().Close(d.ID, d.Status, *d.ClosedAt)
+	if err != nil {
+		log.Printf("error while resolving dispute #%d of shop #%d: %v", d.ID, d.ShopID, err)
+		return ErrWhileSaving
+	}
+	if !closed {
+		return models.ErrDisputeClosed
+	}
+
+	if d.Status == models.DisputeStatusLost {
+		// запустим событие
+		go EventBus().Publish(events.DisputeLost{Dispute: d})
+	}
+	return nil
+}
+
+type DisputeRate struct {
+	*repositories.DisputeRateResultDto
+	Rate             float64 `json:"rate"`
+	LostRate         float64 `json:"lost_rate"`
+	ExceedsThreshold bool    `json:"exceeds_threshold"`
+}
+
+func (s *disputeService) GetRates(dto *analytics.GetDisputeRatesDto) ([]*DisputeRate, error) {
+	if dto == nil {
+		return nil, fmt.Errorf("got invalid dto")
+	}
+	if err := dto.Validate(); err != nil {
+		return nil, err
+	}
+
+	rows, err := repositories.DisputeRepository().GetRates(dto)
+	if err != nil {
+		return nil, err
+	}
+
+	var res = make([]*DisputeRate, len(rows))
+	for i, row := range rows {
+		rate := &DisputeRate{DisputeRateResultDto: row}
+		base := row.OrdersCount
+		if base < row.DisputesCount {
+			base = row.DisputesCount
+		}
+		if base > 0 {
+			rate.Rate = float64(row.DisputesCount) / float64(base)
+			rate.LostRate = float64(row.LostCount) / float64(base)
+		}
+		rate.ExceedsThreshold = rate.Rate > config.DisputeRateModerationThreshold
+		res[i] = rate
+	}
+	return res, nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"payment-go/internal/config"
+	"payment-go/internal/events"
 	"payment-go/internal/models"
 	"payment-go/internal/repositories"
 	"payment-go/internal/transport/model/order"
@@ -13,11 +14,14 @@ import (
 type IEventService interface {
 	OrderCreated(ord *models.Order, dto *order.CreateOrderDto)
 	OrderCompleted(ord *models.Order)
-	OrderUpdated(old *models.Order, new *models.Order)
 
 	CardBalanceIncreased(crd *models.Card)
+	CardDisabled(crd *models.Card, reason string)
 	NoCardsAvailable(paymentMethod *string)
 
+	DisputeLost(d *models.Dispute)
+	SettlementMismatch(stl *models.Settlement)
+
 }
 type eventService struct {
 }
@@ -33,8 +37,6 @@ func EventService() IEventService {
 }
 
 func (s *eventService) OrderCompleted(ord *models.Order) {
-	go WebhookService().SendOrderCompleted(ord, nil)
-
 	if ord == nil || ord.Status != models.StatusCompleted {
 		return
 	}
@@ -47,33 +49,20 @@ func (s *eventService) OrderCompleted(ord *models.Order) {
 
 }
 
-func (s *eventService) OrderUpdated(old *models.Order, new *models.Order) {
-	// если статус заказа был изменён
-	if !old.IsFinished() && new.IsFinished() {
-		go s.OrderCompleted(new)
-	}
-}
-
 func (s *eventService) OrderCreated(ord *models.Order, dto *order.CreateOrderDto) {
 	fmt.Sprintf("Event.OrderCreated %#v\n", ord.ID)
 
 	// если для оплаты необходимо сгенерировать ссылку
 	if ord.HaveLink() {
 
-		// поставим задачу на фоновое создание ссылки
+		// поставим задачу на фоновое создание ссылки, вебхуки отправляются подписчиками шины
 		BankApiService().TaskCreateLink(ord, func(link *models.PaymentLink) {
-
-			// если передан вебхук, то отсылаем на него инфу о создании ссылки
-			WebhookService().SendLinkCreated(link, nil)
-
 			if link.Status == models.StatusFailed {
 				return
 			}
 
 			// если ссылка создалась, запускаем таск на проверку ссылки
-			BankApiService().TaskCheckLink(link, func(link *models.PaymentLink) {
-				WebhookService().SendOrderCompleted(ord, nil)
-			})
+			PaymentLinkService().StartChecking(link)
 		})
 	} else {
 		ord.Status = models.StatusPending
@@ -100,10 +89,15 @@ func (s *eventService) CardBalanceIncreased(crd *models.Card) {
 				"Card #%d will be deactivated soon. Card balance: %.2f",
 				crd.ID, info.Balance,
 			)
+			go EventBus().Publish(events.CardDisabled{Card: crd, Reason: events.CardDisabledReasonBalance})
 		}
 	}
 }
 
+func (s *eventService) CardDisabled(crd *models.Card, reason string) {
+	log.Printf("Event.CardDisabled: card #%d is disabled, reason: %s", crd.ID, reason)
+}
+
 func (s *eventService) NoCardsAvailable(paymentMethod *string) {
 	var text string
 	if paymentMethod != nil {
@@ -119,3 +113,31 @@ func (s *eventService) NoCardsAvailable(paymentMethod *string) {
 	}
 }
 
+func (s *eventService) DisputeLost(d *models.Dispute) {
+	if err := repositories.CardRepository().IncreaseDisputesLost(d.CardID); err != nil {
+		log.Println("Event.DisputeLost: unable to update card stats.", err)
+		return
+	}
+
+	crd, err := repositories.CardRepository().FindById(d.CardID)
+	if err != nil {
+		return
+	}
+
+	// если надо заблокировать карту
+	if crd.Stats.DisputesLost >= config.CardDisableDisputesLost && crd.Status == models.CardStatusEnabled {
+		crd.Status = models.CardStatusDisabled
+		if err = repositories.CardRepository().Save(crd); err != nil {
+			log.Println("Event.DisputeLost: unable to disable card.", err)
+			return
+		}
+		go EventBus().Publish(events.CardDisabled{Card: crd, Reason: events.CardDisabledReasonDisputes})
+	}
+}
+
+func (s *eventService) SettlementMismatch(stl *models.Settlement) {
+	log.Printf(
+		"Event.SettlementMismatch: shop #%d, %s: orders amount %.2f, bank messages amount %.2f",
+		stl.ShopID, stl.Date, stl.OrdersAmount, stl.BankMessagesAmount,
+	)
+}
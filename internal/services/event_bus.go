@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"payment-go/internal/events"
+	"payment-go/internal/providers"
+	"payment-go/internal/utils/eventbus"
+	"sync"
+)
+
+const linkReadyChannel = "link_ready"
+
+var busIns *eventbus.Bus
+var busOnce = sync.Once{}
+
+// EventBus шина доменных событий. Постоянные подписчики (события и вебхуки) регистрируются при создании.
+// OrderFinished и WithdrawUpdated публикуются только из outbox, их вебхуки отправляет OutboxService
+func EventBus() *eventbus.Bus {
+	busOnce.Do(func() {
+		busIns = eventbus.New()
+		subscribeEvents(busIns)
+		subscribeWebhooks(busIns)
+		bridgeLinkReady(busIns)
+		bridgeOrderStatus(busIns)
+	})
+	return busIns
+}
+
+func subscribeEvents(bus *eventbus.Bus) {
+	ctx := context.Background()
+
+	eventbus.Subscribe(ctx, bus, func(e events.OrderCreated) {
+		EventService().OrderCreated(e.Order, e.Dto)
+	})
+	// ошибка начисления оставляет событие в outbox для повтора
+	eventbus.SubscribeWithError(ctx, bus, func(e events.OrderFinished) error {
+		if err := OrderService().ApplyFinished(e.Order, e.EventID); err != nil {
+			return err
+		}
+		EventService().OrderCompleted(e.Order)
+		return nil
+	})
+	eventbus.Subscribe(ctx, bus, func(e events.CardBalanceIncreased) {
+		EventService().CardBalanceIncreased(e.Card)
+	})
+	eventbus.Subscribe(ctx, bus, func(e events.CardDisabled) {
+		EventService().CardDisabled(e.Card, e.Reason)
+	})
+	eventbus.Subscribe(ctx, bus, func(e events.NoCardsAvailable) {
+		EventService().NoCardsAvailable(e.PaymentMethod)
+	})
+	eventbus.Subscribe(ctx, bus, func(e events.DisputeLost) {
+		EventService().DisputeLost(e.Dispute)
+	})
+	eventbus.Subscribe(ctx, bus, func(e events.SettlementMismatch) {
+		EventService().SettlementMismatch(e.Settlement)
+	})
+	eventbus.Subscribe(ctx, bus, func(e events.WithdrawUpdated) {
+		if e.Withdraw.FinishedAt != nil {
+			WithdrawBatchService().WithdrawFinished(e.Withdraw)
+		}
+	})
+}
+
+// bridgeLinkReady пересылает итог создания ссылки через общий pub/sub, чтобы SSE-клиент,
+// подключённый к другому экземпляру, тоже получил ссылку
+func bridgeLinkReady(bus *eventbus.Bus) {
+	ctx := context.Background()
+	pubSub := providers.StateProvider().GetPubSub()
+
+	eventbus.Subscribe(ctx, bus, func(e events.LinkCreated) {
+		data, err := json.Marshal(&events.LinkReady{
+			OrderID: e.Link.OrderID,
+			URL:     e.Link.URL,
+			Status:  e.Link.Status,
+		})
+		if err != nil {
+			return
+		}
+		if err = pubSub.Publish(linkReadyChannel, string(data)); err != nil {
+			log.Println("EventBus: unable to publish link ready.", err)
+		}
+	})
+	pubSub.Subscribe(ctx, linkReadyChannel, func(payload string) {
+		e := events.LinkReady{}
+		if err := json.Unmarshal([]byte(payload), &e); err != nil {
+			return
+		}
+		bus.Publish(e)
+	})
+}
+
+func subscribeWebhooks(bus *eventbus.Bus) {
+	ctx := context.Background()
+
+	eventbus.Subscribe(ctx, bus, func(e events.LinkCreated) {
+		WebhookService().SendLinkCreated(e.Link, nil)
+	})
+}
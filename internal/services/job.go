@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"payment-go/internal/config"
+	"payment-go/internal/providers"
+	"payment-go/internal/repositories"
+	"payment-go/internal/utils/state"
+	"sync"
+)
+
+type IJobService interface {
+	RunExclusive(name string, job func(ctx context.Context))
+	IsOwner(name string) bool
+	Stop(ctx context.Context) error
+}
+type jobService struct {
+	mu     sync.RWMutex
+	leases map[string]*state.Lease
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var jobIns *jobService
+var jobOnce = sync.Once{}
+
+// JobService фоновые задачи, которые во всём кластере выполняет только один узел.
+// Владение задачей хранится в БД и переходит к другому узлу, если владелец перестал продлевать аренду.
+func JobService() IJobService {
+	jobOnce.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		jobIns = &jobService{
+			leases: make(map[string]*state.Lease),
+			ctx:    ctx,
+			cancel: cancel,
+		}
+	})
+	return jobIns
+}
+
+// RunExclusive запускает job, когда узел получит аренду задачи. ctx задачи отменяется при потере аренды
+func (s *jobService) RunExclusive(name string, job func(ctx context.Context)) {
+	lease := state.NewLease(
+		repositories.JobLeaseRepository(), name, providers.StateProvider().NodeID(), config.JobLeaseTTL,
+	)
+
+	s.mu.Lock()
+	s.leases[name] = lease
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		lease.Run(s.ctx, job)
+	}()
+}
+
+// Stop останавливает задачи и освобождает аренды, чтобы другие узлы подхватили их без ожидания TTL
+func (s *jobService) Stop(ctx context.Context) error {
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *jobService) IsOwner(name string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	lease, ok := s.leases[name]
+	return ok && lease.Held()
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories"
+	"payment-go/internal/transport/model/merchant"
+	"payment-go/internal/transport/model/shop/parts"
+	"strings"
+	"sync"
+	"time"
+)
+
+const ownerTokenPrefix = "own_"
+
+var ErrMerchantUnauthorized = errors.New("invalid shop keys or owner token")
+var ErrForeignShop = errors.New("shop is not found")
+var ErrForeignToken = errors.New("token is not found")
+
+// MerchantScope магазины и права, доступные запросу merchant API
+type MerchantScope struct {
+	OwnerID uint
+	Shops   []*models.Shop
+	// ShopKey запрос авторизован ключами одного магазина, а не токеном владельца
+	ShopKey bool
+	// User участник, от имени которого сделан запрос. Пусто для ключей магазина и токенов из админки
+	User        *models.MerchantUser
+	Permissions []models.MerchantPermission
+}
+
+func (sc *MerchantScope) Can(p models.MerchantPermission) bool {
+	for _, perm := range sc.Permissions {
+		if perm == p {
+			return true
+		}
+	}
+	return false
+}
+
+func (sc *MerchantScope) ShopIDs() []uint {
+	res := make([]uint, 0, len(sc.Shops))
+	for _, sh := range sc.Shops {
+		res = append(res, sh.ID)
+	}
+	return res
+}
+
+// FindShop магазин из области доступа. При единственном магазине id можно не указывать
+func (sc *MerchantScope) FindShop(id uint) (*models.Shop, error) {
+	if id == 0 && len(sc.Shops) == 1 {
+		return sc.Shops[0], nil
+	}
+	for _, sh := range sc.Shops {
+		if sh.ID == id {
+			return sh, nil
+		}
+	}
+	return nil, ErrForeignShop
+}
+
+type IMerchantService interface {
+	AuthorizeKeys(publicKey, privateKey string) (*MerchantScope, error)
+	AuthorizeToken(token string) (*MerchantScope, error)
+	GetBalance(sh *models.Shop) float64
+	GetScopeTokens(scope *MerchantScope) ([]*models.OwnerToken, error)
+	IssueToken(dto *merchant.IssueTokenDto) (string, *models.OwnerToken, error)
+	IssueUserToken(u *models.MerchantUser, dto *merchant.CreateTokenDto) (string, *models.OwnerToken, error)
+	RevokeScopeToken(scope *MerchantScope, id uint) (*models.OwnerToken, error)
+	RevokeToken(id uint) (*models.OwnerToken, error)
+	UpdateWebhooks(sh *models.Shop, dto *parts.ShopWebhooksDto) error
+}
+type merchantService struct {
+}
+
+var merchantIns *merchantService
+var merchantOnce = sync.Once{}
+
+// MerchantService самостоятельная работа владельцев с магазинами
+func MerchantService() IMerchantService {
+	merchantOnce.Do(func() {
+		merchantIns = &merchantService{}
+	})
+	return merchantIns
+}
+
+func (s *merchantService) AuthorizeKeys(publicKey, privateKey string) (*MerchantScope, error) {
+	pk, err := uuid.Parse(publicKey)
+	if err != nil || len(privateKey) == 0 {
+		return nil, ErrMerchantUnauthorized
+	}
+
+	sh, err := repositories.ShopRepository().FindByShopKeys(models.ShopKeys{PublicKey: pk, PrivateKey: privateKey})
+	if err != nil {
+		return nil, ErrMerchantUnauthorized
+	}
+	if m, err := repositories.MerchantRepository().FindById(sh.OwnerId); err != nil || !m.Active {
+		return nil, ErrMerchantUnauthorized
+	}
+	return &MerchantScope{
+		OwnerID:     sh.OwnerId,
+		Shops:       []*models.Shop{sh},
+		ShopKey:     true,
+		Permissions: models.ShopKeyPermissions,
+	}, nil
+}
+
+// AuthorizeToken открывает доступ ко всем магазинам мерчанта по токену API или сессии кабинета
+func (s *merchantService) AuthorizeToken(token string) (*MerchantScope, error) {
+	if len(token) == 0 {
+		return nil, ErrMerchantUnauthorized
+	}
+	if !strings.HasPrefix(token, ownerTokenPrefix) {
+		u, err := MerchantAccountService().Authenticate(token)
+		if err != nil {
+			return nil, ErrMerchantUnauthorized
+		}
+		return s.membershipScope(u.MerchantId, u)
+	}
+
+	t, err := repositories.OwnerTokenRepository().FindByHash(tokenHash(token))
+	if err != nil || !t.IsActive() {
+		return nil, ErrMerchantUnauthorized
+	}
+
+	// токен участника действует, пока участник состоит в мерчанте
+	var u *models.MerchantUser
+	if t.MerchantUserId != 0 {
+		u, err = repositories.MerchantUserRepository().FindById(t.MerchantUserId)
+		if err != nil || !u.IsActive() || u.MerchantId != t.OwnerId {
+			return nil, ErrMerchantUnauthorized
+		}
+	}
+
+	if err = repositories.OwnerTokenRepository().Touch(t.ID); err != nil {
+		log.Println(err)
+	}
+	return s.membershipScope(t.OwnerId, u)
+}
+
+// membershipScope магазины действующего мерчанта с правами роли участника
+func (s *merchantService) membershipScope(merchantId uint, u *models.MerchantUser) (*MerchantScope, error) {
+	m, err := repositories.MerchantRepository().FindById(merchantId)
+	if err != nil || !m.Active {
+		return nil, ErrMerchantUnauthorized
+	}
+
+	shops, err := repositories.ShopRepository().GetUserShops(m.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := &MerchantScope{
+		OwnerID:     m.ID,
+		Shops:       shops,
+		User:        u,
+		Permissions: models.ShopKeyPermissions,
+	}
+	if u != nil {
+		scope.Permissions = models.MerchantRolePermissions(u.Role)
+	}
+	return scope, nil
+}
+
+func (s *merchantService) GetBalance(sh *models.Shop) float64 {
+	balance, err := SettlementService().GetBalance(sh.ID)
+	if err != nil {
+		log.Printf("MerchantService: unable to get balance of shop #%d: %v", sh.ID, err)
+		return 0
+	}
+	return balance
+}
+
+// IssueToken выпускает из админки токен на весь мерчант
+func (s *merchantService) IssueToken(dto *merchant.IssueTokenDto) (string, *models.OwnerToken, error) {
+	if err := dto.Validate(); err != nil {
+		return "", nil, err
+	}
+	if _, err := repositories.MerchantRepository().FindById(dto.OwnerID); err != nil {
+		return "", nil, fmt.Errorf("merchant #%d is not found", dto.OwnerID)
+	}
+	return s.issueToken(dto.OwnerID, 0, dto.Name)
+}
+
+// IssueUserToken выпускает токен участника, он действует с правами его роли
+func (s *merchantService) IssueUserToken(u *models.MerchantUser, dto *merchant.CreateTokenDto) (string, *models.OwnerToken, error) {
+	if err := dto.Validate(); err != nil {
+		return "", nil, err
+	}
+	return s.issueToken(u.MerchantId, u.ID, dto.Name)
+}
+
+// GetScopeTokens владелец видит все токены мерчанта, остальные участники только свои
+func (s *merchantService) GetScopeTokens(scope *MerchantScope) ([]*models.OwnerToken, error) {
+	if scope.Can(models.MerchantPermissionTeam) {
+		return repositories.OwnerTokenRepository().GetOwnerTokens(scope.OwnerID)
+	}
+	if scope.User == nil {
+		return nil, ErrForeignToken
+	}
+	return repositories.OwnerTokenRepository().GetUserTokens(scope.User.ID)
+}
+
+func (s *merchantService) RevokeScopeToken(scope *MerchantScope, id uint) (*models.OwnerToken, error) {
+	t, err := repositories.OwnerTokenRepository().FindById(id)
+	if err != nil || t.OwnerId != scope.OwnerID {
+		return nil, ErrForeignToken
+	}
+	own := scope.User != nil && t.MerchantUserId == scope.User.ID
+	if !own && !scope.Can(models.MerchantPermissionTeam) {
+		return nil, ErrForeignToken
+	}
+	return s.RevokeToken(t.ID)
+}
+
+func (s *merchantService) RevokeToken(id uint) (*models.OwnerToken, error) {
+	t, err := repositories.OwnerTokenRepository().FindById(id)
+	if err != nil {
+		return nil, err
+	}
+	if !t.IsActive() {
+		return t, nil
+	}
+
+	now := uint(time.Now().Unix())
+	t.RevokedAt = &now
+	return t, repositories.OwnerTokenRepository().Save(t)
+}
+
+func (s *merchantService) UpdateWebhooks(sh *models.Shop, dto *parts.ShopWebhooksDto) error {
+	sh.Webhooks = dto.Resolve()
+	return repositories.ShopRepository().Save(sh)
+}
+
+// issueToken токен возвращается только здесь, в базе лежит его хеш
+func (s *merchantService) issueToken(ownerId, merchantUserId uint, name string) (string, *models.OwnerToken, error) {
+	token, err := randomToken(ownerTokenPrefix)
+	if err != nil {
+		return "", nil, err
+	}
+
+	t := &models.OwnerToken{
+		OwnerId:        ownerId,
+		MerchantUserId: merchantUserId,
+		Name:           name,
+		TokenHash:      tokenHash(token),
+	}
+	if err = repositories.OwnerTokenRepository().Save(t); err != nil {
+		return "", nil, fmt.Errorf("error while issuing token")
+	}
+	return token, t, nil
+}
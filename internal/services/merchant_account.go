@@ -0,0 +1,340 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"payment-go/internal/config"
+	"payment-go/internal/models"
+	"payment-go/internal/providers"
+	"payment-go/internal/repositories"
+	"payment-go/internal/transport/model/merchant"
+	"payment-go/internal/utils/password"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const merchantSessionPrefix = "merchant_session:"
+
+var ErrInviteInvalid = errors.New("invitation is invalid or expired")
+var ErrForeignMember = errors.New("member is not found")
+var ErrLastOwner = errors.New("merchant must keep at least one active owner")
+
+type IMerchantAccountService interface {
+	AcceptInvite(dto *merchant.AcceptInviteDto) (*models.MerchantUser, error)
+	Authenticate(token string) (*models.MerchantUser, error)
+	CreateFromDto(dto *merchant.CreateMerchantDto) (*models.Merchant, *merchant.InviteResponseDto, error)
+	EnsureMerchants() error
+	Invite(merchantId, invitedBy uint, dto *merchant.InviteDto) (*merchant.InviteResponseDto, error)
+	Login(dto *merchant.LoginDto) (*merchant.LoginResponseDto, error)
+	Logout(token string) error
+	RemoveMember(merchantId uint, actor *models.MerchantUser, id uint) error
+	UpdateFromDto(dto *merchant.UpdateMerchantDto) (*models.Merchant, error)
+	UpdateMember(merchantId uint, actor *models.MerchantUser, dto *merchant.UpdateMemberDto) (*models.MerchantUser, error)
+}
+type merchantAccountService struct {
+}
+
+var merchantAccountIns *merchantAccountService
+var merchantAccountOnce = sync.Once{}
+
+// MerchantAccountService мерчанты, их участники, приглашения и сессии кабинета
+func MerchantAccountService() IMerchantAccountService {
+	merchantAccountOnce.Do(func() {
+		merchantAccountIns = &merchantAccountService{}
+	})
+	return merchantAccountIns
+}
+
+// EnsureMerchants заводит мерчантов для owner_id магазинов, созданных до появления мерчантов
+func (s *merchantAccountService) EnsureMerchants() error {
+	owners, err := repositories.MerchantRepository().GetOrphanOwners()
+	if err != nil {
+		return err
+	}
+
+	for _, id := range owners {
+		m := &models.Merchant{
+			Model:  gorm.Model{ID: id},
+			Name:   fmt.Sprintf("Merchant #%d", id),
+			Active: true,
+		}
+		if err = repositories.MerchantRepository().Create(m); err != nil {
+			return fmt.Errorf("unable to create merchant #%d: %w", id, err)
+		}
+	}
+	if len(owners) != 0 {
+		log.Printf("Merchant: created %d merchants for existing shop owners", len(owners))
+	}
+	return nil
+}
+
+// CreateFromDto создаёт мерчанта и приглашение для его владельца
+func (s *merchantAccountService) CreateFromDto(dto *merchant.CreateMerchantDto) (*models.Merchant, *merchant.InviteResponseDto, error) {
+	if err := dto.Validate(); err != nil {
+		return nil, nil, err
+	}
+	if _, err := repositories.MerchantUserRepository().FindByEmail(dto.OwnerEmail); err == nil {
+		return nil, nil, fmt.Errorf("email '%s' is already registered", dto.OwnerEmail)
+	}
+
+	m := &models.Merchant{
+		Name:   dto.Name,
+		Active: true,
+	}
+	if err := repositories.MerchantRepository().Save(m); err != nil {
+		return nil, nil, fmt.Errorf("error while creating merchant")
+	}
+
+	invite, err := s.Invite(m.ID, 0, &merchant.InviteDto{
+		Email: dto.OwnerEmail,
+		Name:  dto.OwnerName,
+		Role:  models.MerchantRoleOwner,
+	})
+	if err != nil {
+		return m, nil, err
+	}
+	return m, invite, nil
+}
+
+func (s *merchantAccountService) UpdateFromDto(dto *merchant.UpdateMerchantDto) (*models.Merchant, error) {
+	if err := dto.Validate(); err != nil {
+		return nil, err
+	}
+
+	m, err := repositories.MerchantRepository().FindById(dto.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Name = dto.Name
+	m.Active = dto.Active
+	if err = repositories.MerchantRepository().Save(m); err != nil {
+		return m, err
+	}
+	return m, nil
+}
+
+// Invite приглашает участника. Повторное приглашение того же email в тот же мерчант выдаёт новый токен
+func (s *merchantAccountService) Invite(merchantId, invitedBy uint, dto *merchant.InviteDto) (*merchant.InviteResponseDto, error) {
+	if err := dto.Validate(); err != nil {
+		return nil, err
+	}
+
+	u, err := repositories.MerchantUserRepository().FindByEmail(dto.Email)
+	if err == nil && (u.MerchantId != merchantId || u.JoinedAt != nil) {
+		return nil, fmt.Errorf("email '%s' is already registered", dto.Email)
+	} else if err != nil {
+		u = &models.MerchantUser{
+			MerchantId: merchantId,
+			Email:      dto.Email,
+		}
+	}
+
+	token, err := randomToken("inv_")
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := uint(time.Now().Add(config.MerchantInviteTTL).Unix())
+
+	u.Name = dto.Name
+	u.Role = dto.Role
+	u.InvitedBy = invitedBy
+	u.InviteHash = tokenHash(token)
+	u.InviteExpiresAt = &expiresAt
+	if err = repositories.MerchantUserRepository().Save(u); err != nil {
+		return nil, fmt.Errorf("error while saving invitation")
+	}
+
+	return &merchant.InviteResponseDto{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		Member:    merchant.FromMerchantUser(u),
+	}, nil
+}
+
+// AcceptInvite задаёт пароль приглашённому и делает его участником
+func (s *merchantAccountService) AcceptInvite(dto *merchant.AcceptInviteDto) (*models.MerchantUser, error) {
+	if err := dto.Validate(); err != nil {
+		return nil, err
+	}
+
+	u, err := repositories.MerchantUserRepository().FindByInviteHash(tokenHash(dto.Token))
+	if err != nil || u.JoinedAt != nil || u.InviteExpiresAt == nil || *u.InviteExpiresAt < uint(time.Now().Unix()) {
+		return nil, ErrInviteInvalid
+	}
+
+	if u.PasswordHash, err = password.Hash(dto.Password); err != nil {
+		return nil, err
+	}
+	if len(dto.Name) != 0 {
+		u.Name = dto.Name
+	}
+	now := uint(time.Now().Unix())
+	u.JoinedAt = &now
+	u.InviteHash = ""
+	u.InviteExpiresAt = nil
+
+	if err = repositories.MerchantUserRepository().Save(u); err != nil {
+		return nil, fmt.Errorf("error while accepting invitation")
+	}
+	return u, nil
+}
+
+// UpdateMember меняет роль или блокирует участника. Себя менять нельзя, последний владелец остаётся владельцем
+func (s *merchantAccountService) UpdateMember(merchantId uint, actor *models.MerchantUser, dto *merchant.UpdateMemberDto) (*models.MerchantUser, error) {
+	if err := dto.Validate(); err != nil {
+		return nil, err
+	}
+
+	u, err := s.findMember(merchantId, dto.ID)
+	if err != nil {
+		return nil, err
+	}
+	if actor != nil && actor.ID == u.ID && (dto.Disabled || dto.Role != u.Role) {
+		return nil, fmt.Errorf("unable to change own role or disable yourself")
+	}
+	if (dto.Disabled || dto.Role != models.MerchantRoleOwner) && u.Role == models.MerchantRoleOwner && u.IsActive() {
+		if err = s.checkOwnerLeft(merchantId); err != nil {
+			return nil, err
+		}
+	}
+
+	u.Role = dto.Role
+	u.Disabled = dto.Disabled
+	if err = repositories.MerchantUserRepository().Save(u); err != nil {
+		return u, err
+	}
+	return u, nil
+}
+
+// RemoveMember удаляет участника или отзывает приглашение, токены участника отзываются
+func (s *merchantAccountService) RemoveMember(merchantId uint, actor *models.MerchantUser, id uint) error {
+	u, err := s.findMember(merchantId, id)
+	if err != nil {
+		return err
+	}
+	if actor != nil && actor.ID == u.ID {
+		return fmt.Errorf("unable to remove yourself")
+	}
+	if u.Role == models.MerchantRoleOwner && u.IsActive() {
+		if err = s.checkOwnerLeft(merchantId); err != nil {
+			return err
+		}
+	}
+
+	if err = repositories.OwnerTokenRepository().RevokeUserTokens(u.ID); err != nil {
+		return err
+	}
+	return repositories.MerchantUserRepository().Delete(u.ID)
+}
+
+// Login открывает сессию кабинета мерчанта. Токен отдаётся клиенту один раз, в хранилище лежит его хеш
+func (s *merchantAccountService) Login(dto *merchant.LoginDto) (*merchant.LoginResponseDto, error) {
+	if err := dto.Validate(); err != nil {
+		return nil, err
+	}
+
+	u, err := repositories.MerchantUserRepository().FindByEmail(dto.Email)
+	if err != nil || !u.IsActive() || !password.Verify(dto.Password, u.PasswordHash) {
+		log.Printf("Merchant: failed login attempt for '%s'", dto.Email)
+		return nil, ErrInvalidCredentials
+	}
+
+	token, err := randomToken("")
+	if err != nil {
+		return nil, err
+	}
+
+	store := providers.StateProvider().GetStore()
+	if err = store.Set(merchantSessionKey(token), strconv.Itoa(int(u.ID)), config.MerchantSessionTTL); err != nil {
+		return nil, err
+	}
+
+	now := uint(time.Now().Unix())
+	u.LastLoginAt = &now
+	if err = repositories.MerchantUserRepository().Save(u); err != nil {
+		log.Println(err)
+	}
+
+	return &merchant.LoginResponseDto{
+		Token:     token,
+		ExpiresAt: time.Now().Add(config.MerchantSessionTTL).Unix(),
+		User:      merchant.FromMerchantUser(u),
+	}, nil
+}
+
+func (s *merchantAccountService) Logout(token string) error {
+	return providers.StateProvider().GetStore().Delete(merchantSessionKey(token))
+}
+
+// Authenticate находит участника сессии и продлевает её. Роль и блокировка читаются из базы на каждый запрос
+func (s *merchantAccountService) Authenticate(token string) (*models.MerchantUser, error) {
+	if len(token) == 0 {
+		return nil, ErrSessionExpired
+	}
+
+	store := providers.StateProvider().GetStore()
+	key := merchantSessionKey(token)
+	value, err := store.Get(key)
+	if err != nil {
+		return nil, ErrSessionExpired
+	}
+	id, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		return nil, ErrSessionExpired
+	}
+
+	u, err := repositories.MerchantUserRepository().FindById(uint(id))
+	if err != nil || !u.IsActive() {
+		_ = store.Delete(key)
+		return nil, ErrSessionExpired
+	}
+
+	if _, err = store.CompareAndExpire(key, value, config.MerchantSessionTTL); err != nil {
+		log.Println(err)
+	}
+	return u, nil
+}
+
+func (s *merchantAccountService) findMember(merchantId, id uint) (*models.MerchantUser, error) {
+	u, err := repositories.MerchantUserRepository().FindById(id)
+	if err != nil || u.MerchantId != merchantId {
+		return nil, ErrForeignMember
+	}
+	return u, nil
+}
+
+// checkOwnerLeft проверяет, что после изменения у мерчанта останется действующий владелец
+func (s *merchantAccountService) checkOwnerLeft(merchantId uint) error {
+	count, err := repositories.MerchantUserRepository().CountOwners(merchantId)
+	if err != nil {
+		return err
+	}
+	if count < 2 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+func merchantSessionKey(token string) string {
+	return merchantSessionPrefix + tokenHash(token)
+}
+
+func randomToken(prefix string) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return prefix + hex.EncodeToString(raw), nil
+}
+
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
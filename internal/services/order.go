@@ -1,22 +1,31 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 	"log"
+	"payment-go/internal/events"
 	"payment-go/internal/models"
 	"payment-go/internal/repositories"
 	"payment-go/internal/transport/analytics/card"
 	card2 "payment-go/internal/transport/model/card"
 	"payment-go/internal/transport/model/order"
+	"payment-go/internal/utils/card_manager"
 	"sync"
+	"time"
 )
 
 type IOrderService interface {
+	ApplyFinished(ord *models.Order, eventId uuid.UUID) error
 	Create(dto *order.CreateOrderDto) (*models.Order, error)
 	FinishOrderWithStatus(ord *models.Order, status string, moment uint) error
 	GetOrderStatus(orderNumber string) (string, error)
 	GetPaymentInfo(orderNumber string) (order.IOrderPaymentInfoDto, error)
 	GetTotals(dto *card.GetTotalsDto) ([]*repositories.TotalsResultDto, error)
+	SwitchCard(ord *models.Order) error
+	GetReturnUrl(ord *models.Order) (string, error)
 	UpdateFromDto(dto *order.UpdateOrderDto) (*models.Order, error)
 }
 type orderService struct {
@@ -25,6 +34,8 @@ type orderService struct {
 var orderIns IOrderService
 var orderOnce = sync.Once{}
 
+var ErrOrderFinished = errors.New("order is already finished")
+
 func OrderService() IOrderService {
 	orderOnce.Do(func() {
 		orderIns = &orderService{}
@@ -51,14 +62,26 @@ func (s *orderService) Create(dto *order.CreateOrderDto) (*models.Order, error)
 		return nil, fmt.Errorf("shop is inactive")
 	}
 
+	// адреса возврата должны вести на хост магазина
+	successUrl, err := resolveReturnUrl(sh, dto.SuccessUrl)
+	if err != nil {
+		return nil, fmt.Errorf("success_url: %w", err)
+	}
+	failUrl, err := resolveReturnUrl(sh, dto.FailUrl)
+	if err != nil {
+		return nil, fmt.Errorf("fail_url: %w", err)
+	}
+
 	ord := &models.Order{
 		Payload:       dto.Payload,
 		Amount:        dto.Amount,
 		PaymentMethod: dto.PaymentMethod,
 		//Card:          *crd,
-		Shop:     *sh,
-		DatePaid: nil,
-		Status:   models.StatusNew,
+		Shop:       *sh,
+		DatePaid:   nil,
+		Status:     models.StatusNew,
+		SuccessUrl: successUrl,
+		FailUrl:    failUrl,
 	}
 
 	// подберём нужную карту
@@ -77,11 +100,81 @@ func (s *orderService) Create(dto *order.CreateOrderDto) (*models.Order, error)
 	crd.SetOrderId(ord.ID)
 
 	// запустим события
-	go EventService().OrderCreated(ord, dto)
+	go EventBus().Publish(events.OrderCreated{Order: ord, Dto: dto})
 
 	return ord, nil
 }
 
+// SwitchCard меняет карту заказа на другую подходящую и снимает блокировку со старой
+func (s *orderService) SwitchCard(ord *models.Order) error {
+	// старая карта ещё заблокирована заказом, поэтому менеджер выберет другую
+	crd, err := CardService().ChooseCard(ord)
+	if err != nil {
+		return err
+	}
+
+	oldCardId := ord.CardID
+	ord.Card = *crd.GetCard()
+	ord.CardID = ord.Card.ID
+	if err := repositories.OrderRepository().Save(ord); err != nil {
+		crd.Unlock()
+		return fmt.Errorf("error while saving")
+	}
+	crd.SetOrderId(ord.ID)
+
+	if lock := card_manager.CardLocker().GetLocked(oldCardId); lock != nil && lock.GetOrderId() == ord.ID {
+		lock.Unlock()
+	}
+	return nil
+}
+
+var ErrNoReturnUrl = errors.New("return url is not specified")
+
+// GetReturnUrl адрес возврата плательщика в магазин для завершённого заказа, с подписанным статусом
+func (s *orderService) GetReturnUrl(ord *models.Order) (string, error) {
+	var target *string
+	switch ord.Status {
+	case models.StatusCompleted:
+		target = firstNotEmpty(ord.SuccessUrl, ord.Shop.Redirects.SuccessUrl)
+	case models.StatusFailed:
+		target = firstNotEmpty(ord.FailUrl, ord.Shop.Redirects.FailUrl)
+	}
+	if target == nil {
+		return "", ErrNoReturnUrl
+	}
+
+	link, err := ord.Shop.ResolveUrl(*target)
+	if err != nil {
+		return "", err
+	}
+
+	query := &order.ReturnQuery{
+		OrderNumber: ord.Number.String(),
+		Status:      ord.Status,
+	}
+	return query.Apply(link, ord.Shop.Keys.PrivateKey)
+}
+
+func resolveReturnUrl(sh *models.Shop, link string) (*string, error) {
+	if len(link) == 0 {
+		return nil, nil
+	}
+	res, err := sh.ResolveUrl(link)
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func firstNotEmpty(values ...*string) *string {
+	for _, val := range values {
+		if val != nil && len(*val) != 0 {
+			return val
+		}
+	}
+	return nil
+}
+
 func (s *orderService) UpdateFromDto(dto *order.UpdateOrderDto) (*models.Order, error) {
 	if err := dto.Validate(); err != nil {
 		return nil, err
@@ -94,13 +187,17 @@ func (s *orderService) UpdateFromDto(dto *order.UpdateOrderDto) (*models.Order,
 
 	newOrd := *ord
 	newOrd.Status = dto.Status
-	err = repositories.OrderRepository().Save(&newOrd)
+	if !ord.IsFinished() && newOrd.IsFinished() {
+		err = s.FinishOrderWithStatus(&newOrd, dto.Status, uint(time.Now().Unix()))
+	} else {
+		err = repositories.OrderRepository().Save(&newOrd)
+	}
 	if err != nil {
 		return ord, err
 	}
 
 	// запустим события
-	EventService().OrderUpdated(ord, &newOrd)
+	go EventBus().Publish(events.OrderUpdated{Old: ord, New: &newOrd})
 
 	return ord, nil
 }
@@ -123,32 +220,72 @@ func (s *orderService) GetTotals(dto *card.GetTotalsDto) ([]*repositories.Totals
 	return repositories.OrderRepository().GetTotals(dto)
 }
 
+// FinishOrderWithStatus завершает заказ. Если его уже завершил другой обработчик, возвращает ErrOrderFinished
 func (s *orderService) FinishOrderWithStatus(ord *models.Order, status string, moment uint) error {
+	err := repositories.Transaction(func(tx *gorm.DB) error {
+		return finishOrder(tx, ord, status, moment, false)
+	})
+	if err != nil {
+		return err
+	}
+	OutboxService().Flush()
+	return nil
+}
 
+// finishOrder завершает заказ в транзакции tx, Flush вызывается после её фиксации.
+// Заказ меняется условным UPDATE, и событие о завершении пишется в outbox, только если заказ изменился:
+// иначе SMS и проверка ссылки, завершившие заказ одновременно, начислили бы оплату дважды.
+// reopen разрешает завершить заказ с отказом, см. IOrderRepository.Finish
+func finishOrder(tx *gorm.DB, ord *models.Order, status string, moment uint, reopen bool) error {
 	if !models.IsStatusValid(status) {
 		return fmt.Errorf("invalid status on finishing the order #%d", ord.ID)
 	}
 
+	finished, err := repositories.OrderRepository().WithTx(tx).Finish(ord.ID, status, ord.Amount, moment, reopen)
+	if err != nil {
+		return err
+	}
+	if !finished {
+		return ErrOrderFinished
+	}
 	ord.Status = status
 	ord.DatePaid = &moment
-	if err := repositories.OrderRepository().Save(ord); err != nil {
-		return err
+
+	// остальное сделают подписчики события
+	return repositories.OutboxRepository().WithTx(tx).Save(models.NewOutboxEvent(models.OutboxTypeOrderFinished, ord.ID))
+}
+
+// ApplyFinished начисляет оплату заказа на баланс карты. Отметка об обработке события и начисление
+// сохраняются в одной транзакции: при ошибке ничего не меняется, и outbox повторит событие.
+// Баланс магазина считается по отчётам, см. SettlementService().GetBalance
+func (s *orderService) ApplyFinished(ord *models.Order, eventId uuid.UUID) error {
+	if ord.Status != models.StatusCompleted {
+		return nil
+	}
+	req, err := card2.NewChangeBalanceRequest(ord.GetCardId(), ord.Amount, true)
+	if err != nil {
+		// повтор не поможет
+		log.Printf("unable to apply finished order #%d: %v", ord.ID, err)
+		return nil
 	}
 
-	if status == models.StatusCompleted {
-		crd := ord.Card
-		req, err := card2.NewChangeBalanceRequest(crd.ID, ord.Amount, true)
-		if err == nil {
-			err = CardService().ChangeCardBalance(req)
-			if err != nil {
-				log.Printf("error while changing card balance after completed order")
-			}
-		} else {
-			log.Println(err)
+	claimed := false
+	err = repositories.Transaction(func(tx *gorm.DB) error {
+		claimed, err = repositories.OutboxRepository().WithTx(tx).Claim(eventId, "order.balance")
+		if err != nil || !claimed {
+			return err
 		}
+		return repositories.CardRepository().WithTx(tx).IncreaseBalance(req.CardID(), req.Amount())
+	})
+	if err != nil {
+		return fmt.Errorf("error while applying finished order #%d: %w", ord.ID, err)
 	}
 
-	defer EventService().OrderCompleted(ord)
+	if claimed {
+		if crd, err := repositories.CardRepository().FindById(req.CardID()); err == nil {
+			go EventBus().Publish(events.CardBalanceIncreased{Card: crd})
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"payment-go/internal/config"
+	"payment-go/internal/events"
+	"payment-go/internal/models"
+	"payment-go/internal/providers"
+	"payment-go/internal/repositories"
+	"payment-go/internal/transport/model/order"
+	"payment-go/internal/utils/eventbus"
+	"sync"
+	"time"
+)
+
+const orderStatusChannel = "order_status"
+
+type IOrderStreamService interface {
+	Subscribe(ctx context.Context, ord *models.Order, lastEventId uint) <-chan *order.StatusEventDto
+	Snapshot(ord *models.Order) []*order.StatusEventDto
+	ExpiresAt(ord *models.Order) time.Time
+}
+type orderStreamService struct {
+}
+
+var orderStreamIns IOrderStreamService
+var orderStreamOnce = sync.Once{}
+
+// OrderStreamService поток этапов заказа для плательщика: снимок из базы и живые события шины
+func OrderStreamService() IOrderStreamService {
+	orderStreamOnce.Do(func() {
+		orderStreamIns = &orderStreamService{}
+	})
+	return orderStreamIns
+}
+
+// Subscribe отдаёт этапы заказа после lastEventId. Канал закрывается после итогового статуса или отмены ctx
+func (s *orderStreamService) Subscribe(ctx context.Context, ord *models.Order, lastEventId uint) <-chan *order.StatusEventDto {
+	out := make(chan *order.StatusEventDto)
+	if lastEventId >= order.StatusEventFinalStage {
+		close(out)
+		return out
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	// подписываемся до снимка, чтобы не пропустить этап между чтением из базы и подпиской.
+	// Повторы отсекаются по id. Пока снимок отправляется, события ждут в буфере, а при переполнении
+	// издатель ждёт читателя: терять этап нельзя, а после отмены ctx ожидание прекращается
+	live := make(chan *order.StatusEventDto, 8)
+	eventbus.Subscribe(ctx, EventBus(), func(e events.OrderStatusChanged) {
+		if e.OrderID != ord.ID {
+			return
+		}
+		ev := e.Event
+		select {
+		case live <- &ev:
+		case <-ctx.Done():
+		}
+	})
+
+	go func() {
+		defer cancel()
+		defer close(out)
+
+		last := lastEventId
+		send := func(ev *order.StatusEventDto) bool {
+			if ev.Id <= last {
+				return true
+			}
+			select {
+			case out <- ev:
+			case <-ctx.Done():
+				return false
+			}
+			last = ev.Id
+			return !ev.IsFinal()
+		}
+
+		for _, ev := range s.Snapshot(ord) {
+			if !send(ev) {
+				return
+			}
+		}
+
+		expire := time.NewTimer(time.Until(s.ExpiresAt(ord)))
+		defer expire.Stop()
+		for {
+			select {
+			case ev := <-live:
+				if !send(ev) {
+					return
+				}
+			case <-expire.C:
+				ev := order.NewStatusEventDto(order.StatusEventExpired, ord.Number.String(), ord.Status, time.Now().Unix())
+				if !send(ev) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Snapshot этапы, которые заказ уже прошёл, по данным из базы
+func (s *orderStreamService) Snapshot(ord *models.Order) []*order.StatusEventDto {
+	fresh, err := repositories.OrderRepository().FindById(ord.ID)
+	if err == nil {
+		ord = fresh
+	}
+	number := ord.Number.String()
+	res := make([]*order.StatusEventDto, 0, 4)
+
+	link, err := repositories.PaymentLinkRepository().FindByOrderId(ord.ID)
+	if err == nil && len(link.URL) != 0 && link.Status != models.StatusFailed {
+		ev := order.NewStatusEventDto(order.StatusEventLinkCreated, number, ord.Status, link.UpdatedAt.Unix())
+		ev.Link = link.URL
+		res = append(res, ev)
+	}
+
+	switch ord.Status {
+	case models.StatusCompleted:
+		at := ord.UpdatedAt.Unix()
+		if ord.DatePaid != nil {
+			at = int64(*ord.DatePaid)
+		}
+		res = append(res,
+			order.NewStatusEventDto(order.StatusEventPaymentDetected, number, ord.Status, at),
+			order.NewStatusEventDto(order.StatusEventCompleted, number, ord.Status, at),
+		)
+	case models.StatusFailed:
+		res = append(res, order.NewStatusEventDto(order.StatusEventFailed, number, ord.Status, ord.UpdatedAt.Unix()))
+	default:
+		if expiresAt := s.ExpiresAt(ord); time.Now().After(expiresAt) {
+			res = append(res, order.NewStatusEventDto(order.StatusEventExpired, number, ord.Status, expiresAt.Unix()))
+		}
+	}
+	return res
+}
+
+// ExpiresAt после этого плательщику показывается экран истечения времени
+func (s *orderStreamService) ExpiresAt(ord *models.Order) time.Time {
+	return ord.CreatedAt.Add(config.CreateLinkTimeout + config.GetConfig().LinkCheck.Timeout)
+}
+
+// bridgeOrderStatus переводит доменные события в этапы потока и рассылает их через общий pub/sub,
+// чтобы клиент, подключённый к другому экземпляру, тоже их получил
+func bridgeOrderStatus(bus *eventbus.Bus) {
+	ctx := context.Background()
+	pubSub := providers.StateProvider().GetPubSub()
+
+	publish := func(ord *models.Order, status string, event string, link string) {
+		ev := order.NewStatusEventDto(event, ord.Number.String(), status, time.Now().Unix())
+		ev.Link = link
+		data, err := json.Marshal(&events.OrderStatusChanged{OrderID: ord.ID, Event: *ev})
+		if err != nil {
+			return
+		}
+		if err = pubSub.Publish(orderStatusChannel, string(data)); err != nil {
+			log.Println("EventBus: unable to publish order status.", err)
+		}
+	}
+
+	eventbus.Subscribe(ctx, bus, func(e events.LinkCreated) {
+		if e.Link.Status == models.StatusPending {
+			publish(&e.Link.Order, models.StatusPending, order.StatusEventLinkCreated, e.Link.URL)
+		}
+	})
+	eventbus.Subscribe(ctx, bus, func(e events.PaymentDetected) {
+		publish(e.Order, e.Order.Status, order.StatusEventPaymentDetected, "")
+	})
+	eventbus.Subscribe(ctx, bus, func(e events.OrderFinished) {
+		switch e.Order.Status {
+		case models.StatusCompleted:
+			publish(e.Order, e.Order.Status, order.StatusEventCompleted, "")
+		case models.StatusFailed:
+			publish(e.Order, e.Order.Status, order.StatusEventFailed, "")
+		}
+	})
+	pubSub.Subscribe(ctx, orderStatusChannel, func(payload string) {
+		e := events.OrderStatusChanged{}
+		if err := json.Unmarshal([]byte(payload), &e); err != nil {
+			return
+		}
+		bus.Publish(e)
+	})
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"payment-go/internal/config"
+	"payment-go/internal/events"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories"
+	"payment-go/internal/utils/scheduler"
+	"sync"
+	"time"
+)
+
+type IOutboxService interface {
+	SaveWithEvent(entity any, typ string, aggregateId uint) error
+	Flush()
+}
+type outboxService struct {
+	mu sync.Mutex
+}
+
+var obsIns *outboxService
+var obsOnce = sync.Once{}
+
+var ErrUnknownOutboxEvent = errors.New("unknown outbox event type")
+
+func OutboxService() IOutboxService {
+	obsOnce.Do(func() {
+		obsIns = &outboxService{}
+		obsIns.init()
+	})
+	return obsIns
+}
+
+func (s *outboxService) init() {
+	// фоновая отправка событий, в том числе оставшихся после падения процесса
+	SchedulerService().Register(config.JobOutboxRelay, scheduler.Every(config.OutboxRelayInterval), false, Periodic(s.relay))
+}
+
+// SaveWithEvent сохраняет сущность вместе с событием в outbox и сразу запускает отправку
+func (s *outboxService) SaveWithEvent(entity any, typ string, aggregateId uint) error {
+	if err := repositories.OutboxRepository().SaveWithEvents(entity, models.NewOutboxEvent(typ, aggregateId)); err != nil {
+		return err
+	}
+	s.Flush()
+	return nil
+}
+
+// Flush запускает внеочередную отправку событий, если этот узел выполняет общие задачи планировщика
+func (s *outboxService) Flush() {
+	if JobService().IsOwner(config.JobScheduler) {
+		go s.relay()
+	}
+}
+
+func (s *outboxService) relay() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	list, err := repositories.OutboxRepository().GetDue(uint(time.Now().Unix()), config.OutboxBatchSize)
+	if err != nil {
+		log.Println("OutboxService: unable to get events.", err)
+		return
+	}
+	for _, e := range list {
+		s.process(e)
+	}
+}
+
+// process доставляет событие в шину и на вебхук. Каждый шаг выполняется до первого успеха,
+// поэтому получатели могут увидеть событие повторно и должны дедуплицировать его по EventID
+func (s *outboxService) process(e *models.OutboxEvent) {
+	event, deliver, err := s.decode(e)
+	if err != nil {
+		s.retry(e, err)
+		return
+	}
+
+	if e.PublishedAt == nil {
+		if err := EventBus().Publish(event); err != nil {
+			s.retry(e, err)
+			return
+		}
+		now := uint(time.Now().Unix())
+		e.PublishedAt = &now
+		s.save(e)
+	}
+
+	if e.DeliveredAt == nil {
+		if err := deliver(); err != nil {
+			s.retry(e, err)
+			return
+		}
+		now := uint(time.Now().Unix())
+		e.DeliveredAt = &now
+	}
+
+	e.Status = models.OutboxStatusDone
+	e.LastError = ""
+	s.save(e)
+}
+
+// decode восстанавливает событие шины и доставку вебхука по записи outbox
+func (s *outboxService) decode(e *models.OutboxEvent) (any, func() error, error) {
+	switch e.Type {
+	case models.OutboxTypeOrderFinished:
+		ord, err := repositories.OrderRepository().FindById(e.AggregateID)
+		if err != nil {
+			return nil, nil, err
+		}
+		deliver := func() error {
+			return WebhookService().SendOrderCompleted(ord, nil, e.EventID.String())
+		}
+		return events.OrderFinished{EventID: e.EventID, Order: ord}, deliver, nil
+	case models.OutboxTypeWithdrawUpdated:
+		wd, err := repositories.WithdrawRepository().FindById(e.AggregateID)
+		if err != nil {
+			return nil, nil, err
+		}
+		deliver := func() error {
+			if wd.FinishedAt == nil {
+				return nil
+			}
+			return WebhookService().SendWithdrawFinished(wd, e.EventID.String())
+		}
+		return events.WithdrawUpdated{EventID: e.EventID, Withdraw: wd}, deliver, nil
+	case models.OutboxTypeWithdrawBatchCompleted:
+		b, err := repositories.WithdrawBatchRepository().FindById(e.AggregateID)
+		if err != nil {
+			return nil, nil, err
+		}
+		deliver := func() error {
+			progress, err := repositories.WithdrawBatchRepository().GetProgress(b.ID)
+			if err != nil {
+				return err
+			}
+			return WebhookService().SendWithdrawBatchCompleted(b, progress, e.EventID.String())
+		}
+		return events.WithdrawBatchCompleted{EventID: e.EventID, Batch: b}, deliver, nil
+	case models.OutboxTypeSettlementMismatch:
+		stl, err := repositories.SettlementRepository().FindById(e.AggregateID)
+		if err != nil {
+			return nil, nil, err
+		}
+		// расхождение разбирают операторы, магазину не отправляется
+		deliver := func() error {
+			return nil
+		}
+		return events.SettlementMismatch{Settlement: stl}, deliver, nil
+	}
+	return nil, nil, fmt.Errorf("%w: %s", ErrUnknownOutboxEvent, e.Type)
+}
+
+func (s *outboxService) retry(e *models.OutboxEvent, err error) {
+	e.Attempts++
+	e.LastError = err.Error()
+	if e.Attempts >= config.OutboxMaxAttempts {
+		log.Printf("OutboxService: event %s (%s #%d) failed: %v", e.EventID, e.Type, e.AggregateID, err)
+		e.Status = models.OutboxStatusFailed
+	} else {
+		delay := config.OutboxRetryDelay
+		for i := uint(1); i < e.Attempts && delay < config.OutboxMaxRetryDelay; i++ {
+			delay *= 2
+		}
+		if delay > config.OutboxMaxRetryDelay {
+			delay = config.OutboxMaxRetryDelay
+		}
+		e.NextAttemptAt = uint(time.Now().Add(delay).Unix())
+	}
+	s.save(e)
+}
+
+func (s *outboxService) save(e *models.OutboxEvent) {
+	if err := repositories.OutboxRepository().Save(e); err != nil {
+		log.Printf("OutboxService: unable to save event %s: %v", e.EventID, err)
+	}
+}
@@ -1,8 +1,11 @@
 package services
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"payment-go/internal/config"
 	"payment-go/internal/models"
 	"payment-go/internal/repositories"
 	"sync"
@@ -11,9 +14,14 @@ import (
 
 type IPaymentLinkService interface {
 	LoadPendingLinks() []error
+	StartChecking(link *models.PaymentLink)
 	FinishLinkWithStatus(link *models.PaymentLink, ord *models.Order, status string) error
 }
 type paymentLinkService struct {
+	mu       sync.Mutex
+	ctx      context.Context // пока узел владеет проверкой ссылок, иначе nil
+	checking map[uint]bool
+	creating map[uint]bool
 }
 
 var plIns IPaymentLinkService
@@ -21,35 +29,127 @@ var plOnce = sync.Once{}
 
 func PaymentLinkService() IPaymentLinkService {
 	plOnce.Do(func() {
-		plIns = &paymentLinkService{}
+		s := &paymentLinkService{
+			checking: make(map[uint]bool),
+			creating: make(map[uint]bool),
+		}
+		s.init()
+		plIns = s
 	})
 	return plIns
 }
 
+func (s *paymentLinkService) init() {
+	// ссылки проверяет только один узел кластера, при его падении проверку подхватит другой
+	JobService().RunExclusive(config.JobPendingLinks, s.checkPendingLinks)
+}
+
+func (s *paymentLinkService) checkPendingLinks(ctx context.Context) {
+	s.mu.Lock()
+	s.ctx = ctx
+	s.checking = make(map[uint]bool)
+	s.creating = make(map[uint]bool)
+	s.mu.Unlock()
+
+	for {
+		// подхватываем и ссылки, созданные на других узлах
+		for _, err := range s.LoadPendingLinks() {
+			log.Println(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			s.mu.Lock()
+			s.ctx = nil
+			s.mu.Unlock()
+			return
+		case <-time.After(config.PendingLinksReloadInterval):
+		}
+	}
+}
+
 func (s *paymentLinkService) LoadPendingLinks() []error {
-	links, err := repositories.PaymentLinkRepository().GetPending()
+	links, err := repositories.PaymentLinkRepository().GetPending(time.Now().Add(-config.PendingLinksMaxAge))
 	if err != nil {
 		return []error{err}
 	}
 
-	if len(links) == 0 {
-		fmt.Println("No pending links to load")
-	}
-
 	var errors []error
 	for _, link := range links {
-		BankApiService().TaskCheckLink(link, nil)
+		s.StartChecking(link)
+	}
+
+	// продолжим создание ссылок, прерванное остановкой узла.
+	// свежие ссылки ещё может создавать другой узел, а устаревшие уже не нужны
+	now := time.Now()
+	stale, err := repositories.PaymentLinkRepository().GetStaleNew(now.Add(-config.GetConfig().LinkCheck.Timeout), now.Add(-config.CreateLinkTimeout))
+	if err != nil {
+		return append(errors, err)
+	}
+	for _, link := range stale {
+		if err := s.resumeCreating(link); err != nil {
+			errors = append(errors, err)
+		}
 	}
 	return errors
 }
 
+// resumeCreating заново запускает создание ссылки, если оно ещё не запущено этим узлом
+func (s *paymentLinkService) resumeCreating(link *models.PaymentLink) error {
+	ord, err := repositories.OrderRepository().FindById(link.OrderID)
+	if err != nil {
+		return fmt.Errorf("unable to resume payment link #%d: %w", link.ID, err)
+	}
+
+	s.mu.Lock()
+	if s.ctx == nil || s.ctx.Err() != nil || s.creating[link.ID] {
+		s.mu.Unlock()
+		return nil
+	}
+	s.creating[link.ID] = true
+	creating := s.creating
+	s.mu.Unlock()
+
+	// cb может быть вызван синхронно, поэтому блокировку не держим
+	BankApiService().TaskCreateLink(ord, func(created *models.PaymentLink) {
+		s.mu.Lock()
+		delete(creating, link.ID)
+		s.mu.Unlock()
+
+		if created.Status != models.StatusFailed {
+			s.StartChecking(created)
+		}
+	})
+	return nil
+}
+
+// StartChecking запускает проверку ссылки, если этот узел владеет проверкой и ссылка ещё не проверяется.
+// Иначе ссылку подхватит владелец при следующей загрузке
+func (s *paymentLinkService) StartChecking(link *models.PaymentLink) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx == nil || s.ctx.Err() != nil || s.checking[link.ID] {
+		return
+	}
+	s.checking[link.ID] = true
+
+	checking := s.checking
+	BankApiService().TaskCheckLink(s.ctx, link, func(link *models.PaymentLink) {
+		s.mu.Lock()
+		delete(checking, link.ID)
+		s.mu.Unlock()
+	})
+}
+
 func (s *paymentLinkService) FinishLinkWithStatus(link *models.PaymentLink, ord *models.Order, status string) error {
 	if !models.IsStatusValid(status) {
 		return fmt.Errorf("invalid status on finishing payment link #%d for order #%d", link.ID, ord.ID)
 	}
 
 	now := uint(time.Now().Unix())
-	if err := OrderService().FinishOrderWithStatus(ord, status, now); err != nil {
+	// заказ мог завершить обработчик SMS, ссылка всё равно получает итог банка
+	if err := OrderService().FinishOrderWithStatus(ord, status, now); err != nil && !errors.Is(err, ErrOrderFinished) {
 		return err
 	}
 
@@ -0,0 +1,290 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"payment-go/internal/config"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories"
+	"payment-go/internal/transport/model/withdraw"
+	"payment-go/internal/utils/payout"
+	"payment-go/internal/utils/scheduler"
+	"sync"
+	"time"
+)
+
+type IPayoutService interface {
+	Execute(withdrawId uint) (*models.WithdrawPayout, error)
+	Confirm(dto *withdraw.PayoutConfirmDto) (*models.WithdrawPayout, error)
+	GetPayout(withdrawId uint) (*models.WithdrawPayout, error)
+}
+type payoutService struct {
+	mu        sync.Mutex // защищает providers и locks
+	providers map[string]payout.IPayoutProvider
+	locks     map[uint]*withdrawLock
+}
+
+// withdrawLock блокировка выплаты одного вывода, удаляется, когда её никто не ждёт.
+// Блокировка действует в пределах процесса. Между экземплярами выплату защищают уникальный withdraw_id
+// (второй Execute не создаст выплату) и аренда планировщика: повторы выполняет только один узел,
+// а выплата попадает в GetDue лишь после того, как Execute сохранил результат первой попытки
+type withdrawLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+var poIns *payoutService
+var poOnce = sync.Once{}
+
+var ErrWithdrawNotFound = errors.New("withdraw not found")
+var ErrWithdrawFinished = errors.New("withdraw is already finished")
+var ErrPayoutNotFound = errors.New("payout not found")
+var ErrPayoutStarted = errors.New("payout is already started")
+var ErrPayoutNotManual = errors.New("only manual payouts and payouts under review can be confirmed")
+
+func PayoutService() IPayoutService {
+	poOnce.Do(func() {
+		poIns = &payoutService{
+			providers: make(map[string]payout.IPayoutProvider),
+			locks:     make(map[uint]*withdrawLock),
+		}
+		poIns.init()
+	})
+	return poIns
+}
+
+func (s *payoutService) init() {
+	// фоновая проверка незавершённых выплат
+	SchedulerService().Register(config.JobPayouts, scheduler.Every(config.PayoutCheckInterval), false, Periodic(s.checkDuePayouts))
+}
+
+// getProvider провайдеры создаются один раз, чтобы сохранять своё состояние между вызовами
+func (s *payoutService) getProvider(name string) (payout.IPayoutProvider, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if provider, ok := s.providers[name]; ok {
+		return provider, nil
+	}
+	provider, err := payout.NewProvider(name, config.GetConfig().Payout)
+	if err != nil {
+		return nil, err
+	}
+	s.providers[name] = provider
+	return provider, nil
+}
+
+func (s *payoutService) Execute(withdrawId uint) (*models.WithdrawPayout, error) {
+	wd, err := repositories.WithdrawRepository().FindById(withdrawId)
+	if err != nil {
+		return nil, ErrWithdrawNotFound
+	}
+	if wd.FinishedAt != nil {
+		return nil, ErrWithdrawFinished
+	}
+	// деньги уходят только по выводам, прошедшим риск-проверку и подтверждение
+	if err := WithdrawRiskService().AssertApproved(wd.ID); err != nil {
+		return nil, err
+	}
+
+	unlock := s.lock(wd.ID)
+	defer unlock()
+
+	if _, err := repositories.WithdrawPayoutRepository().FindByWithdrawId(wd.ID); err == nil {
+		return nil, ErrPayoutStarted
+	}
+
+	p := &models.WithdrawPayout{
+		WithdrawID: wd.ID,
+		Withdraw:   *wd,
+		Provider:   config.GetConfig().Payout.GetPayoutProvider(wd.Type),
+		Status:     models.PayoutStatusPending,
+	}
+
+	if err := repositories.WithdrawPayoutRepository().Save(p); err != nil {
+		return nil, ErrWhileSaving
+	}
+
+	s.attempt(p)
+	return p, nil
+}
+
+func (s *payoutService) Confirm(dto *withdraw.PayoutConfirmDto) (*models.WithdrawPayout, error) {
+	if err := dto.Validate(); err != nil {
+		return nil, err
+	}
+
+	unlock := s.lock(dto.WithdrawID)
+	defer unlock()
+
+	p, err := repositories.WithdrawPayoutRepository().FindByWithdrawId(dto.WithdrawID)
+	if err != nil {
+		return nil, ErrPayoutNotFound
+	}
+	// выплату на проверке оператор сверяет с провайдером и подтверждает так же, как ручную
+	if p.Provider != config.PayoutProviderManual && !p.IsUnderReview() {
+		return nil, ErrPayoutNotManual
+	}
+	if p.IsFinished() {
+		return nil, ErrWithdrawFinished
+	}
+
+	if dto.Success {
+		p.Finish(models.PayoutStatusSucceeded, "", uint(time.Now().Unix()))
+	} else {
+		p.Finish(models.PayoutStatusFailed, dto.FailureReason, uint(time.Now().Unix()))
+	}
+	if err := repositories.WithdrawPayoutRepository().Save(p); err != nil {
+		return nil, ErrWhileSaving
+	}
+
+	s.finishWithdraw(p)
+	return p, nil
+}
+
+func (s *payoutService) GetPayout(withdrawId uint) (*models.WithdrawPayout, error) {
+	p, err := repositories.WithdrawPayoutRepository().FindByWithdrawId(withdrawId)
+	if err != nil {
+		return nil, ErrPayoutNotFound
+	}
+	return p, nil
+}
+
+func (s *payoutService) checkDuePayouts() {
+	payouts, err := repositories.WithdrawPayoutRepository().GetDue(uint(time.Now().Unix()))
+	if err != nil {
+		log.Println("PayoutService: unable to load pending payouts.", err)
+		return
+	}
+
+	for _, p := range payouts {
+		s.attemptDue(p.WithdrawID)
+	}
+}
+
+// attemptDue повторяет выплату под блокировкой вывода. Выплату перечитываем: её мог обработать параллельный Execute
+func (s *payoutService) attemptDue(withdrawId uint) {
+	unlock := s.lock(withdrawId)
+	defer unlock()
+
+	p, err := repositories.WithdrawPayoutRepository().FindByWithdrawId(withdrawId)
+	if err != nil || p.IsFinished() || p.NextCheckAt == nil || *p.NextCheckAt > uint(time.Now().Unix()) {
+		return
+	}
+	s.attempt(p)
+}
+
+// lock блокирует выплату одного вывода, остальные выводы обрабатываются параллельно
+func (s *payoutService) lock(withdrawId uint) func() {
+	s.mu.Lock()
+	l, ok := s.locks[withdrawId]
+	if !ok {
+		l = &withdrawLock{}
+		s.locks[withdrawId] = l
+	}
+	l.refs++
+	s.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+
+		s.mu.Lock()
+		l.refs--
+		if l.refs == 0 {
+			delete(s.locks, withdrawId)
+		}
+		s.mu.Unlock()
+	}
+}
+
+// attempt отправляет выплату провайдеру либо опрашивает её статус. Вызывается под блокировкой вывода.
+// Пока ExternalID пуст, выплата отправляется повторно: провайдер отличает повтор по номеру вывода
+func (s *payoutService) attempt(p *models.WithdrawPayout) {
+	now := time.Now()
+
+	provider, err := s.getProvider(p.Provider)
+	if err != nil {
+		p.Finish(models.PayoutStatusFailed, err.Error(), uint(now.Unix()))
+		s.savePayout(p)
+		return
+	}
+
+	var res *payout.Result
+	if len(p.ExternalID) == 0 {
+		res, err = provider.Execute(&payout.Request{
+			Number:             p.Withdraw.Number.String(),
+			Type:               p.Withdraw.Type,
+			CardNumber:         p.Withdraw.CardNumber,
+			CardExpirationDate: p.Withdraw.CardExpirationDate,
+			Phone:              p.Withdraw.Phone,
+			Amount:             p.Withdraw.Amount,
+		})
+	} else {
+		res, err = provider.CheckStatus(p.ExternalID)
+	}
+
+	applyResult(p, res, err, now)
+	s.savePayout(p)
+}
+
+// applyResult меняет выплату по ответу провайдера. Ошибка временная и исход по ней неизвестен:
+// деньги могли уйти, поэтому после PayoutMaxAttempts выплата уходит на проверку, а отказ бывает только по ответу провайдера
+func applyResult(p *models.WithdrawPayout, res *payout.Result, err error, now time.Time) {
+	if err != nil {
+		p.Attempts++
+		if p.Attempts >= config.PayoutMaxAttempts {
+			p.Review(err.Error())
+		} else {
+			next := uint(now.Add(config.PayoutRetryDelay * time.Duration(p.Attempts)).Unix())
+			p.NextCheckAt = &next
+			p.FailureReason = err.Error()
+		}
+		return
+	}
+
+	p.ExternalID = res.ExternalID
+	if res.Status == payout.StatusSucceeded {
+		p.Finish(models.PayoutStatusSucceeded, "", uint(now.Unix()))
+	} else if res.Status == payout.StatusFailed {
+		p.Finish(models.PayoutStatusFailed, res.FailureReason, uint(now.Unix()))
+	} else if p.Provider == config.PayoutProviderManual {
+		// ручные выплаты подтверждает оператор, опрашивать нечего
+		p.NextCheckAt = nil
+	} else {
+		next := uint(now.Add(config.PayoutCheckInterval).Unix())
+		p.NextCheckAt = &next
+	}
+}
+
+func (s *payoutService) savePayout(p *models.WithdrawPayout) {
+	if err := repositories.WithdrawPayoutRepository().Save(p); err != nil {
+		log.Printf("PayoutService: unable to save payout for withdraw #%d: %v", p.WithdrawID, err)
+		return
+	}
+	if p.IsFinished() || p.IsUnderReview() {
+		s.finishWithdraw(p)
+	}
+}
+
+// finishWithdraw переводит вывод в итоговый статус или на проверку и отправляет вебхук
+func (s *payoutService) finishWithdraw(p *models.WithdrawPayout) {
+	wd, err := repositories.WithdrawRepository().FindById(p.WithdrawID)
+	if err != nil {
+		log.Printf("PayoutService: withdraw #%d not found", p.WithdrawID)
+		return
+	}
+
+	switch p.Status {
+	case models.PayoutStatusSucceeded:
+		wd.Status = models.StatusCompleted
+	case models.PayoutStatusReview:
+		wd.Status = models.WithdrawStatusReview
+	default:
+		wd.Status = models.StatusFailed
+	}
+	wd.FinishedAt = p.FinishedAt
+	if err := OutboxService().SaveWithEvent(wd, models.OutboxTypeWithdrawUpdated, wd.ID); err != nil {
+		log.Printf("PayoutService: unable to finish withdraw #%d: %v", wd.ID, err)
+	}
+}
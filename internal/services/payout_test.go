@@ -0,0 +1,110 @@
+package services
+
+import (
+	"payment-go/internal/config"
+	"payment-go/internal/models"
+	"payment-go/internal/utils/payout"
+	"testing"
+	"time"
+)
+
+var payoutRequest = &payout.Request{Number: "wd-1", Type: "card", CardNumber: "4111111111111111", Amount: 100}
+
+func newFakePayout() *models.WithdrawPayout {
+	return &models.WithdrawPayout{
+		WithdrawID: 1,
+		Provider:   config.PayoutProviderFake,
+		Status:     models.PayoutStatusPending,
+	}
+}
+
+func TestApplyResultRetriesTransientErrors(t *testing.T) {
+	provider := payout.NewFakeProvider(payout.StatusSucceeded)
+	provider.FailNext(1)
+	p := newFakePayout()
+	now := time.Unix(1700000000, 0)
+
+	res, err := provider.Execute(payoutRequest)
+	applyResult(p, res, err, now)
+	if p.Status != models.PayoutStatusPending || p.Attempts != 1 || len(p.ExternalID) != 0 {
+		t.Fatalf("got status %s, attempts %d, external id %q", p.Status, p.Attempts, p.ExternalID)
+	}
+	if p.NextCheckAt == nil || *p.NextCheckAt != uint(now.Add(config.PayoutRetryDelay).Unix()) {
+		t.Fatalf("retry is not scheduled: %v", p.NextCheckAt)
+	}
+
+	// повтор с тем же номером создаёт выплату, дальше её статус опрашивается
+	res, err = provider.Execute(payoutRequest)
+	applyResult(p, res, err, now)
+	if p.Status != models.PayoutStatusPending || p.ExternalID != "fake-wd-1" {
+		t.Fatalf("got status %s, external id %q", p.Status, p.ExternalID)
+	}
+	if p.NextCheckAt == nil || *p.NextCheckAt != uint(now.Add(config.PayoutCheckInterval).Unix()) {
+		t.Fatalf("status check is not scheduled: %v", p.NextCheckAt)
+	}
+}
+
+func TestApplyResultReviewsAfterMaxAttempts(t *testing.T) {
+	provider := payout.NewFakeProvider(payout.StatusSucceeded)
+	provider.FailNext(config.PayoutMaxAttempts)
+	p := newFakePayout()
+
+	for i := 0; i < config.PayoutMaxAttempts; i++ {
+		res, err := provider.Execute(payoutRequest)
+		applyResult(p, res, err, time.Now())
+	}
+
+	// исход неизвестен: выплата не отклоняется, а ждёт оператора
+	if p.Status != models.PayoutStatusReview {
+		t.Fatalf("got status %s, want %s", p.Status, models.PayoutStatusReview)
+	}
+	if p.FinishedAt != nil || p.NextCheckAt != nil || len(p.FailureReason) == 0 {
+		t.Fatalf("got finished at %v, next check %v, reason %q", p.FinishedAt, p.NextCheckAt, p.FailureReason)
+	}
+	if p.IsFinished() {
+		t.Fatal("payout under review must not be finished")
+	}
+}
+
+func TestApplyResultFinalStates(t *testing.T) {
+	tests := []struct {
+		name    string
+		outcome string
+		reason  string
+		status  string
+	}{
+		{"succeeded", payout.StatusSucceeded, "", models.PayoutStatusSucceeded},
+		{"failed", payout.StatusFailed, "card is closed", models.PayoutStatusFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := payout.NewFakeProvider(tt.outcome)
+			provider.SetOutcome(tt.outcome, tt.reason)
+			p := newFakePayout()
+			now := time.Now()
+
+			res, err := provider.Execute(payoutRequest)
+			applyResult(p, res, err, now)
+			res, err = provider.CheckStatus(p.ExternalID)
+			applyResult(p, res, err, now)
+
+			if p.Status != tt.status || p.FailureReason != tt.reason {
+				t.Fatalf("got status %s, reason %q", p.Status, p.FailureReason)
+			}
+			if p.FinishedAt == nil || *p.FinishedAt != uint(now.Unix()) || p.NextCheckAt != nil {
+				t.Fatalf("got finished at %v, next check %v", p.FinishedAt, p.NextCheckAt)
+			}
+		})
+	}
+}
+
+func TestApplyResultManualPayoutWaitsForOperator(t *testing.T) {
+	p := newFakePayout()
+	p.Provider = config.PayoutProviderManual
+
+	res, err := payout.NewManualProvider().Execute(payoutRequest)
+	applyResult(p, res, err, time.Now())
+	if p.Status != models.PayoutStatusPending || p.NextCheckAt != nil {
+		t.Fatalf("got status %s, next check %v", p.Status, p.NextCheckAt)
+	}
+}
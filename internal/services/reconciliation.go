@@ -0,0 +1,280 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"payment-go/internal/config"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories"
+	"payment-go/internal/transport/model/reconciliation"
+	"payment-go/internal/utils/statement"
+	"strings"
+	"sync"
+	"time"
+)
+
+type IReconciliationService interface {
+	Import(fileName string, format string, data []byte) (*models.BankStatement, error)
+	Link(dto *reconciliation.LinkDto) (*models.Order, error)
+	Ignore(dto *reconciliation.IgnoreDto) (*models.BankStatementLine, error)
+}
+type reconciliationService struct {
+	mu sync.Mutex
+}
+
+var rcIns *reconciliationService
+var rcOnce = sync.Once{}
+
+var ErrLineNotFound = errors.New("statement line not found")
+var ErrLineResolved = errors.New("statement line is already resolved")
+var ErrMessageResolved = errors.New("bank message is already resolved")
+var ErrStatementImported = errors.New("statement is already imported")
+
+func ReconciliationService() IReconciliationService {
+	rcOnce.Do(func() {
+		rcIns = &reconciliationService{}
+	})
+	return rcIns
+}
+
+func (s *reconciliationService) Import(fileName string, format string, data []byte) (*models.BankStatement, error) {
+	var mapping *statement.CSVMapping
+	if csv := config.GetConfig().Statement.Csv; csv != nil {
+		mapping = &statement.CSVMapping{
+			Account:     csv.Account,
+			Amount:      csv.Amount,
+			Date:        csv.Date,
+			Reference:   csv.Reference,
+			Description: csv.Description,
+			DateLayout:  csv.DateLayout,
+		}
+	}
+
+	format = strings.ToLower(format)
+	lines, err := statement.Parse(format, fileName, data, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := repositories.BankStatementRepository().FindByFileHash(hash); err == nil {
+		return nil, ErrStatementImported
+	}
+
+	stmt := &models.BankStatement{
+		FileName: fileName,
+		Format:   format,
+		FileHash: &hash,
+	}
+	// строки и завершение найденных по ним заказов сохраняются вместе: при ошибке выписку можно загрузить заново
+	err = repositories.Transaction(func(tx *gorm.DB) error {
+		stmts := repositories.BankStatementRepository().WithTx(tx)
+		if err := stmts.Save(stmt); err != nil {
+			return err
+		}
+
+		for _, l := range lines {
+			line := &models.BankStatementLine{
+				StatementID: stmt.ID,
+				Account:     l.Account,
+				Amount:      l.Amount,
+				BookedAt:    uint(l.BookedAt.Unix()),
+				Reference:   l.Reference,
+				Description: l.Description,
+				Status:      models.StatementLineStatusUnmatched,
+			}
+
+			// выписки за пересекающиеся периоды содержат одни и те же операции
+			if len(line.Reference) != 0 {
+				exists, err := stmts.HasLine(line.Account, line.Reference, line.BookedAt, line.Amount)
+				if err != nil {
+					return err
+				}
+				if exists {
+					stmt.DuplicateCount++
+					continue
+				}
+			}
+
+			if err := s.match(tx, line, l.BookedAt); err != nil {
+				return err
+			}
+			if err := stmts.SaveLine(line); err != nil {
+				return err
+			}
+			stmt.Lines = append(stmt.Lines, line)
+			stmt.LinesCount++
+			if line.IsResolved() {
+				stmt.MatchedCount++
+			}
+		}
+
+		return stmts.Save(stmt)
+	})
+	if err != nil {
+		log.Printf("ReconciliationService: unable to import statement %s: %v", fileName, err)
+		return nil, ErrWhileSaving
+	}
+
+	OutboxService().Flush()
+	return stmt, nil
+}
+
+// match ищет сообщение банка о зачислении, а если его нет - ожидающий оплаты заказ на эту карту.
+// Ошибку возвращает только завершение заказа, ненайденная пара оставляет строку несопоставленной
+func (s *reconciliationService) match(tx *gorm.DB, line *models.BankStatementLine, bookedAt time.Time) error {
+	from := bookedAt.Add(-config.StatementMatchWindow)
+	to := bookedAt.Add(config.StatementMatchWindow)
+	// в выписке только дата - ищем в пределах всего дня
+	if bookedAt.Hour() == 0 && bookedAt.Minute() == 0 && bookedAt.Second() == 0 {
+		to = to.Add(24 * time.Hour)
+	}
+
+	// поиск идёт в транзакции импорта, чтобы не сопоставить одно сообщение или заказ двум строкам файла
+	stmts := repositories.BankStatementRepository().WithTx(tx)
+	msg, err := stmts.FindMatchingMessage(
+		line.Account, line.Amount, config.StatementAmountTolerance, from, to,
+	)
+	if err == nil {
+		line.Match(msg, msg.OrderID)
+		return nil
+	}
+
+	// SMS не дошло: ищем заказ, который ждал оплату на эту карту
+	crd, err := repositories.CardRepository().FindByCardNumber(line.Account)
+	if err != nil {
+		return nil
+	}
+	orders, err := stmts.FindPendingOrders(
+		crd.ID, line.Amount, config.StatementAmountTolerance, from.Add(-config.CardLockingTimeout), to,
+	)
+	if err != nil || len(orders) != 1 {
+		// несколько кандидатов - решает оператор
+		return nil
+	}
+
+	msg, err = s.completeOrder(tx, line, orders[0])
+	if err != nil {
+		return fmt.Errorf("unable to complete order #%d: %w", orders[0].ID, err)
+	}
+	line.Match(msg, orders[0].ID)
+	return nil
+}
+
+// completeOrder завершает заказ по строке выписки, сохраняя сообщение банка вместо потерянного SMS
+func (s *reconciliationService) completeOrder(tx *gorm.DB, line *models.BankStatementLine, ord *models.Order) (*models.BankMessage, error) {
+	msg := &models.BankMessage{
+		OrderID:    ord.ID,
+		ShopID:     ord.ShopID,
+		RawMessage: strings.TrimSpace("statement: " + line.Reference + " " + line.Description),
+		CardNumber: line.Account,
+		Amount:     line.Amount,
+		Status:     models.BankMessageStatusSuccess,
+	}
+	if err := repositories.BankMessageRepository().WithTx(tx).Save(msg); err != nil {
+		return nil, err
+	}
+
+	if ord.Status != models.StatusCompleted {
+		ord.Amount = line.Amount
+		if err := finishOrder(tx, ord, models.StatusCompleted, line.BookedAt, true); err != nil {
+			return nil, err
+		}
+	}
+	return msg, nil
+}
+
+// Link ручная привязка строки выписки или сообщения банка к заказу
+func (s *reconciliationService) Link(dto *reconciliation.LinkDto) (*models.Order, error) {
+	if err := dto.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ord, err := repositories.OrderRepository().FindById(dto.OrderID)
+	if err != nil {
+		return nil, ErrOrderNotFound
+	}
+
+	if dto.LineID != 0 {
+		line, err := repositories.BankStatementRepository().FindLineById(dto.LineID)
+		if err != nil {
+			return nil, ErrLineNotFound
+		}
+		if line.IsResolved() {
+			return nil, ErrLineResolved
+		}
+
+		err = repositories.Transaction(func(tx *gorm.DB) error {
+			msg, err := s.completeOrder(tx, line, ord)
+			if err != nil {
+				return err
+			}
+			line.BankMessageID = &msg.ID
+			line.Link(ord.ID)
+			return repositories.BankStatementRepository().WithTx(tx).SaveLine(line)
+		})
+		if err != nil {
+			log.Printf("ReconciliationService: unable to link line #%d to order #%d: %v", line.ID, ord.ID, err)
+			return nil, ErrWhileSaving
+		}
+		OutboxService().Flush()
+		return ord, nil
+	}
+
+	msg, err := repositories.BankMessageRepository().FindById(dto.MessageID)
+	if err != nil {
+		return nil, ErrMessageNotFound
+	}
+	if msg.Status != models.BankMessageStatusError {
+		return nil, ErrMessageResolved
+	}
+
+	msg.OrderID = ord.ID
+	msg.ShopID = ord.ShopID
+	msg.SetStatus(models.BankMessageStatusSuccess)
+	err = repositories.Transaction(func(tx *gorm.DB) error {
+		if err := repositories.BankMessageRepository().WithTx(tx).Save(msg); err != nil {
+			return err
+		}
+		if ord.Status == models.StatusCompleted {
+			return nil
+		}
+		ord.Amount = msg.Amount
+		return finishOrder(tx, ord, models.StatusCompleted, uint(msg.CreatedAt.Unix()), true)
+	})
+	if err != nil {
+		log.Printf("ReconciliationService: unable to link message #%d to order #%d: %v", msg.ID, ord.ID, err)
+		return nil, ErrWhileSaving
+	}
+	OutboxService().Flush()
+	return ord, nil
+}
+
+// Ignore убирает строку выписки из очереди, например если это не оплата заказа
+func (s *reconciliationService) Ignore(dto *reconciliation.IgnoreDto) (*models.BankStatementLine, error) {
+	line, err := repositories.BankStatementRepository().FindLineById(dto.LineID)
+	if err != nil {
+		return nil, ErrLineNotFound
+	}
+	if line.IsResolved() {
+		return nil, ErrLineResolved
+	}
+
+	line.Status = models.StatementLineStatusIgnored
+	if err := repositories.BankStatementRepository().SaveLine(line); err != nil {
+		return nil, ErrWhileSaving
+	}
+	return line, nil
+}
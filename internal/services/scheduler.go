@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"payment-go/internal/config"
+	"payment-go/internal/models"
+	"payment-go/internal/providers"
+	"payment-go/internal/repositories"
+	"payment-go/internal/utils/scheduler"
+	"sync"
+	"time"
+)
+
+type JobFunc func(ctx context.Context) error
+
+type ISchedulerService interface {
+	Register(name string, spec scheduler.ISpec, local bool, fn JobFunc)
+	Start()
+	Stop(ctx context.Context) error
+	GetJobs() ([]*models.ScheduledJob, error)
+	IsRunning(name string) bool
+	Trigger(name string) error
+}
+type schedulerService struct {
+	mu      sync.Mutex
+	jobs    map[string]*scheduledJob
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+}
+
+// scheduledJob общие задачи выполняет узел, владеющий планировщиком, локальные - каждый узел
+type scheduledJob struct {
+	name    string
+	spec    scheduler.ISpec
+	local   bool
+	fn      JobFunc
+	running bool
+	nextRun time.Time // только для локальных задач
+}
+
+var schIns *schedulerService
+var schOnce = sync.Once{}
+
+var ErrJobNotFound = errors.New("job not found")
+
+func SchedulerService() ISchedulerService {
+	schOnce.Do(func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		schIns = &schedulerService{
+			jobs:   make(map[string]*scheduledJob),
+			ctx:    ctx,
+			cancel: cancel,
+		}
+	})
+	return schIns
+}
+
+// Register добавляет задачу. Задачи регистрируются при создании сервисов, до Start
+func (s *schedulerService) Register(name string, spec scheduler.ISpec, local bool, fn JobFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[name]; ok {
+		log.Fatalf("Scheduler: job %s is already registered", name)
+	}
+	s.jobs[name] = &scheduledJob{
+		name:    name,
+		spec:    spec,
+		local:   local,
+		fn:      fn,
+		nextRun: spec.Next(time.Now()),
+	}
+
+	// сохраним расписание, время следующего запуска общей задачи не сбрасываем при перезапуске
+	row, err := repositories.ScheduledJobRepository().FindByName(name)
+	if err != nil {
+		row = &models.ScheduledJob{Name: name}
+		if next := spec.Next(time.Now()); !next.IsZero() {
+			nextRunAt := uint(next.Unix())
+			row.NextRunAt = &nextRunAt
+		}
+	}
+	row.Spec = spec.String()
+	row.Local = local
+	if err = repositories.ScheduledJobRepository().SaveResult(row); err != nil {
+		log.Printf("Scheduler: unable to save job %s: %v", name, err)
+	}
+}
+
+func (s *schedulerService) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return
+	}
+	s.started = true
+
+	go s.loop(s.ctx, true)
+	JobService().RunExclusive(config.JobScheduler, func(leaseCtx context.Context) {
+		ctx, cancel := context.WithCancel(leaseCtx)
+		defer cancel()
+		go func() {
+			select {
+			case <-s.ctx.Done():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		s.loop(ctx, false)
+	})
+}
+
+// Stop прекращает запуск задач и ждёт завершения уже запущенных
+func (s *schedulerService) Stop(ctx context.Context) error {
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *schedulerService) GetJobs() ([]*models.ScheduledJob, error) {
+	return repositories.ScheduledJobRepository().FindAll()
+}
+
+func (s *schedulerService) IsRunning(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[name]
+	return ok && job.running
+}
+
+// Trigger локальная задача запускается сразу на этом узле, общая - на узле-владельце при ближайшей проверке
+func (s *schedulerService) Trigger(name string) error {
+	s.mu.Lock()
+	job, ok := s.jobs[name]
+	s.mu.Unlock()
+	if !ok {
+		return ErrJobNotFound
+	}
+
+	if job.local {
+		s.start(s.ctx, job, models.JobRunTriggerManual, nil)
+		return nil
+	}
+	return repositories.ScheduledJobRepository().SetTriggered(name)
+}
+
+// loop проверяет расписание локальных или общих задач, пока не отменён ctx
+func (s *schedulerService) loop(ctx context.Context, local bool) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(config.SchedulerTick):
+		}
+		if local {
+			s.checkLocal(ctx)
+		} else {
+			s.checkShared(ctx)
+		}
+	}
+}
+
+func (s *schedulerService) checkLocal(ctx context.Context) {
+	now := time.Now()
+
+	s.mu.Lock()
+	due := make([]*scheduledJob, 0)
+	for _, job := range s.jobs {
+		if job.local && !job.running && !job.nextRun.IsZero() && !now.Before(job.nextRun) {
+			job.nextRun = job.spec.Next(now)
+			due = append(due, job)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, job := range due {
+		s.start(ctx, job, models.JobRunTriggerSchedule, nil)
+	}
+}
+
+func (s *schedulerService) checkShared(ctx context.Context) {
+	rows, err := repositories.ScheduledJobRepository().FindAll()
+	if err != nil {
+		log.Println("Scheduler: unable to load jobs.", err)
+		return
+	}
+	now := time.Now()
+
+	for _, row := range rows {
+		s.mu.Lock()
+		job, ok := s.jobs[row.Name]
+		busy := ok && job.running
+		s.mu.Unlock()
+		if !ok || job.local || busy {
+			continue
+		}
+
+		trigger := models.JobRunTriggerSchedule
+		if row.Triggered {
+			trigger = models.JobRunTriggerManual
+		} else if row.NextRunAt == nil || uint(now.Unix()) < *row.NextRunAt {
+			continue
+		}
+
+		// следующий запуск фиксируется до выполнения, чтобы после падения задача не повторялась сразу
+		row.Triggered = false
+		if next := job.spec.Next(now); !next.IsZero() {
+			nextRunAt := uint(next.Unix())
+			row.NextRunAt = &nextRunAt
+		} else {
+			row.NextRunAt = nil
+		}
+		if err = repositories.ScheduledJobRepository().Save(row); err != nil {
+			log.Printf("Scheduler: unable to save job %s: %v", row.Name, err)
+			continue
+		}
+		s.start(ctx, job, trigger, row)
+	}
+}
+
+// start выполняет задачу в отдельной горутине и записывает запуск в историю
+func (s *schedulerService) start(ctx context.Context, job *scheduledJob, trigger string, row *models.ScheduledJob) {
+	s.mu.Lock()
+	if job.running {
+		s.mu.Unlock()
+		return
+	}
+	job.running = true
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			s.mu.Lock()
+			job.running = false
+			s.mu.Unlock()
+		}()
+
+		started := time.Now()
+		run := &models.ScheduledJobRun{
+			JobName:   job.name,
+			Node:      providers.StateProvider().NodeID(),
+			Trigger:   trigger,
+			StartedAt: uint(started.Unix()),
+		}
+		_ = repositories.ScheduledJobRepository().SaveRun(run)
+
+		err := s.call(ctx, job)
+		finished := time.Now()
+		duration := uint(finished.Sub(started).Milliseconds())
+		run.Finish(uint(finished.Unix()), duration, err)
+		if err := repositories.ScheduledJobRepository().SaveRun(run); err != nil {
+			log.Printf("Scheduler: unable to save run of %s: %v", job.name, err)
+		}
+
+		if row == nil {
+			var findErr error
+			if row, findErr = repositories.ScheduledJobRepository().FindByName(job.name); findErr != nil {
+				return
+			}
+		}
+		lastRunAt := run.StartedAt
+		row.LastRunAt = &lastRunAt
+		row.LastDuration = duration
+		row.LastError = run.Error
+		if err := repositories.ScheduledJobRepository().SaveResult(row); err != nil {
+			log.Printf("Scheduler: unable to save job %s: %v", job.name, err)
+		}
+	}()
+}
+
+// call паника задачи записывается как ошибка запуска
+func (s *schedulerService) call(ctx context.Context, job *scheduledJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.fn(ctx)
+}
+
+// Periodic оборачивает функцию без контекста и ошибки в задачу планировщика
+func Periodic(fn func()) JobFunc {
+	return func(ctx context.Context) error {
+		fn()
+		return nil
+	}
+}
@@ -0,0 +1,242 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"math"
+	"payment-go/internal/config"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories"
+	"payment-go/internal/transport/model/settlement"
+	"payment-go/internal/utils/scheduler"
+	"sync"
+	"time"
+)
+
+type ISettlementService interface {
+	Close(dto *settlement.CloseSettlementDto) (*models.Settlement, error)
+	GetForDownload(dto *settlement.DownloadSettlementDto) (*models.Settlement, error)
+	GetBalance(shopId uint) (float64, error)
+}
+type settlementService struct {
+	mu sync.Mutex
+}
+
+var stlIns *settlementService
+var stlOnce = sync.Once{}
+
+var ErrSettlementNotFound = errors.New("settlement not found")
+var ErrInvalidSignature = errors.New("invalid signature")
+var ErrSignatureExpired = errors.New("signature is expired")
+
+func SettlementService() ISettlementService {
+	stlOnce.Do(func() {
+		stlIns = &settlementService{}
+		stlIns.init()
+	})
+	return stlIns
+}
+
+func (s *settlementService) init() {
+	// фоновое закрытие прошедших дней
+	SchedulerService().Register(config.JobSettlements, scheduler.MustParse(config.SettlementSchedule), false, Periodic(s.closeFinishedDays))
+}
+
+// closeFinishedDays закрывает по порядку все прошедшие дни магазинов, начиная с дня после последнего отчёта.
+// Дни, пропущенные из-за простоя или ошибки, закрываются при следующих проходах
+func (s *settlementService) closeFinishedDays() {
+	now := time.Now()
+	until := startOfDay(now)
+	if now.Before(until.Add(config.SettlementCloseDelay)) {
+		until = until.AddDate(0, 0, -1)
+	}
+
+	progress, err := repositories.SettlementRepository().GetProgress()
+	if err != nil {
+		log.Println("SettlementService: unable to load shops.", err)
+		return
+	}
+
+	for _, p := range progress {
+		day := startOfDay(p.CreatedAt)
+		if p.SettledTo != 0 {
+			day = startOfDay(time.Unix(int64(p.SettledTo), 0))
+		}
+		for n := 0; day.Before(until) && n < config.SettlementMaxDaysPerRun; n++ {
+			date := day.Format(config.SettlementDateLayout)
+			if _, err := repositories.SettlementRepository().FindByShopAndDate(p.ShopID, date); err != nil {
+				if _, err := s.build(p.ShopID, day); err != nil {
+					// следующие дни не закрываем, чтобы не оставить пропуск
+					log.Printf("SettlementService: unable to close %s for shop #%d: %v", date, p.ShopID, err)
+					break
+				}
+			}
+			day = day.AddDate(0, 0, 1)
+		}
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.In(time.Local)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// Close пересчитывает отчёт за день вручную
+func (s *settlementService) Close(dto *settlement.CloseSettlementDto) (*models.Settlement, error) {
+	if err := dto.Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := repositories.ShopRepository().FindById(dto.ShopID); err != nil {
+		return nil, fmt.Errorf("shop not found")
+	}
+
+	day, _ := time.ParseInLocation(config.SettlementDateLayout, dto.Date, time.Local)
+	return s.build(dto.ShopID, day)
+}
+
+func (s *settlementService) build(shopId uint, day time.Time) (*models.Settlement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	from := day
+	to := day.AddDate(0, 0, 1)
+	date := day.Format(config.SettlementDateLayout)
+	repo := repositories.SettlementRepository()
+
+	stl, err := repo.FindByShopAndDate(shopId, date)
+	rebuild := err == nil
+	if rebuild {
+		// пересчёт: строки собираем заново
+		id, createdAt := stl.ID, stl.CreatedAt
+		*stl = *models.NewSettlement(shopId, date, uint(from.Unix()), uint(to.Unix()))
+		stl.ID, stl.CreatedAt = id, createdAt
+	} else {
+		stl = models.NewSettlement(shopId, date, uint(from.Unix()), uint(to.Unix()))
+	}
+
+	if err := s.collect(stl); err != nil {
+		return nil, err
+	}
+
+	bankAmount, err := repo.GetBankMessagesAmount(shopId, from, to)
+	if err != nil {
+		return nil, err
+	}
+	consistent := stl.CheckBankMessages(bankAmount, config.SettlementMismatchTolerance)
+
+	// старые строки заменяются новыми в одной транзакции, отчёт без строк не остаётся
+	err = repositories.Transaction(func(tx *gorm.DB) error {
+		txRepo := repo.WithTx(tx)
+		if rebuild {
+			if err := txRepo.DeleteLines(stl.ID); err != nil {
+				return err
+			}
+		}
+		if err := txRepo.Save(stl); err != nil {
+			return err
+		}
+		for _, line := range stl.Lines {
+			line.SettlementID = stl.ID
+			if err := txRepo.SaveLine(line); err != nil {
+				return err
+			}
+		}
+		// расхождение фиксируется вместе с отчётом, иначе при падении процесса оно потеряется
+		if !consistent {
+			return repositories.OutboxRepository().WithTx(tx).Save(models.NewOutboxEvent(models.OutboxTypeSettlementMismatch, stl.ID))
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("SettlementService: unable to save settlement of shop #%d for %s: %v", shopId, date, err)
+		return nil, ErrWhileSaving
+	}
+	if !consistent {
+		OutboxService().Flush()
+	}
+
+	return stl, nil
+}
+
+// collect добавляет в отчёт строки за его период: оплаченные заказы с комиссией, проигранные споры и выводы
+func (s *settlementService) collect(stl *models.Settlement) error {
+	repo := repositories.SettlementRepository()
+
+	orders, err := repo.GetCompletedOrders(stl.ShopID, stl.PeriodFrom, stl.PeriodTo)
+	if err != nil {
+		return err
+	}
+	for _, ord := range orders {
+		stl.AddLine(models.SettlementLineOrder, ord.ID, ord.Number.String(), ord.Amount, *ord.DatePaid)
+		fee := math.Round(ord.Amount*config.SettlementOrderFeePercent) / 100
+		if fee != 0 {
+			stl.AddLine(models.SettlementLineFee, ord.ID, ord.Number.String(), -fee, *ord.DatePaid)
+		}
+	}
+
+	disputes, err := repo.GetLostDisputes(stl.ShopID, stl.PeriodFrom, stl.PeriodTo)
+	if err != nil {
+		return err
+	}
+	for _, d := range disputes {
+		stl.AddLine(models.SettlementLineRefund, d.ID, fmt.Sprintf("order #%d", d.OrderID), -d.Amount, *d.ClosedAt)
+	}
+
+	withdraws, err := repo.GetCompletedWithdraws(stl.ShopID, stl.PeriodFrom, stl.PeriodTo)
+	if err != nil {
+		return err
+	}
+	for _, wd := range withdraws {
+		stl.AddLine(models.SettlementLineWithdraw, wd.ID, wd.Number.String(), -wd.Amount, *wd.FinishedAt)
+	}
+	return nil
+}
+
+// GetBalance баланс магазина по отчётам: итоги закрытых дней и ещё не закрытый период, собранный так же.
+// Отдельно баланс не хранится, поэтому он всегда сходится с отчётами
+func (s *settlementService) GetBalance(shopId uint) (float64, error) {
+	net, settledTo, err := repositories.SettlementRepository().GetNetTotal(shopId)
+	if err != nil {
+		return 0, err
+	}
+
+	open := models.NewSettlement(shopId, "", settledTo, uint(time.Now().Unix())+1)
+	if err := s.collect(open); err != nil {
+		return 0, err
+	}
+	return net + open.Net, nil
+}
+
+// GetForDownload проверяет подпись магазина и возвращает его отчёт за день
+func (s *settlementService) GetForDownload(dto *settlement.DownloadSettlementDto) (*models.Settlement, error) {
+	if err := dto.Validate(); err != nil {
+		return nil, err
+	}
+
+	if math.Abs(float64(time.Now().Unix()-dto.Timestamp)) > config.SettlementSignatureTTL.Seconds() {
+		return nil, ErrSignatureExpired
+	}
+
+	sh, err := ShopService().GetShopByPublicKey(dto.PublicKey)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(sh.Keys.PrivateKey))
+	mac.Write([]byte(dto.SignedPayload()))
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(dto.Signature)) {
+		return nil, ErrInvalidSignature
+	}
+
+	stl, err := repositories.SettlementRepository().FindByShopAndDate(sh.ID, dto.Date)
+	if err != nil {
+		return nil, ErrSettlementNotFound
+	}
+	return stl, nil
+}
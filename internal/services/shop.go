@@ -39,6 +39,9 @@ func (s *shopService) CreateFromDto(dto *shop.CreateShopDto) (*models.Shop, erro
 	if err := dto.Validate(); err != nil {
 		return nil, err
 	}
+	if _, err := repositories.MerchantRepository().FindById(dto.OwnerId); err != nil {
+		return nil, fmt.Errorf("merchant #%d is not found", dto.OwnerId)
+	}
 
 	entity, err := models.NewShop(dto.Name, dto.Host, dto.OwnerId)
 	if err != nil {
@@ -66,6 +69,8 @@ func (s *shopService) UpdateFromDto(dto *shop.UpdateShopDto) (*models.Shop, erro
 	sh.Active = dto.Active
 	sh.Moderated = dto.Moderated
 	sh.Webhooks = dto.Webhooks.Resolve()
+	sh.Branding = dto.Branding.Resolve()
+	sh.Redirects = dto.Redirects.Resolve()
 
 	err = repositories.ShopRepository().Save(sh)
 	if err != nil {
@@ -1,23 +1,30 @@
 package services
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
+	"payment-go/internal/config"
 	"payment-go/internal/models"
+	withdraw2 "payment-go/internal/transport/model/withdraw"
 	order2 "payment-go/internal/transport/webhook/order"
-	"strings"
+	"payment-go/internal/transport/webhook/withdraw"
 	"sync"
 )
 
+const WebhookEventIdHeader = "X-Event-Id"
+
 type IWebhookService interface {
 	GetOrderCreatedWebhook(link *string, ord *models.Order) *url.URL
 	GetOrderOnSuccessWebhook(link *string, ord *models.Order) *url.URL
 	GetOrderOnFailureWebhook(link *string, ord *models.Order) *url.URL
 
-	SendOrderCompleted(ord *models.Order, webhook *string)
+	SendOrderCompleted(ord *models.Order, webhook *string, eventId string) error
 	SendLinkCreated(link *models.PaymentLink, webhook *string)
+	SendWithdrawFinished(wd *models.Withdraw, eventId string) error
+	SendWithdrawBatchCompleted(b *models.WithdrawBatch, progress *withdraw2.BatchProgressDto, eventId string) error
 }
 type webhookService struct {
 }
@@ -105,12 +112,10 @@ func (s *webhookService) SendLinkCreated(link *models.PaymentLink, webhook *stri
 	}
 
 	// отсылаем вебхук
-	cl := http.Client{}
-	reader := strings.NewReader(string(data))
-	cl.Post(u.String(), "application/json", reader)
+	_ = s.post(u, data, "")
 }
 
-func (s *webhookService) SendOrderCompleted(ord *models.Order, webhook *string) {
+func (s *webhookService) SendOrderCompleted(ord *models.Order, webhook *string, eventId string) error {
 	success := ord.Status == models.StatusCompleted
 
 	// получаем нужный вебхук
@@ -121,7 +126,7 @@ func (s *webhookService) SendOrderCompleted(ord *models.Order, webhook *string)
 		u = s.GetOrderOnFailureWebhook(webhook, ord)
 	}
 	if u == nil {
-		return
+		return nil
 	}
 
 	// собираем информацию в json
@@ -132,36 +137,79 @@ func (s *webhookService) SendOrderCompleted(ord *models.Order, webhook *string)
 	})
 	if err != nil {
 		fmt.Printf("%#v", data)
-		return
+		return err
 	}
 
 	// отсылаем вебхук
-	cl := http.Client{}
-	reader := strings.NewReader(string(data))
-	_, err = cl.Post(u.String(), "application/json", reader)
+	return s.post(u, data, eventId)
 }
 
-func (s *webhookService) SendWithdrawFinished(wd *models.Withdraw) {
+func (s *webhookService) SendWithdrawFinished(wd *models.Withdraw, eventId string) error {
 	webhook := wd.Shop.Webhooks.OnWithdrawUpdated
 	if webhook == nil {
-		return
+		return nil
 	}
 
 	// prepare url
 	u, err := url.Parse(wd.Shop.Host + *webhook)
 	if err != nil {
-		return
+		return nil
 	}
 
 	// prepare dto
 	dto := withdraw.FromWithdraw(wd)
 	data, err := json.Marshal(&dto)
 	if err != nil {
-		return
+		return err
+	}
+
+	// отсылаем вебхук
+	return s.post(u, data, eventId)
+}
+
+func (s *webhookService) SendWithdrawBatchCompleted(b *models.WithdrawBatch, progress *withdraw2.BatchProgressDto, eventId string) error {
+	webhook := b.Shop.Webhooks.OnBatchCompleted
+	if webhook == nil {
+		return nil
+	}
+
+	// prepare url
+	u, err := url.Parse(b.Shop.Host + *webhook)
+	if err != nil {
+		return nil
+	}
+
+	// prepare dto
+	dto := withdraw.FromBatch(b, progress.Completed, progress.Failed, progress.CompletedAmount)
+	data, err := json.Marshal(&dto)
+	if err != nil {
+		return err
 	}
 
 	// отсылаем вебхук
-	cl := http.Client{}
-	reader := strings.NewReader(string(data))
-	_, err = cl.Post(u.String(), "application/json", reader)
+	return s.post(u, data, eventId)
+}
+
+// post отправляет вебхук. eventId передаётся в заголовке, чтобы магазин мог отбросить повторную доставку
+func (s *webhookService) post(u *url.URL, data []byte, eventId string) error {
+	req, err := http.NewRequest(http.MethodPost, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(eventId) > 0 {
+		req.Header.Set(WebhookEventIdHeader, eventId)
+	}
+
+	cl := http.Client{Timeout: config.WebhookTimeout}
+	res, err := cl.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s responded with status %d", u.String(), res.StatusCode)
+	}
+	return nil
 }
@@ -0,0 +1,302 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"log"
+	"payment-go/internal/config"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories"
+	"payment-go/internal/transport/model/withdraw"
+	"payment-go/internal/utils/table"
+	"sync"
+	"time"
+)
+
+type IWithdrawBatchService interface {
+	CreateFromDto(dto *withdraw.CreateBatchDto) (*models.WithdrawBatch, []*withdraw.BatchItemResultDto, error)
+	CreateFromFile(shopId uint, fileName string, data []byte) (*models.WithdrawBatch, []*withdraw.BatchItemResultDto, error)
+	Approve(batchId uint) (*models.WithdrawBatch, []*withdraw.BatchItemResultDto, error)
+	Decline(batchId uint) (*models.WithdrawBatch, error)
+	GetProgress(batchId uint) (*withdraw.BatchProgressDto, error)
+	WithdrawFinished(wd *models.Withdraw)
+}
+type withdrawBatchService struct {
+	mu sync.Mutex
+}
+
+var wbIns *withdrawBatchService
+var wbOnce = sync.Once{}
+
+var ErrBatchNotFound = errors.New("withdraw batch not found")
+var ErrNoValidItems = errors.New("batch contains no valid items")
+
+func WithdrawBatchService() IWithdrawBatchService {
+	wbOnce.Do(func() {
+		wbIns = &withdrawBatchService{}
+	})
+	return wbIns
+}
+
+func (s *withdrawBatchService) CreateFromDto(dto *withdraw.CreateBatchDto) (*models.WithdrawBatch, []*withdraw.BatchItemResultDto, error) {
+	if err := dto.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	sh, err := ShopService().AuthorizeDto(dto)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dtos := make([]*withdraw.CreateWithdrawDto, len(dto.Items))
+	errs := make([]error, len(dto.Items))
+	for i, item := range dto.Items {
+		dtos[i], errs[i] = withdraw.ParseCreateDtoFromJSON(item)
+	}
+
+	b := models.NewWithdrawBatch(sh, models.WithdrawBatchSourceApi)
+	return s.create(b, dtos, errs)
+}
+
+func (s *withdrawBatchService) CreateFromFile(shopId uint, fileName string, data []byte) (*models.WithdrawBatch, []*withdraw.BatchItemResultDto, error) {
+	sh, err := repositories.ShopRepository().FindById(shopId)
+	if err != nil {
+		return nil, nil, fmt.Errorf("shop not found")
+	}
+
+	t, err := table.Read(fileName, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows := t.Maps()
+	if len(rows) > config.WithdrawBatchMaxSize {
+		return nil, nil, withdraw.ErrBatchTooLarge
+	}
+
+	dtos := make([]*withdraw.CreateWithdrawDto, len(rows))
+	errs := make([]error, len(rows))
+	for i, row := range rows {
+		dtos[i], errs[i] = withdraw.CreateDtoFromRow(row)
+	}
+
+	b := models.NewWithdrawBatch(sh, models.WithdrawBatchSourceFile)
+	b.FileName = fileName
+	return s.create(b, dtos, errs)
+}
+
+// create сохраняет пакет и выводы, прошедшие валидацию. Результат возвращается по каждой позиции
+func (s *withdrawBatchService) create(b *models.WithdrawBatch, dtos []*withdraw.CreateWithdrawDto, errs []error) (*models.WithdrawBatch, []*withdraw.BatchItemResultDto, error) {
+	results := make([]*withdraw.BatchItemResultDto, len(dtos))
+	valid := 0
+	for i, err := range errs {
+		results[i] = &withdraw.BatchItemResultDto{Index: i, Success: err == nil}
+		if err != nil {
+			results[i].Error = err.Error()
+		} else {
+			valid++
+		}
+	}
+	if valid == 0 {
+		return nil, results, ErrNoValidItems
+	}
+
+	// пакет и все его выводы создаются одной транзакцией, половины пакета не бывает
+	err := repositories.Transaction(func(tx *gorm.DB) error {
+		batches := repositories.WithdrawBatchRepository().WithTx(tx)
+		withdraws := repositories.WithdrawRepository().WithTx(tx)
+
+		if err := batches.Save(b); err != nil {
+			return err
+		}
+		for i, dto := range dtos {
+			if errs[i] != nil {
+				continue
+			}
+
+			wd := &models.Withdraw{
+				Number:             uuid.New(),
+				ShopID:             b.ShopID,
+				Shop:               b.Shop,
+				Type:               dto.Type,
+				CardNumber:         dto.CardNumber,
+				CardExpirationDate: dto.CardExpirationDate,
+				Phone:              dto.Phone,
+				Amount:             dto.Amount,
+				Status:             models.StatusNew,
+			}
+			if err := withdraws.Save(wd); err != nil {
+				return err
+			}
+
+			b.AddWithdraw(wd)
+			if err := batches.SaveItem(b.Items[len(b.Items)-1]); err != nil {
+				return err
+			}
+			results[i].Withdraw = withdraw.FromWithdraw(wd)
+		}
+		return batches.Save(b)
+	})
+	if err != nil {
+		log.Printf("WithdrawBatchService: unable to create batch for shop #%d: %v", b.ShopID, err)
+		// транзакция откатилась, созданных выводов нет
+		for _, res := range results {
+			res.Withdraw = nil
+		}
+		return nil, results, ErrWhileSaving
+	}
+
+	// риск-проверка после коммита, когда выводы уже видны в базе
+	for _, item := range b.Items {
+		if _, err := WithdrawRiskService().Evaluate(item.WithdrawID); err != nil {
+			log.Printf("WithdrawBatchService: unable to evaluate withdraw #%d: %v", item.WithdrawID, err)
+		}
+	}
+
+	return b, results, nil
+}
+
+// Approve запускает выплаты по всем незавершённым выводам пакета
+func (s *withdrawBatchService) Approve(batchId uint) (*models.WithdrawBatch, []*withdraw.BatchItemResultDto, error) {
+	b, err := repositories.WithdrawBatchRepository().FindById(batchId)
+	if err != nil {
+		return nil, nil, ErrBatchNotFound
+	}
+
+	if err := b.Approve(); err != nil {
+		return nil, nil, err
+	}
+	// пакет мог одновременно подтвердить или отклонить другой оператор
+	approved, err := repositories.WithdrawBatchRepository().Transition(b, models.WithdrawBatchStatusNew)
+	if err != nil {
+		return nil, nil, ErrWhileSaving
+	}
+	if !approved {
+		return nil, nil, models.ErrBatchNotNew
+	}
+
+	results := make([]*withdraw.BatchItemResultDto, len(b.Items))
+	for i, item := range b.Items {
+		results[i] = &withdraw.BatchItemResultDto{Index: i, Success: true}
+		if item.Withdraw.FinishedAt == nil {
+			if err := WithdrawRiskService().AssertApproved(item.WithdrawID); err != nil {
+				results[i].Success = false
+				results[i].Error = err.Error()
+			} else if _, err := PayoutService().Execute(item.WithdrawID); err != nil {
+				results[i].Success = false
+				results[i].Error = err.Error()
+			}
+		}
+		if wd, err := repositories.WithdrawRepository().FindById(item.WithdrawID); err == nil {
+			item.Withdraw = *wd
+		}
+		results[i].Withdraw = withdraw.FromWithdraw(&item.Withdraw)
+	}
+
+	// выплаты могли завершиться сразу
+	s.checkCompleted(b.ID)
+
+	return b, results, nil
+}
+
+// Decline отклоняет пакет целиком, незавершённые выводы переходят в failed
+func (s *withdrawBatchService) Decline(batchId uint) (*models.WithdrawBatch, error) {
+	b, err := repositories.WithdrawBatchRepository().FindById(batchId)
+	if err != nil {
+		return nil, ErrBatchNotFound
+	}
+
+	now := uint(time.Now().Unix())
+	if err := b.Decline(now); err != nil {
+		return nil, err
+	}
+
+	// пакет, его выводы и события о них меняются вместе
+	err = repositories.Transaction(func(tx *gorm.DB) error {
+		declined, err := repositories.WithdrawBatchRepository().WithTx(tx).Transition(b, models.WithdrawBatchStatusNew)
+		if err != nil {
+			return err
+		}
+		if !declined {
+			return models.ErrBatchNotNew
+		}
+
+		outbox := repositories.OutboxRepository().WithTx(tx)
+		for _, item := range b.Items {
+			wd := &item.Withdraw
+			if wd.FinishedAt != nil {
+				continue
+			}
+			wd.Status = models.StatusFailed
+			wd.FinishedAt = &now
+			if err := outbox.SaveWithEvents(wd, models.NewOutboxEvent(models.OutboxTypeWithdrawUpdated, wd.ID)); err != nil {
+				return err
+			}
+		}
+		return outbox.Save(models.NewOutboxEvent(models.OutboxTypeWithdrawBatchCompleted, b.ID))
+	})
+	if errors.Is(err, models.ErrBatchNotNew) {
+		return nil, err
+	}
+	if err != nil {
+		log.Printf("WithdrawBatchService: unable to decline batch #%d: %v", b.ID, err)
+		return nil, ErrWhileSaving
+	}
+
+	OutboxService().Flush()
+	return b, nil
+}
+
+func (s *withdrawBatchService) GetProgress(batchId uint) (*withdraw.BatchProgressDto, error) {
+	return repositories.WithdrawBatchRepository().GetProgress(batchId)
+}
+
+// WithdrawFinished вызывается при завершении вывода, чтобы закрыть пакет после последнего
+func (s *withdrawBatchService) WithdrawFinished(wd *models.Withdraw) {
+	b, err := repositories.WithdrawBatchRepository().FindByWithdrawId(wd.ID)
+	if err != nil {
+		// вывод не из пакета
+		return
+	}
+	s.checkCompleted(b.ID)
+}
+
+func (s *withdrawBatchService) checkCompleted(batchId uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := repositories.WithdrawBatchRepository().FindById(batchId)
+	if err != nil || b.IsFinished() || b.Status != models.WithdrawBatchStatusApproved {
+		return
+	}
+
+	progress, err := s.GetProgress(b.ID)
+	if err != nil {
+		log.Printf("WithdrawBatchService: unable to get progress of batch #%d: %v", b.ID, err)
+		return
+	}
+	if !progress.IsDone() {
+		return
+	}
+
+	if err := b.Complete(uint(time.Now().Unix())); err != nil {
+		return
+	}
+
+	// пакет завершает один экземпляр, событие о завершении пишется вместе с ним
+	err = repositories.Transaction(func(tx *gorm.DB) error {
+		completed, err := repositories.WithdrawBatchRepository().WithTx(tx).Transition(b, models.WithdrawBatchStatusApproved)
+		if err != nil || !completed {
+			return err
+		}
+		return repositories.OutboxRepository().WithTx(tx).Save(models.NewOutboxEvent(models.OutboxTypeWithdrawBatchCompleted, b.ID))
+	})
+	if err != nil {
+		log.Printf("WithdrawBatchService: unable to complete batch #%d: %v", b.ID, err)
+		return
+	}
+
+	OutboxService().Flush()
+}
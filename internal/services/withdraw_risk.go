@@ -0,0 +1,211 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"payment-go/internal/config"
+	"payment-go/internal/models"
+	"payment-go/internal/repositories"
+	"payment-go/internal/transport/model/withdraw"
+	"payment-go/internal/utils/scheduler"
+	"sync"
+	"time"
+)
+
+type IWithdrawRiskService interface {
+	Evaluate(withdrawId uint) (*models.WithdrawRiskCheck, error)
+	Approve(dto *withdraw.RiskApproveDto) (*models.WithdrawRiskCheck, error)
+	AssertApproved(withdrawId uint) error
+}
+type withdrawRiskService struct {
+	mu sync.Mutex
+}
+
+var wrIns *withdrawRiskService
+var wrOnce = sync.Once{}
+
+var ErrApprovalRequired = errors.New("withdraw requires approval of two different operators")
+
+func WithdrawRiskService() IWithdrawRiskService {
+	wrOnce.Do(func() {
+		wrIns = &withdrawRiskService{}
+		wrIns.init()
+	})
+	return wrIns
+}
+
+func (s *withdrawRiskService) init() {
+	SchedulerService().Register(config.JobWithdrawRisk, scheduler.Every(config.WithdrawRiskCheckInterval), false, Periodic(s.evaluateUnchecked))
+}
+
+// evaluateUnchecked проверяет выводы, созданные без вызова Evaluate: одиночным /api/withdraw/create
+// или если процесс упал между коммитом и проверкой. Статистика берётся по выводам до проверяемого, поэтому поздняя проверка даёт тот же результат
+func (s *withdrawRiskService) evaluateUnchecked() {
+	ids, err := repositories.WithdrawRiskRepository().FindUnchecked(config.WithdrawRiskBatchSize)
+	if err != nil {
+		log.Println("WithdrawRiskService: unable to load unchecked withdraws.", err)
+		return
+	}
+	for _, id := range ids {
+		if _, err := s.Evaluate(id); err != nil {
+			log.Printf("WithdrawRiskService: unable to evaluate withdraw #%d: %v", id, err)
+		}
+	}
+}
+
+// Evaluate проверяет вывод по правилам из withdraw_risk.json. Вызывается после коммита создания вывода,
+// повторный вызов возвращает сохранённый результат
+func (s *withdrawRiskService) Evaluate(withdrawId uint) (*models.WithdrawRiskCheck, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if rc, err := repositories.WithdrawRiskRepository().FindByWithdrawId(withdrawId); err == nil {
+		return rc, nil
+	}
+
+	wd, err := repositories.WithdrawRepository().FindById(withdrawId)
+	if err != nil {
+		return nil, ErrWithdrawNotFound
+	}
+
+	rc := models.NewWithdrawRiskCheck(wd)
+	if err := s.applyRules(rc, wd, config.GetConfig().WithdrawRisk); err != nil {
+		return nil, err
+	}
+
+	// проверка и её результаты сохраняются вместе, иначе повторный вызов вернул бы проверку без части правил
+	err = repositories.Transaction(func(tx *gorm.DB) error {
+		risks := repositories.WithdrawRiskRepository().WithTx(tx)
+		if err := risks.Save(rc); err != nil {
+			return err
+		}
+		for _, o := range rc.Outcomes {
+			o.RiskCheckID = rc.ID
+			if err := risks.SaveOutcome(o); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// вывод мог проверить другой экземпляр, withdraw_id уникален
+		if saved, findErr := repositories.WithdrawRiskRepository().FindByWithdrawId(withdrawId); findErr == nil {
+			return saved, nil
+		}
+		return nil, ErrWhileSaving
+	}
+
+	return rc, nil
+}
+
+func (s *withdrawRiskService) applyRules(rc *models.WithdrawRiskCheck, wd *models.Withdraw, conf *config.WithdrawRiskConfig) error {
+	// окно считается от создания вывода, поэтому поздняя проверка не учитывает выводы, созданные после него
+	since := wd.CreatedAt.Add(-24 * time.Hour)
+
+	if len(wd.CardNumber) != 0 && conf.IsCardBlocked(wd.CardNumber) {
+		rc.AddOutcome(models.RiskRuleBlockedCard, models.RiskResultBlock, "card number is blacklisted")
+	}
+	if len(wd.Phone) != 0 && conf.IsPhoneBlocked(wd.Phone) {
+		rc.AddOutcome(models.RiskRuleBlockedPhone, models.RiskResultBlock, "phone is blacklisted")
+	}
+
+	if conf.RecipientDailyCount != 0 || conf.RecipientDailyAmount != 0 {
+		stats, err := repositories.WithdrawRiskRepository().GetRecipientStats(wd, since)
+		if err != nil {
+			return err
+		}
+		if conf.RecipientDailyCount != 0 {
+			if stats.Count+1 > conf.RecipientDailyCount {
+				rc.AddOutcome(models.RiskRuleRecipientDailyCount, models.RiskResultBlock,
+					fmt.Sprintf("recipient got %d withdrawals in the last 24h, limit is %d", stats.Count, conf.RecipientDailyCount))
+			} else {
+				rc.AddOutcome(models.RiskRuleRecipientDailyCount, models.RiskResultPass, "")
+			}
+		}
+		if conf.RecipientDailyAmount != 0 {
+			if stats.Amount+wd.Amount > conf.RecipientDailyAmount {
+				rc.AddOutcome(models.RiskRuleRecipientDailyAmount, models.RiskResultBlock,
+					fmt.Sprintf("recipient got %.2f in the last 24h, limit is %.2f", stats.Amount, conf.RecipientDailyAmount))
+			} else {
+				rc.AddOutcome(models.RiskRuleRecipientDailyAmount, models.RiskResultPass, "")
+			}
+		}
+	}
+
+	if conf.ShopDailyAmount != 0 {
+		stats, err := repositories.WithdrawRiskRepository().GetShopStats(wd, since)
+		if err != nil {
+			return err
+		}
+		if stats.Amount+wd.Amount > conf.ShopDailyAmount {
+			rc.AddOutcome(models.RiskRuleShopDailyAmount, models.RiskResultBlock,
+				fmt.Sprintf("shop withdrew %.2f in the last 24h, daily cap is %.2f", stats.Amount, conf.ShopDailyAmount))
+		} else {
+			rc.AddOutcome(models.RiskRuleShopDailyAmount, models.RiskResultPass, "")
+		}
+	}
+
+	if conf.FlagFirstTimeRecipient {
+		count, err := repositories.WithdrawRiskRepository().CountPaidToRecipient(wd)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			if rc.RequiredApprovals == 0 {
+				rc.RequiredApprovals = 1
+			}
+			rc.AddOutcome(models.RiskRuleFirstTimeRecipient, models.RiskResultFlag, "shop has never paid to this recipient, an operator must approve")
+		} else {
+			rc.AddOutcome(models.RiskRuleFirstTimeRecipient, models.RiskResultPass, "")
+		}
+	}
+
+	if conf.TwoPersonThreshold != 0 && wd.Amount >= conf.TwoPersonThreshold {
+		rc.RequiredApprovals = 2
+		rc.AddOutcome(models.RiskRuleTwoPersonApproval, models.RiskResultFlag,
+			fmt.Sprintf("amount is above %.2f, two operators must approve", conf.TwoPersonThreshold))
+	}
+
+	return nil
+}
+
+func (s *withdrawRiskService) Approve(dto *withdraw.RiskApproveDto) (*models.WithdrawRiskCheck, error) {
+	if err := dto.Validate(); err != nil {
+		return nil, err
+	}
+
+	rc, err := s.Evaluate(dto.WithdrawID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	approval, err := rc.Approve(dto.Operator)
+	if err != nil {
+		return nil, err
+	}
+	if err := repositories.WithdrawRiskRepository().SaveApproval(approval); err != nil {
+		return nil, ErrWhileSaving
+	}
+
+	return rc, nil
+}
+
+// AssertApproved возвращает ошибку, если вывод заблокирован или ещё не подтверждён нужным числом операторов
+func (s *withdrawRiskService) AssertApproved(withdrawId uint) error {
+	rc, err := s.Evaluate(withdrawId)
+	if err != nil {
+		return err
+	}
+	if rc.IsBlocked() {
+		return models.ErrWithdrawBlocked
+	}
+	if !rc.IsApproved() {
+		return ErrApprovalRequired
+	}
+	return nil
+}
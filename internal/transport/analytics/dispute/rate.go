@@ -0,0 +1,27 @@
+package dispute
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type GetDisputeRatesDto struct {
+	ShopID   []uint `json:"shop_id,omitempty"`
+	DateFrom *uint  `json:"date_from,omitempty"`
+	DateTo   *uint  `json:"date_to,omitempty"`
+}
+
+func BuildRatesDto(data []byte) (*GetDisputeRatesDto, error) {
+	var dto = &GetDisputeRatesDto{}
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return nil, err
+	}
+	return dto, nil
+}
+
+func (dto *GetDisputeRatesDto) Validate() error {
+	if dto.DateFrom != nil && dto.DateTo != nil && *dto.DateFrom >= *dto.DateTo {
+		return fmt.Errorf("date_from must be less than date_to")
+	}
+	return nil
+}
@@ -1,5 +1,20 @@
 package bank
 
+import (
+	"fmt"
+	"strconv"
+)
+
+// статусы транзакции в ответе банка. Банк их не документирует: коды взяты из ответов,
+// которые воспроизводит cmd/mockbank, поэтому Outcome сохраняет прежнее правило для остальных случаев
+const TransactionStatusPending = 0
+const TransactionStatusPaid = 1
+const TransactionStatusDeclined = 2
+
+const OutcomePending = "pending"
+const OutcomePaid = "paid"
+const OutcomeDeclined = "declined"
+
 type CheckLinkResponseMerchant struct {
 	Id string `json:"id"`
 }
@@ -8,15 +23,39 @@ type CheckLinkResponseDto struct {
 	Amount        float64                    `json:"amount"`
 	CardType      string                     `json:"cardType"`
 	Merchant      *CheckLinkResponseMerchant `json:"merchant"`
-	Status        int                        `json:"status"`
+	Status        *int                       `json:"status"`
 }
 
-func (dto *CheckLinkResponseDto) Success() bool {
-	if dto.TransactionId == "" {
-		return false
+func (dto *CheckLinkResponseDto) Validate() error {
+	if len(dto.TransactionId) == 0 {
+		return fmt.Errorf("id is empty")
 	}
-	if dto.Merchant.Id == "" {
-		return false
+	return nil
+}
+
+// Outcome итог оплаты по статусу транзакции. Если статуса нет или он неизвестен, действует прежнее правило:
+// транзакция с заполненным merchant.id оплачена, иначе оплата ожидается и проверка продолжится до таймаута
+func (dto *CheckLinkResponseDto) Outcome() string {
+	if dto.Status != nil {
+		switch *dto.Status {
+		case TransactionStatusPending:
+			return OutcomePending
+		case TransactionStatusPaid:
+			return OutcomePaid
+		case TransactionStatusDeclined:
+			return OutcomeDeclined
+		}
+	}
+	if len(dto.TransactionId) != 0 && dto.Merchant != nil && len(dto.Merchant.Id) != 0 {
+		return OutcomePaid
+	}
+	return OutcomePending
+}
+
+// StatusString статус транзакции для журнала проверки
+func (dto *CheckLinkResponseDto) StatusString() string {
+	if dto.Status == nil {
+		return "none"
 	}
-	return true
+	return strconv.Itoa(*dto.Status)
 }
@@ -0,0 +1,34 @@
+package bank
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCheckLinkOutcome(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"pending", `{"id":"t1","status":0,"merchant":{"id":"m1"}}`, OutcomePending},
+		{"paid", `{"id":"t1","status":1}`, OutcomePaid},
+		{"declined", `{"id":"t1","status":2,"merchant":{"id":"m1"}}`, OutcomeDeclined},
+		// без статуса или с неизвестным статусом оплату подтверждает merchant.id
+		{"no status with merchant", `{"id":"t1","merchant":{"id":"m1"}}`, OutcomePaid},
+		{"no status without merchant", `{"id":"t1","merchant":{"id":""}}`, OutcomePending},
+		{"no status and no merchant", `{"id":"t1"}`, OutcomePending},
+		{"unknown status with merchant", `{"id":"t1","status":7,"merchant":{"id":"m1"}}`, OutcomePaid},
+		{"unknown status without merchant", `{"id":"t1","status":7}`, OutcomePending},
+		{"merchant without transaction", `{"merchant":{"id":"m1"}}`, OutcomePending},
+	}
+	for _, tt := range tests {
+		var dto CheckLinkResponseDto
+		if err := json.Unmarshal([]byte(tt.body), &dto); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := dto.Outcome(); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
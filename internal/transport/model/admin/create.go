@@ -0,0 +1,29 @@
+package admin
+
+import (
+	"fmt"
+	"payment-go/internal/models"
+	"strings"
+)
+
+const MinPasswordLength = 10
+
+type CreateAdminUserDto struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+	Role     string `json:"role"`
+}
+
+func (dto *CreateAdminUserDto) Validate() error {
+	dto.Login = strings.TrimSpace(dto.Login)
+	if len(dto.Login) < 3 {
+		return fmt.Errorf("login must be at least 3 characters long")
+	}
+	if len(dto.Password) < MinPasswordLength {
+		return fmt.Errorf("password must be at least %d characters long", MinPasswordLength)
+	}
+	if !models.IsAdminRole(dto.Role) {
+		return fmt.Errorf("unknown role '%s'", dto.Role)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package admin
+
+import (
+	"fmt"
+	"strings"
+)
+
+type LoginDto struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+func (dto *LoginDto) Validate() error {
+	dto.Login = strings.TrimSpace(dto.Login)
+	if len(dto.Login) == 0 || len(dto.Password) == 0 {
+		return fmt.Errorf("login and password are required")
+	}
+	return nil
+}
+
+type LoginResponseDto struct {
+	Token     string                `json:"token"`
+	ExpiresAt int64                 `json:"expires_at"`
+	User      *AdminUserResponseDto `json:"user"`
+}
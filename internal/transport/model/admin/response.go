@@ -0,0 +1,33 @@
+package admin
+
+import (
+	"payment-go/internal/models"
+)
+
+type AdminUserResponseDto struct {
+	ID          uint   `json:"id"`
+	Login       string `json:"login"`
+	Role        string `json:"role"`
+	Disabled    bool   `json:"disabled"`
+	LastLoginAt *uint  `json:"last_login_at"`
+	CreatedAt   int64  `json:"created_at"`
+}
+
+func FromAdminUser(entity *models.AdminUser) *AdminUserResponseDto {
+	return &AdminUserResponseDto{
+		ID:          entity.ID,
+		Login:       entity.Login,
+		Role:        entity.Role,
+		Disabled:    entity.Disabled,
+		LastLoginAt: entity.LastLoginAt,
+		CreatedAt:   entity.CreatedAt.Unix(),
+	}
+}
+
+func FromAdminUsers(entities []*models.AdminUser) []*AdminUserResponseDto {
+	res := make([]*AdminUserResponseDto, 0, len(entities))
+	for _, entity := range entities {
+		res = append(res, FromAdminUser(entity))
+	}
+	return res
+}
@@ -0,0 +1,27 @@
+package admin
+
+import (
+	"fmt"
+	"payment-go/internal/models"
+)
+
+type UpdateAdminUserDto struct {
+	ID       uint   `json:"id"`
+	Role     string `json:"role"`
+	Disabled bool   `json:"disabled"`
+	// пустой пароль не меняется
+	Password string `json:"password"`
+}
+
+func (dto *UpdateAdminUserDto) Validate() error {
+	if dto.ID == 0 {
+		return fmt.Errorf("admin user id is 0")
+	}
+	if !models.IsAdminRole(dto.Role) {
+		return fmt.Errorf("unknown role '%s'", dto.Role)
+	}
+	if len(dto.Password) != 0 && len(dto.Password) < MinPasswordLength {
+		return fmt.Errorf("password must be at least %d characters long", MinPasswordLength)
+	}
+	return nil
+}
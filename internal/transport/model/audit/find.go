@@ -0,0 +1,26 @@
+package audit
+
+import (
+	"encoding/json"
+	"payment-go/internal/transport/model/shared"
+)
+
+type FindAuditDto struct {
+	Search     string                     `json:"search,omitempty"`
+	Sort       *shared.Sorting            `json:"sort,omitempty"`
+	ID         []uint                     `json:"id,omitempty"`
+	ActorType  []string                   `json:"actor_type,omitempty"`
+	ActorID    []uint                     `json:"actor_id,omitempty"`
+	Action     []string                   `json:"action,omitempty"`
+	EntityType []string                   `json:"entity_type,omitempty"`
+	EntityID   []uint                     `json:"entity_id,omitempty"`
+	CreatedAt  *shared.RangeFilter[int64] `json:"created_at,omitempty"` // unix
+}
+
+func BuildFindDto(data []byte) (*FindAuditDto, error) {
+	var dto = &FindAuditDto{}
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return nil, err
+	}
+	return dto, nil
+}
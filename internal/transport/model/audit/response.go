@@ -0,0 +1,48 @@
+package audit
+
+import (
+	"encoding/json"
+	"payment-go/internal/models"
+	"payment-go/internal/utils/audit"
+)
+
+type AuditLogResponseDto struct {
+	ID         uint                    `json:"id"`
+	ActorType  string                  `json:"actor_type"`
+	ActorID    uint                    `json:"actor_id"`
+	ActorLogin string                  `json:"actor_login"`
+	Action     string                  `json:"action"`
+	EntityType string                  `json:"entity_type"`
+	EntityID   uint                    `json:"entity_id"`
+	Changes    map[string]audit.Change `json:"changes"`
+	IP         string                  `json:"ip"`
+	UserAgent  string                  `json:"user_agent"`
+	CreatedAt  int64                   `json:"created_at"`
+}
+
+func FromAuditLog(entity *models.AuditLog) *AuditLogResponseDto {
+	changes := make(map[string]audit.Change)
+	_ = json.Unmarshal([]byte(entity.Changes), &changes)
+
+	return &AuditLogResponseDto{
+		ID:         entity.ID,
+		ActorType:  entity.ActorType,
+		ActorID:    entity.ActorID,
+		ActorLogin: entity.ActorLogin,
+		Action:     entity.Action,
+		EntityType: entity.EntityType,
+		EntityID:   entity.EntityID,
+		Changes:    changes,
+		IP:         entity.IP,
+		UserAgent:  entity.UserAgent,
+		CreatedAt:  entity.CreatedAt.Unix(),
+	}
+}
+
+func FromAuditLogs(entities []*models.AuditLog) []*AuditLogResponseDto {
+	res := make([]*AuditLogResponseDto, 0, len(entities))
+	for _, entity := range entities {
+		res = append(res, FromAuditLog(entity))
+	}
+	return res
+}
@@ -0,0 +1,31 @@
+package dispute
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type CreateDisputeDto struct {
+	OrderID    uint    `json:"order_id"`
+	Amount     float64 `json:"amount,omitempty"`
+	Reason     string  `json:"reason"`
+	DeadlineAt *uint   `json:"deadline_at,omitempty"`
+}
+
+func CreateDtoFromJSON(data []byte) (*CreateDisputeDto, error) {
+	var dto *CreateDisputeDto
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return nil, err
+	}
+	return dto, nil
+}
+
+func (dto *CreateDisputeDto) Validate() error {
+	if dto.OrderID == 0 {
+		return fmt.Errorf("order_id is required")
+	}
+	if dto.Amount < 0 {
+		return fmt.Errorf("amount must be positive float")
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package dispute
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type AddEvidenceDto struct {
+	DisputeID     uint   `json:"dispute_id"`
+	BankMessageID *uint  `json:"bank_message_id,omitempty"`
+	Payload       string `json:"payload,omitempty"`
+}
+
+func EvidenceDtoFromJSON(data []byte) (*AddEvidenceDto, error) {
+	var dto *AddEvidenceDto
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return nil, err
+	}
+	return dto, nil
+}
+
+func (dto *AddEvidenceDto) Validate() error {
+	if dto.DisputeID == 0 {
+		return fmt.Errorf("dispute_id is required")
+	}
+	if (dto.BankMessageID == nil || *dto.BankMessageID == 0) && len(dto.Payload) == 0 {
+		return fmt.Errorf("bank_message_id or payload is required")
+	}
+	return nil
+}
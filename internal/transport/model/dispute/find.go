@@ -0,0 +1,25 @@
+package dispute
+
+import (
+	"encoding/json"
+	"payment-go/internal/transport/model/shared"
+)
+
+type FindDisputeDto struct {
+	Search  string                       `json:"search,omitempty"`
+	Sort    *shared.Sorting              `json:"sort,omitempty"`
+	ID      []uint                       `json:"id,omitempty"`
+	OrderID []uint                       `json:"order_id,omitempty"`
+	CardID  []uint                       `json:"card_id,omitempty"`
+	ShopID  []uint                       `json:"shop_id,omitempty"`
+	Amount  *shared.RangeFilter[float64] `json:"amount,omitempty"`
+	Status  []string                     `json:"status,omitempty"`
+}
+
+func BuildFindDto(data []byte) (*FindDisputeDto, error) {
+	var dto = &FindDisputeDto{}
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return nil, err
+	}
+	return dto, nil
+}
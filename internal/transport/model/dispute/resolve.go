@@ -0,0 +1,15 @@
+package dispute
+
+import "encoding/json"
+
+type ResolveDisputeDto struct {
+	DisputeID uint `json:"dispute_id"`
+}
+
+func ResolveDtoFromJSON(data []byte) (*ResolveDisputeDto, error) {
+	var dto *ResolveDisputeDto
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return nil, err
+	}
+	return dto, nil
+}
@@ -0,0 +1,71 @@
+package dispute
+
+import (
+	"payment-go/internal/models"
+	"payment-go/internal/transport/model/bank_message"
+	"time"
+)
+
+type DisputeResponseDto struct {
+	ID         uint                   `json:"id"`
+	OrderID    uint                   `json:"order_id"`
+	CardID     uint                   `json:"card_id"`
+	ShopID     uint                   `json:"shop_id"`
+	Amount     float64                `json:"amount"`
+	Reason     string                 `json:"reason"`
+	DeadlineAt *uint                  `json:"deadline_at"`
+	Expired    bool                   `json:"expired"`
+	ClosedAt   *uint                  `json:"closed_at"`
+	Status     string                 `json:"status"`
+	CreatedAt  int64                  `json:"created_at"`
+	Evidence   []*EvidenceResponseDto `json:"evidence"`
+}
+
+type EvidenceResponseDto struct {
+	ID          uint                                 `json:"id"`
+	BankMessage *bank_message.BankMessageResponseDto `json:"bank_message,omitempty"`
+	Payload     string                               `json:"payload,omitempty"`
+	CreatedAt   int64                                `json:"created_at"`
+}
+
+func FromDispute(d *models.Dispute) *DisputeResponseDto {
+	evidence := make([]*EvidenceResponseDto, len(d.Evidence))
+	for i, ev := range d.Evidence {
+		evidence[i] = FromEvidence(ev)
+	}
+
+	return &DisputeResponseDto{
+		ID:         d.ID,
+		OrderID:    d.OrderID,
+		CardID:     d.CardID,
+		ShopID:     d.ShopID,
+		Amount:     d.Amount,
+		Reason:     d.Reason,
+		DeadlineAt: d.DeadlineAt,
+		Expired:    d.IsExpired(uint(time.Now().Unix())),
+		ClosedAt:   d.ClosedAt,
+		Status:     d.Status,
+		CreatedAt:  d.CreatedAt.Unix(),
+		Evidence:   evidence,
+	}
+}
+
+func FromDisputes(disputes []*models.Dispute) []*DisputeResponseDto {
+	var res = make([]*DisputeResponseDto, len(disputes))
+	for i, d := range disputes {
+		res[i] = FromDispute(d)
+	}
+	return res
+}
+
+func FromEvidence(ev *models.DisputeEvidence) *EvidenceResponseDto {
+	res := &EvidenceResponseDto{
+		ID:        ev.ID,
+		Payload:   ev.Payload,
+		CreatedAt: ev.CreatedAt.Unix(),
+	}
+	if ev.BankMessage != nil {
+		res.BankMessage = bank_message.FromBankMessage(ev.BankMessage)
+	}
+	return res
+}
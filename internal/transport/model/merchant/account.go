@@ -0,0 +1,82 @@
+package merchant
+
+import (
+	"fmt"
+	"payment-go/internal/models"
+	"strings"
+)
+
+// CreateMerchantDto мерчант создаётся вместе с приглашением для владельца
+type CreateMerchantDto struct {
+	Name       string `json:"name"`
+	OwnerEmail string `json:"owner_email"`
+	OwnerName  string `json:"owner_name"`
+}
+
+func (dto *CreateMerchantDto) Validate() error {
+	dto.Name = strings.TrimSpace(dto.Name)
+	if len(dto.Name) < 3 {
+		return fmt.Errorf("merchant name must be at least 3 characters long")
+	}
+	dto.OwnerEmail = normalizeEmail(dto.OwnerEmail)
+	if !validEmail(dto.OwnerEmail) {
+		return fmt.Errorf("invalid owner_email")
+	}
+	return nil
+}
+
+type UpdateMerchantDto struct {
+	ID     uint   `json:"id"`
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
+}
+
+func (dto *UpdateMerchantDto) Validate() error {
+	if dto.ID == 0 {
+		return fmt.Errorf("please, specify the id")
+	}
+	dto.Name = strings.TrimSpace(dto.Name)
+	if len(dto.Name) < 3 {
+		return fmt.Errorf("merchant name must be at least 3 characters long")
+	}
+	return nil
+}
+
+type MerchantResponseDto struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	Active    bool   `json:"active"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+func FromMerchant(entity *models.Merchant) *MerchantResponseDto {
+	return &MerchantResponseDto{
+		ID:        entity.ID,
+		Name:      entity.Name,
+		Active:    entity.Active,
+		CreatedAt: entity.CreatedAt.Unix(),
+	}
+}
+
+func FromMerchants(entities []*models.Merchant) []*MerchantResponseDto {
+	res := make([]*MerchantResponseDto, 0, len(entities))
+	for _, entity := range entities {
+		res = append(res, FromMerchant(entity))
+	}
+	return res
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func validEmail(email string) bool {
+	at := strings.Index(email, "@")
+	return len(email) <= 255 && at > 0 && at < len(email)-1 && !strings.ContainsAny(email, " \t")
+}
+
+// InviteMemberDto приглашение участника из админки, например нового владельца
+type InviteMemberDto struct {
+	MerchantID uint `json:"merchant_id"`
+	InviteDto
+}
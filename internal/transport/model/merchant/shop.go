@@ -0,0 +1,41 @@
+package merchant
+
+import (
+	"payment-go/internal/models"
+	"payment-go/internal/transport/model/shop"
+	"payment-go/internal/transport/model/shop/parts"
+)
+
+// ShopActionDto действие с магазином. При авторизации ключами магазина shop_id можно не указывать
+type ShopActionDto struct {
+	ShopID uint `json:"shop_id"`
+}
+
+type UpdateWebhooksDto struct {
+	ShopID   uint                  `json:"shop_id"`
+	Webhooks parts.ShopWebhooksDto `json:"webhooks"`
+}
+
+type ShopResponseDto struct {
+	*shop.ShopResponseDto
+	Balance float64 `json:"balance"`
+}
+
+func FromShop(entity *models.Shop, balance float64) *ShopResponseDto {
+	return &ShopResponseDto{
+		ShopResponseDto: shop.FromShop(entity),
+		Balance:         balance,
+	}
+}
+
+type BalanceResponseDto struct {
+	ShopID  uint    `json:"shop_id"`
+	Balance float64 `json:"balance"`
+}
+
+type HostValidationResponseDto struct {
+	ShopID        uint   `json:"shop_id"`
+	Host          string `json:"host"`
+	HostValidated bool   `json:"host_validated"`
+	ConfirmCode   string `json:"confirm_code,omitempty"`
+}
@@ -0,0 +1,137 @@
+package merchant
+
+import (
+	"fmt"
+	"payment-go/internal/models"
+	"strings"
+)
+
+const MinPasswordLength = 10
+
+const MemberStatusInvited = "invited"
+const MemberStatusActive = "active"
+const MemberStatusDisabled = "disabled"
+
+type LoginDto struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+func (dto *LoginDto) Validate() error {
+	dto.Email = normalizeEmail(dto.Email)
+	if len(dto.Email) == 0 || len(dto.Password) == 0 {
+		return fmt.Errorf("email and password are required")
+	}
+	return nil
+}
+
+type LoginResponseDto struct {
+	Token     string             `json:"token"`
+	ExpiresAt int64              `json:"expires_at"`
+	User      *MemberResponseDto `json:"user"`
+}
+
+type InviteDto struct {
+	Email string `json:"email"`
+	Name  string `json:"name"`
+	Role  string `json:"role"`
+}
+
+func (dto *InviteDto) Validate() error {
+	dto.Email = normalizeEmail(dto.Email)
+	if !validEmail(dto.Email) {
+		return fmt.Errorf("invalid email")
+	}
+	dto.Name = strings.TrimSpace(dto.Name)
+	if !models.IsMerchantRole(dto.Role) {
+		return fmt.Errorf("unknown role '%s'", dto.Role)
+	}
+	return nil
+}
+
+// InviteResponseDto токен приглашения показывается один раз, его нужно передать приглашённому
+type InviteResponseDto struct {
+	Token     string             `json:"token"`
+	ExpiresAt uint               `json:"expires_at"`
+	Member    *MemberResponseDto `json:"member"`
+}
+
+type AcceptInviteDto struct {
+	Token    string `json:"token"`
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+func (dto *AcceptInviteDto) Validate() error {
+	dto.Token = strings.TrimSpace(dto.Token)
+	if len(dto.Token) == 0 {
+		return fmt.Errorf("invitation token is required")
+	}
+	dto.Name = strings.TrimSpace(dto.Name)
+	if len(dto.Password) < MinPasswordLength {
+		return fmt.Errorf("password must be at least %d characters long", MinPasswordLength)
+	}
+	return nil
+}
+
+type UpdateMemberDto struct {
+	ID       uint   `json:"id"`
+	Role     string `json:"role"`
+	Disabled bool   `json:"disabled"`
+}
+
+func (dto *UpdateMemberDto) Validate() error {
+	if dto.ID == 0 {
+		return fmt.Errorf("please, specify the id")
+	}
+	if !models.IsMerchantRole(dto.Role) {
+		return fmt.Errorf("unknown role '%s'", dto.Role)
+	}
+	return nil
+}
+
+type MemberActionDto struct {
+	ID uint `json:"id"`
+}
+
+type MemberResponseDto struct {
+	ID          uint                        `json:"id"`
+	MerchantID  uint                        `json:"merchant_id"`
+	Email       string                      `json:"email"`
+	Name        string                      `json:"name"`
+	Role        string                      `json:"role"`
+	Status      string                      `json:"status"`
+	Permissions []models.MerchantPermission `json:"permissions"`
+	JoinedAt    *uint                       `json:"joined_at"`
+	LastLoginAt *uint                       `json:"last_login_at"`
+	CreatedAt   int64                       `json:"created_at"`
+}
+
+func FromMerchantUser(entity *models.MerchantUser) *MemberResponseDto {
+	status := MemberStatusActive
+	if entity.JoinedAt == nil {
+		status = MemberStatusInvited
+	} else if entity.Disabled {
+		status = MemberStatusDisabled
+	}
+	return &MemberResponseDto{
+		ID:          entity.ID,
+		MerchantID:  entity.MerchantId,
+		Email:       entity.Email,
+		Name:        entity.Name,
+		Role:        entity.Role,
+		Status:      status,
+		Permissions: models.MerchantRolePermissions(entity.Role),
+		JoinedAt:    entity.JoinedAt,
+		LastLoginAt: entity.LastLoginAt,
+		CreatedAt:   entity.CreatedAt.Unix(),
+	}
+}
+
+func FromMerchantUsers(entities []*models.MerchantUser) []*MemberResponseDto {
+	res := make([]*MemberResponseDto, 0, len(entities))
+	for _, entity := range entities {
+		res = append(res, FromMerchantUser(entity))
+	}
+	return res
+}
@@ -0,0 +1,70 @@
+package merchant
+
+import (
+	"fmt"
+	"payment-go/internal/models"
+	"strings"
+)
+
+type IssueTokenDto struct {
+	OwnerID uint   `json:"owner_id"`
+	Name    string `json:"name"`
+}
+
+func (dto *IssueTokenDto) Validate() error {
+	if dto.OwnerID == 0 {
+		return fmt.Errorf("please, specify the owner_id")
+	}
+	dto.Name = strings.TrimSpace(dto.Name)
+	if len(dto.Name) == 0 {
+		return fmt.Errorf("token name is required")
+	}
+	return nil
+}
+
+// CreateTokenDto токен участника выпускается на него самого
+type CreateTokenDto struct {
+	Name string `json:"name"`
+}
+
+func (dto *CreateTokenDto) Validate() error {
+	dto.Name = strings.TrimSpace(dto.Name)
+	if len(dto.Name) == 0 {
+		return fmt.Errorf("token name is required")
+	}
+	return nil
+}
+
+type TokenActionDto struct {
+	ID uint `json:"id"`
+}
+
+type TokenResponseDto struct {
+	ID             uint   `json:"id"`
+	OwnerID        uint   `json:"owner_id"`
+	MerchantUserID uint   `json:"merchant_user_id"`
+	Name           string `json:"name"`
+	LastUsedAt     *uint  `json:"last_used_at"`
+	RevokedAt      *uint  `json:"revoked_at"`
+	CreatedAt      int64  `json:"created_at"`
+}
+
+func FromOwnerToken(entity *models.OwnerToken) *TokenResponseDto {
+	return &TokenResponseDto{
+		ID:             entity.ID,
+		OwnerID:        entity.OwnerId,
+		MerchantUserID: entity.MerchantUserId,
+		Name:           entity.Name,
+		LastUsedAt:     entity.LastUsedAt,
+		RevokedAt:      entity.RevokedAt,
+		CreatedAt:      entity.CreatedAt.Unix(),
+	}
+}
+
+func FromOwnerTokens(entities []*models.OwnerToken) []*TokenResponseDto {
+	res := make([]*TokenResponseDto, 0, len(entities))
+	for _, entity := range entities {
+		res = append(res, FromOwnerToken(entity))
+	}
+	return res
+}
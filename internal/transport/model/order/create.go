@@ -19,6 +19,9 @@ type CreateOrderDto struct {
 	Payload                string          `json:"payload"`
 	LinkCreatedCallbackUrl string          `json:"link_callback_url"`
 	PaymentCallbackUrl     string          `json:"payment_callback_url"`
+	// путь или адрес на хосте магазина для возврата плательщика
+	SuccessUrl string `json:"success_url"`
+	FailUrl    string `json:"fail_url"`
 }
 
 func (dto *CreateOrderDto) Validate() error {
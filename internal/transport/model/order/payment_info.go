@@ -34,7 +34,7 @@ func NewPaymentInfo(ord *models.Order) (IOrderPaymentInfoDto, error) {
 			timestamp:   ord.CreatedAt.Unix(),
 		}, nil
 	} else if ord.PaymentMethod == config.PaymentMethodBankTransfer {
-		if time.Since(ord.CreatedAt) >= config.CreateLinkTimeout+config.CheckLinkTimeout {
+		if time.Since(ord.CreatedAt) >= config.CreateLinkTimeout+config.GetConfig().LinkCheck.Timeout {
 			return nil, fmt.Errorf("payment information is unavailable")
 		}
 
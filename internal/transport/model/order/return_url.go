@@ -0,0 +1,37 @@
+package order
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+)
+
+// ReturnQuery параметры, с которыми плательщик возвращается в магазин.
+// signature = hex(HMAC-SHA256(private_key, "order_number:status"))
+type ReturnQuery struct {
+	OrderNumber string
+	Status      string
+}
+
+func (q *ReturnQuery) SignedPayload() string {
+	return q.OrderNumber + ":" + q.Status
+}
+
+// Apply добавляет подписанные параметры к адресу магазина
+func (q *ReturnQuery) Apply(target string, privateKey string) (string, error) {
+	u, err := url.Parse(target)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, []byte(privateKey))
+	mac.Write([]byte(q.SignedPayload()))
+
+	values := u.Query()
+	values.Set("order_number", q.OrderNumber)
+	values.Set("status", q.Status)
+	values.Set("signature", hex.EncodeToString(mac.Sum(nil)))
+	u.RawQuery = values.Encode()
+	return u.String(), nil
+}
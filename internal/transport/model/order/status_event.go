@@ -0,0 +1,50 @@
+package order
+
+const StatusEventLinkCreated = "link_created"
+const StatusEventExpired = "expired"
+const StatusEventPaymentDetected = "payment_detected"
+const StatusEventCompleted = "completed"
+const StatusEventFailed = "failed"
+
+// этапы идут по возрастанию, id события равен этапу.
+// Повторное подключение с Last-Event-ID получает только следующие этапы
+var statusEventStages = map[string]uint{
+	StatusEventLinkCreated:     1,
+	StatusEventExpired:         2, // оплата может прийти и после истечения времени
+	StatusEventPaymentDetected: 3,
+	StatusEventCompleted:       4,
+	StatusEventFailed:          4,
+}
+
+const StatusEventFinalStage uint = 4
+
+// StatusEventDto событие потока статусов заказа (SSE, WebSocket, long-poll)
+type StatusEventDto struct {
+	Id          uint   `json:"id"`
+	Event       string `json:"event"`
+	OrderNumber string `json:"order_number"`
+	Status      string `json:"status"`
+	Link        string `json:"link,omitempty"`
+	At          int64  `json:"at"`
+}
+
+func NewStatusEventDto(event string, orderNumber string, status string, at int64) *StatusEventDto {
+	return &StatusEventDto{
+		Id:          statusEventStages[event],
+		Event:       event,
+		OrderNumber: orderNumber,
+		Status:      status,
+		At:          at,
+	}
+}
+
+func (e *StatusEventDto) IsFinal() bool {
+	return e.Id >= StatusEventFinalStage
+}
+
+// StatusEventsResponseDto ответ long-poll
+type StatusEventsResponseDto struct {
+	Success     bool              `json:"success"`
+	Events      []*StatusEventDto `json:"events"`
+	LastEventId uint              `json:"last_event_id"`
+}
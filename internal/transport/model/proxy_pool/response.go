@@ -0,0 +1,44 @@
+package proxy_pool
+
+import "payment-go/internal/utils/proxy_pool"
+
+type HealthResponseDto struct {
+	Addr        string  `json:"addr"`
+	State       string  `json:"state"`
+	Requests    uint64  `json:"requests"`
+	Failures    uint64  `json:"failures"`
+	SuccessRate float64 `json:"success_rate"`
+	LatencyMs   int64   `json:"latency_ms"`
+	LastError   string  `json:"last_error,omitempty"`
+	LastUsedAt  *uint   `json:"last_used_at"`
+	OpenedAt    *uint   `json:"opened_at"`
+}
+
+func FromStats(st proxy_pool.Stats) *HealthResponseDto {
+	res := &HealthResponseDto{
+		Addr:        st.Addr,
+		State:       st.State,
+		Requests:    st.Requests,
+		Failures:    st.Failures,
+		SuccessRate: st.SuccessRate,
+		LatencyMs:   st.Latency.Milliseconds(),
+		LastError:   st.LastError,
+	}
+	if !st.LastUsedAt.IsZero() {
+		lastUsedAt := uint(st.LastUsedAt.Unix())
+		res.LastUsedAt = &lastUsedAt
+	}
+	if st.OpenedAt != nil {
+		openedAt := uint(st.OpenedAt.Unix())
+		res.OpenedAt = &openedAt
+	}
+	return res
+}
+
+func FromStatsList(list []proxy_pool.Stats) []*HealthResponseDto {
+	res := make([]*HealthResponseDto, len(list))
+	for i, st := range list {
+		res[i] = FromStats(st)
+	}
+	return res
+}
@@ -0,0 +1,25 @@
+package reconciliation
+
+import (
+	"encoding/json"
+	"payment-go/internal/transport/model/shared"
+)
+
+type FindLinesDto struct {
+	Search      string                       `json:"search,omitempty"`
+	Sort        *shared.Sorting              `json:"sort,omitempty"`
+	ID          []uint                       `json:"id,omitempty"`
+	StatementID []uint                       `json:"statement_id,omitempty"`
+	Account     []string                     `json:"account,omitempty"`
+	Amount      *shared.RangeFilter[float64] `json:"amount,omitempty"`
+	BookedAt    *shared.RangeFilter[uint]    `json:"booked_at,omitempty"`
+	Status      []string                     `json:"status,omitempty"`
+}
+
+func BuildFindLinesDto(data []byte) (*FindLinesDto, error) {
+	var dto = &FindLinesDto{}
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return nil, err
+	}
+	return dto, nil
+}
@@ -0,0 +1,43 @@
+package reconciliation
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// LinkDto привязка строки выписки или сообщения банка к заказу
+type LinkDto struct {
+	LineID    uint `json:"line_id,omitempty"`
+	MessageID uint `json:"message_id,omitempty"`
+	OrderID   uint `json:"order_id"`
+}
+
+func LinkDtoFromJSON(data []byte) (*LinkDto, error) {
+	var dto *LinkDto
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return nil, err
+	}
+	return dto, nil
+}
+
+func (dto *LinkDto) Validate() error {
+	if (dto.LineID == 0) == (dto.MessageID == 0) {
+		return fmt.Errorf("either line_id or message_id is required")
+	}
+	if dto.OrderID == 0 {
+		return fmt.Errorf("order_id is required")
+	}
+	return nil
+}
+
+type IgnoreDto struct {
+	LineID uint `json:"line_id"`
+}
+
+func IgnoreDtoFromJSON(data []byte) (*IgnoreDto, error) {
+	var dto *IgnoreDto
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return nil, err
+	}
+	return dto, nil
+}
@@ -0,0 +1,71 @@
+package reconciliation
+
+import (
+	"payment-go/internal/models"
+	"payment-go/internal/transport/model/bank_message"
+)
+
+type StatementResponseDto struct {
+	ID             uint   `json:"id"`
+	FileName       string `json:"file_name"`
+	Format         string `json:"format"`
+	LinesCount     uint   `json:"lines_count"`
+	MatchedCount   uint   `json:"matched_count"`
+	DuplicateCount uint   `json:"duplicate_count"`
+	CreatedAt      int64  `json:"created_at"`
+}
+
+type LineResponseDto struct {
+	ID          uint                                 `json:"id"`
+	StatementID uint                                 `json:"statement_id"`
+	Account     string                               `json:"account"`
+	Amount      float64                              `json:"amount"`
+	BookedAt    uint                                 `json:"booked_at"`
+	Reference   string                               `json:"reference"`
+	Description string                               `json:"description"`
+	BankMessage *bank_message.BankMessageResponseDto `json:"bank_message,omitempty"`
+	OrderID     *uint                                `json:"order_id"`
+	OrderNumber string                               `json:"order_number,omitempty"`
+	Status      string                               `json:"status"`
+}
+
+func FromStatement(s *models.BankStatement) *StatementResponseDto {
+	return &StatementResponseDto{
+		ID:             s.ID,
+		FileName:       s.FileName,
+		Format:         s.Format,
+		LinesCount:     s.LinesCount,
+		MatchedCount:   s.MatchedCount,
+		DuplicateCount: s.DuplicateCount,
+		CreatedAt:      s.CreatedAt.Unix(),
+	}
+}
+
+func FromLine(l *models.BankStatementLine) *LineResponseDto {
+	res := &LineResponseDto{
+		ID:          l.ID,
+		StatementID: l.StatementID,
+		Account:     l.Account,
+		Amount:      l.Amount,
+		BookedAt:    l.BookedAt,
+		Reference:   l.Reference,
+		Description: l.Description,
+		OrderID:     l.OrderID,
+		Status:      l.Status,
+	}
+	if l.BankMessage != nil {
+		res.BankMessage = bank_message.FromBankMessage(l.BankMessage)
+	}
+	if l.Order != nil {
+		res.OrderNumber = l.Order.Number.String()
+	}
+	return res
+}
+
+func FromLines(lines []*models.BankStatementLine) []*LineResponseDto {
+	res := make([]*LineResponseDto, len(lines))
+	for i, l := range lines {
+		res[i] = FromLine(l)
+	}
+	return res
+}
@@ -0,0 +1,21 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"payment-go/internal/transport/model/shared"
+)
+
+type FindRunsDto struct {
+	Sort    *shared.Sorting `json:"sort,omitempty"`
+	JobName []string        `json:"job_name,omitempty"`
+	Node    string          `json:"node,omitempty"`
+	Failed  *bool           `json:"failed,omitempty"`
+}
+
+func BuildFindRunsDto(data []byte) (*FindRunsDto, error) {
+	var dto = &FindRunsDto{}
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return nil, err
+	}
+	return dto, nil
+}
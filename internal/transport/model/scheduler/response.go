@@ -0,0 +1,61 @@
+package scheduler
+
+import "payment-go/internal/models"
+
+type JobResponseDto struct {
+	Name         string `json:"name"`
+	Spec         string `json:"spec"`
+	Local        bool   `json:"local"`
+	Running      bool   `json:"running"`
+	NextRunAt    *uint  `json:"next_run_at"`
+	LastRunAt    *uint  `json:"last_run_at"`
+	LastDuration uint   `json:"last_duration"`
+	LastError    string `json:"last_error,omitempty"`
+	Triggered    bool   `json:"triggered"`
+}
+
+type RunResponseDto struct {
+	ID         uint   `json:"id"`
+	JobName    string `json:"job_name"`
+	Node       string `json:"node"`
+	Trigger    string `json:"trigger"`
+	StartedAt  uint   `json:"started_at"`
+	FinishedAt *uint  `json:"finished_at"`
+	Duration   uint   `json:"duration"`
+	Error      string `json:"error,omitempty"`
+}
+
+func FromJob(job *models.ScheduledJob, running bool) *JobResponseDto {
+	return &JobResponseDto{
+		Name:         job.Name,
+		Spec:         job.Spec,
+		Local:        job.Local,
+		Running:      running,
+		NextRunAt:    job.NextRunAt,
+		LastRunAt:    job.LastRunAt,
+		LastDuration: job.LastDuration,
+		LastError:    job.LastError,
+		Triggered:    job.Triggered,
+	}
+}
+
+func FromRun(run *models.ScheduledJobRun) *RunResponseDto {
+	return &RunResponseDto{
+		ID:         run.ID,
+		JobName:    run.JobName,
+		Node:       run.Node,
+		Trigger:    run.Trigger,
+		StartedAt:  run.StartedAt,
+		FinishedAt: run.FinishedAt,
+		Duration:   run.Duration,
+		Error:      run.Error,
+	}
+}
+
+func FromRuns(runs []*models.ScheduledJobRun) []*RunResponseDto {
+	res := make([]*RunResponseDto, len(runs))
+	for i, run := range runs {
+		res[i] = FromRun(run)
+	}
+	return res
+}
@@ -0,0 +1,25 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type TriggerJobDto struct {
+	Name string `json:"name"`
+}
+
+func TriggerDtoFromJSON(data []byte) (*TriggerJobDto, error) {
+	var dto *TriggerJobDto
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return nil, err
+	}
+	return dto, nil
+}
+
+func (dto *TriggerJobDto) Validate() error {
+	if len(dto.Name) == 0 {
+		return fmt.Errorf("name is required")
+	}
+	return nil
+}
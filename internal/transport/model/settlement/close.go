@@ -0,0 +1,36 @@
+package settlement
+
+import (
+	"encoding/json"
+	"fmt"
+	"payment-go/internal/config"
+	"time"
+)
+
+type CloseSettlementDto struct {
+	ShopID uint   `json:"shop_id"`
+	Date   string `json:"date"`
+}
+
+func CloseDtoFromJSON(data []byte) (*CloseSettlementDto, error) {
+	var dto *CloseSettlementDto
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return nil, err
+	}
+	return dto, nil
+}
+
+func (dto *CloseSettlementDto) Validate() error {
+	if dto.ShopID == 0 {
+		return fmt.Errorf("shop_id is required")
+	}
+	day, err := time.ParseInLocation(config.SettlementDateLayout, dto.Date, time.Local)
+	if err != nil {
+		return fmt.Errorf("date must be in format YYYY-MM-DD")
+	}
+	// текущий день ещё не закончился
+	if !day.AddDate(0, 0, 1).Before(time.Now()) {
+		return fmt.Errorf("day is not finished yet")
+	}
+	return nil
+}
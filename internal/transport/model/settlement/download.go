@@ -0,0 +1,47 @@
+package settlement
+
+import (
+	"fmt"
+	"payment-go/internal/config"
+	"strings"
+	"time"
+)
+
+const FormatCSV = "csv"
+const FormatJSON = "json"
+
+// DownloadSettlementDto запрос магазина на выгрузку отчёта.
+// Signature = hex(HMAC-SHA256(private_key, "public_key:date:format:timestamp"))
+type DownloadSettlementDto struct {
+	PublicKey string
+	Date      string
+	Format    string
+	Timestamp int64
+	Signature string
+}
+
+func (dto *DownloadSettlementDto) Validate() error {
+	dto.Format = strings.ToLower(dto.Format)
+	if len(dto.Format) == 0 {
+		dto.Format = FormatJSON
+	}
+	dto.Signature = strings.ToLower(dto.Signature)
+
+	if len(dto.PublicKey) == 0 {
+		return fmt.Errorf("public_key is required")
+	}
+	if _, err := time.ParseInLocation(config.SettlementDateLayout, dto.Date, time.Local); err != nil {
+		return fmt.Errorf("date must be in format YYYY-MM-DD")
+	}
+	if dto.Format != FormatCSV && dto.Format != FormatJSON {
+		return fmt.Errorf("format must be csv or json")
+	}
+	if len(dto.Signature) == 0 {
+		return fmt.Errorf("signature is required")
+	}
+	return nil
+}
+
+func (dto *DownloadSettlementDto) SignedPayload() string {
+	return fmt.Sprintf("%s:%s:%s:%d", dto.PublicKey, dto.Date, dto.Format, dto.Timestamp)
+}
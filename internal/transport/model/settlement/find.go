@@ -0,0 +1,24 @@
+package settlement
+
+import (
+	"encoding/json"
+	"payment-go/internal/transport/model/shared"
+)
+
+type FindSettlementDto struct {
+	Sort     *shared.Sorting              `json:"sort,omitempty"`
+	ID       []uint                       `json:"id,omitempty"`
+	ShopID   []uint                       `json:"shop_id,omitempty"`
+	DateFrom string                       `json:"date_from,omitempty"`
+	DateTo   string                       `json:"date_to,omitempty"`
+	Net      *shared.RangeFilter[float64] `json:"net,omitempty"`
+	Mismatch *bool                        `json:"mismatch,omitempty"`
+}
+
+func BuildFindDto(data []byte) (*FindSettlementDto, error) {
+	var dto = &FindSettlementDto{}
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return nil, err
+	}
+	return dto, nil
+}
@@ -0,0 +1,110 @@
+package settlement
+
+import (
+	"bytes"
+	"encoding/csv"
+	"payment-go/internal/models"
+	"strconv"
+)
+
+type SettlementResponseDto struct {
+	ID                 uint               `json:"id"`
+	ShopID             uint               `json:"shop_id"`
+	Date               string             `json:"date"`
+	PeriodFrom         uint               `json:"period_from"`
+	PeriodTo           uint               `json:"period_to"`
+	OrdersCount        uint               `json:"orders_count"`
+	OrdersAmount       float64            `json:"orders_amount"`
+	Fees               float64            `json:"fees"`
+	Refunds            float64            `json:"refunds"`
+	Withdrawals        float64            `json:"withdrawals"`
+	Net                float64            `json:"net"`
+	BankMessagesAmount float64            `json:"bank_messages_amount"`
+	Mismatch           bool               `json:"mismatch"`
+	CreatedAt          int64              `json:"created_at"`
+	Lines              []*LineResponseDto `json:"lines,omitempty"`
+}
+
+type LineResponseDto struct {
+	Type        string  `json:"type"`
+	ReferenceID uint    `json:"reference_id"`
+	Reference   string  `json:"reference"`
+	Amount      float64 `json:"amount"`
+	Moment      uint    `json:"moment"`
+}
+
+func FromSettlement(s *models.Settlement) *SettlementResponseDto {
+	res := &SettlementResponseDto{
+		ID:                 s.ID,
+		ShopID:             s.ShopID,
+		Date:               s.Date,
+		PeriodFrom:         s.PeriodFrom,
+		PeriodTo:           s.PeriodTo,
+		OrdersCount:        s.OrdersCount,
+		OrdersAmount:       s.OrdersAmount,
+		Fees:               s.Fees,
+		Refunds:            s.Refunds,
+		Withdrawals:        s.Withdrawals,
+		Net:                s.Net,
+		BankMessagesAmount: s.BankMessagesAmount,
+		Mismatch:           s.Mismatch,
+		CreatedAt:          s.CreatedAt.Unix(),
+	}
+	if len(s.Lines) != 0 {
+		res.Lines = make([]*LineResponseDto, len(s.Lines))
+		for i, line := range s.Lines {
+			res.Lines[i] = &LineResponseDto{
+				Type:        line.Type,
+				ReferenceID: line.ReferenceID,
+				Reference:   line.Reference,
+				Amount:      line.Amount,
+				Moment:      line.Moment,
+			}
+		}
+	}
+	return res
+}
+
+func FromSettlements(items []*models.Settlement) []*SettlementResponseDto {
+	res := make([]*SettlementResponseDto, len(items))
+	for i, s := range items {
+		res[i] = FromSettlement(s)
+	}
+	return res
+}
+
+// ToCSV выгрузка отчёта: строки операций и итоговые суммы в конце
+func ToCSV(s *models.Settlement) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	money := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	}
+
+	rows := [][]string{{"type", "reference_id", "reference", "amount", "moment"}}
+	for _, line := range s.Lines {
+		rows = append(rows, []string{
+			line.Type,
+			strconv.Itoa(int(line.ReferenceID)),
+			line.Reference,
+			money(line.Amount),
+			strconv.Itoa(int(line.Moment)),
+		})
+	}
+	rows = append(rows,
+		[]string{},
+		[]string{"date", s.Date},
+		[]string{"orders_count", strconv.Itoa(int(s.OrdersCount))},
+		[]string{"orders_amount", money(s.OrdersAmount)},
+		[]string{"fees", money(s.Fees)},
+		[]string{"refunds", money(s.Refunds)},
+		[]string{"withdrawals", money(s.Withdrawals)},
+		[]string{"net", money(s.Net)},
+	)
+
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -4,3 +4,13 @@ type Sorting struct {
 	Field     string `json:"field"`
 	Direction string `json:"direction"`
 }
+
+// Allowed сортировка только по перечисленным полям, Field попадает в ORDER BY как есть
+func (s *Sorting) Allowed(fields ...string) bool {
+	for _, field := range fields {
+		if s.Field == field {
+			return true
+		}
+	}
+	return false
+}
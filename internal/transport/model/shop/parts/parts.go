@@ -7,6 +7,35 @@ type ShopWebhooksDto struct {
 	OnSuccess         *string `json:"on_success,omitempty"`
 	OnFailure         *string `json:"on_failure,omitempty"`
 	OnWithdrawUpdated *string `json:"on_withdraw_updated,omitempty"`
+	OnBatchCompleted  *string `json:"on_batch_completed,omitempty"`
+}
+
+type ShopBrandingDto struct {
+	LogoUrl         *string `json:"logo_url,omitempty"`
+	PrimaryColor    *string `json:"primary_color,omitempty"`
+	BackgroundColor *string `json:"background_color,omitempty"`
+	ReturnUrl       *string `json:"return_url,omitempty"`
+}
+
+func (sb *ShopBrandingDto) Resolve() models.ShopBranding {
+	return models.ShopBranding{
+		LogoUrl:         sb.LogoUrl,
+		PrimaryColor:    sb.PrimaryColor,
+		BackgroundColor: sb.BackgroundColor,
+		ReturnUrl:       sb.ReturnUrl,
+	}
+}
+
+type ShopRedirectsDto struct {
+	SuccessUrl *string `json:"success_url,omitempty"`
+	FailUrl    *string `json:"fail_url,omitempty"`
+}
+
+func (sr *ShopRedirectsDto) Resolve() models.ShopRedirects {
+	return models.ShopRedirects{
+		SuccessUrl: sr.SuccessUrl,
+		FailUrl:    sr.FailUrl,
+	}
 }
 
 func (sw *ShopWebhooksDto) Resolve() models.ShopWebhooks {
@@ -15,5 +44,6 @@ func (sw *ShopWebhooksDto) Resolve() models.ShopWebhooks {
 		OnSuccess:         sw.OnSuccess,
 		OnFailure:         sw.OnFailure,
 		OnWithdrawUpdated: sw.OnWithdrawUpdated,
+		OnBatchCompleted:  sw.OnBatchCompleted,
 	}
 }
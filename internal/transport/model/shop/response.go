@@ -6,16 +6,18 @@ import (
 )
 
 type ShopResponseDto struct {
-	ID            uint                  `json:"id"`
-	Name          string                `json:"name"`
-	Host          string                `json:"host"`
-	OwnerId       uint                  `json:"owner_id"`
-	Active        bool                  `json:"active"`
-	HostValidated bool                  `json:"host_validated"`
-	Moderated     bool                  `json:"moderated"`
-	PublicKey     string                `json:"public_key"`
-	ConfirmCode   string                `json:"confirm_code"`
-	Webhooks      parts.ShopWebhooksDto `json:"webhooks"`
+	ID            uint                   `json:"id"`
+	Name          string                 `json:"name"`
+	Host          string                 `json:"host"`
+	OwnerId       uint                   `json:"owner_id"`
+	Active        bool                   `json:"active"`
+	HostValidated bool                   `json:"host_validated"`
+	Moderated     bool                   `json:"moderated"`
+	PublicKey     string                 `json:"public_key"`
+	ConfirmCode   string                 `json:"confirm_code"`
+	Webhooks      parts.ShopWebhooksDto  `json:"webhooks"`
+	Branding      parts.ShopBrandingDto  `json:"branding"`
+	Redirects     parts.ShopRedirectsDto `json:"redirects"`
 }
 
 func FromShop(entity *models.Shop) *ShopResponseDto {
@@ -33,6 +35,17 @@ func FromShop(entity *models.Shop) *ShopResponseDto {
 			OnSuccess:         entity.Webhooks.OnSuccess,
 			OnFailure:         entity.Webhooks.OnFailure,
 			OnWithdrawUpdated: entity.Webhooks.OnWithdrawUpdated,
+			OnBatchCompleted:  entity.Webhooks.OnBatchCompleted,
+		},
+		Branding: parts.ShopBrandingDto{
+			LogoUrl:         entity.Branding.LogoUrl,
+			PrimaryColor:    entity.Branding.PrimaryColor,
+			BackgroundColor: entity.Branding.BackgroundColor,
+			ReturnUrl:       entity.Branding.ReturnUrl,
+		},
+		Redirects: parts.ShopRedirectsDto{
+			SuccessUrl: entity.Redirects.SuccessUrl,
+			FailUrl:    entity.Redirects.FailUrl,
 		},
 	}
 }
@@ -6,11 +6,13 @@ import (
 )
 
 type UpdateShopDto struct {
-	ID        uint                  `json:"id"`
-	Name      string                `json:"name"`
-	Active    bool                  `json:"active"`
-	Moderated bool                  `json:"moderated"`
-	Webhooks  parts.ShopWebhooksDto `json:"webhooks"`
+	ID        uint                   `json:"id"`
+	Name      string                 `json:"name"`
+	Active    bool                   `json:"active"`
+	Moderated bool                   `json:"moderated"`
+	Webhooks  parts.ShopWebhooksDto  `json:"webhooks"`
+	Branding  parts.ShopBrandingDto  `json:"branding"`
+	Redirects parts.ShopRedirectsDto `json:"redirects"`
 }
 
 func (dto *UpdateShopDto) Validate() error {
@@ -0,0 +1,142 @@
+package withdraw
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"payment-go/internal/config"
+	"payment-go/internal/models"
+	"payment-go/internal/transport/model/shared"
+)
+
+var ErrEmptyBatch = errors.New("batch contains no items")
+var ErrBatchTooLarge = fmt.Errorf("batch can contain at most %d items", config.WithdrawBatchMaxSize)
+
+type CreateBatchDto struct {
+	Auth  models.ShopKeys   `json:"auth"`
+	Items []json.RawMessage `json:"items"`
+}
+
+func ParseCreateBatchDtoFromJSON(data []byte) (*CreateBatchDto, error) {
+	var dto *CreateBatchDto
+	if err := json.Unmarshal(data, &dto); err != nil || dto == nil {
+		return nil, ErrParseJson
+	}
+	if err := dto.Validate(); err != nil {
+		return nil, err
+	}
+	return dto, nil
+}
+
+func (dto *CreateBatchDto) Validate() error {
+	if len(dto.Items) == 0 {
+		return ErrEmptyBatch
+	}
+	if len(dto.Items) > config.WithdrawBatchMaxSize {
+		return ErrBatchTooLarge
+	}
+	return nil
+}
+
+func (dto *CreateBatchDto) GetCredentials() models.ShopKeys {
+	return dto.Auth
+}
+
+// BatchItemResultDto результат валидации и создания одного вывода пакета
+type BatchItemResultDto struct {
+	Index    int          `json:"index"`
+	Success  bool         `json:"success"`
+	Error    string       `json:"error,omitempty"`
+	Withdraw *ResponseDto `json:"withdraw,omitempty"`
+}
+
+type BatchApprovalDto struct {
+	BatchID uint `json:"batch_id"`
+}
+
+func BatchApprovalDtoFromJSON(data []byte) (*BatchApprovalDto, error) {
+	var dto *BatchApprovalDto
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return nil, err
+	}
+	return dto, nil
+}
+
+type FindBatchDto struct {
+	Search      string                       `json:"search,omitempty"`
+	Sort        *shared.Sorting              `json:"sort,omitempty"`
+	ID          []uint                       `json:"id,omitempty"`
+	Number      string                       `json:"number,omitempty"`
+	ShopID      []uint                       `json:"shop_id,omitempty"`
+	Source      []string                     `json:"source,omitempty"`
+	TotalAmount *shared.RangeFilter[float64] `json:"total_amount,omitempty"`
+	Status      []string                     `json:"status,omitempty"`
+}
+
+func BuildFindBatchDto(data []byte) (*FindBatchDto, error) {
+	var dto = &FindBatchDto{}
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return nil, err
+	}
+	return dto, nil
+}
+
+// BatchProgressDto количество и сумма выводов пакета по статусам
+type BatchProgressDto struct {
+	Total           uint    `json:"total"`
+	Finished        uint    `json:"finished"`
+	Completed       uint    `json:"completed"`
+	Failed          uint    `json:"failed"`
+	CompletedAmount float64 `json:"completed_amount"`
+	FailedAmount    float64 `json:"failed_amount"`
+}
+
+func (p *BatchProgressDto) IsDone() bool {
+	return p.Total != 0 && p.Finished >= p.Total
+}
+
+type BatchResponseDto struct {
+	ID          uint              `json:"id"`
+	Number      string            `json:"number"`
+	ShopID      uint              `json:"shop_id"`
+	Source      string            `json:"source"`
+	FileName    string            `json:"file_name,omitempty"`
+	Total       uint              `json:"total"`
+	TotalAmount float64           `json:"total_amount"`
+	Status      string            `json:"status"`
+	Progress    *BatchProgressDto `json:"progress,omitempty"`
+	CreatedAt   int64             `json:"created_at"`
+	FinishedAt  *uint             `json:"finished_at"`
+	Withdraws   []*ResponseDto    `json:"withdraws,omitempty"`
+}
+
+func FromBatch(b *models.WithdrawBatch, progress *BatchProgressDto) *BatchResponseDto {
+	res := &BatchResponseDto{
+		ID:          b.ID,
+		Number:      b.Number.String(),
+		ShopID:      b.ShopID,
+		Source:      b.Source,
+		FileName:    b.FileName,
+		Total:       b.Total,
+		TotalAmount: b.TotalAmount,
+		Status:      b.Status,
+		Progress:    progress,
+		CreatedAt:   b.CreatedAt.Unix(),
+		FinishedAt:  b.FinishedAt,
+	}
+	if len(b.Items) != 0 {
+		res.Withdraws = make([]*ResponseDto, len(b.Items))
+		for i, item := range b.Items {
+			res.Withdraws[i] = FromWithdraw(&item.Withdraw)
+		}
+	}
+	return res
+}
+
+func FromBatches(batches []*models.WithdrawBatch) []*BatchResponseDto {
+	res := make([]*BatchResponseDto, len(batches))
+	for i, b := range batches {
+		res[i] = FromBatch(b, nil)
+	}
+	return res
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"payment-go/internal/config"
 	"payment-go/internal/models"
+	"strconv"
 	"strings"
 )
 
@@ -26,14 +27,43 @@ var (
 
 func ParseCreateDtoFromJSON(data []byte) (*CreateWithdrawDto, error) {
 	var dto *CreateWithdrawDto
-	if err := json.Unmarshal(data, &dto); err != nil {
+	if err := json.Unmarshal(data, &dto); err != nil || dto == nil {
 		return nil, ErrParseJson
 	}
 
-	if dto.Amount <= 0 {
+	if err := dto.Validate(); err != nil {
+		return nil, err
+	}
+
+	return dto, nil
+}
+
+// CreateDtoFromRow собирает dto из строки загруженного файла
+func CreateDtoFromRow(row map[string]string) (*CreateWithdrawDto, error) {
+	amount, err := strconv.ParseFloat(strings.ReplaceAll(row["amount"], ",", "."), 64)
+	if err != nil {
 		return nil, ErrInvalidAmount
 	}
 
+	dto := &CreateWithdrawDto{
+		Type:               row["type"],
+		CardNumber:         row["card_number"],
+		CardExpirationDate: row["card_expiration_date"],
+		Phone:              row["phone"],
+		Amount:             amount,
+	}
+	if err := dto.Validate(); err != nil {
+		return nil, err
+	}
+
+	return dto, nil
+}
+
+func (dto *CreateWithdrawDto) Validate() error {
+	if dto.Amount <= 0 {
+		return ErrInvalidAmount
+	}
+
 	dto.Type = strings.ToLower(dto.Type)
 	assertType := false
 	for _, typ := range config.GetConfig().Withdraw.SupportedTypes {
@@ -43,7 +73,7 @@ func ParseCreateDtoFromJSON(data []byte) (*CreateWithdrawDto, error) {
 		}
 	}
 	if !assertType {
-		return nil, ErrUnknownWithdrawType
+		return ErrUnknownWithdrawType
 	}
 
 	dto.Phone = strings.Trim(dto.Phone, " ")
@@ -51,10 +81,10 @@ func ParseCreateDtoFromJSON(data []byte) (*CreateWithdrawDto, error) {
 	dto.CardExpirationDate = strings.Trim(dto.CardExpirationDate, " ")
 
 	if (len(dto.CardNumber) == 0 || len(dto.CardExpirationDate) == 0) && len(dto.Phone) == 0 {
-		return nil, ErrNoRecipientInfo
+		return ErrNoRecipientInfo
 	}
 
-	return dto, nil
+	return nil
 }
 
 func (dto *CreateWithdrawDto) GetCredentials() models.ShopKeys {
@@ -18,6 +18,7 @@ type FindWithdrawDto struct {
 	Phone              string                       `json:"phone,omitempty"`
 	Amount             *shared.RangeFilter[float64] `json:"amount,omitempty"`
 	Status             []string                     `json:"status,omitempty"`
+	RiskStatus         []string                     `json:"risk_status,omitempty"`
 }
 
 func BuildFindDto(data []byte) (*FindWithdrawDto, error) {
@@ -0,0 +1,55 @@
+package withdraw
+
+import (
+	"encoding/json"
+	"fmt"
+	"payment-go/internal/models"
+)
+
+type PayoutConfirmDto struct {
+	WithdrawID    uint   `json:"withdraw_id"`
+	Success       bool   `json:"success"`
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+func PayoutConfirmDtoFromJSON(data []byte) (*PayoutConfirmDto, error) {
+	var dto *PayoutConfirmDto
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return nil, err
+	}
+	return dto, nil
+}
+
+func (dto *PayoutConfirmDto) Validate() error {
+	if dto.WithdrawID == 0 {
+		return fmt.Errorf("withdraw_id is required")
+	}
+	if !dto.Success && len(dto.FailureReason) == 0 {
+		return fmt.Errorf("failure_reason is required")
+	}
+	return nil
+}
+
+type PayoutResponseDto struct {
+	WithdrawID    uint   `json:"withdraw_id"`
+	Provider      string `json:"provider"`
+	ExternalID    string `json:"external_id"`
+	Attempts      uint   `json:"attempts"`
+	NextCheckAt   *uint  `json:"next_check_at"`
+	FailureReason string `json:"failure_reason,omitempty"`
+	FinishedAt    *uint  `json:"finished_at"`
+	Status        string `json:"status"`
+}
+
+func FromPayout(p *models.WithdrawPayout) *PayoutResponseDto {
+	return &PayoutResponseDto{
+		WithdrawID:    p.WithdrawID,
+		Provider:      p.Provider,
+		ExternalID:    p.ExternalID,
+		Attempts:      p.Attempts,
+		NextCheckAt:   p.NextCheckAt,
+		FailureReason: p.FailureReason,
+		FinishedAt:    p.FinishedAt,
+		Status:        p.Status,
+	}
+}
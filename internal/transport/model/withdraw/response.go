@@ -19,6 +19,7 @@ type ResponseDto struct {
 	CreatedAt          int64                 `json:"created_at"`
 	UpdatedAt          int64                 `json:"updated_at"`
 	FinishedAt         *uint                 `json:"finished_at"`
+	Risk               *RiskCheckResponseDto `json:"risk,omitempty"`
 }
 
 func FromWithdraw(wd *models.Withdraw) *ResponseDto {
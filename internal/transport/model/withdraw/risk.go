@@ -0,0 +1,96 @@
+package withdraw
+
+import (
+	"encoding/json"
+	"fmt"
+	"payment-go/internal/models"
+	"strings"
+)
+
+type RiskApproveDto struct {
+	WithdrawID uint   `json:"withdraw_id"`
+	Operator   string `json:"operator"`
+}
+
+func RiskApproveDtoFromJSON(data []byte) (*RiskApproveDto, error) {
+	var dto *RiskApproveDto
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return nil, err
+	}
+	return dto, nil
+}
+
+func (dto *RiskApproveDto) Validate() error {
+	if dto.WithdrawID == 0 {
+		return fmt.Errorf("withdraw_id is required")
+	}
+	dto.Operator = strings.TrimSpace(dto.Operator)
+	if len(dto.Operator) == 0 {
+		return fmt.Errorf("operator is required")
+	}
+	return nil
+}
+
+type RiskCheckResponseDto struct {
+	Status            string                    `json:"status"`
+	RequiredApprovals uint                      `json:"required_approvals"`
+	Approved          bool                      `json:"approved"`
+	Outcomes          []*RiskOutcomeResponseDto `json:"outcomes"`
+	Approvals         []*ApprovalResponseDto    `json:"approvals"`
+	CreatedAt         int64                     `json:"created_at"`
+}
+
+type RiskOutcomeResponseDto struct {
+	Rule    string `json:"rule"`
+	Result  string `json:"result"`
+	Message string `json:"message,omitempty"`
+}
+
+type ApprovalResponseDto struct {
+	Operator  string `json:"operator"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+func FromRiskCheck(rc *models.WithdrawRiskCheck) *RiskCheckResponseDto {
+	outcomes := make([]*RiskOutcomeResponseDto, len(rc.Outcomes))
+	for i, o := range rc.Outcomes {
+		outcomes[i] = &RiskOutcomeResponseDto{
+			Rule:    o.Rule,
+			Result:  o.Result,
+			Message: o.Message,
+		}
+	}
+
+	approvals := make([]*ApprovalResponseDto, len(rc.Approvals))
+	for i, a := range rc.Approvals {
+		approvals[i] = &ApprovalResponseDto{
+			Operator:  a.Operator,
+			CreatedAt: a.CreatedAt.Unix(),
+		}
+	}
+
+	return &RiskCheckResponseDto{
+		Status:            rc.Status,
+		RequiredApprovals: rc.RequiredApprovals,
+		Approved:          rc.IsApproved(),
+		Outcomes:          outcomes,
+		Approvals:         approvals,
+		CreatedAt:         rc.CreatedAt.Unix(),
+	}
+}
+
+// FromWithdrawsWithRisk ответ по выводам вместе с результатами проверок риска
+func FromWithdrawsWithRisk(wds []*models.Withdraw, checks []*models.WithdrawRiskCheck) []*ResponseDto {
+	byWithdraw := make(map[uint]*models.WithdrawRiskCheck, len(checks))
+	for _, rc := range checks {
+		byWithdraw[rc.WithdrawID] = rc
+	}
+
+	res := FromWithdraws(wds)
+	for i, wd := range wds {
+		if rc, ok := byWithdraw[wd.ID]; ok {
+			res[i].Risk = FromRiskCheck(rc)
+		}
+	}
+	return res
+}
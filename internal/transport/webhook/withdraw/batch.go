@@ -0,0 +1,27 @@
+package withdraw
+
+import "payment-go/internal/models"
+
+type WithdrawBatchCompletedWebhookDto struct {
+	Number          string  `json:"number"`
+	Status          string  `json:"status"`
+	Total           uint    `json:"total"`
+	TotalAmount     float64 `json:"total_amount"`
+	Completed       uint    `json:"completed"`
+	Failed          uint    `json:"failed"`
+	CompletedAmount float64 `json:"completed_amount"`
+	FinishedAt      *uint   `json:"finished_at"`
+}
+
+func FromBatch(b *models.WithdrawBatch, completed, failed uint, completedAmount float64) *WithdrawBatchCompletedWebhookDto {
+	return &WithdrawBatchCompletedWebhookDto{
+		Number:          b.Number.String(),
+		Status:          b.Status,
+		Total:           b.Total,
+		TotalAmount:     b.TotalAmount,
+		Completed:       completed,
+		Failed:          failed,
+		CompletedAmount: completedAmount,
+		FinishedAt:      b.FinishedAt,
+	}
+}
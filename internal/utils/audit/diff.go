@@ -0,0 +1,101 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// вместо значений этих полей в журнал попадает отпечаток
+var secretFields = []string{"privatekey", "private_key", "password", "token", "secret", "invitehash", "invite_hash"}
+
+// поля, которые меняются при любом сохранении
+var ignoredFields = map[string]bool{"UpdatedAt": true}
+
+// Diff сравнивает две версии сущности по json-представлению. Вложенные поля записываются через точку.
+// nil вместо версии означает создание или удаление
+func Diff(before, after any) map[string]Change {
+	b := flatten(before)
+	a := flatten(after)
+
+	res := make(map[string]Change)
+	for key, bv := range b {
+		av, ok := a[key]
+		if !ok || !reflect.DeepEqual(bv, av) {
+			res[key] = Change{Before: bv, After: av}
+		}
+	}
+	for key, av := range a {
+		if _, ok := b[key]; !ok {
+			res[key] = Change{Before: nil, After: av}
+		}
+	}
+	return res
+}
+
+func flatten(value any) map[string]any {
+	res := make(map[string]any)
+	if value == nil || reflect.ValueOf(value).Kind() == reflect.Pointer && reflect.ValueOf(value).IsNil() {
+		return res
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return res
+	}
+	var decoded any
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		return res
+	}
+	walk("", decoded, res)
+	return res
+}
+
+func walk(prefix string, value any, res map[string]any) {
+	obj, ok := value.(map[string]any)
+	if !ok {
+		if len(prefix) != 0 {
+			res[prefix] = value
+		}
+		return
+	}
+
+	for key, v := range obj {
+		if ignoredFields[key] {
+			continue
+		}
+		path := key
+		if len(prefix) != 0 {
+			path = prefix + "." + key
+		}
+		if isSecret(key) {
+			res[path] = redact(v)
+			continue
+		}
+		walk(path, v, res)
+	}
+}
+
+// redact скрывает секрет, оставляя отпечаток, по которому видно, что значение изменилось
+func redact(value any) string {
+	data, _ := json.Marshal(value)
+	sum := sha256.Sum256(data)
+	return "***" + hex.EncodeToString(sum[:4])
+}
+
+func isSecret(key string) bool {
+	key = strings.ToLower(key)
+	for _, field := range secretFields {
+		if strings.Contains(key, field) {
+			return true
+		}
+	}
+	return false
+}
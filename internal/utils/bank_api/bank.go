@@ -1,136 +1,205 @@
 package bank_api
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"net/http"
 	"payment-go/internal/config"
 	"payment-go/internal/transport/bank"
+	"payment-go/internal/utils/proxy"
 	"strings"
+	"time"
 )
 
 type IBank interface {
-	CheckMerchant(proxy.IProxy, *bank.CheckMerchantRequestDto) (*bank.CheckMerchantResponseDto, error)
-	GetAttributes(proxy.IProxy) (*bank.AttributesDto, error)
-	GetLink(proxy.IProxy, *bank.LinkRequestDto) (*bank.LinkResponseDto, error)
+	GetAttributes(ctx context.Context, pr proxy.IProxy) (*bank.AttributesDto, error)
+	CheckMerchant(ctx context.Context, pr proxy.IProxy, requestDto *bank.CheckMerchantRequestDto) (*bank.CheckMerchantResponseDto, error)
+	GetLink(ctx context.Context, pr proxy.IProxy, requestDto *bank.LinkRequestDto) (*bank.LinkResponseDto, error)
+	CheckLink(ctx context.Context, pr proxy.IProxy, transactionId string) (*bank.CheckLinkResponseDto, error)
+	GetTransactionId(ctx context.Context, pr proxy.IProxy, paymentUrl string) (string, error)
 }
+
+// bankApi клиент API million.az
 type bankApi struct {
+	conf *config.BankIntegrationConfig
 }
 
-func NewBank() IBank {
-	return &bankApi{}
+// IContextProxy прокси, поддерживающий отмену запроса через контекст
+type IContextProxy interface {
+	Do(req *http.Request) (*http.Response, error)
 }
 
-func (b *bankApi) GetAttributes(pr proxy.IProxy) (*bank.AttributesDto, error) {
-	res, err := pr.Get(config.BankLinks().GetAttsUrl)
-	if err != nil {
-		return nil, err
-	}
+// validatable ответы банка проверяют свои обязательные поля
+type validatable interface {
+	Validate() error
+}
+
+func newMillionBank(conf *config.BankIntegrationConfig) IBank {
+	return &bankApi{conf: conf}
+}
 
+func (b *bankApi) GetAttributes(ctx context.Context, pr proxy.IProxy) (*bank.AttributesDto, error) {
 	var atts bank.AttributesDto
-	err = json.NewDecoder(res.Body).Decode(&atts)
+	err := b.call(ctx, pr, "get attributes", config.BankAttributesTimeout, http.MethodGet, b.conf.Links.GetAttsUrl, nil, &atts)
 	if err != nil {
 		return nil, err
 	}
-
-	if err = atts.Validate(); err != nil {
-		return nil, err
-	}
-
 	return &atts, nil
 }
 
-func (b *bankApi) CheckMerchant(pr proxy.IProxy, requestDto *bank.CheckMerchantRequestDto) (*bank.CheckMerchantResponseDto, error) {
-	data, err := json.Marshal(requestDto)
+func (b *bankApi) CheckMerchant(ctx context.Context, pr proxy.IProxy, requestDto *bank.CheckMerchantRequestDto) (*bank.CheckMerchantResponseDto, error) {
+	var resp bank.CheckMerchantResponseDto
+	err := b.call(ctx, pr, "check merchant", config.BankCheckMerchantTimeout, http.MethodPost, b.conf.Links.CheckMerchantUrl, requestDto, &resp)
 	if err != nil {
 		return nil, err
 	}
+	return &resp, nil
+}
 
-	reader := strings.NewReader(string(data))
-	res, err := pr.Post(config.BankLinks().CheckMerchantUrl, "application/json", reader)
+func (b *bankApi) GetLink(ctx context.Context, pr proxy.IProxy, requestDto *bank.LinkRequestDto) (*bank.LinkResponseDto, error) {
+	var resp bank.LinkResponseDto
+	err := b.call(ctx, pr, "get link", config.BankGetLinkTimeout, http.MethodPost, b.conf.Links.PaymentUrl, requestDto, &resp)
 	if err != nil {
 		return nil, err
 	}
+	return &resp, nil
+}
 
-	defer res.Body.Close()
-
-	body, err := ioutil.ReadAll(res.Body)
+func (b *bankApi) CheckLink(ctx context.Context, pr proxy.IProxy, transactionId string) (*bank.CheckLinkResponseDto, error) {
+	var resp bank.CheckLinkResponseDto
+	err := b.call(ctx, pr, "check link", config.BankCheckLinkTimeout, http.MethodGet, b.conf.Links.TransactionInfo+transactionId, nil, &resp)
 	if err != nil {
 		return nil, err
 	}
+	return &resp, nil
+}
 
-	var resp bank.CheckMerchantResponseDto
-	err = json.Unmarshal(body, &resp)
+// GetTransactionId достаёт id транзакции из редиректа ссылки на оплату
+func (b *bankApi) GetTransactionId(ctx context.Context, pr proxy.IProxy, paymentUrl string) (string, error) {
+	const op = "get transaction id"
+	ctx, cancel := context.WithTimeout(ctx, config.BankTransactionIdTimeout)
+	defer cancel()
+
+	// переходим по ссылке
+	res, err := b.send(ctx, pr, http.MethodGet, paymentUrl, nil)
 	if err != nil {
-		return nil, err
+		return "", transient(op, err)
 	}
+	defer res.Body.Close()
 
-	if err = resp.Validate(); err != nil {
-		return nil, err
+	if err = checkStatus(op, res); err != nil {
+		return "", err
+	}
+	// проверяем наличие редиректа
+	redirect := res.Header.Get("Location")
+	if len(redirect) < 5 {
+		return "", transient(op, fmt.Errorf("no redirect"))
 	}
 
-	return &resp, nil
+	// разобьём редирект по /
+	redirectParts := strings.Split(redirect, "/")
+	l := len(redirectParts)
+	if l < 5 {
+		return "", transient(op, fmt.Errorf("unexpected redirect %s", redirect))
+	}
+	// достанем id транзакции
+	transactionId := redirectParts[l-1]
+	if len(transactionId) < 10 {
+		return "", transient(op, fmt.Errorf("invalid transaction id %s", transactionId))
+	}
+	return transactionId, nil
 }
 
-func (b *bankApi) GetLink(pr proxy.IProxy, requestDto *bank.LinkRequestDto) (*bank.LinkResponseDto, error) {
-	data, err := json.Marshal(requestDto)
-	if err != nil {
-		return nil, err
+// call выполняет запрос к банку с таймаутом op и разбирает ответ в resp
+func (b *bankApi) call(ctx context.Context, pr proxy.IProxy, op string, timeout time.Duration, method, url string, body any, resp validatable) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("bank %s: %w", op, err)
+		}
 	}
 
-	reader := strings.NewReader(string(data))
-	res, err := pr.Post(config.BankLinks().PaymentUrl, "application/json", reader)
+	res, err := b.send(ctx, pr, method, url, data)
 	if err != nil {
-		return nil, err
+		return transient(op, err)
 	}
-
 	defer res.Body.Close()
 
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		return nil, err
+	if err = checkStatus(op, res); err != nil {
+		return err
 	}
 
-	var resp bank.LinkResponseDto
-	err = json.Unmarshal(body, &resp)
+	// ответ читается целиком, но не больше лимита
+	raw, err := io.ReadAll(io.LimitReader(res.Body, config.BankMaxResponseSize+1))
 	if err != nil {
-		return nil, err
+		return transient(op, err)
 	}
-
-	if err = resp.Validate(); err != nil {
-		return nil, err
+	if len(raw) > config.BankMaxResponseSize {
+		return transient(op, fmt.Errorf("response is larger than %d bytes", config.BankMaxResponseSize))
 	}
 
-	return &resp, nil
-}
-
-func (b *bankApi) CheckLink(pr proxy.IProxy, transactionId string) (bool, error) {
-	fmt.Println("transactionId", transactionId)
-	fmt.Println("bank link transaction info", config.BankLinks().TransactionInfo+transactionId)
-	res, err := pr.Get(config.BankLinks().TransactionInfo + transactionId)
-	if err != nil {
-		var data []byte
-		res.Body.Read(data)
-		fmt.Println(data)
-		fmt.Println("CheckLink http error: ", err)
-		return false, err
+	if err = json.Unmarshal(raw, resp); err != nil {
+		return transient(op, err)
 	}
-
-	body, err := ioutil.ReadAll(res.Body)
-	if err != nil {
-		fmt.Println(body)
-		fmt.Println("CheckLink ioutil error: ", err)
-		return false, err
+	// неполный ответ со статусом 200 - сбой банка, а не отказ
+	if err = resp.Validate(); err != nil {
+		return transient(op, err)
 	}
+	return nil
+}
 
-	var resp bank.CheckLinkResponseDto
-	err = json.Unmarshal(body, &resp)
-	if err != nil {
-		fmt.Println("CheckLink json error: ", err)
-		return false, err
+// send отправляет запрос через прокси. Если прокси не поддерживает контекст, при отмене ответ отбрасывается.
+// Токен банка передаётся в заголовке, поэтому такой банк работает только через прокси с поддержкой Do
+func (b *bankApi) send(ctx context.Context, pr proxy.IProxy, method, url string, data []byte) (*http.Response, error) {
+	token := b.conf.Credentials[config.BankCredentialToken]
+	if cp, ok := pr.(IContextProxy); ok {
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		if method == http.MethodPost {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		if len(token) != 0 {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		return cp.Do(req)
+	}
+	if len(token) != 0 {
+		return nil, fmt.Errorf("proxy does not support authorization headers")
+	}
+
+	type result struct {
+		res *http.Response
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var r result
+		if method == http.MethodPost {
+			r.res, r.err = pr.Post(url, "application/json", bytes.NewReader(data))
+		} else {
+			r.res, r.err = pr.Get(url)
+		}
+		done <- r
+	}()
+
+	select {
+	case r := <-done:
+		return r.res, r.err
+	case <-ctx.Done():
+		go func() {
+			if r := <-done; r.err == nil {
+				r.res.Body.Close()
+			}
+		}()
+		return nil, ctx.Err()
 	}
-
-	fmt.Println(resp)
-
-	return resp.Success(), nil
 }
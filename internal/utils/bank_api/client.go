@@ -0,0 +1,33 @@
+package bank_api
+
+import (
+	"fmt"
+	"payment-go/internal/config"
+)
+
+// реализации клиентов по типу банка из bank.json
+var factories = map[string]func(conf *config.BankIntegrationConfig) IBank{
+	config.BankTypeMillion: newMillionBank,
+}
+
+// NewClient создаёт клиент банка по его подключению
+func NewClient(conf *config.BankIntegrationConfig) (IBank, error) {
+	factory, ok := factories[conf.Type]
+	if !ok {
+		return nil, fmt.Errorf("bank %s: unknown type %s", conf.Name, conf.Type)
+	}
+	return factory(conf), nil
+}
+
+// NewClientByName создаёт клиент банка из конфигурации, пустое имя - банк по умолчанию
+func NewClientByName(name string) (IBank, *config.BankIntegrationConfig, error) {
+	conf, ok := config.GetConfig().Bank.GetBank(name)
+	if !ok {
+		return nil, nil, fmt.Errorf("bank %s is not configured", name)
+	}
+	client, err := NewClient(conf)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, conf, nil
+}
@@ -0,0 +1,72 @@
+package bank_api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const ErrorTransient = "transient"      // сеть, прокси или сбой банка, запрос можно повторить
+const ErrorRateLimited = "rate_limited" // банк ограничил частоту запросов, повторять после паузы
+const ErrorRejected = "rejected"        // банк отказал по существу запроса, повтор не поможет
+
+// Error ошибка запроса к банку с её классом
+type Error struct {
+	Kind       string
+	Op         string
+	RetryAfter time.Duration // только для ErrorRateLimited, если банк её указал
+	Err        error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("bank %s (%s): %v", e.Op, e.Kind, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Kind класс ошибки. Неклассифицированные ошибки считаются временными
+func Kind(err error) string {
+	var bankErr *Error
+	if errors.As(err, &bankErr) {
+		return bankErr.Kind
+	}
+	return ErrorTransient
+}
+
+// RetryAfter пауза, которую запросил банк
+func RetryAfter(err error) time.Duration {
+	var bankErr *Error
+	if errors.As(err, &bankErr) {
+		return bankErr.RetryAfter
+	}
+	return 0
+}
+
+func transient(op string, err error) error {
+	return &Error{Kind: ErrorTransient, Op: op, Err: err}
+}
+
+func rejected(op string, err error) error {
+	return &Error{Kind: ErrorRejected, Op: op, Err: err}
+}
+
+// checkStatus классифицирует неуспешный HTTP ответ банка
+func checkStatus(op string, res *http.Response) error {
+	switch {
+	case res.StatusCode == http.StatusTooManyRequests:
+		err := &Error{Kind: ErrorRateLimited, Op: op, Err: fmt.Errorf("status %d", res.StatusCode)}
+		if seconds, convErr := strconv.Atoi(res.Header.Get("Retry-After")); convErr == nil && seconds > 0 {
+			err.RetryAfter = time.Duration(seconds) * time.Second
+		}
+		return err
+	case res.StatusCode >= 500:
+		return transient(op, fmt.Errorf("status %d", res.StatusCode))
+	case res.StatusCode >= 400:
+		return rejected(op, fmt.Errorf("status %d", res.StatusCode))
+	}
+	return nil
+}
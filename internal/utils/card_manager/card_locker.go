@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"payment-go/internal/config"
 	"payment-go/internal/models"
+	"payment-go/internal/providers"
 	"sync"
 	"time"
 )
@@ -14,30 +15,37 @@ type ICardLocker interface {
 	UnlockCard(cardId uint)
 	UnlockCardByOrderId(orderId uint)
 	IsLocked(cardId uint) bool
+	SetOrderId(cardId uint, orderId uint)
+	// GC снимает просроченные блокировки
+	GC()
 }
 type cardLocker struct {
 	mu    sync.RWMutex
 	cards []*lockedCard
 }
 
-var lockIns *cardLocker
+var lockIns ICardLocker
 var lockOnce = sync.Once{}
 
+// CardLocker при общем состоянии в Redis блокировки видны всем экземплярам приложения
 func CardLocker() ICardLocker {
 	lockOnce.Do(func() {
+		if config.GetConfig().StateBackend == config.StateBackendRedis {
+			lockIns = &storeCardLocker{
+				store: providers.StateProvider().GetStore(),
+			}
+			return
+		}
+
 		lockIns = &cardLocker{
 			mu:    sync.RWMutex{},
 			cards: make([]*lockedCard, 0),
 		}
-
-		go lockIns.gc()
 	})
 	return lockIns
 }
 
-func (cl *cardLocker) gc() {
-	time.Sleep(config.CardLockerGCInterval)
-
+func (cl *cardLocker) GC() {
 	cl.mu.Lock()
 	defer cl.mu.Unlock()
 
@@ -48,8 +56,6 @@ func (cl *cardLocker) gc() {
 		}
 	}
 	cl.cards = newList
-
-	go cl.gc()
 }
 
 func (cl *cardLocker) LockCard(crd *models.Card) (ISafeCard, error) {
@@ -122,6 +128,18 @@ func (cl *cardLocker) IsLocked(cardId uint) bool {
 	return false
 }
 
+func (cl *cardLocker) SetOrderId(cardId uint, orderId uint) {
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+
+	for _, lock := range cl.cards {
+		if lock.id == cardId {
+			lock.orderId = orderId
+			break
+		}
+	}
+}
+
 func (cl *cardLocker) GetLocked(cardId uint) ISafeCard {
 	cl.mu.Lock()
 	defer cl.mu.Unlock()
@@ -152,6 +170,7 @@ func (lc *lockedCard) GetCard() *models.Card {
 }
 func (lc *lockedCard) SetOrderId(orderId uint) {
 	lc.orderId = orderId
+	CardLocker().SetOrderId(lc.id, orderId)
 }
 func (lc *lockedCard) GetOrderId() uint {
 	return lc.orderId
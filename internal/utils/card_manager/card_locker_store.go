@@ -0,0 +1,158 @@
+package card_manager
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"payment-go/internal/config"
+	"payment-go/internal/models"
+	"payment-go/internal/utils/state"
+	"strconv"
+	"time"
+)
+
+// storeCardLocker хранит блокировки карт в общем хранилище, время жизни блокировки ограничено его TTL
+type storeCardLocker struct {
+	store state.IStore
+}
+
+type storedLock struct {
+	OrderID  uint         `json:"order_id"`
+	Card     *models.Card `json:"card"`
+	UnlockAt int64        `json:"unlock_at"`
+}
+
+func cardLockKey(cardId uint) string {
+	return fmt.Sprintf("card_lock:%d", cardId)
+}
+
+func orderLockKey(orderId uint) string {
+	return fmt.Sprintf("card_lock_order:%d", orderId)
+}
+
+func (cl *storeCardLocker) LockCard(crd *models.Card) (ISafeCard, error) {
+	if crd == nil {
+		return nil, fmt.Errorf("unable to lock nil card")
+	}
+
+	if !crd.CanBeLocked() {
+		return &defaultCard{card: crd}, nil
+	}
+
+	unlockTime := time.Now().Add(config.CardLockingTimeout)
+	data, err := json.Marshal(&storedLock{Card: crd, UnlockAt: unlockTime.Unix()})
+	if err != nil {
+		return nil, err
+	}
+	ok, err := cl.store.SetNX(cardLockKey(crd.ID), string(data), config.CardLockingTimeout)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("card is already locked")
+	}
+
+	return &lockedCard{
+		id:         crd.ID,
+		card:       crd,
+		orderId:    0,
+		unlockTime: unlockTime,
+	}, nil
+}
+
+func (cl *storeCardLocker) UnlockCard(cardId uint) {
+	lock := cl.get(cardId)
+	if lock == nil {
+		return
+	}
+	if err := cl.store.Delete(cardLockKey(cardId)); err != nil {
+		log.Printf("CardLocker: unable to unlock card #%d: %v", cardId, err)
+	}
+	if lock.OrderID != 0 {
+		_ = cl.store.Delete(orderLockKey(lock.OrderID))
+	}
+}
+
+func (cl *storeCardLocker) UnlockCardByOrderId(orderId uint) {
+	value, err := cl.store.Get(orderLockKey(orderId))
+	if err != nil {
+		return
+	}
+	cardId, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return
+	}
+	if lock := cl.get(uint(cardId)); lock != nil && lock.OrderID == orderId {
+		cl.UnlockCard(uint(cardId))
+	}
+}
+
+func (cl *storeCardLocker) IsLocked(cardId uint) bool {
+	return cl.get(cardId) != nil
+}
+
+func (cl *storeCardLocker) GetLocked(cardId uint) ISafeCard {
+	lock := cl.get(cardId)
+	if lock == nil {
+		return nil
+	}
+	return &lockedCard{
+		id:         cardId,
+		card:       lock.Card,
+		orderId:    lock.OrderID,
+		unlockTime: time.Unix(lock.UnlockAt, 0),
+	}
+}
+
+func (cl *storeCardLocker) SetOrderId(cardId uint, orderId uint) {
+	lock, value := cl.load(cardId)
+	if lock == nil {
+		return
+	}
+	lock.OrderID = orderId
+
+	data, err := json.Marshal(lock)
+	if err != nil {
+		log.Printf("CardLocker: unable to set order #%d for card #%d: %v", orderId, cardId, err)
+		return
+	}
+	// блокировка могла истечь или смениться после чтения, тогда заказ к ней не привязывается
+	ok, err := cl.store.CompareAndSet(cardLockKey(cardId), value, string(data))
+	if err == nil && !ok {
+		err = fmt.Errorf("lock is expired or changed")
+	}
+	if err == nil {
+		ttl := time.Until(time.Unix(lock.UnlockAt, 0))
+		if ttl > 0 {
+			err = cl.store.Set(orderLockKey(orderId), strconv.Itoa(int(cardId)), ttl)
+		}
+	}
+	if err != nil {
+		log.Printf("CardLocker: unable to set order #%d for card #%d: %v", orderId, cardId, err)
+	}
+}
+
+// GC блокировки снимаются по TTL хранилища
+func (cl *storeCardLocker) GC() {}
+
+func (cl *storeCardLocker) get(cardId uint) *storedLock {
+	lock, _ := cl.load(cardId)
+	return lock
+}
+
+// load блокировка карты и её значение в хранилище для CompareAndSet
+func (cl *storeCardLocker) load(cardId uint) (*storedLock, string) {
+	value, err := cl.store.Get(cardLockKey(cardId))
+	if err != nil {
+		if !errors.Is(err, state.ErrNotFound) {
+			log.Printf("CardLocker: unable to get lock of card #%d: %v", cardId, err)
+		}
+		return nil, ""
+	}
+	lock := &storedLock{}
+	if err = json.Unmarshal([]byte(value), lock); err != nil {
+		return nil, ""
+	}
+	return lock, value
+}
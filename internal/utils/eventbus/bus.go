@@ -0,0 +1,107 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"runtime/debug"
+	"sync"
+)
+
+type handler func(event any) error
+
+// Bus типизированная шина событий. Подписка идёт на конкретный тип события,
+// обработчики вызываются вне блокировки, паника одного подписчика не затрагивает остальных.
+type Bus struct {
+	mu   sync.RWMutex
+	seq  uint64
+	subs map[reflect.Type]map[uint64]handler
+}
+
+func New() *Bus {
+	return &Bus{
+		subs: make(map[reflect.Type]map[uint64]handler),
+	}
+}
+
+// Subscribe подписывает обработчик на события типа T. Подписка снимается
+// при отмене ctx или вызовом возвращаемой функции.
+func Subscribe[T any](ctx context.Context, b *Bus, fn func(event T)) (unsubscribe func()) {
+	return SubscribeWithError(ctx, b, func(event T) error {
+		fn(event)
+		return nil
+	})
+}
+
+// SubscribeWithError как Subscribe, но ошибка обработчика возвращается из Publish,
+// чтобы издатель мог повторить событие
+func SubscribeWithError[T any](ctx context.Context, b *Bus, fn func(event T) error) (unsubscribe func()) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+
+	b.mu.Lock()
+	b.seq++
+	id := b.seq
+	if b.subs[typ] == nil {
+		b.subs[typ] = make(map[uint64]handler)
+	}
+	b.subs[typ][id] = func(event any) error {
+		return fn(event.(T))
+	}
+	b.mu.Unlock()
+
+	once := sync.Once{}
+	unsubscribe = func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs[typ], id)
+			if len(b.subs[typ]) == 0 {
+				delete(b.subs, typ)
+			}
+			b.mu.Unlock()
+		})
+	}
+
+	if ctx.Done() != nil {
+		go func() {
+			<-ctx.Done()
+			unsubscribe()
+		}()
+	}
+	return unsubscribe
+}
+
+// Publish синхронно вызывает всех подписчиков на тип события. Ошибка или паника подписчика
+// не прерывает остальных и возвращается вызывающему
+func (b *Bus) Publish(event any) error {
+	if event == nil {
+		return nil
+	}
+
+	b.mu.RLock()
+	subs := b.subs[reflect.TypeOf(event)]
+	handlers := make([]handler, 0, len(subs))
+	for _, h := range subs {
+		handlers = append(handlers, h)
+	}
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, h := range handlers {
+		if err := b.call(h, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (b *Bus) call(h handler, event any) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("EventBus: subscriber panic on %T: %v\n%s", event, r, debug.Stack())
+			err = fmt.Errorf("subscriber panic on %T: %v", event, r)
+		}
+	}()
+	return h(event)
+}
@@ -0,0 +1,46 @@
+package eventbus
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type testEvent struct{ n int }
+
+func TestPublishReturnsSubscriberErrors(t *testing.T) {
+	b := New()
+	ctx := context.Background()
+	errFailed := errors.New("failed")
+
+	calls := 0
+	Subscribe(ctx, b, func(e testEvent) { calls++ })
+	SubscribeWithError(ctx, b, func(e testEvent) error { return errFailed })
+	Subscribe(ctx, b, func(e testEvent) { panic("boom") })
+
+	err := b.Publish(testEvent{n: 1})
+	if !errors.Is(err, errFailed) {
+		t.Fatalf("got %v, want errFailed", err)
+	}
+	if calls != 1 {
+		t.Fatalf("subscriber called %d times, want 1", calls)
+	}
+}
+
+func TestPublishWithoutErrors(t *testing.T) {
+	b := New()
+	got := 0
+	unsubscribe := SubscribeWithError(context.Background(), b, func(e testEvent) error {
+		got = e.n
+		return nil
+	})
+
+	if err := b.Publish(testEvent{n: 7}); err != nil || got != 7 {
+		t.Fatalf("got %d, %v", got, err)
+	}
+
+	unsubscribe()
+	if err := b.Publish(testEvent{n: 8}); err != nil || got != 7 {
+		t.Fatalf("unsubscribed handler was called: %d, %v", got, err)
+	}
+}
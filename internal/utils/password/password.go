@@ -0,0 +1,63 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// хеш хранится строкой "pbkdf2_sha256$итерации$соль$хеш", соль и хеш в base64
+
+const algorithm = "pbkdf2_sha256"
+const iterations = 210000
+const saltLength = 16
+const keyLength = 32
+
+// ограничения для хешей из БД, чтобы испорченная запись не заняла процессор
+const maxIterations = 10 * iterations
+const minKeyLength = 16
+const maxKeyLength = 64
+
+func Hash(password string) (string, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := pbkdf2.Key([]byte(password), salt, iterations, keyLength, sha256.New)
+
+	return fmt.Sprintf("%s$%d$%s$%s",
+		algorithm,
+		iterations,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify сравнивает пароль с хешем за постоянное время
+func Verify(password, encoded string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != algorithm {
+		return false
+	}
+	iter, err := strconv.Atoi(parts[1])
+	if err != nil || iter <= 0 || iter > maxIterations {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	expected, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil || len(expected) < minKeyLength || len(expected) > maxKeyLength {
+		return false
+	}
+
+	key := pbkdf2.Key([]byte(password), salt, iter, len(expected), sha256.New)
+	return subtle.ConstantTimeCompare(key, expected) == 1
+}
@@ -0,0 +1,54 @@
+package password
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"testing"
+)
+
+func TestHashAndVerify(t *testing.T) {
+	encoded, err := Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !Verify("correct horse", encoded) {
+		t.Fatal("valid password was rejected")
+	}
+	if Verify("wrong horse", encoded) {
+		t.Fatal("invalid password was accepted")
+	}
+}
+
+// формат совместим с ранее сохранёнными хешами: PBKDF2-HMAC-SHA256("password", "salt", 1, 32)
+func TestVerifyKnownVector(t *testing.T) {
+	key, _ := hex.DecodeString("120fb6cffcf8b32c43e7225256c4f837a86548c92ccc35480805987cb70be17b")
+	encoded := encode(1, []byte("salt"), key)
+	if !Verify("password", encoded) {
+		t.Fatal("known vector was rejected")
+	}
+}
+
+func TestVerifyRejectsMalformedHash(t *testing.T) {
+	salt := []byte("0123456789abcdef")
+	key := make([]byte, keyLength)
+	tests := map[string]string{
+		"empty":               "",
+		"wrong algorithm":     "md5$1$c2FsdA$c2FsdA",
+		"zero iterations":     encode(0, salt, key),
+		"too many iterations": encode(maxIterations+1, salt, key),
+		"short key":           encode(1, salt, key[:minKeyLength-1]),
+		"long key":            encode(1, salt, make([]byte, maxKeyLength+1)),
+	}
+	for name, encoded := range tests {
+		if Verify("password", encoded) {
+			t.Errorf("%s: hash was accepted", name)
+		}
+	}
+}
+
+func encode(iter int, salt, key []byte) string {
+	return algorithm + "$" + strconv.Itoa(iter) + "$" +
+		base64.RawStdEncoding.EncodeToString(salt) + "$" +
+		base64.RawStdEncoding.EncodeToString(key)
+}
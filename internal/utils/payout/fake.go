@@ -0,0 +1,93 @@
+package payout
+
+import (
+	"fmt"
+	"payment-go/internal/config"
+	"sync"
+)
+
+// FakeProvider провайдер для тестов и локальной разработки.
+// Все выплаты сразу уходят в pending, при проверке статуса получают заданный исход.
+type FakeProvider struct {
+	mu       sync.Mutex
+	outcome  string
+	reason   string
+	failures int
+	payouts  map[string]*Result
+}
+
+func NewFakeProvider(outcome string) *FakeProvider {
+	return &FakeProvider{
+		outcome: outcome,
+		payouts: make(map[string]*Result),
+	}
+}
+
+// SetOutcome задаёт итоговый статус для всех последующих проверок
+func (p *FakeProvider) SetOutcome(status string, reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.outcome = status
+	p.reason = reason
+}
+
+// FailNext следующие n вызовов вернут временную ошибку
+func (p *FakeProvider) FailNext(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failures = n
+}
+
+func (p *FakeProvider) Name() string {
+	return config.PayoutProviderFake
+}
+
+func (p *FakeProvider) Execute(req *Request) (*Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.takeFailure(); err != nil {
+		return nil, err
+	}
+
+	// повтор с тем же номером возвращает уже созданную выплату
+	if res, ok := p.payouts["fake-"+req.Number]; ok {
+		return res, nil
+	}
+
+	res := &Result{
+		ExternalID: "fake-" + req.Number,
+		Status:     StatusPending,
+	}
+	p.payouts[res.ExternalID] = res
+	return res, nil
+}
+
+func (p *FakeProvider) CheckStatus(externalId string) (*Result, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.takeFailure(); err != nil {
+		return nil, err
+	}
+
+	res, ok := p.payouts[externalId]
+	if !ok {
+		return &Result{
+			ExternalID:    externalId,
+			Status:        StatusFailed,
+			FailureReason: "payout not found",
+		}, nil
+	}
+	res.Status = p.outcome
+	res.FailureReason = p.reason
+	return res, nil
+}
+
+func (p *FakeProvider) takeFailure() error {
+	if p.failures > 0 {
+		p.failures--
+		return fmt.Errorf("fake provider: temporary error")
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package payout
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"payment-go/internal/config"
+	"time"
+)
+
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+// httpProvider выплаты через HTTP API банка
+type httpProvider struct {
+	conf   *config.PayoutHttpConfig
+	client *http.Client
+}
+
+func NewHttpProvider(conf *config.PayoutHttpConfig) IPayoutProvider {
+	return &httpProvider{
+		conf:   conf,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (p *httpProvider) Name() string {
+	return config.PayoutProviderHttp
+}
+
+func (p *httpProvider) Execute(req *Request) (*Result, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, p.conf.PayoutUrl, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	// повтор после таймаута банк должен вернуть как уже созданную выплату
+	request.Header.Set(HeaderIdempotencyKey, req.Number)
+
+	return p.do(request, true)
+}
+
+func (p *httpProvider) CheckStatus(externalId string) (*Result, error) {
+	request, err := http.NewRequest(http.MethodGet, p.conf.StatusUrl+url.PathEscape(externalId), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.do(request, false)
+}
+
+// do выполняет запрос. rejectable - 4xx означает отказ банка в выплате. При опросе статуса 4xx
+// (не найдена, нет доступа) ничего не говорит о самой выплате и считается временной ошибкой
+func (p *httpProvider) do(request *http.Request, rejectable bool) (*Result, error) {
+	if len(p.conf.Token) != 0 {
+		request.Header.Set("Authorization", "Bearer "+p.conf.Token)
+	}
+
+	res, err := p.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	// 5xx, таймаут, конфликт и лимит запросов - временная ошибка, выплату повторим
+	if res.StatusCode >= 500 || (res.StatusCode >= 400 && !rejectable) || isRetryableStatus(res.StatusCode) {
+		return nil, fmt.Errorf("payout api responded with status %d", res.StatusCode)
+	}
+
+	var result Result
+	err = json.Unmarshal(body, &result)
+
+	// 4xx - банк отклонил запрос
+	if res.StatusCode >= 400 {
+		result.Status = StatusFailed
+		if len(result.FailureReason) == 0 {
+			result.FailureReason = fmt.Sprintf("payout api responded with status %d", res.StatusCode)
+		}
+		return &result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch result.Status {
+	case StatusPending, StatusSucceeded, StatusFailed:
+	default:
+		return nil, fmt.Errorf("payout api responded with unknown status %s", result.Status)
+	}
+
+	return &result, nil
+}
+
+func isRetryableStatus(code int) bool {
+	return code == http.StatusRequestTimeout || code == http.StatusConflict || code == http.StatusTooManyRequests
+}
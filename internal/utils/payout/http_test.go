@@ -0,0 +1,112 @@
+package payout
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"payment-go/internal/config"
+	"testing"
+	"time"
+)
+
+func newTestProvider(handler http.HandlerFunc, timeout time.Duration) (*httpProvider, func()) {
+	server := httptest.NewServer(handler)
+	provider := &httpProvider{
+		conf: &config.PayoutHttpConfig{
+			PayoutUrl: server.URL + "/payout",
+			StatusUrl: server.URL + "/status/",
+		},
+		client: &http.Client{Timeout: timeout},
+	}
+	return provider, server.Close
+}
+
+func respond(code int, body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(code)
+		w.Write([]byte(body))
+	}
+}
+
+func TestHttpProviderExecute(t *testing.T) {
+	tests := []struct {
+		name      string
+		code      int
+		body      string
+		temporary bool
+		status    string
+	}{
+		{"pending", http.StatusOK, `{"external_id":"x1","status":"pending"}`, false, StatusPending},
+		{"succeeded", http.StatusOK, `{"external_id":"x1","status":"succeeded"}`, false, StatusSucceeded},
+		{"rejected", http.StatusUnprocessableEntity, `{"failure_reason":"card is closed"}`, false, StatusFailed},
+		{"rejected without body", http.StatusBadRequest, ``, false, StatusFailed},
+		{"server error", http.StatusBadGateway, ``, true, ""},
+		{"rate limited", http.StatusTooManyRequests, ``, true, ""},
+		{"conflict", http.StatusConflict, ``, true, ""},
+		{"unknown status", http.StatusOK, `{"external_id":"x1","status":"processing"}`, true, ""},
+		{"malformed", http.StatusOK, `{`, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, stop := newTestProvider(respond(tt.code, tt.body), time.Second)
+			defer stop()
+
+			res, err := provider.Execute(&Request{Number: "wd-1", Amount: 10})
+			if tt.temporary {
+				if err == nil {
+					t.Fatalf("got %+v, want temporary error", res)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Status != tt.status {
+				t.Fatalf("got status %s, want %s", res.Status, tt.status)
+			}
+			if res.Status == StatusFailed && len(res.FailureReason) == 0 {
+				t.Fatal("failure reason is empty")
+			}
+		})
+	}
+}
+
+func TestHttpProviderSendsIdempotencyKey(t *testing.T) {
+	var key string
+	provider, stop := newTestProvider(func(w http.ResponseWriter, r *http.Request) {
+		key = r.Header.Get(HeaderIdempotencyKey)
+		respond(http.StatusOK, `{"external_id":"x1","status":"pending"}`)(w, r)
+	}, time.Second)
+	defer stop()
+
+	if _, err := provider.Execute(&Request{Number: "wd-1"}); err != nil {
+		t.Fatal(err)
+	}
+	if key != "wd-1" {
+		t.Fatalf("got idempotency key %q", key)
+	}
+}
+
+// при опросе 4xx не означает отказ в выплате: она могла пройти
+func TestHttpProviderCheckStatusClientErrorIsTemporary(t *testing.T) {
+	for _, code := range []int{http.StatusNotFound, http.StatusUnauthorized, http.StatusBadRequest} {
+		provider, stop := newTestProvider(respond(code, `{"failure_reason":"not found"}`), time.Second)
+		res, err := provider.CheckStatus("x1")
+		stop()
+		if err == nil {
+			t.Fatalf("%d: got %+v, want temporary error", code, res)
+		}
+	}
+}
+
+func TestHttpProviderTimeout(t *testing.T) {
+	release := make(chan struct{})
+	provider, stop := newTestProvider(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}, 50*time.Millisecond)
+	defer stop()
+	defer close(release)
+
+	if res, err := provider.Execute(&Request{Number: "wd-1"}); err == nil {
+		t.Fatalf("got %+v, want timeout error", res)
+	}
+}
@@ -0,0 +1,30 @@
+package payout
+
+import "payment-go/internal/config"
+
+// manualProvider выплату производит оператор вручную, затем подтверждает её через /crud/withdraw/payout-confirm
+type manualProvider struct {
+}
+
+func NewManualProvider() IPayoutProvider {
+	return &manualProvider{}
+}
+
+func (p *manualProvider) Name() string {
+	return config.PayoutProviderManual
+}
+
+func (p *manualProvider) Execute(req *Request) (*Result, error) {
+	return &Result{
+		ExternalID: req.Number,
+		Status:     StatusPending,
+	}, nil
+}
+
+func (p *manualProvider) CheckStatus(externalId string) (*Result, error) {
+	// статус меняется только оператором
+	return &Result{
+		ExternalID: externalId,
+		Status:     StatusPending,
+	}, nil
+}
@@ -0,0 +1,60 @@
+package payout
+
+import (
+	"errors"
+	"fmt"
+	"payment-go/internal/config"
+)
+
+const StatusPending = "pending"
+const StatusSucceeded = "succeeded"
+const StatusFailed = "failed"
+
+// Request данные получателя выплаты
+type Request struct {
+	Number             string  `json:"number"`
+	Type               string  `json:"type"`
+	CardNumber         string  `json:"card_number,omitempty"`
+	CardExpirationDate string  `json:"card_expiration_date,omitempty"`
+	Phone              string  `json:"phone,omitempty"`
+	Amount             float64 `json:"amount"`
+}
+
+// Result состояние выплаты на стороне провайдера
+type Result struct {
+	ExternalID    string `json:"external_id"`
+	Status        string `json:"status"`
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+// IPayoutProvider Провайдер выплат. Execute отправляет выплату, CheckStatus опрашивает её состояние.
+// Ошибка означает временную проблему (сеть, 5xx, таймаут) - выплата будет повторена,
+// окончательный отказ возвращается через Result.Status = StatusFailed.
+// Execute должен быть идемпотентным по Request.Number: после таймаута неизвестно, дошёл ли запрос,
+// и повтор с тем же номером не должен создавать вторую выплату.
+type IPayoutProvider interface {
+	Name() string
+	Execute(req *Request) (*Result, error)
+	CheckStatus(externalId string) (*Result, error)
+}
+
+var ErrUnknownProvider = errors.New("unknown payout provider")
+
+func NewProvider(name string, conf *config.PayoutConfig) (IPayoutProvider, error) {
+	switch name {
+	case config.PayoutProviderManual:
+		return NewManualProvider(), nil
+	case config.PayoutProviderHttp:
+		if conf == nil || conf.Http == nil {
+			return nil, fmt.Errorf("http payout provider is not configured")
+		}
+		return NewHttpProvider(conf.Http), nil
+	case config.PayoutProviderFake:
+		return NewFakeProvider(StatusSucceeded), nil
+	}
+	return nil, ErrUnknownProvider
+}
+
+func (r *Result) IsFinished() bool {
+	return r.Status == StatusSucceeded || r.Status == StatusFailed
+}
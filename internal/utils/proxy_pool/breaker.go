@@ -0,0 +1,55 @@
+package proxy_pool
+
+import "time"
+
+const StateClosed = "closed"      // прокси работает
+const StateOpen = "open"          // прокси исключён из ротации
+const StateHalfOpen = "half_open" // пропускается один пробный запрос
+
+// breaker размыкается после нескольких отказов подряд и через openTime пропускает пробный запрос
+type breaker struct {
+	state     string
+	failures  uint
+	openedAt  time.Time
+	probing   bool
+	threshold uint
+	openTime  time.Duration
+}
+
+func (b *breaker) allow(now time.Time) bool {
+	switch b.state {
+	case StateOpen:
+		if now.Sub(b.openedAt) < b.openTime {
+			return false
+		}
+		b.state = StateHalfOpen
+		b.probing = false
+		fallthrough
+	case StateHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+	}
+	return true
+}
+
+// release возвращает пробный запрос, который не дал результата, следующий запрос снова станет пробным
+func (b *breaker) release() {
+	b.probing = false
+}
+
+func (b *breaker) success() {
+	b.state = StateClosed
+	b.failures = 0
+	b.probing = false
+}
+
+func (b *breaker) failure(now time.Time) {
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.threshold {
+		b.state = StateOpen
+		b.openedAt = now
+		b.probing = false
+	}
+}
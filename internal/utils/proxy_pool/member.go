@@ -0,0 +1,187 @@
+package proxy_pool
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ProxyError запрос не выполнен по вине прокси, его можно повторить через другой прокси
+type ProxyError struct {
+	Addr string
+	Err  error
+}
+
+func (e *ProxyError) Error() string {
+	return fmt.Sprintf("proxy %s: %v", e.Addr, e.Err)
+}
+
+func (e *ProxyError) Unwrap() error {
+	return e.Err
+}
+
+// Member прокси пула. Реализует proxy.IProxy и учитывает результат каждого запроса
+type Member struct {
+	mu        sync.Mutex
+	addr      string
+	client    *http.Client
+	breaker   breaker
+	requests  uint64
+	failures  uint64
+	latency   time.Duration // скользящее среднее
+	lastError string
+	lastUsed  time.Time
+}
+
+func newMember(addr string, opts Options) (*Member, error) {
+	proxyUrl, err := url.Parse(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %s: %w", addr, err)
+	}
+
+	return &Member{
+		addr: addr,
+		client: &http.Client{
+			Transport: &http.Transport{Proxy: http.ProxyURL(proxyUrl)},
+			Timeout:   opts.Timeout,
+			// редиректы нужны вызывающему коду, например для получения id транзакции
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		breaker: breaker{
+			state:     StateClosed,
+			threshold: opts.FailureThreshold,
+			openTime:  opts.OpenTime,
+		},
+	}, nil
+}
+
+func (m *Member) Addr() string {
+	return m.addr
+}
+
+func (m *Member) Get(url string) (*http.Response, error) {
+	return m.do(context.Background(), func() (*http.Response, error) {
+		return m.client.Get(url)
+	})
+}
+
+func (m *Member) Post(url, contentType string, body io.Reader) (*http.Response, error) {
+	return m.do(context.Background(), func() (*http.Response, error) {
+		return m.client.Post(url, contentType, body)
+	})
+}
+
+// Do выполняет запрос с его контекстом. Отмена контекста не считается отказом прокси
+func (m *Member) Do(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		m.release()
+		return nil, err
+	}
+	return m.do(req.Context(), func() (*http.Response, error) {
+		return m.client.Do(req)
+	})
+}
+
+// do выполняет запрос, полученный через acquire. Запрос, прерванный отменой ctx, ничего не говорит о прокси:
+// он не учитывается, а пробный запрос освобождается
+func (m *Member) do(ctx context.Context, request func() (*http.Response, error)) (*http.Response, error) {
+	start := time.Now()
+	res, err := request()
+	if err != nil && ctx.Err() != nil {
+		m.release()
+		return nil, ctx.Err()
+	}
+	if err == nil && isProxyFailure(res.StatusCode) {
+		res.Body.Close()
+		err = fmt.Errorf("status %d", res.StatusCode)
+	}
+	m.record(time.Since(start), err)
+
+	if err != nil {
+		return nil, &ProxyError{Addr: m.addr, Err: err}
+	}
+	return res, nil
+}
+
+// isProxyFailure ответы, которые возвращает сам прокси, а не банк
+func isProxyFailure(status int) bool {
+	switch status {
+	case http.StatusProxyAuthRequired, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func (m *Member) record(latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.requests++
+	m.lastUsed = now
+	if err != nil {
+		m.failures++
+		m.lastError = err.Error()
+		m.breaker.failure(now)
+		return
+	}
+
+	if m.latency == 0 {
+		m.latency = latency
+	} else {
+		m.latency = (m.latency*4 + latency) / 5
+	}
+	m.breaker.success()
+}
+
+func (m *Member) release() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.breaker.release()
+}
+
+// acquire можно ли отправить запрос через прокси
+func (m *Member) acquire() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.breaker.allow(time.Now())
+}
+
+func (m *Member) state() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.breaker.state
+}
+
+func (m *Member) stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	st := Stats{
+		Addr:        m.addr,
+		State:       m.breaker.state,
+		Requests:    m.requests,
+		Failures:    m.failures,
+		SuccessRate: 1,
+		Latency:     m.latency,
+		LastError:   m.lastError,
+		LastUsedAt:  m.lastUsed,
+	}
+	if m.requests != 0 {
+		st.SuccessRate = float64(m.requests-m.failures) / float64(m.requests)
+	}
+	if m.breaker.state != StateClosed {
+		openedAt := m.breaker.openedAt
+		st.OpenedAt = &openedAt
+	}
+	return st
+}
@@ -0,0 +1,71 @@
+package proxy_pool
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newHalfOpenMember прокси, у которого истекло время исключения: следующий запрос станет пробным
+func newHalfOpenMember(t *testing.T, addr string) *Member {
+	m, err := newMember(addr, Options{FailureThreshold: 1, OpenTime: time.Minute, Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.breaker.state = StateOpen
+	m.breaker.openedAt = time.Now().Add(-time.Hour)
+	return m
+}
+
+func TestDoReleasesProbeOnCancelledContext(t *testing.T) {
+	m := newHalfOpenMember(t, "http://127.0.0.1:1")
+	if !m.acquire() {
+		t.Fatal("probe was not allowed")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://bank.test/", nil)
+	if _, err := m.Do(req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+
+	if !m.acquire() {
+		t.Fatal("probe was not released")
+	}
+}
+
+func TestDoDoesNotRecordCancelledRequest(t *testing.T) {
+	started := make(chan struct{})
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer proxy.Close()
+
+	m := newHalfOpenMember(t, proxy.URL)
+	if !m.acquire() {
+		t.Fatal("probe was not allowed")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://bank.test/", nil)
+	if _, err := m.Do(req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+
+	st := m.stats()
+	if st.Requests != 0 || st.Failures != 0 || st.State != StateHalfOpen {
+		t.Fatalf("got %d requests, %d failures, state %s", st.Requests, st.Failures, st.State)
+	}
+	if !m.acquire() {
+		t.Fatal("probe was not released")
+	}
+}
@@ -0,0 +1,100 @@
+package proxy_pool
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrNoProxyAvailable = errors.New("no proxy available")
+
+type Options struct {
+	FailureThreshold uint          // отказов подряд до исключения прокси
+	OpenTime         time.Duration // через сколько исключённый прокси проверяется снова
+	Timeout          time.Duration
+}
+
+type Stats struct {
+	Addr        string
+	State       string
+	Requests    uint64
+	Failures    uint64
+	SuccessRate float64
+	Latency     time.Duration
+	LastError   string
+	LastUsedAt  time.Time
+	OpenedAt    *time.Time
+}
+
+type IPool interface {
+	// Acquire выдаёт следующий по кругу рабочий прокси, кроме указанных в exclude
+	Acquire(exclude map[string]bool) (*Member, error)
+	// Probe отправляет пробный запрос через исключённые прокси, у которых истекло время исключения
+	Probe(url string)
+	Stats() []Stats
+	Len() int
+}
+type pool struct {
+	mu      sync.Mutex
+	members []*Member
+	next    int
+}
+
+func NewPool(addrs []string, opts Options) (IPool, error) {
+	p := &pool{}
+	for _, addr := range addrs {
+		m, err := newMember(addr, opts)
+		if err != nil {
+			return nil, err
+		}
+		p.members = append(p.members, m)
+	}
+	return p, nil
+}
+
+func (p *pool) Acquire(exclude map[string]bool) (*Member, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	size := len(p.members)
+	for i := 0; i < size; i++ {
+		m := p.members[(p.next+i)%size]
+		if exclude[m.addr] || !m.acquire() {
+			continue
+		}
+		p.next = (p.next + i + 1) % size
+		return m, nil
+	}
+	return nil, ErrNoProxyAvailable
+}
+
+func (p *pool) Probe(url string) {
+	var wg sync.WaitGroup
+	for _, m := range p.members {
+		if m.state() == StateClosed || !m.acquire() {
+			continue
+		}
+
+		wg.Add(1)
+		go func(m *Member) {
+			defer wg.Done()
+			// любой ответ банка означает, что прокси снова работает
+			if res, err := m.Get(url); err == nil {
+				res.Body.Close()
+			}
+		}(m)
+	}
+	wg.Wait()
+}
+
+func (p *pool) Stats() []Stats {
+	res := make([]Stats, len(p.members))
+	for i, m := range p.members {
+		res[i] = m.stats()
+	}
+	return res
+}
+
+func (p *pool) Len() int {
+	return len(p.members)
+}
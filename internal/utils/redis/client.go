@@ -0,0 +1,258 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// Nil ответ сервера "нет значения"
+var Nil = errors.New("redis: nil")
+
+// Error ошибка, которую вернул сервер. Соединение после неё остаётся рабочим
+type Error string
+
+func (e Error) Error() string {
+	return "redis: " + string(e)
+}
+
+// Client минимальный клиент RESP2 с пулом соединений
+type Client struct {
+	addr     string
+	password string
+	db       int
+	timeout  time.Duration
+	pool     chan *conn
+}
+
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func NewClient(addr, password string, db int, poolSize int, timeout time.Duration) *Client {
+	return &Client{
+		addr:     addr,
+		password: password,
+		db:       db,
+		timeout:  timeout,
+		pool:     make(chan *conn, poolSize),
+	}
+}
+
+// Do выполняет команду и возвращает ответ: string, int64, []any или nil
+func (c *Client) Do(args ...any) (any, error) {
+	cn, err := c.get()
+	if err != nil {
+		return nil, err
+	}
+
+	_ = cn.SetDeadline(time.Now().Add(c.timeout))
+	res, err := cn.do(args...)
+	var srvErr Error
+	if err != nil && !errors.Is(err, Nil) && !errors.As(err, &srvErr) {
+		_ = cn.Close()
+		return nil, err
+	}
+	c.put(cn)
+	return res, err
+}
+
+func (c *Client) String(args ...any) (string, error) {
+	res, err := c.Do(args...)
+	if err != nil {
+		return "", err
+	}
+	s, ok := res.(string)
+	if !ok {
+		return "", fmt.Errorf("redis: unexpected reply %T", res)
+	}
+	return s, nil
+}
+
+func (c *Client) Int(args ...any) (int64, error) {
+	res, err := c.Do(args...)
+	if err != nil {
+		return 0, err
+	}
+	n, ok := res.(int64)
+	if !ok {
+		return 0, fmt.Errorf("redis: unexpected reply %T", res)
+	}
+	return n, nil
+}
+
+// Subscribe слушает канал на отдельном соединении, пока не отменён ctx или не оборвалась связь
+func (c *Client) Subscribe(ctx context.Context, channel string, handler func(payload string)) error {
+	cn, err := c.dial()
+	if err != nil {
+		return err
+	}
+	defer cn.Close()
+
+	if _, err = cn.do("SUBSCRIBE", channel); err != nil {
+		return err
+	}
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = cn.Close()
+		case <-stop:
+		}
+	}()
+
+	for {
+		res, err := cn.read()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		msg, ok := res.([]any)
+		if !ok || len(msg) != 3 || msg[0] != "message" {
+			continue
+		}
+		if payload, ok := msg[2].(string); ok {
+			handler(payload)
+		}
+	}
+}
+
+func (c *Client) get() (*conn, error) {
+	select {
+	case cn := <-c.pool:
+		return cn, nil
+	default:
+		return c.dial()
+	}
+}
+
+func (c *Client) put(cn *conn) {
+	_ = cn.SetDeadline(time.Time{})
+	select {
+	case c.pool <- cn:
+	default:
+		_ = cn.Close()
+	}
+}
+
+func (c *Client) dial() (*conn, error) {
+	nc, err := net.DialTimeout("tcp", c.addr, c.timeout)
+	if err != nil {
+		return nil, err
+	}
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc)}
+
+	_ = cn.SetDeadline(time.Now().Add(c.timeout))
+	if len(c.password) > 0 {
+		if _, err = cn.do("AUTH", c.password); err != nil {
+			_ = cn.Close()
+			return nil, err
+		}
+	}
+	if c.db != 0 {
+		if _, err = cn.do("SELECT", c.db); err != nil {
+			_ = cn.Close()
+			return nil, err
+		}
+	}
+	_ = cn.SetDeadline(time.Time{})
+	return cn, nil
+}
+
+func (cn *conn) do(args ...any) (any, error) {
+	if err := cn.write(args); err != nil {
+		return nil, err
+	}
+	return cn.read()
+}
+
+func (cn *conn) write(args []any) error {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		var s string
+		switch v := arg.(type) {
+		case string:
+			s = v
+		case []byte:
+			s = string(v)
+		case int:
+			s = strconv.Itoa(v)
+		case int64:
+			s = strconv.FormatInt(v, 10)
+		case uint:
+			s = strconv.FormatUint(uint64(v), 10)
+		default:
+			s = fmt.Sprint(v)
+		}
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(s)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, s...)
+		buf = append(buf, '\r', '\n')
+	}
+	_, err := cn.Write(buf)
+	return err
+}
+
+func (cn *conn) read() (any, error) {
+	line, err := cn.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, Error(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		size, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, Nil
+		}
+		data := make([]byte, size+2)
+		if _, err = io.ReadFull(cn.r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		size, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, Nil
+		}
+		list := make([]any, size)
+		for i := range list {
+			list[i], err = cn.read()
+			if err != nil && !errors.Is(err, Nil) {
+				return nil, err
+			}
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+}
@@ -0,0 +1,118 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func newTestClient(t *testing.T) (*Client, *miniredis.Miniredis) {
+	t.Helper()
+	m := miniredis.RunT(t)
+	return NewClient(m.Addr(), "", 0, 2, time.Second), m
+}
+
+func TestClientReplies(t *testing.T) {
+	c, _ := newTestClient(t)
+
+	if s, err := c.String("SET", "key", "value"); err != nil || s != "OK" {
+		t.Fatalf("SET: %q, %v", s, err)
+	}
+	if s, err := c.String("GET", "key"); err != nil || s != "value" {
+		t.Fatalf("GET: %q, %v", s, err)
+	}
+	if n, err := c.Int("INCRBY", "counter", 5); err != nil || n != 5 {
+		t.Fatalf("INCRBY: %d, %v", n, err)
+	}
+	if _, err := c.String("GET", "missing"); !errors.Is(err, Nil) {
+		t.Fatalf("GET missing: got %v, want Nil", err)
+	}
+
+	res, err := c.Do("MGET", "key", "missing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, ok := res.([]any)
+	if !ok || len(list) != 2 || list[0] != "value" || list[1] != nil {
+		t.Fatalf("MGET: %#v", res)
+	}
+}
+
+func TestClientServerErrorKeepsConnection(t *testing.T) {
+	c, _ := newTestClient(t)
+
+	var srvErr Error
+	if _, err := c.Do("NOSUCHCOMMAND"); !errors.As(err, &srvErr) {
+		t.Fatalf("got %v, want server error", err)
+	}
+	if len(c.pool) != 1 {
+		t.Fatalf("connection was not returned to the pool")
+	}
+	if s, err := c.String("PING"); err != nil || s != "PONG" {
+		t.Fatalf("PING: %q, %v", s, err)
+	}
+}
+
+func TestClientAuthAndSelect(t *testing.T) {
+	m := miniredis.RunT(t)
+	m.RequireAuth("secret")
+
+	if _, err := NewClient(m.Addr(), "wrong", 0, 1, time.Second).Do("PING"); err == nil {
+		t.Fatal("expected auth error")
+	}
+
+	c := NewClient(m.Addr(), "secret", 3, 1, time.Second)
+	if _, err := c.Do("SET", "key", "db3"); err != nil {
+		t.Fatal(err)
+	}
+	m.Select(3)
+	if v, err := m.Get("key"); err != nil || v != "db3" {
+		t.Fatalf("key in db 3: %q, %v", v, err)
+	}
+}
+
+func TestClientSubscribe(t *testing.T) {
+	c, m := newTestClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	got := make(chan string, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Subscribe(ctx, "channel", func(payload string) {
+			got <- payload
+		})
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for m.PubSubNumSub("channel")["channel"] == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("subscription was not created")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if _, err := c.Do("PUBLISH", "channel", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case payload := <-got:
+		if payload != "hello" {
+			t.Fatalf("got %q", payload)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message was not received")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("got %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Subscribe did not return after cancel")
+	}
+}
@@ -0,0 +1,192 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrInvalidSpec = errors.New("invalid schedule spec")
+
+// ISpec расписание задачи
+type ISpec interface {
+	// Next момент следующего запуска строго после from
+	Next(from time.Time) time.Time
+	String() string
+}
+
+// Parse разбирает расписание: "@every 5m" или cron из пяти полей "минута час день месяц день_недели"
+func Parse(spec string) (ISpec, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidSpec, spec)
+		}
+		return Every(d), nil
+	}
+	return ParseCron(spec)
+}
+
+// MustParse как Parse, но паникует на ошибке. Для расписаний, заданных константами
+func MustParse(spec string) ISpec {
+	s, err := Parse(spec)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+type everySpec struct {
+	interval time.Duration
+}
+
+// Every запуск с фиксированным интервалом
+func Every(interval time.Duration) ISpec {
+	return &everySpec{interval: interval}
+}
+
+func (s *everySpec) Next(from time.Time) time.Time {
+	return from.Add(s.interval)
+}
+
+func (s *everySpec) String() string {
+	return "@every " + s.interval.String()
+}
+
+type cronSpec struct {
+	source string
+	minute uint64
+	hour   uint64
+	dom    uint64
+	month  uint64
+	dow    uint64
+	anyDom bool
+	anyDow bool
+}
+
+type cronField struct {
+	min, max int
+}
+
+var cronFields = []cronField{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+
+// ParseCron поддерживает *, списки, диапазоны и шаги: "*/15 9-18 * * 1-5"
+func ParseCron(spec string) (ISpec, error) {
+	parts := strings.Fields(spec)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidSpec, spec)
+	}
+
+	var bits [5]uint64
+	for i, part := range parts {
+		b, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidSpec, spec)
+		}
+		bits[i] = b
+	}
+	// воскресенье можно указать как 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	return &cronSpec{
+		source: spec,
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		anyDom: parts[2] == "*",
+		anyDow: parts[4] == "*",
+	}, nil
+}
+
+func parseCronField(field string, f cronField) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			var err error
+			step, err = strconv.Atoi(item[i+1:])
+			if err != nil || step <= 0 {
+				return 0, ErrInvalidSpec
+			}
+			item = item[:i]
+		}
+
+		from, to := f.min, f.max
+		if f.max == 6 {
+			to = 7
+		}
+		if item != "*" {
+			bounds := strings.SplitN(item, "-", 2)
+			var err error
+			if from, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, ErrInvalidSpec
+			}
+			to = from
+			if len(bounds) == 2 {
+				if to, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, ErrInvalidSpec
+				}
+			} else if step > 1 {
+				to = f.max
+			}
+		}
+		max := f.max
+		if f.max == 6 {
+			max = 7
+		}
+		if from < f.min || to > max || from > to {
+			return 0, ErrInvalidSpec
+		}
+		for v := from; v <= to; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (s *cronSpec) Next(from time.Time) time.Time {
+	t := from.Truncate(time.Minute).Add(time.Minute)
+	// больше пяти лет перебора означает расписание, которое никогда не сработает (например, 31 февраля)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// dayMatches как в cron: если заданы и день месяца, и день недели, достаточно совпадения одного из них
+func (s *cronSpec) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.anyDom || s.anyDow {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+func (s *cronSpec) String() string {
+	return s.source
+}
@@ -0,0 +1,104 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04:05", s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestParseEvery(t *testing.T) {
+	spec, err := Parse("@every 90s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := date("2024-03-01 10:00:00")
+	if got := spec.Next(from); !got.Equal(from.Add(90 * time.Second)) {
+		t.Fatalf("got %v", got)
+	}
+	if spec.String() != "@every 1m30s" {
+		t.Fatalf("got %q", spec.String())
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	tests := []struct {
+		spec string
+		from string
+		want string
+	}{
+		{"* * * * *", "2024-03-01 10:00:30", "2024-03-01 10:01:00"},
+		{"*/15 * * * *", "2024-03-01 10:14:59", "2024-03-01 10:15:00"},
+		{"*/15 * * * *", "2024-03-01 10:45:00", "2024-03-01 11:00:00"},
+		{"10 * * * *", "2024-03-01 10:10:00", "2024-03-01 11:10:00"},
+		{"0 9-18 * * 1-5", "2024-03-01 18:30:00", "2024-03-04 09:00:00"},
+		{"0,30 12 * * *", "2024-03-01 12:00:00", "2024-03-01 12:30:00"},
+		{"0 0 1 * *", "2024-03-15 00:00:00", "2024-04-01 00:00:00"},
+		{"0 0 * 1 *", "2024-03-15 00:00:00", "2025-01-01 00:00:00"},
+		// воскресенье задаётся и как 0, и как 7
+		{"0 0 * * 7", "2024-03-01 00:00:00", "2024-03-03 00:00:00"},
+		{"0 0 * * 0", "2024-03-01 00:00:00", "2024-03-03 00:00:00"},
+		// заданы и день месяца, и день недели: достаточно совпадения одного из них
+		{"0 0 13 * 5", "2024-03-09 00:00:00", "2024-03-13 00:00:00"},
+		{"0 0 13 * 5", "2024-03-13 00:00:00", "2024-03-15 00:00:00"},
+		{"0 0 29 2 *", "2024-03-01 00:00:00", "2028-02-29 00:00:00"},
+	}
+	for _, tt := range tests {
+		spec, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.spec, err)
+		}
+		if got := spec.Next(date(tt.from)); !got.Equal(date(tt.want)) {
+			t.Errorf("%s from %s: got %v, want %s", tt.spec, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestCronNeverFires(t *testing.T) {
+	spec, err := Parse("0 0 31 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := spec.Next(date("2024-01-01 00:00:00")); !got.IsZero() {
+		t.Fatalf("got %v, want zero time", got)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"@every",
+		"@every -5m",
+		"@every 0s",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	} {
+		if _, err := Parse(spec); !errors.Is(err, ErrInvalidSpec) {
+			t.Errorf("%q: got %v, want ErrInvalidSpec", spec, err)
+		}
+	}
+}
+
+func TestMustParsePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("invalid spec did not panic")
+		}
+	}()
+	MustParse("not a spec")
+}
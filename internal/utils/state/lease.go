@@ -0,0 +1,99 @@
+package state
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// ILeaseBackend хранилище аренды, общее для всех узлов
+type ILeaseBackend interface {
+	// TryAcquire получает свободную или просроченную аренду либо продлевает свою
+	TryAcquire(name, owner string, ttl time.Duration) (bool, error)
+	Release(name, owner string) error
+}
+
+// Lease распределённая аренда: в каждый момент её держит не больше одного узла.
+// Владелец продлевает аренду каждые ttl/3, при падении узла её получает другой после истечения ttl.
+type Lease struct {
+	backend ILeaseBackend
+	name    string
+	owner   string
+	ttl     time.Duration
+	held    atomic.Bool
+}
+
+func NewLease(backend ILeaseBackend, name, owner string, ttl time.Duration) *Lease {
+	return &Lease{
+		backend: backend,
+		name:    name,
+		owner:   owner,
+		ttl:     ttl,
+	}
+}
+
+func (l *Lease) Name() string {
+	return l.name
+}
+
+// Held держит ли узел аренду в данный момент
+func (l *Lease) Held() bool {
+	return l.held.Load()
+}
+
+// Run ждёт получения аренды и вызывает job с контекстом, который отменяется при потере аренды.
+// После потери аренды дожидается завершения job и снова пытается получить аренду, пока не отменён ctx.
+func (l *Lease) Run(ctx context.Context, job func(ctx context.Context)) {
+	interval := l.ttl / 3
+	for ctx.Err() == nil {
+		ok, err := l.backend.TryAcquire(l.name, l.owner, l.ttl)
+		if err != nil {
+			log.Printf("Lease %s: %v", l.name, err)
+		}
+		if !ok {
+			select {
+			case <-ctx.Done():
+			case <-time.After(interval):
+			}
+			continue
+		}
+
+		log.Printf("Lease %s: acquired by %s", l.name, l.owner)
+		l.held.Store(true)
+		jobCtx, cancel := context.WithCancel(ctx)
+		jobDone := make(chan struct{})
+		go func() {
+			defer close(jobDone)
+			job(jobCtx)
+		}()
+		l.hold(jobCtx, interval)
+		l.held.Store(false)
+		cancel()
+		// задача прошлой аренды не должна работать одновременно с новой
+		<-jobDone
+	}
+
+	if err := l.backend.Release(l.name, l.owner); err != nil {
+		log.Printf("Lease %s: unable to release: %v", l.name, err)
+	}
+}
+
+// hold продлевает аренду, пока это удаётся
+func (l *Lease) hold(ctx context.Context, interval time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		ok, err := l.backend.TryAcquire(l.name, l.owner, l.ttl)
+		if err != nil {
+			log.Printf("Lease %s: %v", l.name, err)
+		}
+		if !ok {
+			log.Printf("Lease %s: lost by %s", l.name, l.owner)
+			return
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package state
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testLeaseBackend аренда в памяти, которую тест может отобрать у владельца
+type testLeaseBackend struct {
+	mu       sync.Mutex
+	owner    string
+	released bool
+}
+
+func (b *testLeaseBackend) TryAcquire(name, owner string, ttl time.Duration) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.owner != "" && b.owner != owner {
+		return false, nil
+	}
+	b.owner = owner
+	return true, nil
+}
+
+func (b *testLeaseBackend) Release(name, owner string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.owner == owner {
+		b.owner = ""
+	}
+	b.released = true
+	return nil
+}
+
+// setOwner передаёт аренду другому узлу, пустой owner освобождает её
+func (b *testLeaseBackend) setOwner(owner string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.owner = owner
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestLeaseRunsJobWhileHeld(t *testing.T) {
+	backend := &testLeaseBackend{}
+	lease := NewLease(backend, "job", "node-1", 30*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	started := make(chan context.Context, 2)
+	done := make(chan struct{})
+	go func() {
+		lease.Run(ctx, func(jobCtx context.Context) {
+			started <- jobCtx
+			<-jobCtx.Done()
+		})
+		close(done)
+	}()
+
+	var jobCtx context.Context
+	select {
+	case jobCtx = <-started:
+	case <-time.After(time.Second):
+		t.Fatal("job was not started")
+	}
+	if !lease.Held() {
+		t.Fatal("lease is not held")
+	}
+
+	// аренду забрал другой узел: задача останавливается, а узел ждёт освобождения
+	backend.setOwner("node-2")
+	select {
+	case <-jobCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("job was not cancelled after losing the lease")
+	}
+	waitFor(t, "lease to be released", func() bool { return !lease.Held() })
+
+	// аренда снова свободна
+	backend.setOwner("")
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("job was not restarted after reacquiring the lease")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+	if !backend.released {
+		t.Fatal("lease was not released")
+	}
+}
+
+func TestLeaseWaitsForPreviousJob(t *testing.T) {
+	backend := &testLeaseBackend{}
+	lease := NewLease(backend, "job", "node-1", 30*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	running, overlapped, starts := 0, false, 0
+	go lease.Run(ctx, func(jobCtx context.Context) {
+		mu.Lock()
+		running++
+		overlapped = overlapped || running > 1
+		starts++
+		mu.Unlock()
+
+		<-jobCtx.Done()
+		// задача завершается не сразу после отмены
+		time.Sleep(100 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+	})
+
+	waitFor(t, "job to start", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return starts == 1
+	})
+	backend.setOwner("node-2")
+	time.Sleep(40 * time.Millisecond)
+	backend.setOwner("")
+	waitFor(t, "job to restart", func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return starts == 2
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if overlapped {
+		t.Fatal("job was restarted before the previous one exited")
+	}
+}
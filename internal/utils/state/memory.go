@@ -0,0 +1,160 @@
+package state
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryValue struct {
+	value    string
+	expireAt time.Time
+}
+
+type memoryStore struct {
+	mu   sync.Mutex
+	keys map[string]*memoryValue
+}
+
+// NewMemoryStore хранилище в памяти процесса для запуска в один экземпляр
+func NewMemoryStore() IStore {
+	return &memoryStore{
+		keys: make(map[string]*memoryValue),
+	}
+}
+
+// get вызывается под блокировкой, просроченные ключи удаляются при обращении
+func (s *memoryStore) get(key string) *memoryValue {
+	v, ok := s.keys[key]
+	if !ok {
+		return nil
+	}
+	if !v.expireAt.IsZero() && time.Now().After(v.expireAt) {
+		delete(s.keys, key)
+		return nil
+	}
+	return v
+}
+
+func (s *memoryStore) Get(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v := s.get(key)
+	if v == nil {
+		return "", ErrNotFound
+	}
+	return v.value, nil
+}
+
+func (s *memoryStore) Set(key, value string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v := &memoryValue{value: value}
+	if ttl > 0 {
+		v.expireAt = time.Now().Add(ttl)
+	}
+	s.keys[key] = v
+	return nil
+}
+
+func (s *memoryStore) SetNX(key, value string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.get(key) != nil {
+		return false, nil
+	}
+	s.keys[key] = &memoryValue{value: value, expireAt: time.Now().Add(ttl)}
+	return true, nil
+}
+
+func (s *memoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys, key)
+	return nil
+}
+
+func (s *memoryStore) CompareAndDelete(key, value string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v := s.get(key)
+	if v == nil || v.value != value {
+		return false, nil
+	}
+	delete(s.keys, key)
+	return true, nil
+}
+
+func (s *memoryStore) CompareAndExpire(key, value string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v := s.get(key)
+	if v == nil || v.value != value {
+		return false, nil
+	}
+	v.expireAt = time.Now().Add(ttl)
+	return true, nil
+}
+
+func (s *memoryStore) CompareAndSet(key, old, value string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v := s.get(key)
+	if v == nil || v.value != old {
+		return false, nil
+	}
+	v.value = value
+	return true, nil
+}
+
+type memoryPubSub struct {
+	mu   sync.RWMutex
+	seq  uint64
+	subs map[string]map[uint64]func(payload string)
+}
+
+// NewMemoryPubSub рассылка внутри процесса
+func NewMemoryPubSub() IPubSub {
+	return &memoryPubSub{
+		subs: make(map[string]map[uint64]func(payload string)),
+	}
+}
+
+func (p *memoryPubSub) Publish(channel, payload string) error {
+	p.mu.RLock()
+	handlers := make([]func(payload string), 0, len(p.subs[channel]))
+	for _, h := range p.subs[channel] {
+		handlers = append(handlers, h)
+	}
+	p.mu.RUnlock()
+
+	for _, h := range handlers {
+		go h(payload)
+	}
+	return nil
+}
+
+func (p *memoryPubSub) Subscribe(ctx context.Context, channel string, handler func(payload string)) {
+	p.mu.Lock()
+	p.seq++
+	id := p.seq
+	if p.subs[channel] == nil {
+		p.subs[channel] = make(map[uint64]func(payload string))
+	}
+	p.subs[channel][id] = handler
+	p.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		p.mu.Lock()
+		delete(p.subs[channel], id)
+		p.mu.Unlock()
+	}()
+}
@@ -0,0 +1,100 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"log"
+	"payment-go/internal/utils/redis"
+	"time"
+)
+
+const compareAndDeleteScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("DEL", KEYS[1]) else return 0 end`
+const compareAndExpireScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then return redis.call("PEXPIRE", KEYS[1], ARGV[2]) else return 0 end`
+const compareAndSetScript = `if redis.call("GET", KEYS[1]) == ARGV[1] then redis.call("SET", KEYS[1], ARGV[2], "KEEPTTL") return 1 else return 0 end`
+
+const redisResubscribeDelay = 1 * time.Second
+
+type redisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore хранилище в Redis, общее для всех экземпляров приложения
+func NewRedisStore(client *redis.Client, prefix string) IStore {
+	return &redisStore{client: client, prefix: prefix}
+}
+
+func (s *redisStore) Get(key string) (string, error) {
+	v, err := s.client.String("GET", s.prefix+key)
+	if errors.Is(err, redis.Nil) {
+		return "", ErrNotFound
+	}
+	return v, err
+}
+
+func (s *redisStore) Set(key, value string, ttl time.Duration) error {
+	var err error
+	if ttl > 0 {
+		_, err = s.client.Do("SET", s.prefix+key, value, "PX", ttl.Milliseconds())
+	} else {
+		_, err = s.client.Do("SET", s.prefix+key, value)
+	}
+	return err
+}
+
+func (s *redisStore) SetNX(key, value string, ttl time.Duration) (bool, error) {
+	_, err := s.client.Do("SET", s.prefix+key, value, "PX", ttl.Milliseconds(), "NX")
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *redisStore) Delete(key string) error {
+	_, err := s.client.Do("DEL", s.prefix+key)
+	return err
+}
+
+func (s *redisStore) CompareAndDelete(key, value string) (bool, error) {
+	n, err := s.client.Int("EVAL", compareAndDeleteScript, 1, s.prefix+key, value)
+	return n == 1, err
+}
+
+func (s *redisStore) CompareAndExpire(key, value string, ttl time.Duration) (bool, error) {
+	n, err := s.client.Int("EVAL", compareAndExpireScript, 1, s.prefix+key, value, ttl.Milliseconds())
+	return n == 1, err
+}
+
+func (s *redisStore) CompareAndSet(key, old, value string) (bool, error) {
+	n, err := s.client.Int("EVAL", compareAndSetScript, 1, s.prefix+key, old, value)
+	return n == 1, err
+}
+
+type redisPubSub struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisPubSub рассылка через Redis PUBLISH/SUBSCRIBE
+func NewRedisPubSub(client *redis.Client, prefix string) IPubSub {
+	return &redisPubSub{client: client, prefix: prefix}
+}
+
+func (p *redisPubSub) Publish(channel, payload string) error {
+	_, err := p.client.Do("PUBLISH", p.prefix+channel, payload)
+	return err
+}
+
+// Subscribe переподписывается при обрыве соединения, пока не отменён ctx
+func (p *redisPubSub) Subscribe(ctx context.Context, channel string, handler func(payload string)) {
+	go func() {
+		for ctx.Err() == nil {
+			err := p.client.Subscribe(ctx, p.prefix+channel, handler)
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("RedisPubSub: subscription to %s is lost: %v", channel, err)
+			time.Sleep(redisResubscribeDelay)
+		}
+	}()
+}
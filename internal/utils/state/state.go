@@ -0,0 +1,31 @@
+package state
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var ErrNotFound = errors.New("key not found")
+
+// IStore общее для всех узлов хранилище ключей с временем жизни
+type IStore interface {
+	Get(key string) (string, error)
+	// Set при ttl = 0 сохраняет ключ без времени жизни
+	Set(key, value string, ttl time.Duration) error
+	SetNX(key, value string, ttl time.Duration) (bool, error)
+	Delete(key string) error
+	// CompareAndDelete удаляет ключ, только если его значение равно value
+	CompareAndDelete(key, value string) (bool, error)
+	// CompareAndExpire продлевает ключ, только если его значение равно value
+	CompareAndExpire(key, value string, ttl time.Duration) (bool, error)
+	// CompareAndSet заменяет значение ключа, только если оно равно old. Время жизни ключа не меняется,
+	// истёкший ключ не создаётся заново
+	CompareAndSet(key, old, value string) (bool, error)
+}
+
+// IPubSub рассылка сообщений всем узлам, включая отправителя
+type IPubSub interface {
+	Publish(channel, payload string) error
+	Subscribe(ctx context.Context, channel string, handler func(payload string))
+}
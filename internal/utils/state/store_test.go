@@ -0,0 +1,148 @@
+package state
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"payment-go/internal/utils/redis"
+)
+
+const testTTL = 50 * time.Millisecond
+
+type testStore struct {
+	IStore
+	// wait проматывает время хранилища
+	wait func(d time.Duration)
+}
+
+func testStores(t *testing.T) map[string]testStore {
+	m := miniredis.RunT(t)
+	client := redis.NewClient(m.Addr(), "", 0, 2, time.Second)
+	return map[string]testStore{
+		"memory": {IStore: NewMemoryStore(), wait: time.Sleep},
+		"redis":  {IStore: NewRedisStore(client, "test:"), wait: m.FastForward},
+	}
+}
+
+func TestStoreSetAndGet(t *testing.T) {
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			if _, err := s.Get("key"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("got %v, want ErrNotFound", err)
+			}
+			if err := s.Set("key", "value", testTTL); err != nil {
+				t.Fatal(err)
+			}
+			if v, err := s.Get("key"); err != nil || v != "value" {
+				t.Fatalf("got %q, %v", v, err)
+			}
+			if err := s.Set("forever", "value", 0); err != nil {
+				t.Fatal(err)
+			}
+
+			s.wait(2 * testTTL)
+			if _, err := s.Get("key"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("expired key: got %v, want ErrNotFound", err)
+			}
+			if _, err := s.Get("forever"); err != nil {
+				t.Fatalf("key without ttl: %v", err)
+			}
+
+			if err := s.Delete("forever"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Get("forever"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("deleted key: got %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestStoreSetNX(t *testing.T) {
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			if ok, err := s.SetNX("key", "first", testTTL); err != nil || !ok {
+				t.Fatalf("first SetNX: %v, %v", ok, err)
+			}
+			if ok, err := s.SetNX("key", "second", testTTL); err != nil || ok {
+				t.Fatalf("second SetNX: %v, %v", ok, err)
+			}
+			s.wait(2 * testTTL)
+			if ok, err := s.SetNX("key", "third", testTTL); err != nil || !ok {
+				t.Fatalf("SetNX after expiry: %v, %v", ok, err)
+			}
+		})
+	}
+}
+
+func TestStoreCompareAndDelete(t *testing.T) {
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			_ = s.Set("key", "value", testTTL)
+			if ok, err := s.CompareAndDelete("key", "other"); err != nil || ok {
+				t.Fatalf("wrong value: %v, %v", ok, err)
+			}
+			if ok, err := s.CompareAndDelete("key", "value"); err != nil || !ok {
+				t.Fatalf("right value: %v, %v", ok, err)
+			}
+			if _, err := s.Get("key"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("got %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestStoreCompareAndExpire(t *testing.T) {
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			_ = s.Set("key", "value", testTTL)
+			if ok, err := s.CompareAndExpire("key", "other", 4*testTTL); err != nil || ok {
+				t.Fatalf("wrong value: %v, %v", ok, err)
+			}
+			if ok, err := s.CompareAndExpire("key", "value", 4*testTTL); err != nil || !ok {
+				t.Fatalf("right value: %v, %v", ok, err)
+			}
+
+			s.wait(2 * testTTL)
+			if _, err := s.Get("key"); err != nil {
+				t.Fatalf("extended key: %v", err)
+			}
+
+			s.wait(4 * testTTL)
+			if ok, err := s.CompareAndExpire("key", "value", testTTL); err != nil || ok {
+				t.Fatalf("expired key: %v, %v", ok, err)
+			}
+		})
+	}
+}
+
+func TestStoreCompareAndSet(t *testing.T) {
+	for name, s := range testStores(t) {
+		t.Run(name, func(t *testing.T) {
+			_ = s.Set("key", "value", 2*testTTL)
+			if ok, err := s.CompareAndSet("key", "other", "new"); err != nil || ok {
+				t.Fatalf("wrong value: %v, %v", ok, err)
+			}
+			if ok, err := s.CompareAndSet("key", "value", "new"); err != nil || !ok {
+				t.Fatalf("right value: %v, %v", ok, err)
+			}
+			if v, err := s.Get("key"); err != nil || v != "new" {
+				t.Fatalf("got %q, %v", v, err)
+			}
+
+			// время жизни сохраняется, истёкший ключ не создаётся заново
+			s.wait(3 * testTTL)
+			if _, err := s.Get("key"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("key outlived its ttl: %v", err)
+			}
+			if ok, err := s.CompareAndSet("key", "new", "newer"); err != nil || ok {
+				t.Fatalf("expired key: %v, %v", ok, err)
+			}
+			if _, err := s.Get("key"); !errors.Is(err, ErrNotFound) {
+				t.Fatalf("expired key was recreated: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package statement
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type camtDocument struct {
+	Statements []camtStatement `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type camtStatement struct {
+	IBAN    string      `xml:"Acct>Id>IBAN"`
+	OtherID string      `xml:"Acct>Id>Othr>Id"`
+	Entries []camtEntry `xml:"Ntry"`
+}
+
+type camtEntry struct {
+	Amount       string   `xml:"Amt"`
+	CreditDebit  string   `xml:"CdtDbtInd"`
+	BookingTime  string   `xml:"BookgDt>DtTm"`
+	BookingDate  string   `xml:"BookgDt>Dt"`
+	Reference    string   `xml:"AcctSvcrRef"`
+	EndToEndId   string   `xml:"NtryDtls>TxDtls>Refs>EndToEndId"`
+	Unstructured []string `xml:"NtryDtls>TxDtls>RmtInf>Ustrd"`
+	AddtlInfo    string   `xml:"AddtlNtryInf"`
+}
+
+func parseCAMT053(data []byte) ([]*Line, error) {
+	var doc camtDocument
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("camt.053: %w", err)
+	}
+
+	var res []*Line
+	for _, stmt := range doc.Statements {
+		account := stmt.IBAN
+		if len(account) == 0 {
+			account = stmt.OtherID
+		}
+
+		for _, e := range stmt.Entries {
+			if e.CreditDebit != "CRDT" {
+				continue
+			}
+
+			amount, err := parseAmount(e.Amount)
+			if err != nil {
+				return nil, fmt.Errorf("camt.053: invalid amount %q", e.Amount)
+			}
+
+			bookedAt, err := parseCAMTDate(e.BookingTime, e.BookingDate)
+			if err != nil {
+				return nil, err
+			}
+
+			reference := e.Reference
+			if len(reference) == 0 {
+				reference = e.EndToEndId
+			}
+			description := strings.Join(e.Unstructured, " ")
+			if len(description) == 0 {
+				description = e.AddtlInfo
+			}
+
+			res = append(res, &Line{
+				Account:     strings.ReplaceAll(account, " ", ""),
+				Amount:      amount,
+				BookedAt:    bookedAt,
+				Reference:   reference,
+				Description: description,
+			})
+		}
+	}
+	return res, nil
+}
+
+func parseCAMTDate(dateTime, date string) (time.Time, error) {
+	if len(dateTime) != 0 {
+		if t, err := time.Parse(time.RFC3339, dateTime); err == nil {
+			return t, nil
+		}
+		if t, err := time.ParseInLocation("2006-01-02T15:04:05", dateTime, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if t, err := time.ParseInLocation("2006-01-02", date, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("camt.053: invalid booking date")
+}
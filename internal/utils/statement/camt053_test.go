@@ -0,0 +1,81 @@
+package statement
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const camtSample = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">
+  <BkToCstmrStmt>
+    <GrpHdr><MsgId>STMT240301</MsgId><CreDtTm>2024-03-02T06:00:00+03:00</CreDtTm></GrpHdr>
+    <Stmt>
+      <Id>STMT240301-1</Id>
+      <Acct><Id><IBAN>DE89 3704 0044 0532 0130 00</IBAN></Id></Acct>
+      <Ntry>
+        <Amt Ccy="EUR">1500.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <BookgDt><DtTm>2024-03-01T10:15:00+03:00</DtTm></BookgDt>
+        <AcctSvcrRef>B240301001</AcctSvcrRef>
+        <NtryDtls><TxDtls>
+          <Refs><EndToEndId>E2E-1</EndToEndId></Refs>
+          <RmtInf><Ustrd>Order 42</Ustrd><Ustrd>Ivanov I.</Ustrd></RmtInf>
+        </TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">200.50</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <BookgDt><Dt>2024-03-01</Dt></BookgDt>
+        <AcctSvcrRef>B240301002</AcctSvcrRef>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">75,25</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <RvslInd>true</RvslInd>
+        <BookgDt><Dt>2024-03-02</Dt></BookgDt>
+        <AddtlNtryInf>Reversal of a wrong debit</AddtlNtryInf>
+        <NtryDtls><TxDtls><Refs><EndToEndId>E2E-7</EndToEndId></Refs></TxDtls></NtryDtls>
+      </Ntry>
+    </Stmt>
+    <Stmt>
+      <Id>STMT240301-2</Id>
+      <Acct><Id><Othr><Id>40702810900000012345</Id></Othr></Id></Acct>
+      <Ntry>
+        <Amt Ccy="RUB">42</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <BookgDt><DtTm>2024-03-03T09:30:00</DtTm></BookgDt>
+        <AcctSvcrRef>B240303001</AcctSvcrRef>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`
+
+func TestParseCAMT053(t *testing.T) {
+	lines, err := Parse(FormatCAMT053, "statement.xml", []byte(camtSample), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Line{
+		{"DE89370400440532013000", 1500, time.Date(2024, 3, 1, 7, 15, 0, 0, time.UTC), "B240301001", "Order 42 Ivanov I."},
+		// сторно списания приходит как CRDT, без AcctSvcrRef берётся EndToEndId
+		{"DE89370400440532013000", 75.25, localDate(2024, 3, 2), "E2E-7", "Reversal of a wrong debit"},
+		{"40702810900000012345", 42, time.Date(2024, 3, 3, 9, 30, 0, 0, time.Local), "B240303001", ""},
+	}
+	assertLines(t, lines, want)
+}
+
+func TestParseCAMT053Invalid(t *testing.T) {
+	tests := map[string]string{
+		"amount": strings.Replace(camtSample, "1500.00", "15OO", 1),
+		"date":   strings.Replace(camtSample, "<Dt>2024-03-02</Dt>", "<Dt>02.03.2024</Dt>", 1),
+		"xml":    camtSample[:100],
+	}
+	for name, data := range tests {
+		if _, err := parseCAMT053([]byte(data)); err == nil {
+			t.Errorf("%s: invalid statement was accepted", name)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package statement
+
+import (
+	"fmt"
+	"payment-go/internal/utils/table"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func parseCSV(fileName string, data []byte, mapping *CSVMapping) ([]*Line, error) {
+	if mapping == nil {
+		return nil, fmt.Errorf("csv column mapping is not configured")
+	}
+
+	t, err := table.Read(fileName, data)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*Line
+	for i, row := range t.Maps() {
+		amount, err := parseAmount(row[strings.ToLower(mapping.Amount)])
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid amount", i+1)
+		}
+		// списания нас не интересуют
+		if amount <= 0 {
+			continue
+		}
+
+		bookedAt, err := time.ParseInLocation(mapping.DateLayout, row[strings.ToLower(mapping.Date)], time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid date", i+1)
+		}
+
+		res = append(res, &Line{
+			Account:     strings.ReplaceAll(row[strings.ToLower(mapping.Account)], " ", ""),
+			Amount:      amount,
+			BookedAt:    bookedAt,
+			Reference:   row[strings.ToLower(mapping.Reference)],
+			Description: row[strings.ToLower(mapping.Description)],
+		})
+	}
+	return res, nil
+}
+
+// parseAmount понимает "1 234,56", "1234.56" и "1,234.56"
+func parseAmount(str string) (float64, error) {
+	str = strings.ReplaceAll(strings.TrimSpace(str), " ", "")
+	if strings.Contains(str, ",") && strings.Contains(str, ".") {
+		str = strings.ReplaceAll(str, ",", "")
+	} else {
+		str = strings.ReplaceAll(str, ",", ".")
+	}
+	return strconv.ParseFloat(str, 64)
+}
@@ -0,0 +1,61 @@
+package statement
+
+import (
+	"testing"
+)
+
+var csvMapping = &CSVMapping{
+	Account:     "Счёт",
+	Amount:      "Сумма",
+	Date:        "Дата",
+	Reference:   "Номер",
+	Description: "Назначение",
+	DateLayout:  "02.01.2006",
+}
+
+func TestParseCSV(t *testing.T) {
+	data := "\xef\xbb\xbfСчёт;Сумма;Дата;Номер;Назначение\n" +
+		"4070 2810 9000 0001 2345;\"1 234,56\";01.03.2024;P-1;Оплата заказа 42\n" +
+		"40702810900000012345;-100,00;01.03.2024;P-2;Комиссия\n" +
+		";;;;\n" +
+		"40702810900000012345;42,5;02.03.2024;P-3;\n"
+
+	lines, err := Parse(FormatCSV, "statement.csv", []byte(data), csvMapping)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// списание и пустая строка пропускаются
+	want := []Line{
+		{"40702810900000012345", 1234.56, localDate(2024, 3, 1), "P-1", "Оплата заказа 42"},
+		{"40702810900000012345", 42.5, localDate(2024, 3, 2), "P-3", ""},
+	}
+	assertLines(t, lines, want)
+}
+
+func TestParseCSVInvalid(t *testing.T) {
+	header := "account,amount,date,reference,description\n"
+	mapping := &CSVMapping{
+		Account:     "account",
+		Amount:      "amount",
+		Date:        "date",
+		Reference:   "reference",
+		Description: "description",
+		DateLayout:  "2006-01-02",
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		mapping *CSVMapping
+	}{
+		{"amount", header + "123,abc,2024-03-01,P-1,\n", mapping},
+		{"date", header + "123,10.00,01.03.2024,P-1,\n", mapping},
+		{"mapping", header + "123,10.00,2024-03-01,P-1,\n", nil},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(FormatCSV, "statement.csv", []byte(tt.data), tt.mapping); err == nil {
+			t.Errorf("%s: invalid statement was accepted", tt.name)
+		}
+	}
+}
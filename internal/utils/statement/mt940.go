@@ -0,0 +1,123 @@
+package statement
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// :61:YYMMDD[MMDD]{C|D|RC|RD}[funds code]amount...
+var mt940LineRegexp = regexp.MustCompile(`^(\d{6})(\d{4})?(RC|RD|C|D)([A-Z])?(\d+,\d*)(.*)$`)
+
+type mt940Field struct {
+	tag   string
+	value string
+}
+
+func parseMT940(data []byte) ([]*Line, error) {
+	fields := splitMT940(data)
+
+	var res []*Line
+	var account string
+	var last *Line
+	for _, f := range fields {
+		switch f.tag {
+		case "25":
+			account = strings.ReplaceAll(f.value, " ", "")
+			// счёт может быть указан как BIC/номер
+			if i := strings.LastIndex(account, "/"); i >= 0 {
+				account = account[i+1:]
+			}
+		case "61":
+			line, credit, err := parseMT940Line(f.value)
+			if err != nil {
+				return nil, err
+			}
+			last = nil
+			if !credit {
+				continue
+			}
+			line.Account = account
+			res = append(res, line)
+			last = line
+		case "86":
+			if last != nil {
+				last.Description = strings.TrimSpace(last.Description + " " + strings.ReplaceAll(f.value, "\n", " "))
+			}
+		}
+	}
+	return res, nil
+}
+
+func splitMT940(data []byte) []*mt940Field {
+	var fields []*mt940Field
+	var current *mt940Field
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(line) > 1 && line[0] == ':' {
+			if end := strings.Index(line[1:], ":"); end > 0 {
+				current = &mt940Field{tag: line[1 : end+1], value: line[end+2:]}
+				fields = append(fields, current)
+				continue
+			}
+		}
+		// продолжение предыдущего поля
+		if current != nil && line != "-" && len(line) != 0 {
+			current.value += "\n" + line
+		}
+	}
+	return fields
+}
+
+func parseMT940Line(value string) (*Line, bool, error) {
+	first, rest, _ := strings.Cut(value, "\n")
+	m := mt940LineRegexp.FindStringSubmatch(first)
+	if m == nil {
+		return nil, false, fmt.Errorf("mt940: invalid statement line %q", first)
+	}
+
+	bookedAt, err := time.ParseInLocation("060102", m[1], time.Local)
+	if err != nil {
+		return nil, false, fmt.Errorf("mt940: invalid date %q", m[1])
+	}
+
+	amount, err := parseAmount(m[5])
+	if err != nil {
+		return nil, false, fmt.Errorf("mt940: invalid amount %q", m[5])
+	}
+
+	// сторно списания - это тоже зачисление
+	credit := m[3] == "C" || m[3] == "RD"
+
+	// после суммы: код типа операции из 4 символов, референс клиента и необязательный //референс банка.
+	// Референсом строки служит референс банка, как AcctSvcrRef в camt.053: он уникален и по нему отсеиваются повторы.
+	// Референс клиента задаёт плательщик, он сохраняется в начале описания
+	reference := m[6]
+	if len(reference) > 4 {
+		reference = reference[4:]
+	} else {
+		reference = ""
+	}
+	var description string
+	if customer, bank, ok := strings.Cut(reference, "//"); ok {
+		reference = bank
+		if customer != "NONREF" {
+			description = customer
+		}
+	}
+	if len(rest) != 0 {
+		reference = strings.TrimSpace(reference + " " + rest)
+	}
+
+	return &Line{
+		Amount:      amount,
+		BookedAt:    bookedAt,
+		Reference:   strings.TrimSpace(reference),
+		Description: strings.TrimSpace(description),
+	}, credit, nil
+}
@@ -0,0 +1,130 @@
+package statement
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func localDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+}
+
+const mt940Sample = `{1:F01BANKRUMMXXXX0000000000}{2:I940BANKRUMMXXXXN}{4:
+:20:STMT240301
+:25:BANKRUMM/40702810900000012345
+:28C:00059/001
+:60F:C240229RUB1000,00
+:61:2403010301C1500,00NTRFORDER-42//B240301001
+:86:Оплата заказа 42
+от Иванова И.И.
+:61:240301D200,50NMSCNONREF//B240301002
+:86:Комиссия банка
+:61:2403020302RD75,25NTRFNONREF//B240302001
+:86:Возврат ошибочного списания
+:61:240302RC300,NTRFNONREF//B240302002
+:86:Сторно зачисления
+:61:240303CR42,NTRFNONREF//B240303001
+SUPPLEMENTARY
+:62F:C240303RUB2466,50
+-}`
+
+func TestParseMT940(t *testing.T) {
+	lines, err := Parse(FormatMT940, "statement.sta", []byte(mt940Sample), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Line{
+		// дата проводки указана, референс клиента сохраняется в описании, :86: продолжается на следующей строке
+		{"40702810900000012345", 1500, localDate(2024, 3, 1), "B240301001", "ORDER-42 Оплата заказа 42 от Иванова И.И."},
+		// сторно списания - зачисление, сторно зачисления и списание пропускаются
+		{"40702810900000012345", 75.25, localDate(2024, 3, 2), "B240302001", "Возврат ошибочного списания"},
+		// без даты проводки, с кодом средств, дополнительными сведениями и суммой без копеек
+		{"40702810900000012345", 42, localDate(2024, 3, 3), "B240303001 SUPPLEMENTARY", ""},
+	}
+	assertLines(t, lines, want)
+}
+
+func TestParseMT940Fields(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want Line
+	}{
+		{
+			name: "account without bic",
+			data: ":25:40702 81090 0000012345\n:61:240301C10,00NTRF//B1\n",
+			want: Line{"40702810900000012345", 10, localDate(2024, 3, 1), "B1", ""},
+		},
+		{
+			name: "customer reference only",
+			data: ":25:123\n:61:240301C10,5NTRFORDER-7\n",
+			want: Line{"123", 10.5, localDate(2024, 3, 1), "ORDER-7", ""},
+		},
+		{
+			name: "comma decimals",
+			data: ":25:123\n:61:240301C1234,56NTRF//B1\n:86:?20Order 42?21paid\n",
+			want: Line{"123", 1234.56, localDate(2024, 3, 1), "B1", "?20Order 42?21paid"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := parseMT940([]byte(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertLines(t, lines, []Line{tt.want})
+		})
+	}
+}
+
+func TestParseMT940Invalid(t *testing.T) {
+	for _, data := range []string{
+		":61:2403C10,00NTRF\n",
+		":61:241301C10,00NTRF\n",
+		":61:240301X10,00NTRF\n",
+		":61:240301C10.00NTRF\n",
+	} {
+		if _, err := parseMT940([]byte(data)); err == nil {
+			t.Errorf("%q: invalid line was accepted", data)
+		}
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := map[string]float64{
+		"1 234,56":  1234.56,
+		"1234.56":   1234.56,
+		"1,234.56":  1234.56,
+		"42,":       42,
+		" -100,00 ": -100,
+	}
+	for str, want := range tests {
+		got, err := parseAmount(str)
+		if err != nil || got != want {
+			t.Errorf("%q: got %v, %v, want %v", str, got, err, want)
+		}
+	}
+}
+
+func assertLines(t *testing.T, got []*Line, want []Line) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Account != w.Account || g.Amount != w.Amount || !g.BookedAt.Equal(w.BookedAt) ||
+			g.Reference != w.Reference || g.Description != w.Description {
+			t.Errorf("line %d:\n got %+v\nwant %+v", i, *g, w)
+		}
+	}
+}
+
+func TestParseRejectsEmptyStatement(t *testing.T) {
+	data := strings.Split(mt940Sample, ":61:")[0]
+	if _, err := Parse(FormatMT940, "statement.sta", []byte(data), nil); err != ErrNoEntries {
+		t.Fatalf("got %v, want ErrNoEntries", err)
+	}
+}
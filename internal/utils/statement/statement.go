@@ -0,0 +1,56 @@
+package statement
+
+import (
+	"errors"
+	"time"
+)
+
+const FormatCSV = "csv"
+const FormatMT940 = "mt940"
+const FormatCAMT053 = "camt053"
+
+var ErrUnknownFormat = errors.New("unknown statement format")
+var ErrNoEntries = errors.New("statement contains no credit entries")
+
+// Line зачисление из выписки банка
+type Line struct {
+	Account     string
+	Amount      float64
+	BookedAt    time.Time
+	Reference   string
+	Description string
+}
+
+// CSVMapping соответствие колонок csv файла полям выписки
+type CSVMapping struct {
+	Account     string `json:"account"`
+	Amount      string `json:"amount"`
+	Date        string `json:"date"`
+	Reference   string `json:"reference"`
+	Description string `json:"description"`
+	DateLayout  string `json:"date_layout"`
+}
+
+// Parse разбирает файл выписки. Списания пропускаются, возвращаются только зачисления
+func Parse(format string, fileName string, data []byte, mapping *CSVMapping) ([]*Line, error) {
+	var lines []*Line
+	var err error
+
+	switch format {
+	case FormatCSV:
+		lines, err = parseCSV(fileName, data, mapping)
+	case FormatMT940:
+		lines, err = parseMT940(data)
+	case FormatCAMT053:
+		lines, err = parseCAMT053(data)
+	default:
+		return nil, ErrUnknownFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, ErrNoEntries
+	}
+	return lines, nil
+}
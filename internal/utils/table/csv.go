@@ -0,0 +1,23 @@
+package table
+
+import (
+	"bytes"
+	"encoding/csv"
+)
+
+func readCSV(data []byte) ([][]string, error) {
+	// уберём BOM, который добавляет Excel
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	// разделитель может быть как запятой, так и точкой с запятой
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		r.Comma = ';'
+	}
+
+	return r.ReadAll()
+}
@@ -0,0 +1,78 @@
+package table
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported file format, expected csv or xlsx")
+var ErrEmptyTable = errors.New("file contains no rows")
+
+// Table строки файла, первая строка - заголовок
+type Table struct {
+	Header []string
+	Rows   [][]string
+}
+
+// Read читает csv или xlsx в зависимости от расширения файла
+func Read(fileName string, data []byte) (*Table, error) {
+	var rows [][]string
+	var err error
+
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		rows, err = readCSV(data)
+	case ".xlsx":
+		rows, err = readXLSX(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// пропустим пустые строки
+	res := make([][]string, 0, len(rows))
+	for _, row := range rows {
+		if !isEmptyRow(row) {
+			res = append(res, row)
+		}
+	}
+	if len(res) == 0 {
+		return nil, ErrEmptyTable
+	}
+
+	header := make([]string, len(res[0]))
+	for i, col := range res[0] {
+		header[i] = strings.ToLower(strings.TrimSpace(col))
+	}
+	return &Table{
+		Header: header,
+		Rows:   res[1:],
+	}, nil
+}
+
+// Maps строки в виде колонка -> значение
+func (t *Table) Maps() []map[string]string {
+	res := make([]map[string]string, len(t.Rows))
+	for i, row := range t.Rows {
+		m := make(map[string]string, len(t.Header))
+		for j, col := range t.Header {
+			if len(col) != 0 && j < len(row) {
+				m[col] = strings.TrimSpace(row[j])
+			}
+		}
+		res[i] = m
+	}
+	return res
+}
+
+func isEmptyRow(row []string) bool {
+	for _, col := range row {
+		if len(strings.TrimSpace(col)) != 0 {
+			return false
+		}
+	}
+	return true
+}
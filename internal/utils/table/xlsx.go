@@ -0,0 +1,211 @@
+package table
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidXLSX = errors.New("invalid xlsx file")
+
+// xlsxMaxPartSize предел распакованного размера одной части файла, защита от zip-бомб
+const xlsxMaxPartSize = 32 << 20
+
+// xlsxMaxColumns колонок в листе не больше, чем допускает формат (A..XFD)
+const xlsxMaxColumns = 16384
+
+// читаем только первый лист и только значения ячеек, без формул и стилей
+
+type xlsxWorkbook struct {
+	Sheets []xlsxWorkbookSheet `xml:"sheets>sheet"`
+}
+
+type xlsxWorkbookSheet struct {
+	RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+}
+
+type xlsxRelationships struct {
+	Items []xlsxRelationship `xml:"Relationship"`
+}
+
+type xlsxRelationship struct {
+	ID     string `xml:"Id,attr"`
+	Target string `xml:"Target,attr"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxRichText struct {
+	Text string        `xml:"t"`
+	Runs []xlsxTextRun `xml:"r"`
+}
+
+type xlsxTextRun struct {
+	Text string `xml:"t"`
+}
+
+type xlsxSheet struct {
+	Rows []xlsxRow `xml:"sheetData>row"`
+}
+
+type xlsxRow struct {
+	Cells []xlsxCell `xml:"c"`
+}
+
+type xlsxCell struct {
+	Ref    string       `xml:"r,attr"`
+	Type   string       `xml:"t,attr"`
+	Value  string       `xml:"v"`
+	Inline xlsxRichText `xml:"is"`
+}
+
+func (rt *xlsxRichText) String() string {
+	if len(rt.Runs) == 0 {
+		return rt.Text
+	}
+	var sb strings.Builder
+	for _, run := range rt.Runs {
+		sb.WriteString(run.Text)
+	}
+	return sb.String()
+}
+
+func readXLSX(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, ErrInvalidXLSX
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var shared xlsxSharedStrings
+	if f, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeZipXML(f, &shared); err != nil {
+			return nil, ErrInvalidXLSX
+		}
+	}
+
+	f, ok := files[firstSheetPath(files)]
+	if !ok {
+		return nil, ErrInvalidXLSX
+	}
+	var sheet xlsxSheet
+	if err := decodeZipXML(f, &sheet); err != nil {
+		return nil, ErrInvalidXLSX
+	}
+
+	rows := make([][]string, len(sheet.Rows))
+	for i, row := range sheet.Rows {
+		var res []string
+		for j, cell := range row.Cells {
+			col := columnIndex(cell.Ref)
+			if col < 0 {
+				col = j
+			}
+			if col >= xlsxMaxColumns {
+				return nil, ErrInvalidXLSX
+			}
+			for len(res) <= col {
+				res = append(res, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				idx, err := strconv.Atoi(cell.Value)
+				if err != nil || idx < 0 || idx >= len(shared.Items) {
+					return nil, ErrInvalidXLSX
+				}
+				res[col] = shared.Items[idx].String()
+			case "inlineStr":
+				res[col] = cell.Inline.String()
+			default:
+				res[col] = cell.Value
+			}
+		}
+		rows[i] = res
+	}
+	return rows, nil
+}
+
+// firstSheetPath путь к первому листу книги по workbook.xml и его связям.
+// Если книга не описывает листы, берём sheet1.xml, как пишут его простые генераторы
+func firstSheetPath(files map[string]*zip.File) string {
+	const fallback = "xl/worksheets/sheet1.xml"
+
+	wbFile, ok := files["xl/workbook.xml"]
+	relsFile, relsOk := files["xl/_rels/workbook.xml.rels"]
+	if !ok || !relsOk {
+		return fallback
+	}
+
+	var wb xlsxWorkbook
+	var rels xlsxRelationships
+	if decodeZipXML(wbFile, &wb) != nil || decodeZipXML(relsFile, &rels) != nil || len(wb.Sheets) == 0 {
+		return fallback
+	}
+
+	for _, rel := range rels.Items {
+		if rel.ID != wb.Sheets[0].RelID {
+			continue
+		}
+		// цель указывается относительно xl/ либо от корня архива
+		if strings.HasPrefix(rel.Target, "/") {
+			return path.Clean(strings.TrimPrefix(rel.Target, "/"))
+		}
+		return path.Join("xl", rel.Target)
+	}
+	return fallback
+}
+
+func decodeZipXML(f *zip.File, v interface{}) error {
+	if f.UncompressedSize64 > xlsxMaxPartSize {
+		return ErrInvalidXLSX
+	}
+
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// заголовку zip верить нельзя, поэтому ограничиваем и само чтение
+	data, err := io.ReadAll(io.LimitReader(rc, xlsxMaxPartSize+1))
+	if err != nil {
+		return err
+	}
+	if len(data) > xlsxMaxPartSize {
+		return ErrInvalidXLSX
+	}
+	return xml.Unmarshal(data, v)
+}
+
+// columnIndex номер колонки по ссылке на ячейку: "C12" -> 2.
+// Для ссылок дальше последней колонки формата возвращает xlsxMaxColumns
+func columnIndex(ref string) int {
+	idx := 0
+	n := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		idx = idx*26 + int(ch-'A'+1)
+		if idx > xlsxMaxColumns {
+			return xlsxMaxColumns
+		}
+		n++
+	}
+	if n == 0 {
+		return -1
+	}
+	return idx - 1
+}
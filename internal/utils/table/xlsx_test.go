@@ -0,0 +1,109 @@
+package table
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func buildXLSX(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func sheetXML(cells string) string {
+	return `<worksheet><sheetData><row>` + cells + `</row></sheetData></worksheet>`
+}
+
+func TestReadXLSXFirstSheetFromWorkbook(t *testing.T) {
+	data := buildXLSX(t, map[string]string{
+		"xl/workbook.xml": `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Выводы" r:id="rId7"/><sheet name="Прочее" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships>` +
+			`<Relationship Id="rId1" Target="worksheets/sheet1.xml"/>` +
+			`<Relationship Id="rId7" Target="/xl/worksheets/data.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml": sheetXML(`<c r="A1" t="inlineStr"><is><t>wrong</t></is></c>`),
+		"xl/worksheets/data.xml":   sheetXML(`<c r="B1" t="inlineStr"><is><t>right</t></is></c>`),
+	})
+
+	rows, err := readXLSX(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || len(rows[0]) != 2 || rows[0][1] != "right" {
+		t.Fatalf("unexpected rows %q", rows)
+	}
+}
+
+func TestReadXLSXWithoutWorkbook(t *testing.T) {
+	data := buildXLSX(t, map[string]string{
+		"xl/sharedStrings.xml":     `<sst><si><t>amount</t></si></sst>`,
+		"xl/worksheets/sheet1.xml": sheetXML(`<c r="A1" t="s"><v>0</v></c><c r="C1"><v>10.5</v></c>`),
+	})
+
+	rows, err := readXLSX(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"amount", "", "10.5"}
+	if len(rows) != 1 || strings.Join(rows[0], "|") != strings.Join(want, "|") {
+		t.Fatalf("got %q, want %q", rows, want)
+	}
+}
+
+func TestReadXLSXRejectsHugeColumn(t *testing.T) {
+	for _, ref := range []string{"XFE1", "ZZZZZZZZ1", "ZZZZZZZZZZZZZZZZZZZZ1"} {
+		data := buildXLSX(t, map[string]string{
+			"xl/worksheets/sheet1.xml": sheetXML(`<c r="` + ref + `"><v>1</v></c>`),
+		})
+		if _, err := readXLSX(data); !errors.Is(err, ErrInvalidXLSX) {
+			t.Errorf("%s: got %v, want ErrInvalidXLSX", ref, err)
+		}
+	}
+}
+
+func TestReadXLSXRejectsOversizedPart(t *testing.T) {
+	data := buildXLSX(t, map[string]string{
+		"xl/worksheets/sheet1.xml": sheetXML(strings.Repeat(" ", xlsxMaxPartSize)),
+	})
+	if _, err := readXLSX(data); !errors.Is(err, ErrInvalidXLSX) {
+		t.Fatalf("got %v, want ErrInvalidXLSX", err)
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want int
+	}{
+		{"A1", 0},
+		{"C12", 2},
+		{"Z3", 25},
+		{"AA3", 26},
+		{"XFD1", xlsxMaxColumns - 1},
+		{"XFE1", xlsxMaxColumns},
+		{"12", -1},
+	}
+	for _, tt := range tests {
+		if got := columnIndex(tt.ref); got != tt.want {
+			t.Errorf("columnIndex(%q) = %d, want %d", tt.ref, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// минимальная серверная часть RFC 6455: текстовые сообщения сервера, ping/pong и закрытие.
+// Сообщения клиента читаются только чтобы обработать управляющие кадры
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xA
+)
+
+const CloseNormal uint16 = 1000
+const CloseGoingAway uint16 = 1001
+
+const maxClientPayload = 64 * 1024
+const writeTimeout = 10 * time.Second
+
+var ErrNotWebSocket = errors.New("not a websocket handshake")
+var ErrClosed = errors.New("websocket closed")
+var ErrOriginNotAllowed = errors.New("websocket origin is not allowed")
+
+// OriginChecker разрешает подключение со страницы origin. Браузер не даёт странице подменить Origin,
+// поэтому проверка защищает от подключения чужих сайтов от имени плательщика
+type OriginChecker func(origin *url.URL) bool
+
+// IsUpgrade запрос на переключение протокола на websocket
+func IsUpgrade(ctx *fiber.Ctx) bool {
+	return strings.EqualFold(ctx.Get(fiber.HeaderUpgrade), "websocket") &&
+		strings.Contains(strings.ToLower(ctx.Get(fiber.HeaderConnection)), "upgrade")
+}
+
+// Upgrade отвечает 101 и передаёт соединение в handler. Соединение закрывается после выхода из handler.
+// Без checkOrigin принимаются только подключения со своего хоста
+func Upgrade(ctx *fiber.Ctx, checkOrigin OriginChecker, handler func(conn *Conn)) error {
+	key := ctx.Get("Sec-WebSocket-Key")
+	if !IsUpgrade(ctx) || len(key) == 0 || ctx.Get("Sec-WebSocket-Version") != "13" {
+		ctx.Set("Sec-WebSocket-Version", "13")
+		return ctx.Status(fiber.StatusUpgradeRequired).SendString(ErrNotWebSocket.Error())
+	}
+	if !originAllowed(ctx, checkOrigin) {
+		return ctx.Status(fiber.StatusForbidden).SendString(ErrOriginNotAllowed.Error())
+	}
+
+	ctx.Status(fiber.StatusSwitchingProtocols)
+	ctx.Set(fiber.HeaderUpgrade, "websocket")
+	ctx.Set(fiber.HeaderConnection, "Upgrade")
+	ctx.Set("Sec-WebSocket-Accept", acceptKey(key))
+
+	ctx.Context().Hijack(func(c net.Conn) {
+		handler(&Conn{
+			conn: c,
+			br:   bufio.NewReader(c),
+		})
+	})
+	return nil
+}
+
+// originAllowed клиенты не из браузера Origin не передают, их пропускаем
+func originAllowed(ctx *fiber.Ctx, checkOrigin OriginChecker) bool {
+	origin := ctx.Get(fiber.HeaderOrigin)
+	if len(origin) == 0 {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || len(u.Host) == 0 {
+		return false
+	}
+	if strings.EqualFold(u.Host, string(ctx.Request().Host())) {
+		return true
+	}
+	return checkOrigin != nil && checkOrigin(u)
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+type Conn struct {
+	conn   net.Conn
+	br     *bufio.Reader
+	mu     sync.Mutex
+	closed bool
+}
+
+func (c *Conn) WriteText(data []byte) error {
+	return c.writeFrame(opText, data)
+}
+
+func (c *Conn) Ping() error {
+	return c.writeFrame(opPing, nil)
+}
+
+// Close отправляет кадр закрытия, само соединение закрывается после выхода из обработчика
+func (c *Conn) Close(code uint16) error {
+	payload := make([]byte, 2)
+	binary.BigEndian.PutUint16(payload, code)
+	err := c.writeFrame(opClose, payload)
+
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	return err
+}
+
+// ReadLoop читает кадры клиента, отвечает на ping. Возвращается при закрытии соединения клиентом или ошибке
+func (c *Conn) ReadLoop() error {
+	for {
+		op, payload, err := c.readFrame()
+		if err != nil {
+			return err
+		}
+		switch op {
+		case opPing:
+			if err = c.writeFrame(opPong, payload); err != nil {
+				return err
+			}
+		case opClose:
+			_ = c.writeFrame(opClose, payload)
+			return ErrClosed
+		}
+	}
+}
+
+func (c *Conn) writeFrame(op byte, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return ErrClosed
+	}
+
+	header := make([]byte, 2, 10)
+	header[0] = 0x80 | op
+	switch n := len(payload); {
+	case n < 126:
+		header[1] = byte(n)
+	case n <= 0xFFFF:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	_ = c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := c.conn.Write(append(header, payload...)); err != nil {
+		c.closed = true
+		return err
+	}
+	return nil
+}
+
+func (c *Conn) readFrame() (byte, []byte, error) {
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(c.br, head); err != nil {
+		return 0, nil, err
+	}
+	op := head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+	n := uint64(head[1] & 0x7F)
+
+	switch n {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(c.br, ext); err != nil {
+			return 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err := io.ReadFull(c.br, ext); err != nil {
+			return 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext)
+	}
+	// кадры клиента обязаны быть маскированы
+	if !masked || n > maxClientPayload {
+		return 0, nil, ErrClosed
+	}
+
+	mask := make([]byte, 4)
+	if _, err := io.ReadFull(c.br, mask); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return op, payload, nil
+}
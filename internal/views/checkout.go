@@ -0,0 +1,37 @@
+package views
+
+const CheckoutMethodLink = "link" // переход на страницу банка
+const CheckoutMethodCard = "card" // перевод на номер карты
+
+const CheckoutStatusPending = "pending"
+const CheckoutStatusSuccess = "success"
+const CheckoutStatusFailure = "failure"
+const CheckoutStatusExpired = "expired"
+
+// CheckoutPage данные страницы оплаты
+type CheckoutPage struct {
+	Localized
+	OrderNumber string
+	Amount      string
+	Method      string
+	CardNumber  string
+	ExpiresAt   int64 // unix, после этого показывается экран истечения
+	Status      string
+	Branding    CheckoutBranding
+	// есть адрес возврата в магазин для соответствующего исхода
+	SuccessReturn bool
+	FailReturn    bool
+}
+
+type CheckoutBranding struct {
+	ShopName        string
+	LogoUrl         string
+	PrimaryColor    string
+	BackgroundColor string
+	ReturnUrl       string
+}
+
+type ErrorPage struct {
+	Localized
+	Message string
+}
@@ -0,0 +1,105 @@
+package views
+
+import "strings"
+
+const LangRu = "ru"
+const LangEn = "en"
+const LangAz = "az"
+const DefaultLang = LangRu
+
+var translations = map[string]map[string]string{
+	LangRu: {
+		"title":          "Оплата заказа",
+		"amount":         "Сумма",
+		"order":          "Заказ",
+		"waiting_link":   "Готовим страницу оплаты…",
+		"redirecting":    "Переходим к оплате…",
+		"pay_by_card":    "Переведите точную сумму на карту",
+		"card_number":    "Номер карты",
+		"copy":           "Копировать",
+		"copied":         "Скопировано",
+		"time_left":      "Осталось времени",
+		"waiting_status": "Ожидаем поступление оплаты",
+		"success_title":  "Оплата прошла",
+		"success_text":   "Спасибо! Заказ оплачен.",
+		"failure_title":  "Оплата не прошла",
+		"failure_text":   "Платёж отклонён. Попробуйте оформить заказ заново.",
+		"expired_title":  "Время оплаты истекло",
+		"expired_text":   "Если вы уже оплатили заказ, статус обновится автоматически.",
+		"return_to_shop": "Вернуться в магазин",
+		"returning":      "Возвращаем в магазин…",
+		"not_found":      "Заказ не найден",
+	},
+	LangEn: {
+		"title":          "Order payment",
+		"amount":         "Amount",
+		"order":          "Order",
+		"waiting_link":   "Preparing the payment page…",
+		"redirecting":    "Redirecting to payment…",
+		"pay_by_card":    "Transfer the exact amount to the card",
+		"card_number":    "Card number",
+		"copy":           "Copy",
+		"copied":         "Copied",
+		"time_left":      "Time left",
+		"waiting_status": "Waiting for the payment",
+		"success_title":  "Payment successful",
+		"success_text":   "Thank you! The order is paid.",
+		"failure_title":  "Payment failed",
+		"failure_text":   "The payment was declined. Please place the order again.",
+		"expired_title":  "Payment time is over",
+		"expired_text":   "If you have already paid, the status will update automatically.",
+		"return_to_shop": "Return to the shop",
+		"returning":      "Returning to the shop…",
+		"not_found":      "Order not found",
+	},
+	LangAz: {
+		"title":          "Sifarişin ödənişi",
+		"amount":         "Məbləğ",
+		"order":          "Sifariş",
+		"waiting_link":   "Ödəniş səhifəsi hazırlanır…",
+		"redirecting":    "Ödənişə keçid edilir…",
+		"pay_by_card":    "Dəqiq məbləği karta köçürün",
+		"card_number":    "Kart nömrəsi",
+		"copy":           "Kopyala",
+		"copied":         "Kopyalandı",
+		"time_left":      "Qalan vaxt",
+		"waiting_status": "Ödəniş gözlənilir",
+		"success_title":  "Ödəniş uğurludur",
+		"success_text":   "Təşəkkür edirik! Sifariş ödənilib.",
+		"failure_title":  "Ödəniş alınmadı",
+		"failure_text":   "Ödəniş rədd edildi. Sifarişi yenidən rəsmiləşdirin.",
+		"expired_title":  "Ödəniş vaxtı bitib",
+		"expired_text":   "Əgər artıq ödəmisinizsə, status avtomatik yenilənəcək.",
+		"return_to_shop": "Mağazaya qayıt",
+		"returning":      "Mağazaya qaytarılırsınız…",
+		"not_found":      "Sifariş tapılmadı",
+	},
+}
+
+// Localized данные страницы с переводом на выбранный язык
+type Localized struct {
+	Lang string
+}
+
+// Tr перевод ключа, в шаблоне: {{.Tr "title"}}
+func (l Localized) Tr(key string) string {
+	if val, ok := translations[l.Lang][key]; ok {
+		return val
+	}
+	return translations[DefaultLang][key]
+}
+
+// DetectLang язык из параметра ?lang или заголовка Accept-Language
+func DetectLang(query, acceptLanguage string) string {
+	if _, ok := translations[query]; ok {
+		return query
+	}
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		lang := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		lang = strings.ToLower(strings.SplitN(lang, "-", 2)[0])
+		if _, ok := translations[lang]; ok {
+			return lang
+		}
+	}
+	return DefaultLang
+}
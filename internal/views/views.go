@@ -0,0 +1,25 @@
+package views
+
+import (
+	"embed"
+	"html/template"
+	"io"
+)
+
+//go:embed templates/*.html
+var templatesFS embed.FS
+
+// страницы собираются из общего layout.html и своего шаблона
+var pages = map[string]*template.Template{
+	"checkout": parse("checkout.html"),
+	"error":    parse("error.html"),
+}
+
+func parse(name string) *template.Template {
+	return template.Must(template.ParseFS(templatesFS, "templates/layout.html", "templates/"+name))
+}
+
+// Render выводит страницу name с данными data
+func Render(w io.Writer, name string, data any) error {
+	return pages[name].ExecuteTemplate(w, "layout", data)
+}